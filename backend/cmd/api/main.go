@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,14 +13,28 @@ import (
 	"backend/internal/delivery/http/handler"
 	"backend/internal/delivery/http/middleware"
 	"backend/internal/delivery/http/router"
+	"backend/internal/domain/entity"
+	"backend/internal/domain/event"
 	"backend/internal/infrastructure/cloudinary"
 	"backend/internal/infrastructure/config"
 	"backend/internal/infrastructure/database"
 	"backend/internal/infrastructure/email"
+	"backend/internal/infrastructure/eventbus"
 	"backend/internal/infrastructure/logger"
+	"backend/internal/infrastructure/metrics"
+	"backend/internal/infrastructure/scheduler"
+	"backend/internal/infrastructure/shutdown"
+	"backend/internal/infrastructure/webhook"
 	"backend/internal/repository/postgres"
 	"backend/internal/usecase/auth"
+	"backend/internal/usecase/conversation"
+	"backend/internal/usecase/message"
+	"backend/internal/usecase/search"
 	"backend/internal/usecase/user"
+	"backend/pkg/phone"
+	"backend/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func main() {
@@ -29,6 +44,10 @@ func main() {
 		fmt.Printf("Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
 	// Initialize logger
 	logger.Init(cfg.Server.Mode)
@@ -43,64 +62,247 @@ func main() {
 	}
 
 	// Run migrations
-	// if err := database.AutoMigrate(db); err != nil {
-	// 	logger.Fatal("Failed to run migrations", err)
-	// }
-	// logger.Info("Database migrations completed")
+	if err := database.AutoMigrate(db); err != nil {
+		logger.Fatal("Failed to run migrations", err)
+	}
+	logger.Info("Database migrations completed")
 
 	// Initialize repositories
 	avatarRepo := postgres.NewAvatarRepository(db)
+	avatarDeletionRepo := postgres.NewAvatarDeletionRepository(db)
 	userRepo := postgres.NewUserRepository(db, avatarRepo)
 	refreshTokenRepo := postgres.NewRefreshTokenRepository(db)
+	revokedTokenRepo := postgres.NewRevokedTokenRepository(db)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	attachmentDeletionRepo := postgres.NewAttachmentDeletionRepository(db)
+	loginEventRepo := postgres.NewLoginEventRepository(db)
+	recentSearchRepo := postgres.NewRecentSearchRepository(db)
+	idempotencyKeyRepo := postgres.NewIdempotencyKeyRepository(db)
+	txManager := postgres.NewTxManager(db)
 
 	// Initialize Cloudinary service
 	cloudinaryServ, err := cloudinary.NewService(
 		cfg.Cloudinary.CloudName,
 		cfg.Cloudinary.APIKey,
 		cfg.Cloudinary.APISecret,
+		cfg.Cloudinary.ThumbnailSizes,
+		time.Second*time.Duration(cfg.Cloudinary.TimeoutSeconds),
 	)
 	if err != nil {
 		logger.Fatal("Failed to initialize Cloudinary service", err)
 	}
 
 	// Initialize Email service
-	var emailService email.EmailService
-	if cfg.Email.SMTPUsername != "" && cfg.Email.SMTPPassword != "" {
-		// Use real email service if credentials are provided
-		emailService = email.NewEmailService(
-			cfg.Email.SMTPHost,
-			cfg.Email.SMTPPort,
-			cfg.Email.SMTPUsername,
-			cfg.Email.SMTPPassword,
-			cfg.Email.FromEmail,
-			cfg.Email.FromName,
-			cfg.Email.FrontendURL,
-		)
-		logger.Info("Using real email service")
-	} else {
-		// Use mock email service for development
-		emailService = email.NewMockEmailService()
-		logger.Info("Using mock email service (emails will be logged to console)")
-	}
+	emailService := newEmailService(&cfg.Email)
 
 	// Initialize use cases
-	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.AccessTokenExpireMinutes, cfg.JWT.RefreshTokenExpireDays)
-	userUseCase := user.NewUserUseCase(userRepo, avatarRepo, cloudinaryServ)
-	refreshTokenUseCase := auth.NewRefreshTokenUseCase(refreshTokenRepo)
-	// Initialize OAuth service and use case
-	oauthService := auth.NewGoogleOAuthService(cfg.OAuth.GoogleClientID, cfg.OAuth.GoogleClientSecret, cfg.OAuth.GoogleRedirectURL)
-	oauthUseCase := auth.NewOAuthUseCase(userRepo, oauthService)
+	signingKeys, err := loadJWTSigningKeys(cfg.JWT.Keys)
+	if err != nil {
+		logger.Fatal("Failed to load JWT signing keys", err)
+	}
+	jwtService, err := auth.NewJWTService(
+		cfg.JWT.Algorithm,
+		cfg.JWT.Secret,
+		signingKeys,
+		cfg.JWT.Issuer,
+		cfg.JWT.Audience,
+		cfg.JWT.AllowLegacyTokens,
+		cfg.JWT.AccessTokenExpireMinutes,
+		cfg.JWT.RefreshTokenExpireDays,
+		cfg.JWT.RememberMeExpireDays,
+		revokedTokenRepo,
+	)
+	if err != nil {
+		logger.Fatal("Failed to initialize JWT service", err)
+	}
+	deletionGracePeriod := 24 * time.Hour * time.Duration(cfg.Account.DeletionGracePeriodDays)
+	passwordPolicy := entity.PasswordPolicy{
+		MinLength:     cfg.Password.MinLength,
+		RequireUpper:  cfg.Password.RequireUpper,
+		RequireLower:  cfg.Password.RequireLower,
+		RequireDigit:  cfg.Password.RequireDigit,
+		RequireSymbol: cfg.Password.RequireSymbol,
+	}
+	if err := entity.ValidateBcryptCost(cfg.Password.BcryptCost); err != nil {
+		logger.Fatal("Invalid password configuration", err)
+	}
+	// Domain events are delivered in-process; subscribers are registered below once their dependencies exist
+	eventBus := eventbus.New()
+	// Verification, password reset and email change emails share the per-address limit
+	emailLimiter := auth.NewEmailRateLimiter(
+		cfg.Email.RateLimitMaxSends,
+		time.Minute*time.Duration(cfg.Email.RateLimitWindowMinutes),
+	)
+	messageUseCase := message.NewMessageUseCase(messageRepo, conversationRepo, userRepo, idempotencyKeyRepo, txManager, cloudinaryServ, attachmentDeletionRepo, eventBus, cfg.Message.MaxAttachments)
+	recentSearchUseCase := search.NewRecentSearchUseCase(recentSearchRepo, cfg.Search.RecentLimit)
+	searchUseCase := search.NewSearchUseCase(userRepo, messageRepo)
+	userUseCase := user.NewUserUseCase(
+		userRepo,
+		avatarRepo,
+		avatarDeletionRepo,
+		txManager,
+		cloudinaryServ,
+		messageUseCase,
+		emailService,
+		emailLimiter,
+		eventBus,
+		deletionGracePeriod,
+		time.Minute*time.Duration(cfg.Profile.NameChangeCooldownMinutes),
+		time.Minute*time.Duration(cfg.Profile.AvatarChangeCooldownMinutes),
+		passwordPolicy,
+		cfg.Password.BcryptCost,
+	)
+	refreshTokenUseCase := auth.NewRefreshTokenUseCase(
+		refreshTokenRepo,
+		txManager,
+		time.Minute*time.Duration(cfg.JWT.RefreshTokenIdleTimeoutMinutes),
+	)
+	loginHistoryUseCase := auth.NewLoginHistoryUseCase(loginEventRepo)
+	// Initialize OAuth services and use case
+	oauthTimeout := time.Second * time.Duration(cfg.OAuth.TimeoutSeconds)
+	oauthProviders := map[string]auth.OAuthService{
+		auth.ProviderGoogle: auth.NewGoogleOAuthService(cfg.OAuth.GoogleClientID, cfg.OAuth.GoogleClientSecret, cfg.OAuth.GoogleRedirectURL, oauthTimeout),
+		auth.ProviderGitHub: auth.NewGitHubOAuthService(cfg.OAuth.GitHubClientID, cfg.OAuth.GitHubClientSecret, cfg.OAuth.GitHubRedirectURL, oauthTimeout),
+	}
+	oauthStateStore := postgres.NewOAuthStateStore(db)
+	oauthIdentityRepo := postgres.NewUserOAuthIdentityRepository(db)
+	oauthUseCase := auth.NewOAuthUseCase(userRepo, avatarRepo, oauthIdentityRepo, oauthStateStore, oauthProviders, cloudinaryServ, &http.Client{Timeout: oauthTimeout}, eventBus, deletionGracePeriod)
 	// Initialize Auth use case
-	authUseCase := auth.NewAuthUseCase(userRepo, emailService)
+	emailQueue := email.NewEmailQueue(
+		emailService,
+		cfg.Email.QueueSize,
+		cfg.Email.MaxAttempts,
+		time.Second*time.Duration(cfg.Email.RetryBackoffSeconds),
+		cfg.Email.SendRatePerSecond,
+		cfg.Email.SendBurst,
+	)
+	emailQueue.Start(context.Background())
+	eventBus.Subscribe(event.UserVerified, auth.WelcomeEmailHandler(emailQueue))
+	webhookDispatcher := webhook.NewWebhookDispatcher(
+		cfg.Webhook.URLs,
+		cfg.Webhook.Secret,
+		time.Second*time.Duration(cfg.Webhook.TimeoutSeconds),
+		cfg.Webhook.QueueSize,
+		cfg.Webhook.MaxAttempts,
+		time.Second*time.Duration(cfg.Webhook.RetryBackoffSeconds),
+	)
+	webhookDispatcher.Start(context.Background())
+	if len(cfg.Webhook.URLs) > 0 {
+		for _, name := range webhook.Events {
+			eventBus.Subscribe(name, webhookDispatcher.Handle)
+		}
+		logger.Info(fmt.Sprintf("Webhooks enabled for %d endpoints", len(cfg.Webhook.URLs)))
+	}
+	authUseCase := auth.NewAuthUseCase(userRepo, emailService, emailQueue, emailLimiter, eventBus, deletionGracePeriod, passwordPolicy, cfg.Password.BcryptCost)
+	conversationUseCase := conversation.NewConversationUseCase(conversationRepo, messageRepo, userRepo)
 
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(userUseCase, jwtService, refreshTokenUseCase)
-	oauthHandler := handler.NewOAuthHandler(oauthUseCase, jwtService, refreshTokenUseCase)
-	authHandler := handler.NewAuthHandler(authUseCase, jwtService, refreshTokenUseCase)
+	userHandler := handler.NewUserHandler(userUseCase, jwtService, refreshTokenUseCase, loginHistoryUseCase, oauthUseCase, cloudinaryServ)
+	oauthHandler := handler.NewOAuthHandler(oauthUseCase, jwtService, refreshTokenUseCase, loginHistoryUseCase, cloudinaryServ)
+	authHandler := handler.NewAuthHandler(authUseCase, jwtService, refreshTokenUseCase, loginHistoryUseCase, cloudinaryServ)
+	conversationHandler := handler.NewConversationHandler(conversationUseCase)
+	messageHandler := handler.NewMessageHandler(messageUseCase, recentSearchUseCase, cfg.Message.MaxAttachments)
+	searchHandler := handler.NewSearchHandler(searchUseCase, recentSearchUseCase)
+	healthHandler := handler.NewHealthHandler(map[string]handler.HealthCheck{
+		"database": func(ctx context.Context) error { return database.Ping(ctx, db) },
+	}, 2*time.Second)
 	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+	clientVersionMiddleware := middleware.NewClientVersionMiddleware(cfg.Client.MinVersions)
+	corsOrigins := cfg.CORS.AllowedOrigins
+	if len(corsOrigins) == 0 && cfg.Server.Mode == "debug" {
+		corsOrigins = []string{"*"}
+	}
+	corsMiddleware := middleware.NewCORSMiddleware(corsOrigins, cfg.CORS.AllowedMethods, cfg.CORS.AllowedHeaders, cfg.CORS.AllowCredentials)
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(
+		middleware.NewMemoryRateLimitStore(),
+		middleware.RateLimit{Rate: cfg.RateLimit.RequestsPerSecond, Burst: cfg.RateLimit.Burst},
+		middleware.RateLimit{Rate: cfg.RateLimit.AuthRequestsPerMinute / 60, Burst: cfg.RateLimit.AuthBurst},
+		middleware.RateLimit{Rate: cfg.RateLimit.UserRequestsPerSecond, Burst: cfg.RateLimit.UserBurst},
+	)
+
+	utils.UseProblemDetails(cfg.Server.ProblemDetails)
+	phone.SetDefaultRegion(cfg.Profile.PhoneDefaultRegion)
+
+	// Reload minimum client versions, the error format and the phone region when the config file changes
+	config.Watch(func(newCfg *config.Config) {
+		clientVersionMiddleware.SetMinVersions(newCfg.Client.MinVersions)
+		utils.UseProblemDetails(newCfg.Server.ProblemDetails)
+		if newCfg.Profile.PhoneDefaultRegion == "" || phone.IsRegion(newCfg.Profile.PhoneDefaultRegion) {
+			phone.SetDefaultRegion(newCfg.Profile.PhoneDefaultRegion)
+		}
+		logger.Info("Client version requirements, error format and phone region reloaded")
+	})
+
+	// Start background cleanup jobs
+	cleanupInterval := time.Minute * time.Duration(cfg.Cleanup.IntervalMinutes)
+	cleanupScheduler := scheduler.New()
+	cleanupScheduler.Every("purge_deleted_accounts", cleanupInterval, func(ctx context.Context) error {
+		purged, err := userUseCase.PurgeDeletedAccounts(ctx)
+		if purged > 0 {
+			logger.Info(fmt.Sprintf("Purged %d deleted accounts", purged))
+		}
+		return err
+	})
+	cleanupScheduler.Every("retry_avatar_deletions", cleanupInterval, func(ctx context.Context) error {
+		deleted, err := userUseCase.RetryAvatarDeletions(ctx)
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("Deleted %d leftover avatars from Cloudinary", deleted))
+		}
+		return err
+	})
+	cleanupScheduler.Every("retry_attachment_deletions", cleanupInterval, func(ctx context.Context) error {
+		deleted, err := messageUseCase.RetryAttachmentDeletions(ctx)
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("Deleted %d leftover attachments from Cloudinary", deleted))
+		}
+		return err
+	})
+	cleanupScheduler.Every("delete_expired_refresh_tokens", cleanupInterval, func(ctx context.Context) error {
+		deleted, err := refreshTokenRepo.DeleteExpired(ctx)
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("Deleted %d expired refresh tokens", deleted))
+		}
+		return err
+	})
+	cleanupScheduler.Every("delete_expired_revoked_tokens", cleanupInterval, func(ctx context.Context) error {
+		deleted, err := revokedTokenRepo.DeleteExpired(ctx)
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("Deleted %d expired revoked tokens", deleted))
+		}
+		return err
+	})
+	cleanupScheduler.Every("delete_expired_oauth_states", cleanupInterval, func(ctx context.Context) error {
+		deleted, err := oauthStateStore.DeleteExpired(ctx)
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("Deleted %d expired oauth states", deleted))
+		}
+		return err
+	})
+	cleanupScheduler.Every("delete_expired_idempotency_keys", cleanupInterval, func(ctx context.Context) error {
+		deleted, err := idempotencyKeyRepo.DeleteExpired(ctx)
+		if deleted > 0 {
+			logger.Info(fmt.Sprintf("Deleted %d expired idempotency keys", deleted))
+		}
+		return err
+	})
+	// appCtx is cancelled on SIGINT or SIGTERM, starting the graceful shutdown
+	appCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	cleanupScheduler.Start(appCtx)
+
+	// Expose Prometheus metrics unless disabled
+	var metricsMiddleware *middleware.MetricsMiddleware
+	if cfg.Metrics.Enabled {
+		sqlDB, err := db.DB()
+		if err != nil {
+			logger.Fatal("Failed to access database pool", err)
+		}
+		metricsMiddleware = middleware.NewMetricsMiddleware(metrics.NewRegistry(sqlDB, emailQueue))
+	}
 
 	// Setup router
-	r := router.NewRouter(userHandler, oauthHandler, authHandler, authMiddleware)
+	r := router.NewRouter(userHandler, oauthHandler, authHandler, conversationHandler, messageHandler, searchHandler, healthHandler, authMiddleware, clientVersionMiddleware, corsMiddleware, rateLimitMiddleware, metricsMiddleware, cfg.Server.LogSkipPaths, cfg.Server.TrustedProxies)
 	ginRouter := r.Setup()
 
 	// Create HTTP server
@@ -111,26 +313,129 @@ func main() {
 
 	// Start server in goroutine
 	go func() {
-		logger.Info(fmt.Sprintf("Server starting on port %s", cfg.Server.Port))
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.Server.TLSEnabled() {
+			srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			logger.Info(fmt.Sprintf("Server starting with HTTPS on port %s", cfg.Server.Port))
+			err = srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			logger.Info(fmt.Sprintf("Server starting with plain HTTP on port %s", cfg.Server.Port))
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Failed to start server", err)
 		}
 	}()
 
 	// Wait for interrupt signal for graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	<-appCtx.Done()
+	stopSignals()
 
 	logger.Info("Shutting down server...")
 
-	// Graceful shutdown with 5 second timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	// Stop producers of background work before the subsystems that drain it
+	shutdownTimeout := time.Second * time.Duration(cfg.Server.ShutdownTimeoutSeconds)
+	coordinator := shutdown.New(shutdownTimeout)
+	coordinator.Add("cleanup_scheduler", shutdown.Wait(cleanupScheduler.Stop))
+	coordinator.Add("http_server", srv.Shutdown)
+	// Write the logins recorded by requests that have completed
+	coordinator.Add("login_history", shutdown.Wait(loginHistoryUseCase.Wait))
+	// Let subscribers finish handling the events published so far, which may queue emails and webhooks
+	coordinator.Add("event_bus", shutdown.Wait(eventBus.Wait))
+	// Deliver the webhooks queued by the events handled above
+	coordinator.Add("webhooks", func(ctx context.Context) error {
+		webhookDispatcher.Stop(ctx)
+		if failed := webhookDispatcher.Failed(); failed > 0 {
+			logger.Warn(fmt.Sprintf("%d webhooks could not be delivered", failed))
+		}
+		return nil
+	})
+	// Send the emails queued by requests that have completed
+	coordinator.Add("email_queue", func(ctx context.Context) error {
+		emailQueue.Stop(ctx)
+		if failed := emailQueue.Failed(); failed > 0 {
+			logger.Warn(fmt.Sprintf("%d emails could not be sent", failed))
+		}
+		return nil
+	})
 
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := coordinator.Shutdown(); err != nil {
 		logger.Fatal("Server forced to shutdown", err)
 	}
 
 	logger.Info("Server exited gracefully")
 }
+
+// newEmailService creates the configured email service, falling back to the mock service when no provider
+// is configured. Config validation has already rejected a chosen provider whose credentials are missing.
+func newEmailService(cfg *config.EmailConfig) email.EmailService {
+	hasSMTPCredentials := cfg.SMTPUsername != "" && cfg.SMTPPassword != ""
+
+	provider := cfg.Provider
+	if provider == "" && hasSMTPCredentials {
+		// Configurations predating the provider setting use SMTP whenever credentials are set
+		provider = email.ProviderSMTP
+	}
+
+	switch provider {
+	case email.ProviderSMTP:
+		logger.Info("Using SMTP email service")
+		return email.NewEmailService(
+			cfg.SMTPHost,
+			cfg.SMTPPort,
+			cfg.SMTPUsername,
+			cfg.SMTPPassword,
+			cfg.SMTPTLSMode,
+			cfg.FromEmail,
+			cfg.FromName,
+			cfg.FrontendURL,
+			time.Second*time.Duration(cfg.TimeoutSeconds),
+		)
+	case email.ProviderSendGrid:
+		logger.Info("Using SendGrid email service")
+		return email.NewSendGridEmailService(cfg.SendGridAPIKey, cfg.FromEmail, cfg.FromName, cfg.FrontendURL, time.Second*time.Duration(cfg.TimeoutSeconds))
+	case "", email.ProviderMock:
+	default:
+		logger.Warn(fmt.Sprintf("Unknown email provider %q", provider))
+	}
+
+	// Use mock email service for development
+	logger.Info("Using mock email service (emails will be logged to console)")
+	return email.NewMockEmailService()
+}
+
+// loadJWTSigningKeys reads the configured RS256 key pairs from disk
+func loadJWTSigningKeys(keyConfigs []config.JWTKeyConfig) ([]auth.SigningKey, error) {
+	keys := make([]auth.SigningKey, 0, len(keyConfigs))
+	for _, kc := range keyConfigs {
+		key := auth.SigningKey{
+			ID:         kc.KID,
+			VerifyOnly: kc.VerifyOnly,
+		}
+
+		if kc.PrivateKeyFile != "" {
+			data, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read private key for kid %q: %w", kc.KID, err)
+			}
+			key.PrivateKey, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse private key for kid %q: %w", kc.KID, err)
+			}
+		}
+
+		if kc.PublicKeyFile != "" {
+			data, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key for kid %q: %w", kc.KID, err)
+			}
+			key.PublicKey, err = jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key for kid %q: %w", kc.KID, err)
+			}
+		}
+
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
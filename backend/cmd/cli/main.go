@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/infrastructure/config"
+	"backend/internal/infrastructure/database"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/repository/postgres"
+	"backend/internal/usecase/user"
+)
+
+const usage = `Usage: cli <command> [flags]
+
+Commands:
+  create-admin   Create a verified user with the admin role
+
+Run "cli <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "create-admin":
+		err = createAdmin(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// createAdmin bootstraps an administrator through the same use case and
+// repositories as the API, so password policy, bcrypt cost and uniqueness
+// checks are identical to a normal registration.
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := fs.String("email", "", "email address of the admin (required)")
+	password := fs.String("password", "", "password of the admin; read from stdin when empty")
+	name := fs.String("name", "Administrator", "display name of the admin")
+	username := fs.String("username", "admin", "unique username of the admin")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*email) == "" {
+		fs.Usage()
+		return fmt.Errorf("-email is required")
+	}
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		p, err := readLine(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		*password = p
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	logger.Init(cfg.Server.Mode)
+	defer logger.Sync()
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := database.AutoMigrate(db); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	if err := entity.ValidateBcryptCost(cfg.Password.BcryptCost); err != nil {
+		return err
+	}
+	passwordPolicy := entity.PasswordPolicy{
+		MinLength:     cfg.Password.MinLength,
+		RequireUpper:  cfg.Password.RequireUpper,
+		RequireLower:  cfg.Password.RequireLower,
+		RequireDigit:  cfg.Password.RequireDigit,
+		RequireSymbol: cfg.Password.RequireSymbol,
+	}
+
+	avatarRepo := postgres.NewAvatarRepository(db)
+	userRepo := postgres.NewUserRepository(db, avatarRepo)
+	// Avatars, attachments, email and events are not needed to create an account from the command line
+	userUseCase := user.NewUserUseCase(
+		userRepo,
+		avatarRepo,
+		postgres.NewAvatarDeletionRepository(db),
+		postgres.NewTxManager(db),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		24*time.Hour*time.Duration(cfg.Account.DeletionGracePeriodDays),
+		time.Minute*time.Duration(cfg.Profile.NameChangeCooldownMinutes),
+		time.Minute*time.Duration(cfg.Profile.AvatarChangeCooldownMinutes),
+		passwordPolicy,
+		cfg.Password.BcryptCost,
+	)
+
+	admin, err := userUseCase.CreateAdmin(context.Background(), *email, *password, *name, *username)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %s (id %s, username %s)\n", admin.Email, admin.ID, admin.Username)
+	return nil
+}
+
+// readLine reads a single line without its trailing newline
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"backend/pkg/utils"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Conversation types
+const (
+	ConversationTypeDirect = "direct"
+	ConversationTypeGroup  = "group"
+)
+
+// CreateConversationRequest represents the create conversation request.
+// MemberIDs lists the other participants; the creator is added implicitly,
+// so a direct conversation (exactly two members) takes a single member ID.
+type CreateConversationRequest struct {
+	Type      string   `json:"type" validate:"required,oneof=direct group"`
+	Name      string   `json:"name" validate:"required_if=Type group,max=100"`
+	MemberIDs []string `json:"member_ids" validate:"required,min=1,max=256,unique,dive,uuid"`
+}
+
+// ConversationResponse represents the conversation response
+type ConversationResponse struct {
+	ID                string     `json:"id"`
+	Type              string     `json:"type"`
+	Name              string     `json:"name,omitempty"`
+	ParticipantIDs    []string   `json:"participant_ids"`
+	UnreadCount       int64      `json:"unread_count"`
+	Muted             bool       `json:"muted"`                          // whether the requesting user muted notifications
+	MarkedUnread      bool       `json:"marked_unread"`                  // flagged unread by the requesting user until they read or send
+	LastReadMessageID string     `json:"last_read_message_id,omitempty"` // the last message the requesting user read
+	LastReadAt        *time.Time `json:"last_read_at,omitempty"`         // when the requesting user read it
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+func init() {
+	utils.Validator().RegisterStructValidation(validateCreateConversationRequest, CreateConversationRequest{})
+}
+
+// validateCreateConversationRequest enforces rules that depend on the conversation type
+func validateCreateConversationRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(CreateConversationRequest)
+	if req.Type == ConversationTypeDirect && len(req.MemberIDs) != 1 {
+		sl.ReportError(req.MemberIDs, "MemberIDs", "MemberIDs", "direct_members", "")
+	}
+}
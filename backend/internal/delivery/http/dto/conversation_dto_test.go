@@ -0,0 +1,124 @@
+package dto_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/delivery/http/dto"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	memberA = "7f1c2a8e-3b4d-4e5f-8a9b-0c1d2e3f4a5b"
+	memberB = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+)
+
+// validationDetails runs the request through the shared validator and the
+// validation response path, returning the field-level messages
+func validationDetails(t *testing.T, req dto.CreateConversationRequest) []string {
+	t.Helper()
+
+	err := utils.Validator().Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	utils.ValidationErrorResponse(c, err)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var body struct {
+		Error struct {
+			Code    string   `json:"code"`
+			Details []string `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "VALIDATION_ERROR", body.Error.Code)
+	return body.Error.Details
+}
+
+func TestCreateConversationRequest_ValidDirect(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeDirect,
+		MemberIDs: []string{memberA},
+	})
+
+	assert.Empty(t, details)
+}
+
+func TestCreateConversationRequest_ValidGroup(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeGroup,
+		Name:      "Weekend plans",
+		MemberIDs: []string{memberA, memberB},
+	})
+
+	assert.Empty(t, details)
+}
+
+func TestCreateConversationRequest_InvalidUUID(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeGroup,
+		Name:      "Team",
+		MemberIDs: []string{memberA, "not-a-uuid"},
+	})
+
+	assert.Equal(t, []string{"MemberIDs[1] must be a valid UUID"}, details)
+}
+
+func TestCreateConversationRequest_EmptyMemberList(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeGroup,
+		Name:      "Team",
+		MemberIDs: []string{},
+	})
+
+	assert.Equal(t, []string{"MemberIDs must contain at least 1 item(s)"}, details)
+}
+
+func TestCreateConversationRequest_OverlongGroupName(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeGroup,
+		Name:      strings.Repeat("a", 101),
+		MemberIDs: []string{memberA},
+	})
+
+	assert.Equal(t, []string{"Name must be at most 100 characters"}, details)
+}
+
+func TestCreateConversationRequest_GroupRequiresName(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeGroup,
+		MemberIDs: []string{memberA},
+	})
+
+	assert.Equal(t, []string{"Name is required"}, details)
+}
+
+func TestCreateConversationRequest_DirectNeedsExactlyTwoMembers(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      dto.ConversationTypeDirect,
+		MemberIDs: []string{memberA, memberB},
+	})
+
+	assert.Equal(t, []string{"MemberIDs must contain exactly one other user for a direct conversation"}, details)
+}
+
+func TestCreateConversationRequest_InvalidType(t *testing.T) {
+	details := validationDetails(t, dto.CreateConversationRequest{
+		Type:      "channel",
+		MemberIDs: []string{memberA},
+	})
+
+	assert.Equal(t, []string{"Type must be one of: direct group"}, details)
+}
@@ -0,0 +1,103 @@
+package dto
+
+import "time"
+
+// SendMessageRequest represents the send message request
+type SendMessageRequest struct {
+	Body string `json:"body" validate:"required,max=4000"`
+}
+
+// EditMessageRequest represents the edit message request
+type EditMessageRequest struct {
+	Body string `json:"body" validate:"required,max=4000"`
+}
+
+// MarkReadRequest represents the request to mark a conversation read up to a message
+type MarkReadRequest struct {
+	MessageID string `json:"message_id" validate:"required,uuid"`
+}
+
+// ReactRequest represents the request to toggle a reaction to a message
+type ReactRequest struct {
+	Emoji string `json:"emoji" validate:"required,max=32"`
+}
+
+// ReadStateResponse represents the user's read state of a conversation
+type ReadStateResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// MessageResponse represents the message response
+type MessageResponse struct {
+	ID             string           `json:"id"`
+	ConversationID string           `json:"conversation_id"`
+	SenderID       string           `json:"sender_id"`
+	Body           string           `json:"body"` // "message deleted" once the sender deletes it
+	Attachments    []*AttachmentDTO `json:"attachments"`
+	Reactions      []*ReactionDTO   `json:"reactions"`
+	Mentions       []string         `json:"mentions"` // IDs of the participants mentioned with @username
+	CreatedAt      time.Time        `json:"created_at"`
+	EditedAt       *time.Time       `json:"edited_at,omitempty"`
+	DeletedAt      *time.Time       `json:"deleted_at,omitempty"`
+}
+
+// AttachmentDTO represents a file sent with a message
+type AttachmentDTO struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// MediaItemDTO represents an attachment in a conversation's media gallery
+type MediaItemDTO struct {
+	AttachmentDTO
+	MessageID string    `json:"message_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReactionDTO represents how many users reacted to a message with an emoji
+type ReactionDTO struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+}
+
+// MessageSearchResponse represents the messages matching a search, newest first
+type MessageSearchResponse struct {
+	Messages []*MessageResponse `json:"messages"`
+}
+
+// MessageHistoryResponse represents one page of a conversation's messages, newest first
+type MessageHistoryResponse struct {
+	Messages   []*MessageResponse `json:"messages"`
+	NextCursor string             `json:"next_cursor,omitempty"` // pass as ?before= to load older messages
+}
+
+// MediaGalleryResponse represents one page of the attachments shared in a conversation, newest first
+type MediaGalleryResponse struct {
+	Items      []*MediaItemDTO `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"` // pass as ?before= to load older attachments
+}
+
+// MentionResponse represents a message mentioning the requesting user, with the conversation it was sent in
+type MentionResponse struct {
+	Message      *MessageResponse      `json:"message"`
+	Conversation *ConversationResponse `json:"conversation"`
+}
+
+// MentionsResponse represents one page of the requesting user's unseen mentions, newest first
+type MentionsResponse struct {
+	Mentions   []*MentionResponse `json:"mentions"`
+	NextCursor string             `json:"next_cursor,omitempty"` // pass as ?before= to load older mentions
+}
+
+// ClearHistoryRequest represents the request to delete every message the user sent
+type ClearHistoryRequest struct {
+	Password string `json:"password"` // required unless the account signs in only through OAuth
+}
+
+// ClearHistoryResponse represents how many messages were deleted
+type ClearHistoryResponse struct {
+	Deleted int `json:"deleted"`
+}
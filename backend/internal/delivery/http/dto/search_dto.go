@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// RecentSearchResponse represents a query in the user's recent searches
+type RecentSearchResponse struct {
+	Query      string    `json:"query"`
+	SearchedAt time.Time `json:"searched_at"`
+}
+
+// SearchUserResponse represents a user found by a search. Only the public profile is included:
+// email and phone are never revealed to other users.
+type SearchUserResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Username  string `json:"username,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// SearchResponse represents the results of a search across users and messages, grouped by type
+type SearchResponse struct {
+	Users    []*SearchUserResponse `json:"users"`
+	Messages []*MessageResponse    `json:"messages"`
+}
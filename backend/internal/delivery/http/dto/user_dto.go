@@ -7,41 +7,69 @@ type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 	Name     string `json:"name" validate:"required"`
-	Phone    string `json:"phone" validate:"required"`
+	Username string `json:"username" validate:"required,username"` // unique, for @-mentions
+	Phone    string `json:"phone" validate:"omitempty,phone"`      // optional, E.164, e.g. +15551234567
 }
 
 // LoginRequest represents the user login request
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	RememberMe bool   `json:"remember_me"` // use the longer "remember me" refresh expiry
 }
 
 // UpdateUserRequest represents the user update request
 type UpdateUserRequest struct {
-	Name  string `json:"name" validate:"required"`
-	Phone string `json:"phone"`
+	Name     string `json:"name" validate:"required"`
+	Username string `json:"username" validate:"omitempty,username"` // empty keeps the current username
+	Phone    string `json:"phone" validate:"omitempty,phone"`       // E.164; empty removes the number
+}
+
+// ChangeEmailRequest represents the request to change the user's email address
+type ChangeEmailRequest struct {
+	Email           string `json:"email" validate:"required,email"`
+	CurrentPassword string `json:"current_password"` // required unless the account signs in only through OAuth
+}
+
+// ConfirmEmailChangeRequest represents the email change confirmation request
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
 }
 
 // AvatarDTO represents avatar data transfer object
 type AvatarDTO struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	PublicID  string    `json:"public_id"`
-	PublicURL string    `json:"public_url"`
-	SecureURL string    `json:"secure_url"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string         `json:"id"`
+	UserID     string         `json:"user_id"`
+	PublicID   string         `json:"public_id"`
+	PublicURL  string         `json:"public_url"`
+	SecureURL  string         `json:"secure_url"`
+	Thumbnails map[int]string `json:"thumbnails,omitempty"` // size in pixels -> URL of the square crop
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
 }
 
 // UserResponse represents the user response
 type UserResponse struct {
-	ID        string     `json:"id"`
-	Email     string     `json:"email"`
-	Name      string     `json:"name"`
-	Avatar    *AvatarDTO `json:"avatar,omitempty"`
-	Phone     string     `json:"phone"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID              string     `json:"id"`
+	Email           string     `json:"email"`
+	EmailVerified   bool       `json:"email_verified"`
+	Name            string     `json:"name"`
+	Username        string     `json:"username,omitempty"`
+	Avatar          *AvatarDTO `json:"avatar,omitempty"`
+	Phone           string     `json:"phone,omitempty"`
+	LinkedProviders []string   `json:"linked_providers,omitempty"` // OAuth providers, only in the user's own profile
+	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// LoginEventResponse represents a login in the user's login history
+type LoginEventResponse struct {
+	ID        string    `json:"id"`
+	Method    string    `json:"method"` // "password" or the OAuth provider
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // LoginResponse represents the login response with tokens
@@ -62,23 +90,29 @@ type RefreshTokenResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-// ListUsersResponse represents the list users response
-type ListUsersResponse struct {
-	Users  []*UserResponse `json:"users"`
-	Total  int             `json:"total"`
-	Limit  int             `json:"limit"`
-	Offset int             `json:"offset"`
-}
-
 // OAuthCallbackRequest represents the OAuth callback request
 type OAuthCallbackRequest struct {
 	Code  string `json:"code" validate:"required"`
 	State string `json:"state" validate:"required"`
 }
 
+// IDTokenLoginRequest represents a login with an ID token obtained by the client
+type IDTokenLoginRequest struct {
+	IDToken string `json:"id_token" binding:"required"`
+}
+
 // OAuthAuthURLResponse represents the OAuth authorization URL response
 type OAuthAuthURLResponse struct {
 	AuthURL string `json:"auth_url"`
 	State   string `json:"state"`
 }
 
+// SessionResponse represents an active login session
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,86 @@
+package dto_test
+
+import (
+	"testing"
+
+	"backend/internal/delivery/http/dto"
+	"backend/pkg/phone"
+	"backend/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterRequest_Phone(t *testing.T) {
+	tests := []struct {
+		phone string
+		valid bool
+	}{
+		{"+15551234567", true},
+		{"+1 (555) 123-4567", true},
+		{"+84 912 345 678", true},
+		{"", true},            // phone is optional
+		{"5551234567", false}, // no default region configured
+		{"+1555", false},
+		{"not a number", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.phone, func(t *testing.T) {
+			req := dto.RegisterRequest{Email: "test@example.com", Password: "Password123", Name: "Test User", Username: "test_user", Phone: tt.phone}
+
+			err := utils.Validator().Struct(req)
+
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdateUserRequest_PhoneAndUsernameOptional(t *testing.T) {
+	assert.NoError(t, utils.Validator().Struct(dto.UpdateUserRequest{Name: "Test User"}))
+	assert.Error(t, utils.Validator().Struct(dto.UpdateUserRequest{Name: "Test User", Phone: "12"}))
+	assert.Error(t, utils.Validator().Struct(dto.UpdateUserRequest{Name: "Test User", Username: "a b"}))
+}
+
+func TestRegisterRequest_PhoneWithDefaultRegion(t *testing.T) {
+	phone.SetDefaultRegion("US")
+	t.Cleanup(func() { phone.SetDefaultRegion("") })
+
+	req := dto.RegisterRequest{Email: "test@example.com", Password: "Password123", Name: "Test User", Username: "test_user", Phone: "(555) 123-4567"}
+
+	assert.NoError(t, utils.Validator().Struct(req))
+}
+
+func TestRegisterRequest_Username(t *testing.T) {
+	tests := []struct {
+		username string
+		valid    bool
+	}{
+		{"alice", true},
+		{"Alice_99", true},
+		{"abc", true},
+		{"", false},
+		{"ab", false},
+		{"alice smith", false},
+		{"alice.smith", false},
+		{"@alice", false},
+		{"abcdefghijklmnopqrstuvwxyz12345", false}, // 31 characters
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			req := dto.RegisterRequest{Email: "test@example.com", Password: "Password123", Name: "Test User", Username: tt.username}
+
+			err := utils.Validator().Struct(req)
+
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -5,11 +5,12 @@ import (
 	"time"
 
 	"backend/internal/delivery/http/dto"
-	"backend/internal/domain/errors"
+	"backend/internal/domain/entity"
 	"backend/internal/usecase/auth"
 	"backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 // AuthHandler handles HTTP requests for authentication operations
@@ -17,6 +18,9 @@ type AuthHandler struct {
 	authUseCase         auth.AuthUseCase
 	jwtService          auth.JWTService
 	refreshTokenUseCase auth.RefreshTokenUseCase
+	loginHistoryUseCase auth.LoginHistoryUseCase
+	thumbnailer         AvatarThumbnailer
+	validate            *validator.Validate
 }
 
 // NewAuthHandler creates a new authentication handler
@@ -24,11 +28,16 @@ func NewAuthHandler(
 	authUseCase auth.AuthUseCase,
 	jwtService auth.JWTService,
 	refreshTokenUseCase auth.RefreshTokenUseCase,
+	loginHistoryUseCase auth.LoginHistoryUseCase,
+	thumbnailer AvatarThumbnailer,
 ) *AuthHandler {
 	return &AuthHandler{
 		authUseCase:         authUseCase,
 		jwtService:          jwtService,
 		refreshTokenUseCase: refreshTokenUseCase,
+		loginHistoryUseCase: loginHistoryUseCase,
+		thumbnailer:         thumbnailer,
+		validate:            utils.Validator(),
 	}
 }
 
@@ -51,23 +60,26 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authUseCase.Register(c.Request.Context(), req.Email, req.Password, req.Name, req.Phone)
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	user, err := h.authUseCase.Register(c.Request.Context(), req.Email, req.Password, req.Name, req.Username, req.Phone)
 	if err != nil {
-		if err == errors.ErrUserAlreadyExists {
-			utils.ErrorResponse(c, http.StatusConflict, "user already exists", err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to register user", err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
 	userResponse := &dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Phone:     user.Phone,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		Username:      user.Username,
+		Phone:         user.Phone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}
 
 	utils.SuccessResponse(c, http.StatusCreated, "registration successful, please check your email to verify your account", userResponse)
@@ -95,58 +107,58 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	user, err := h.authUseCase.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
-		if err == errors.ErrInvalidCredentials {
-			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid credentials", err)
-			return
-		}
-		if err == errors.ErrEmailNotVerified {
-			utils.ErrorResponse(c, http.StatusForbidden, "email not verified", err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to login", err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
 	// Generate JWT tokens
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID)
+	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Roles())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate access token", err)
 		return
 	}
 
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID)
+	// "Remember me" logins get the longer refresh lifetime; otherwise the configured default applies
+	var refreshTTL time.Duration
+	if req.RememberMe {
+		refreshTTL = h.jwtService.GetRememberMeRefreshTokenExpiration()
+	}
+	refreshToken, expiresAt, err := h.jwtService.GenerateRefreshToken(user.ID, refreshTTL)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate refresh token", err)
 		return
 	}
 
 	// Store refresh token
-	expiresAt := time.Now().Add(h.jwtService.GetRefreshTokenExpiration())
-	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), user.ID, refreshToken, expiresAt); err != nil {
+	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), user.ID, refreshToken, expiresAt, c.Request.UserAgent(), c.ClientIP()); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to store refresh token", err)
 		return
 	}
+	h.loginHistoryUseCase.RecordLogin(c.Request.Context(), user.ID, entity.LoginMethodPassword, c.ClientIP(), c.Request.UserAgent())
 
 	// Return tokens and user info
 	userResponse := &dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Phone:     user.Phone,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		Username:      user.Username,
+		Phone:         user.Phone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}
 
 	// Convert Avatar entity to AvatarDTO if exists
 	if user.Avatar != nil {
 		userResponse.Avatar = &dto.AvatarDTO{
-			ID:        user.Avatar.ID,
-			UserID:    user.Avatar.UserID,
-			PublicID:  user.Avatar.PublicID,
-			PublicURL: user.Avatar.PublicURL,
-			SecureURL: user.Avatar.SecureURL,
-			CreatedAt: user.Avatar.CreatedAt,
-			UpdatedAt: user.Avatar.UpdatedAt,
+			ID:         user.Avatar.ID,
+			UserID:     user.Avatar.UserID,
+			PublicID:   user.Avatar.PublicID,
+			PublicURL:  user.Avatar.PublicURL,
+			SecureURL:  user.Avatar.SecureURL,
+			Thumbnails: avatarThumbnails(h.thumbnailer, user.Avatar),
+			CreatedAt:  user.Avatar.CreatedAt,
+			UpdatedAt:  user.Avatar.UpdatedAt,
 		}
 	}
 
@@ -178,15 +190,7 @@ func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 
 	err := h.authUseCase.VerifyEmail(c.Request.Context(), req.Token)
 	if err != nil {
-		if err == errors.ErrInvalidVerificationToken {
-			utils.ErrorResponse(c, http.StatusBadRequest, "invalid verification token", err)
-			return
-		}
-		if err == errors.ErrVerificationTokenExpired {
-			utils.ErrorResponse(c, http.StatusGone, "verification token expired", err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to verify email", err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
@@ -214,11 +218,30 @@ func (h *AuthHandler) ResendVerification(c *gin.Context) {
 
 	err := h.authUseCase.ResendVerificationEmail(c.Request.Context(), req.Email)
 	if err != nil {
-		if err == errors.ErrUserNotFound {
-			utils.ErrorResponse(c, http.StatusNotFound, "user not found", err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to resend verification email", err)
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "verification email sent successfully", nil)
+}
+
+// ResendVerificationMe handles resending the verification email to the current user
+// @Summary Resend verification email to the current user
+// @Description Resend verification email to the authenticated user's address
+// @Tags auth
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 429 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /auth/resend-verification-me [post]
+func (h *AuthHandler) ResendVerificationMe(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	err := h.authUseCase.ResendVerificationEmailForUser(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
 		return
 	}
 
@@ -274,15 +297,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 
 	err := h.authUseCase.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
 	if err != nil {
-		if err == errors.ErrInvalidResetToken {
-			utils.ErrorResponse(c, http.StatusBadRequest, "invalid reset token", err)
-			return
-		}
-		if err == errors.ErrResetTokenExpired {
-			utils.ErrorResponse(c, http.StatusGone, "reset token expired", err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to reset password", err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
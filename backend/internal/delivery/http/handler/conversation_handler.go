@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+
+	"backend/internal/delivery/http/dto"
+	"backend/internal/domain/entity"
+	"backend/internal/usecase/conversation"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ConversationHandler handles HTTP requests for conversations
+type ConversationHandler struct {
+	conversationUseCase conversation.ConversationUseCase
+	validate            *validator.Validate
+}
+
+// NewConversationHandler creates a new conversation handler
+func NewConversationHandler(conversationUseCase conversation.ConversationUseCase) *ConversationHandler {
+	return &ConversationHandler{
+		conversationUseCase: conversationUseCase,
+		validate:            utils.Validator(),
+	}
+}
+
+// CreateConversation starts a conversation between the authenticated user and the requested members
+func (h *ConversationHandler) CreateConversation(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dto.CreateConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	conv, err := h.conversationUseCase.Create(c.Request.Context(), userID, req.Type, req.Name, req.MemberIDs)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "conversation created successfully", toConversationResponse(conv, userID))
+}
+
+// ListConversations lists the conversations the authenticated user takes part in
+func (h *ConversationHandler) ListConversations(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	summaries, err := h.conversationUseCase.ListForUser(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	responses := make([]*dto.ConversationResponse, len(summaries))
+	for i, summary := range summaries {
+		responses[i] = toSummaryResponse(summary, userID)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "conversations retrieved successfully", responses)
+}
+
+// GetConversation retrieves a conversation the authenticated user takes part in
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	summary, err := h.conversationUseCase.GetByID(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "conversation retrieved successfully", toSummaryResponse(summary, userID))
+}
+
+// MuteConversation mutes the conversation's notifications for the authenticated user
+func (h *ConversationHandler) MuteConversation(c *gin.Context) {
+	h.setMuted(c, true)
+}
+
+// UnmuteConversation unmutes the conversation's notifications for the authenticated user
+func (h *ConversationHandler) UnmuteConversation(c *gin.Context) {
+	h.setMuted(c, false)
+}
+
+func (h *ConversationHandler) setMuted(c *gin.Context, muted bool) {
+	userID := c.GetString("userID")
+
+	conv, err := h.conversationUseCase.SetMuted(c.Request.Context(), userID, c.Param("id"), muted)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "conversation updated successfully", toConversationResponse(conv, userID))
+}
+
+// MarkUnread flags the conversation as unread for the authenticated user until they next read or send in it
+func (h *ConversationHandler) MarkUnread(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	summary, err := h.conversationUseCase.MarkUnread(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "conversation marked unread", toSummaryResponse(summary, userID))
+}
+
+// toConversationResponse converts a conversation entity to its response DTO as seen by userID
+func toConversationResponse(conv *entity.Conversation, userID string) *dto.ConversationResponse {
+	return &dto.ConversationResponse{
+		ID:             conv.ID,
+		Type:           conv.Type,
+		Name:           conv.Name,
+		ParticipantIDs: conv.ParticipantIDs,
+		Muted:          conv.IsMutedBy(userID),
+		CreatedAt:      conv.CreatedAt,
+		UpdatedAt:      conv.UpdatedAt,
+	}
+}
+
+// toSummaryResponse converts a conversation summary to its response DTO as seen by userID
+func toSummaryResponse(summary *conversation.Summary, userID string) *dto.ConversationResponse {
+	response := toConversationResponse(summary.Conversation, userID)
+	response.UnreadCount = summary.UnreadCount
+	if summary.Read == nil {
+		return response
+	}
+	response.MarkedUnread = summary.Read.MarkedUnread
+	if summary.Read.LastReadMessageID != "" {
+		lastReadAt := summary.Read.UpdatedAt
+		response.LastReadMessageID = summary.Read.LastReadMessageID
+		response.LastReadAt = &lastReadAt
+	}
+	return response
+}
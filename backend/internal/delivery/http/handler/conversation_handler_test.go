@@ -0,0 +1,242 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/delivery/http/dto"
+	"backend/internal/delivery/http/handler"
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/usecase/conversation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conversationUseCase keeps created conversations in memory
+type conversationUseCase struct {
+	conversation.ConversationUseCase
+	conversations []*entity.Conversation
+	unread        map[string]int64
+	reads         map[string]*entity.ConversationRead // keyed by user id
+}
+
+func (uc *conversationUseCase) Create(ctx context.Context, userID, conversationType, name string, memberIDs []string) (*entity.Conversation, error) {
+	conv := entity.NewConversation(conversationType, name, append([]string{userID}, memberIDs...))
+	uc.conversations = append(uc.conversations, conv)
+	return conv, nil
+}
+
+func (uc *conversationUseCase) ListForUser(ctx context.Context, userID string) ([]*conversation.Summary, error) {
+	var result []*conversation.Summary
+	for _, conv := range uc.conversations {
+		if conv.HasParticipant(userID) {
+			result = append(result, &conversation.Summary{Conversation: conv, UnreadCount: uc.unread[conv.ID], Read: uc.reads[userID]})
+		}
+	}
+	return result, nil
+}
+
+func (uc *conversationUseCase) SetMuted(ctx context.Context, userID, conversationID string, muted bool) (*entity.Conversation, error) {
+	for _, conv := range uc.conversations {
+		if conv.ID == conversationID {
+			conv.SetMuted(userID, muted)
+			return conv, nil
+		}
+	}
+	return nil, errors.ErrConversationNotFound
+}
+
+func (uc *conversationUseCase) MarkUnread(ctx context.Context, userID, conversationID string) (*conversation.Summary, error) {
+	for _, conv := range uc.conversations {
+		if conv.ID == conversationID {
+			if uc.reads == nil {
+				uc.reads = make(map[string]*entity.ConversationRead)
+			}
+			uc.reads[userID] = &entity.ConversationRead{ConversationID: conv.ID, UserID: userID, MarkedUnread: true}
+			return &conversation.Summary{Conversation: conv, Read: uc.reads[userID]}, nil
+		}
+	}
+	return nil, errors.ErrConversationNotFound
+}
+
+// serveConversations sends the request to the conversation handler as the given user
+func serveConversations(uc conversation.ConversationUseCase, userID, method, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	h := handler.NewConversationHandler(uc)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/conversations", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", userID)
+
+	if method == http.MethodPost {
+		h.CreateConversation(c)
+	} else {
+		h.ListConversations(c)
+	}
+	return w
+}
+
+func TestCreateConversation_ThenList(t *testing.T) {
+	const (
+		creator = "7f1c2a8e-3b4d-4e5f-8a9b-0c1d2e3f4a5b"
+		other   = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	)
+	uc := &conversationUseCase{}
+
+	w := serveConversations(uc, creator, http.MethodPost, `{"type":"direct","member_ids":["`+other+`"]}`)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Data dto.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "direct", created.Data.Type)
+	assert.Equal(t, []string{creator, other}, created.Data.ParticipantIDs)
+
+	// Both participants see the conversation
+	for _, userID := range []string{creator, other} {
+		w = serveConversations(uc, userID, http.MethodGet, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var listed struct {
+			Data []dto.ConversationResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+		require.Len(t, listed.Data, 1)
+		assert.Equal(t, created.Data.ID, listed.Data[0].ID)
+	}
+}
+
+func TestListConversations_EmptyList(t *testing.T) {
+	w := serveConversations(&conversationUseCase{}, "user-1", http.MethodGet, "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"data":[]`)
+}
+
+func TestCreateConversation_ValidationFails(t *testing.T) {
+	uc := &conversationUseCase{}
+
+	w := serveConversations(uc, "user-1", http.MethodPost, `{"type":"direct","member_ids":["not-a-uuid"]}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, uc.conversations)
+}
+
+func TestListConversations_IncludesUnreadCount(t *testing.T) {
+	read := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	unread := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-3"})
+	uc := &conversationUseCase{
+		conversations: []*entity.Conversation{read, unread},
+		unread:        map[string]int64{unread.ID: 5},
+	}
+
+	w := serveConversations(uc, "user-1", http.MethodGet, "")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 2)
+	// Zero is reported explicitly rather than omitted
+	assert.Equal(t, float64(0), listed.Data[0]["unread_count"])
+	assert.Equal(t, float64(5), listed.Data[1]["unread_count"])
+}
+
+func TestListConversations_IncludesOwnReadPosition(t *testing.T) {
+	conv := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	readAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc := &conversationUseCase{
+		conversations: []*entity.Conversation{conv},
+		reads: map[string]*entity.ConversationRead{
+			"user-1": {ConversationID: conv.ID, UserID: "user-1", LastReadMessageID: "msg-1", UpdatedAt: readAt},
+		},
+	}
+
+	w := serveConversations(uc, "user-1", http.MethodGet, "")
+	var listed struct {
+		Data []dto.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	assert.Equal(t, "msg-1", listed.Data[0].LastReadMessageID)
+	require.NotNil(t, listed.Data[0].LastReadAt)
+	assert.True(t, readAt.Equal(*listed.Data[0].LastReadAt))
+
+	// A participant who has not read anything gets neither field
+	w = serveConversations(uc, "user-2", http.MethodGet, "")
+	assert.NotContains(t, w.Body.String(), "last_read_message_id")
+	assert.NotContains(t, w.Body.String(), "last_read_at")
+}
+
+func TestMuteConversation_ShowsMutedOnlyToThatUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conv := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	uc := &conversationUseCase{conversations: []*entity.Conversation{conv}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/conversations/"+conv.ID+"/mute", nil)
+	c.Params = gin.Params{{Key: "id", Value: conv.ID}}
+	c.Set("userID", "user-1")
+
+	handler.NewConversationHandler(uc).MuteConversation(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var muted struct {
+		Data dto.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &muted))
+	assert.True(t, muted.Data.Muted)
+
+	w = serveConversations(uc, "user-2", http.MethodGet, "")
+	var listed struct {
+		Data []dto.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	assert.False(t, listed.Data[0].Muted)
+}
+
+func TestMarkUnread_ShowsMarkedUnreadOnlyToThatUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conv := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	uc := &conversationUseCase{conversations: []*entity.Conversation{conv}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/conversations/"+conv.ID+"/mark-unread", nil)
+	c.Params = gin.Params{{Key: "id", Value: conv.ID}}
+	c.Set("userID", "user-1")
+
+	handler.NewConversationHandler(uc).MarkUnread(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var marked struct {
+		Data dto.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &marked))
+	assert.True(t, marked.Data.MarkedUnread)
+	// Nothing was read, so there is no read position to report
+	assert.Empty(t, marked.Data.LastReadMessageID)
+	assert.Nil(t, marked.Data.LastReadAt)
+
+	w = serveConversations(uc, "user-2", http.MethodGet, "")
+	var listed struct {
+		Data []dto.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	assert.False(t, listed.Data[0].MarkedUnread)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"backend/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HealthCheck reports whether a dependency is usable
+type HealthCheck func(ctx context.Context) error
+
+// HealthHandler handles the liveness and readiness probes
+type HealthHandler struct {
+	checks  map[string]HealthCheck
+	timeout time.Duration
+}
+
+// NewHealthHandler creates a new health handler. checks maps each dependency name (e.g. "database")
+// to its check; a check taking longer than timeout counts as failed.
+func NewHealthHandler(checks map[string]HealthCheck, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Live reports that the process is up, without checking its dependencies
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready runs every dependency check concurrently and returns 503 unless all of them pass.
+// Failure details are logged rather than returned, as they may describe the infrastructure.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		ready  = true
+		checks = make(map[string]string, len(h.checks))
+	)
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			status := "up"
+			if err := check(ctx); err != nil {
+				logger.WarnContext(ctx, "Readiness check failed", zap.String("dependency", name), zap.Error(err))
+				status = "down"
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			checks[name] = status
+			if status != "up" {
+				ready = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/delivery/http/handler"
+	"backend/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func checkReadiness(t *testing.T, checks map[string]handler.HealthCheck) (int, readinessResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger.Init("release")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+
+	handler.NewHealthHandler(checks, 50*time.Millisecond).Ready(c)
+
+	var body readinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestReady_AllDependenciesUp(t *testing.T) {
+	code, body := checkReadiness(t, map[string]handler.HealthCheck{
+		"database": func(ctx context.Context) error { return nil },
+	})
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]string{"database": "up"}, body.Checks)
+}
+
+func TestReady_DependencyDown(t *testing.T) {
+	code, body := checkReadiness(t, map[string]handler.HealthCheck{
+		"database": func(ctx context.Context) error { return errors.New("connection refused to db.internal:5432") },
+		"email":    func(ctx context.Context) error { return nil },
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"database": "down", "email": "up"}, body.Checks)
+}
+
+func TestReady_HangingCheckTimesOut(t *testing.T) {
+	start := time.Now()
+	code, body := checkReadiness(t, map[string]handler.HealthCheck{
+		"database": func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "down", body.Checks["database"])
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"backend/internal/delivery/http/dto"
+	"backend/internal/domain/entity"
+	"backend/internal/usecase/message"
+	"backend/internal/usecase/search"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// attachmentRequestOverhead is the room left in an attachment upload request for the multipart framing and caption
+	attachmentRequestOverhead = 1024 * 1024
+	// attachmentFormMemory is how much of an attachment upload is held in memory before spilling to disk
+	attachmentFormMemory = 1024 * 1024
+	// maxAttachmentNameLength caps the stored file name, in bytes
+	maxAttachmentNameLength = 255
+)
+
+// allowedAttachmentTypes are the sniffed content types accepted as chat attachments
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"application/zip": true, // also covers Office documents
+	"text/plain":      true,
+	"video/mp4":       true,
+	"video/webm":      true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+	"application/ogg": true,
+}
+
+// MessageHandler handles HTTP requests for conversation messages
+type MessageHandler struct {
+	messageUseCase      message.MessageUseCase
+	recentSearchUseCase search.RecentSearchUseCase
+	validate            *validator.Validate
+	// maxAttachmentRequestSize bounds an attachment upload request: the largest attachments a message can carry
+	maxAttachmentRequestSize int64
+}
+
+// NewMessageHandler creates a new message handler; maxAttachments is the number of files a message can carry.
+// Message searches are recorded in the user's recent searches.
+func NewMessageHandler(messageUseCase message.MessageUseCase, recentSearchUseCase search.RecentSearchUseCase, maxAttachments int) *MessageHandler {
+	return &MessageHandler{
+		messageUseCase:           messageUseCase,
+		recentSearchUseCase:      recentSearchUseCase,
+		validate:                 utils.Validator(),
+		maxAttachmentRequestSize: int64(max(maxAttachments, 1))*entity.MaxAttachmentSize + attachmentRequestOverhead,
+	}
+}
+
+// SendMessage posts a message from the authenticated user to the conversation.
+// Clients retrying a send should repeat the Idempotency-Key header to get the original message back.
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dto.SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	msg, err := h.messageUseCase.Send(c.Request.Context(), userID, c.Param("id"), req.Body, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "message sent successfully", toMessageResponse(msg))
+}
+
+// SendAttachment uploads files and posts them to the conversation as a message from the authenticated user.
+// The multipart form carries one or more files, each in a "file" part, and an optional caption in "body".
+func (h *MessageHandler) SendAttachment(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	// Cap the request body and keep little of it in memory: larger parts spill to a temp file.
+	// The size of each file is enforced while streaming it to Cloudinary.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxAttachmentRequestSize)
+	if err := c.Request.ParseMultipartForm(attachmentFormMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "file size exceeds 20MB limit", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid multipart form", err)
+		return
+	}
+
+	headers := c.Request.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "file is required", nil)
+		return
+	}
+
+	uploads := make([]message.AttachmentUpload, 0, len(headers))
+	for _, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "failed to read file", err)
+			return
+		}
+		defer file.Close()
+
+		// The declared type is client-controlled, so the type is taken from the file's content
+		detectedType, err := detectContentType(file)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "failed to read file", err)
+			return
+		}
+		contentType, _, err := mime.ParseMediaType(detectedType)
+		if err != nil || !allowedAttachmentTypes[contentType] {
+			utils.ErrorResponse(c, http.StatusBadRequest, "unsupported file type. Allowed: images, pdf, zip, text, mp4, webm, mp3, wav, ogg", nil)
+			return
+		}
+
+		fileName := filepath.Base(header.Filename)
+		if len(fileName) > maxAttachmentNameLength {
+			fileName = fileName[:maxAttachmentNameLength]
+		}
+		uploads = append(uploads, message.AttachmentUpload{File: file, FileName: fileName, ContentType: contentType})
+	}
+
+	msg, err := h.messageUseCase.SendAttachments(c.Request.Context(), userID, c.Param("id"), uploads, c.Request.FormValue("body"))
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "message sent successfully", toMessageResponse(msg))
+}
+
+// ListMessages returns a page of the conversation's history, newest first.
+// The before query parameter takes the next_cursor of the previous page.
+func (h *MessageHandler) ListMessages(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	page, err := h.messageUseCase.History(c.Request.Context(), userID, c.Param("id"), c.Query("before"), limit)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	response := &dto.MessageHistoryResponse{
+		Messages:   make([]*dto.MessageResponse, len(page.Messages)),
+		NextCursor: page.NextCursor,
+	}
+	for i, msg := range page.Messages {
+		response.Messages[i] = toMessageResponse(msg)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "messages retrieved successfully", response)
+}
+
+// ListMedia returns a page of the attachments shared in the conversation, newest first.
+// The type query parameter keeps a single media type; before takes the next_cursor of the previous page.
+func (h *MessageHandler) ListMedia(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	page, err := h.messageUseCase.Media(c.Request.Context(), userID, c.Param("id"), c.Query("type"), c.Query("before"), limit)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	response := &dto.MediaGalleryResponse{
+		Items:      make([]*dto.MediaItemDTO, len(page.Attachments)),
+		NextCursor: page.NextCursor,
+	}
+	for i, attachment := range page.Attachments {
+		response.Items[i] = &dto.MediaItemDTO{
+			AttachmentDTO: *toAttachmentDTO(attachment),
+			MessageID:     attachment.MessageID,
+			CreatedAt:     attachment.CreatedAt,
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "media retrieved successfully", response)
+}
+
+// SearchMessages returns the conversation's messages containing the q query parameter, newest first
+func (h *MessageHandler) SearchMessages(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	messages, err := h.messageUseCase.Search(c.Request.Context(), userID, c.Param("id"), c.Query("q"), limit)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+	h.recentSearchUseCase.Record(c.Request.Context(), userID, c.Query("q"))
+
+	response := &dto.MessageSearchResponse{Messages: make([]*dto.MessageResponse, len(messages))}
+	for i, msg := range messages {
+		response.Messages[i] = toMessageResponse(msg)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "messages retrieved successfully", response)
+}
+
+// ListMentions returns a page of the messages mentioning the authenticated user that they have not read yet,
+// newest first. The before query parameter takes the next_cursor of the previous page.
+func (h *MessageHandler) ListMentions(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	page, err := h.messageUseCase.Mentions(c.Request.Context(), userID, c.Query("before"), limit)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	response := &dto.MentionsResponse{
+		Mentions:   make([]*dto.MentionResponse, len(page.Mentions)),
+		NextCursor: page.NextCursor,
+	}
+	for i, mention := range page.Mentions {
+		response.Mentions[i] = &dto.MentionResponse{
+			Message:      toMessageResponse(mention.Message),
+			Conversation: toConversationResponse(mention.Conversation, userID),
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "mentions retrieved successfully", response)
+}
+
+// ClearHistory deletes every message the authenticated user sent after confirming their password.
+// The messages stay in their conversations as deleted messages.
+func (h *MessageHandler) ClearHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dto.ClearHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	deleted, err := h.messageUseCase.ClearHistory(c.Request.Context(), userID, req.Password)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "message history cleared successfully", &dto.ClearHistoryResponse{Deleted: deleted})
+}
+
+// MarkRead records the message as the last one the authenticated user has read in the conversation
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	userID := c.GetString("userID")
+	conversationID := c.Param("id")
+
+	var req dto.MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	if err := h.messageUseCase.MarkRead(c.Request.Context(), userID, conversationID, req.MessageID); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	unread, err := h.messageUseCase.UnreadCount(c.Request.Context(), userID, conversationID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "conversation marked as read", &dto.ReadStateResponse{UnreadCount: unread})
+}
+
+// EditMessage replaces the body of a message sent by the authenticated user
+func (h *MessageHandler) EditMessage(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dto.EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	msg, err := h.messageUseCase.Edit(c.Request.Context(), userID, c.Param("id"), req.Body)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "message updated successfully", toMessageResponse(msg))
+}
+
+// ReactToMessage toggles the authenticated user's emoji reaction to a message
+func (h *MessageHandler) ReactToMessage(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dto.ReactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	msg, err := h.messageUseCase.React(c.Request.Context(), userID, c.Param("id"), req.Emoji)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "reaction updated successfully", toMessageResponse(msg))
+}
+
+// DeleteMessage deletes a message sent by the authenticated user, leaving a tombstone in the history
+func (h *MessageHandler) DeleteMessage(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	msg, err := h.messageUseCase.Delete(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "message deleted successfully", toMessageResponse(msg))
+}
+
+// toMessageResponse converts a message entity to its response DTO
+func toMessageResponse(msg *entity.Message) *dto.MessageResponse {
+	response := &dto.MessageResponse{
+		ID:             msg.ID,
+		ConversationID: msg.ConversationID,
+		SenderID:       msg.SenderID,
+		Body:           msg.Body,
+		Attachments:    []*dto.AttachmentDTO{},
+		Reactions:      []*dto.ReactionDTO{},
+		Mentions:       []string{},
+		CreatedAt:      msg.CreatedAt,
+		EditedAt:       msg.EditedAt,
+		DeletedAt:      msg.DeletedAt,
+	}
+	if msg.IsDeleted() {
+		response.Body = entity.DeletedMessageBody
+		return response
+	}
+
+	for _, attachment := range msg.Attachments {
+		response.Attachments = append(response.Attachments, toAttachmentDTO(attachment))
+	}
+	for _, reaction := range msg.Reactions {
+		response.Reactions = append(response.Reactions, &dto.ReactionDTO{
+			Emoji: reaction.Emoji,
+			Count: reaction.Count,
+		})
+	}
+	response.Mentions = append(response.Mentions, msg.Mentions...)
+	return response
+}
+
+func toAttachmentDTO(attachment *entity.Attachment) *dto.AttachmentDTO {
+	return &dto.AttachmentDTO{
+		ID:          attachment.ID,
+		URL:         attachment.URL,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+	}
+}
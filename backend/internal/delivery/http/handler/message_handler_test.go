@@ -0,0 +1,458 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/delivery/http/dto"
+	"backend/internal/delivery/http/handler"
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/usecase/message"
+	"backend/internal/usecase/search"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageUseCase accepts messages from the participants of a single conversation
+type messageUseCase struct {
+	message.MessageUseCase
+	participants []string
+	sent         []*entity.Message
+	cursor       string
+	limit        int
+	readUpTo     string
+	uploaded     [][]byte
+	query        string
+	idemKey      string
+}
+
+// recentSearches records the queries searched for
+type recentSearches struct {
+	search.RecentSearchUseCase
+	recorded []string
+}
+
+func (uc *recentSearches) Record(ctx context.Context, userID, query string) {
+	uc.recorded = append(uc.recorded, query)
+}
+
+func (uc *messageUseCase) Search(ctx context.Context, userID, conversationID, query string, limit int) ([]*entity.Message, error) {
+	uc.query, uc.limit = query, limit
+	return uc.sent, nil
+}
+
+func (uc *messageUseCase) SendAttachments(ctx context.Context, senderID, conversationID string, uploads []message.AttachmentUpload, body string) (*entity.Message, error) {
+	msg := entity.NewMessage(conversationID, senderID, body)
+	for _, upload := range uploads {
+		content, err := io.ReadAll(upload.File)
+		if err != nil {
+			return nil, err
+		}
+		uc.uploaded = append(uc.uploaded, content)
+		msg.Attachments = append(msg.Attachments,
+			entity.NewAttachment(msg.ID, "chat/"+conversationID+"/file", "raw", "https://cdn.example.com/file", upload.FileName, upload.ContentType, int64(len(content))))
+	}
+	uc.sent = append(uc.sent, msg)
+	return msg, nil
+}
+
+func (uc *messageUseCase) History(ctx context.Context, userID, conversationID, cursor string, limit int) (*message.HistoryPage, error) {
+	uc.cursor, uc.limit = cursor, limit
+	return &message.HistoryPage{Messages: uc.sent, NextCursor: "next"}, nil
+}
+
+func (uc *messageUseCase) MarkRead(ctx context.Context, userID, conversationID, messageID string) error {
+	uc.readUpTo = messageID
+	return nil
+}
+
+func (uc *messageUseCase) UnreadCount(ctx context.Context, userID, conversationID string) (int64, error) {
+	return 2, nil
+}
+
+func (uc *messageUseCase) Delete(ctx context.Context, userID, messageID string) (*entity.Message, error) {
+	msg := entity.NewMessage("conv-1", "user-1", "secret")
+	if userID != msg.SenderID {
+		return nil, errors.ErrNotMessageSender
+	}
+	msg.MarkDeleted()
+	return msg, nil
+}
+
+func (uc *messageUseCase) React(ctx context.Context, userID, messageID, emoji string) (*entity.Message, error) {
+	if emoji != "👍" {
+		return nil, errors.ErrInvalidReaction
+	}
+	msg := entity.NewMessage("conv-1", "user-1", "hello")
+	msg.Reactions = []*entity.ReactionCount{{Emoji: emoji, Count: 1}}
+	return msg, nil
+}
+
+func (uc *messageUseCase) Send(ctx context.Context, senderID, conversationID, body, idempotencyKey string) (*entity.Message, error) {
+	uc.idemKey = idempotencyKey
+	conv := &entity.Conversation{ID: conversationID, ParticipantIDs: uc.participants}
+	if !conv.HasParticipant(senderID) {
+		return nil, errors.ErrNotConversationMember
+	}
+	msg := entity.NewMessage(conversationID, senderID, body)
+	uc.sent = append(uc.sent, msg)
+	return msg, nil
+}
+
+// sendMessage posts body to conversation conv-1 as the given user
+func sendMessage(uc message.MessageUseCase, userID, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/conversations/conv-1/messages", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", userID)
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).SendMessage(c)
+	return w
+}
+
+func TestSendMessage_ReturnsCreatedMessage(t *testing.T) {
+	uc := &messageUseCase{participants: []string{"user-1", "user-2"}}
+
+	w := sendMessage(uc, "user-1", `{"body":"hello"}`)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	var body struct {
+		Data dto.MessageResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "conv-1", body.Data.ConversationID)
+	assert.Equal(t, "user-1", body.Data.SenderID)
+	assert.Equal(t, "hello", body.Data.Body)
+	assert.Nil(t, body.Data.EditedAt)
+}
+
+func TestSendMessage_PassesIdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &messageUseCase{participants: []string{"user-1"}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/conversations/conv-1/messages", strings.NewReader(`{"body":"hello"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Idempotency-Key", "retry-1")
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", "user-1")
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).SendMessage(c)
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "retry-1", uc.idemKey)
+}
+
+func TestSendMessage_NotParticipant(t *testing.T) {
+	uc := &messageUseCase{participants: []string{"user-1", "user-2"}}
+
+	w := sendMessage(uc, "user-3", `{"body":"hello"}`)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, uc.sent)
+}
+
+func TestSendMessage_InvalidBody(t *testing.T) {
+	bodies := map[string]string{
+		"missing":  `{}`,
+		"too long": `{"body":"` + strings.Repeat("a", entity.MaxMessageLength+1) + `"}`,
+	}
+
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			uc := &messageUseCase{participants: []string{"user-1"}}
+
+			w := sendMessage(uc, "user-1", body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Empty(t, uc.sent)
+		})
+	}
+}
+
+func TestListMessages_PassesCursorAndReturnsNext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &messageUseCase{sent: []*entity.Message{entity.NewMessage("conv-1", "user-1", "hello")}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/conversations/conv-1/messages?before=abc&limit=20", nil)
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", "user-1")
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).ListMessages(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc", uc.cursor)
+	assert.Equal(t, 20, uc.limit)
+	var body struct {
+		Data dto.MessageHistoryResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data.Messages, 1)
+	assert.Equal(t, "hello", body.Data.Messages[0].Body)
+	assert.Equal(t, "next", body.Data.NextCursor)
+}
+
+func TestSearchMessages_PassesQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &messageUseCase{sent: []*entity.Message{entity.NewMessage("conv-1", "user-1", "lunch?")}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/conversations/conv-1/messages/search?q=lunch+time&limit=5", nil)
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", "user-1")
+
+	recent := &recentSearches{}
+	handler.NewMessageHandler(uc, recent, message.DefaultMaxAttachments).SearchMessages(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "lunch time", uc.query)
+	assert.Equal(t, 5, uc.limit)
+	assert.Equal(t, []string{"lunch time"}, recent.recorded)
+	var body struct {
+		Data dto.MessageSearchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data.Messages, 1)
+	assert.Equal(t, "lunch?", body.Data.Messages[0].Body)
+}
+
+func TestMarkRead_ReturnsUnreadCount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &messageUseCase{}
+	messageID := "7f1c2a8e-3b4d-4e5f-8a9b-0c1d2e3f4a5b"
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/conversations/conv-1/read", strings.NewReader(`{"message_id":"`+messageID+`"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", "user-1")
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).MarkRead(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, messageID, uc.readUpTo)
+	var body struct {
+		Data dto.ReadStateResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(2), body.Data.UnreadCount)
+}
+
+// deleteMessage deletes a message sent by user-1 as the given user
+func deleteMessage(uc message.MessageUseCase, userID string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/api/v1/messages/msg-1", nil)
+	c.Params = gin.Params{{Key: "id", Value: "msg-1"}}
+	c.Set("userID", userID)
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).DeleteMessage(c)
+	return w
+}
+
+func TestDeleteMessage_ReturnsTombstone(t *testing.T) {
+	w := deleteMessage(&messageUseCase{}, "user-1")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data dto.MessageResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, entity.DeletedMessageBody, body.Data.Body)
+	assert.NotNil(t, body.Data.DeletedAt)
+}
+
+func TestDeleteMessage_NotSender(t *testing.T) {
+	w := deleteMessage(&messageUseCase{}, "user-2")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestListMessages_ShowsDeletedMessagesAsTombstones(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deleted := entity.NewMessage("conv-1", "user-1", "secret")
+	deleted.MarkDeleted()
+	uc := &messageUseCase{sent: []*entity.Message{deleted}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/conversations/conv-1/messages", nil)
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", "user-1")
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).ListMessages(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data dto.MessageHistoryResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data.Messages, 1)
+	assert.Equal(t, entity.DeletedMessageBody, body.Data.Messages[0].Body)
+}
+
+// reactToMessage posts a reaction request body to message msg-1 as user-2
+func reactToMessage(uc message.MessageUseCase, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/messages/msg-1/reactions", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "msg-1"}}
+	c.Set("userID", "user-2")
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).ReactToMessage(c)
+	return w
+}
+
+func TestReactToMessage_ReturnsReactionCounts(t *testing.T) {
+	w := reactToMessage(&messageUseCase{}, `{"emoji":"👍"}`)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data dto.MessageResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data.Reactions, 1)
+	assert.Equal(t, "👍", body.Data.Reactions[0].Emoji)
+	assert.Equal(t, int64(1), body.Data.Reactions[0].Count)
+}
+
+func TestReactToMessage_InvalidEmoji(t *testing.T) {
+	bodies := map[string]string{
+		"missing":   `{}`,
+		"not emoji": `{"emoji":"ok"}`,
+	}
+
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			w := reactToMessage(&messageUseCase{}, body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+// formFile is a file sent in an attachment upload form
+type formFile struct {
+	name    string
+	content []byte
+}
+
+// sendAttachment posts the files as attachment form files with an optional caption
+func sendAttachment(t *testing.T, uc message.MessageUseCase, caption string, files ...formFile) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, file := range files {
+		part, err := writer.CreateFormFile("file", file.name)
+		require.NoError(t, err)
+		_, err = part.Write(file.content)
+		require.NoError(t, err)
+	}
+	if caption != "" {
+		require.NoError(t, writer.WriteField("body", caption))
+	}
+	require.NoError(t, writer.Close())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/conversations/conv-1/messages/attachment", &body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	c.Params = gin.Params{{Key: "id", Value: "conv-1"}}
+	c.Set("userID", "user-1")
+
+	handler.NewMessageHandler(uc, &recentSearches{}, message.DefaultMaxAttachments).SendAttachment(c)
+	return w
+}
+
+func TestSendAttachment_ReturnsMessageWithAttachment(t *testing.T) {
+	uc := &messageUseCase{}
+	content := []byte("%PDF-1.4\n" + strings.Repeat("x", 1024))
+
+	w := sendAttachment(t, uc, "see attached", formFile{"../report.pdf", content})
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	// Sniffing the type must not consume the bytes sent to the upload
+	assert.Equal(t, [][]byte{content}, uc.uploaded)
+	var body struct {
+		Data dto.MessageResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "see attached", body.Data.Body)
+	require.Len(t, body.Data.Attachments, 1)
+	assert.Equal(t, "report.pdf", body.Data.Attachments[0].FileName)
+	assert.Equal(t, "application/pdf", body.Data.Attachments[0].ContentType)
+	assert.Equal(t, int64(len(content)), body.Data.Attachments[0].Size)
+}
+
+func TestSendAttachment_RejectsUnsupportedContent(t *testing.T) {
+	files := map[string][]byte{
+		"executable": []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00"),
+		"html":       []byte("<html><script>alert(1)</script></html>"),
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			uc := &messageUseCase{}
+
+			w := sendAttachment(t, uc, "", formFile{"file.pdf", content})
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Empty(t, uc.sent)
+		})
+	}
+}
+
+func TestSendAttachment_SendsEveryFileInOneMessage(t *testing.T) {
+	uc := &messageUseCase{}
+	pdf := []byte("%PDF-1.4\n" + strings.Repeat("x", 1024))
+	text := []byte("notes")
+
+	w := sendAttachment(t, uc, "", formFile{"report.pdf", pdf}, formFile{"notes.txt", text})
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, [][]byte{pdf, text}, uc.uploaded)
+	var body struct {
+		Data dto.MessageResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data.Attachments, 2)
+	assert.Equal(t, "report.pdf", body.Data.Attachments[0].FileName)
+	assert.Equal(t, "notes.txt", body.Data.Attachments[1].FileName)
+}
+
+func TestSendAttachment_UnsupportedFileRejectsWholeUpload(t *testing.T) {
+	uc := &messageUseCase{}
+	pdf := []byte("%PDF-1.4\n" + strings.Repeat("x", 1024))
+	html := []byte("<html><script>alert(1)</script></html>")
+
+	w := sendAttachment(t, uc, "", formFile{"report.pdf", pdf}, formFile{"page.pdf", html})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, uc.uploaded)
+}
@@ -2,9 +2,9 @@ package handler
 
 import (
 	"net/http"
-	"time"
 
 	"backend/internal/delivery/http/dto"
+	"backend/internal/domain/entity"
 	"backend/internal/usecase/auth"
 	"backend/pkg/utils"
 
@@ -16,6 +16,8 @@ type OAuthHandler struct {
 	oauthUseCase        auth.OAuthUseCase
 	jwtService          auth.JWTService
 	refreshTokenUseCase auth.RefreshTokenUseCase
+	loginHistoryUseCase auth.LoginHistoryUseCase
+	thumbnailer         AvatarThumbnailer
 }
 
 // NewOAuthHandler creates a new OAuth handler
@@ -23,11 +25,15 @@ func NewOAuthHandler(
 	oauthUseCase auth.OAuthUseCase,
 	jwtService auth.JWTService,
 	refreshTokenUseCase auth.RefreshTokenUseCase,
+	loginHistoryUseCase auth.LoginHistoryUseCase,
+	thumbnailer AvatarThumbnailer,
 ) *OAuthHandler {
 	return &OAuthHandler{
 		oauthUseCase:        oauthUseCase,
 		jwtService:          jwtService,
 		refreshTokenUseCase: refreshTokenUseCase,
+		loginHistoryUseCase: loginHistoryUseCase,
+		thumbnailer:         thumbnailer,
 	}
 }
 
@@ -41,19 +47,31 @@ func NewOAuthHandler(
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /auth/google [get]
 func (h *OAuthHandler) GetGoogleAuthURL(c *gin.Context) {
-	// Generate state token for CSRF protection
-	state, err := h.oauthUseCase.GenerateStateToken()
+	h.getAuthURL(c, auth.ProviderGoogle)
+}
+
+// GetGitHubAuthURL generates and returns the GitHub OAuth authorization URL
+// @Summary Get GitHub OAuth URL
+// @Description Get the GitHub OAuth authorization URL for user login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.OAuthAuthURLResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /auth/github [get]
+func (h *OAuthHandler) GetGitHubAuthURL(c *gin.Context) {
+	h.getAuthURL(c, auth.ProviderGitHub)
+}
+
+// getAuthURL generates and returns the provider's OAuth authorization URL
+func (h *OAuthHandler) getAuthURL(c *gin.Context, provider string) {
+	// The state is kept server-side and must be sent back with the callback
+	authURL, state, err := h.oauthUseCase.GetAuthURL(c.Request.Context(), provider)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate state token", err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
-	// Store state in session/cookie for validation (in production, use Redis or session store)
-	c.SetCookie("oauth_state", state, 600, "/", "", false, true)
-
-	// Get Google OAuth URL
-	authURL := h.oauthUseCase.GetGoogleAuthURL(state)
-
 	utils.SuccessResponse(c, http.StatusOK, "success", dto.OAuthAuthURLResponse{
 		AuthURL: authURL,
 		State:   state,
@@ -74,6 +92,28 @@ func (h *OAuthHandler) GetGoogleAuthURL(c *gin.Context) {
 // @Failure 500 {object} utils.ErrorResponse
 // @Router /auth/google/callback [get]
 func (h *OAuthHandler) HandleGoogleCallback(c *gin.Context) {
+	h.handleCallback(c, auth.ProviderGoogle)
+}
+
+// HandleGitHubCallback handles the GitHub OAuth callback
+// @Summary Handle GitHub OAuth callback
+// @Description Handle the callback from GitHub OAuth and authenticate user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param code query string true "Authorization code from GitHub"
+// @Param state query string true "State token for CSRF protection"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /auth/github/callback [get]
+func (h *OAuthHandler) HandleGitHubCallback(c *gin.Context) {
+	h.handleCallback(c, auth.ProviderGitHub)
+}
+
+// handleCallback authenticates the user from the provider's OAuth callback
+func (h *OAuthHandler) handleCallback(c *gin.Context, provider string) {
 	code := c.Query("code")
 	state := c.Query("state")
 
@@ -82,63 +122,89 @@ func (h *OAuthHandler) HandleGoogleCallback(c *gin.Context) {
 		return
 	}
 
-	// Validate state token (CSRF protection)
-	storedState, err := c.Cookie("oauth_state")
-	if err != nil || storedState != state {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "invalid state token", nil)
+	// Handle the provider callback
+	user, err := h.oauthUseCase.HandleCallback(c.Request.Context(), provider, state, code)
+	if err != nil {
+		utils.HandleDomainError(c, err)
 		return
 	}
 
-	// Clear the state cookie
-	c.SetCookie("oauth_state", "", -1, "/", "", false, true)
+	h.respondWithLogin(c, user, provider)
+}
+
+// LoginWithGoogleIDToken logs in with a Google ID token obtained by the client
+// @Summary Login with Google ID token
+// @Description Authenticate with an ID token from Google Sign-In, without the redirect flow
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.IDTokenLoginRequest true "Google ID token"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /auth/google/token [post]
+func (h *OAuthHandler) LoginWithGoogleIDToken(c *gin.Context) {
+	var req dto.IDTokenLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
 
-	// Handle Google callback
-	user, err := h.oauthUseCase.HandleGoogleCallback(c.Request.Context(), code)
+	user, err := h.oauthUseCase.LoginWithIDToken(c.Request.Context(), auth.ProviderGoogle, req.IDToken)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to authenticate with Google", err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
+	h.respondWithLogin(c, user, auth.ProviderGoogle)
+}
+
+// respondWithLogin issues a new session for the user who logged in with provider and returns its tokens
+func (h *OAuthHandler) respondWithLogin(c *gin.Context, user *entity.User, provider string) {
 	// Generate JWT tokens
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID)
+	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Roles())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate access token", err)
 		return
 	}
 
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID)
+	refreshToken, expiresAt, err := h.jwtService.GenerateRefreshToken(user.ID, 0)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate refresh token", err)
 		return
 	}
 
 	// Store refresh token
-	expiresAt := time.Now().Add(h.jwtService.GetRefreshTokenExpiration())
-	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), user.ID, refreshToken, expiresAt); err != nil {
+	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), user.ID, refreshToken, expiresAt, c.Request.UserAgent(), c.ClientIP()); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to store refresh token", err)
 		return
 	}
+	h.loginHistoryUseCase.RecordLogin(c.Request.Context(), user.ID, provider, c.ClientIP(), c.Request.UserAgent())
 
 	// Return tokens and user info
 	userResponse := &dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Phone:     user.Phone,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		Username:      user.Username,
+		Phone:         user.Phone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}
 
 	// Convert Avatar entity to AvatarDTO if exists
 	if user.Avatar != nil {
 		userResponse.Avatar = &dto.AvatarDTO{
-			ID:        user.Avatar.ID,
-			UserID:    user.Avatar.UserID,
-			PublicID:  user.Avatar.PublicID,
-			PublicURL: user.Avatar.PublicURL,
-			SecureURL: user.Avatar.SecureURL,
-			CreatedAt: user.Avatar.CreatedAt,
-			UpdatedAt: user.Avatar.UpdatedAt,
+			ID:         user.Avatar.ID,
+			UserID:     user.Avatar.UserID,
+			PublicID:   user.Avatar.PublicID,
+			PublicURL:  user.Avatar.PublicURL,
+			SecureURL:  user.Avatar.SecureURL,
+			Thumbnails: avatarThumbnails(h.thumbnailer, user.Avatar),
+			CreatedAt:  user.Avatar.CreatedAt,
+			UpdatedAt:  user.Avatar.UpdatedAt,
 		}
 	}
 
@@ -148,3 +214,26 @@ func (h *OAuthHandler) HandleGoogleCallback(c *gin.Context) {
 		User:         userResponse,
 	})
 }
+
+// UnlinkProvider disconnects an OAuth provider from the authenticated user's account
+// @Summary Unlink OAuth provider
+// @Description Disconnect an OAuth provider from the current account. Requires a password to be set.
+// @Tags users
+// @Produce json
+// @Param provider path string true "OAuth provider (google or github)"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 401 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 409 {object} utils.ErrorResponse
+// @Failure 500 {object} utils.ErrorResponse
+// @Router /users/me/oauth/{provider} [delete]
+func (h *OAuthHandler) UnlinkProvider(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := h.oauthUseCase.UnlinkProvider(c.Request.Context(), userID, c.Param("provider")); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "oauth provider unlinked successfully", nil)
+}
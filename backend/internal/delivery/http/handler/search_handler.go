@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend/internal/delivery/http/dto"
+	"backend/internal/usecase/search"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchHandler handles HTTP requests for searching across users and messages
+type SearchHandler struct {
+	searchUseCase       search.SearchUseCase
+	recentSearchUseCase search.RecentSearchUseCase
+}
+
+// NewSearchHandler creates a new search handler; searches are recorded in the user's recent searches
+func NewSearchHandler(searchUseCase search.SearchUseCase, recentSearchUseCase search.RecentSearchUseCase) *SearchHandler {
+	return &SearchHandler{
+		searchUseCase:       searchUseCase,
+		recentSearchUseCase: recentSearchUseCase,
+	}
+}
+
+// Search returns the users the authenticated user can contact and the messages of their conversations
+// matching the q query parameter, grouped by type. limit caps each section.
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	results, err := h.searchUseCase.Search(c.Request.Context(), userID, c.Query("q"), limit)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+	h.recentSearchUseCase.Record(c.Request.Context(), userID, c.Query("q"))
+
+	response := &dto.SearchResponse{
+		Users:    make([]*dto.SearchUserResponse, len(results.Users)),
+		Messages: make([]*dto.MessageResponse, len(results.Messages)),
+	}
+	for i, user := range results.Users {
+		response.Users[i] = &dto.SearchUserResponse{ID: user.ID, Name: user.Name, Username: user.Username}
+		if user.Avatar != nil {
+			response.Users[i].AvatarURL = user.Avatar.SecureURL
+		}
+	}
+	for i, msg := range results.Messages {
+		response.Messages[i] = toMessageResponse(msg)
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "search results retrieved successfully", response)
+}
+
+// ListRecentSearches lists the authenticated user's recent search queries, newest first
+func (h *SearchHandler) ListRecentSearches(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	searches, err := h.recentSearchUseCase.List(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	response := make([]dto.RecentSearchResponse, len(searches))
+	for i, recent := range searches {
+		response[i] = dto.RecentSearchResponse{Query: recent.Query, SearchedAt: recent.SearchedAt}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "recent searches retrieved successfully", response)
+}
+
+// ClearRecentSearches forgets every query the authenticated user searched for
+func (h *SearchHandler) ClearRecentSearches(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := h.recentSearchUseCase.Clear(c.Request.Context(), userID); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "recent searches cleared successfully", nil)
+}
@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/delivery/http/dto"
+	"backend/internal/delivery/http/handler"
+	"backend/internal/domain/entity"
+	"backend/internal/usecase/search"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchUseCase returns fixed results
+type searchUseCase struct {
+	search.SearchUseCase
+	results *search.Results
+	query   string
+	limit   int
+}
+
+func (uc *searchUseCase) Search(ctx context.Context, userID, query string, limit int) (*search.Results, error) {
+	uc.query, uc.limit = query, limit
+	return uc.results, nil
+}
+
+func TestSearch_GroupsResultsWithoutPrivateFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	alice := entity.NewUser("alice@example.com", "hashed", "Alice", "alice", "+15550100")
+	uc := &searchUseCase{results: &search.Results{
+		Users:    []*entity.User{alice},
+		Messages: []*entity.Message{entity.NewMessage("conv-1", "user-2", "ask alice")},
+	}}
+	recent := &recentSearches{}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/search?q=alice&limit=3", nil)
+	c.Set("userID", "user-1")
+
+	handler.NewSearchHandler(uc, recent).Search(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "alice", uc.query)
+	assert.Equal(t, 3, uc.limit)
+	assert.Equal(t, []string{"alice"}, recent.recorded)
+	// Other users never see an email or phone number
+	assert.NotContains(t, w.Body.String(), "alice@example.com")
+	assert.NotContains(t, w.Body.String(), "+15550100")
+
+	var body struct {
+		Data dto.SearchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data.Users, 1)
+	assert.Equal(t, &dto.SearchUserResponse{ID: alice.ID, Name: "Alice", Username: "alice"}, body.Data.Users[0])
+	require.Len(t, body.Data.Messages, 1)
+	assert.Equal(t, "ask alice", body.Data.Messages[0].Body)
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"time"
 
 	"backend/internal/delivery/http/dto"
 	"backend/internal/domain/entity"
+	domainErrors "backend/internal/domain/errors"
 	"backend/internal/usecase/auth"
 	"backend/internal/usecase/user"
 	"backend/pkg/utils"
@@ -15,21 +19,39 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+const (
+	// maxAvatarRequestSize bounds an avatar upload request: the largest avatar plus room for the multipart framing
+	maxAvatarRequestSize = entity.MaxAvatarSize + 1024*1024
+	// avatarFormMemory is how much of an avatar upload is held in memory before spilling to disk
+	avatarFormMemory = 1024 * 1024
+)
+
 // UserHandler handles HTTP requests for user operations
 type UserHandler struct {
 	userUseCase         user.UserUseCase
 	jwtService          auth.JWTService
 	refreshTokenUseCase auth.RefreshTokenUseCase
+	loginHistoryUseCase auth.LoginHistoryUseCase
+	oauthUseCase        auth.OAuthUseCase
+	thumbnailer         AvatarThumbnailer
 	validate            *validator.Validate
 }
 
-// NewUserHandler creates a new user handler
-func NewUserHandler(userUseCase user.UserUseCase, jwtService auth.JWTService, refreshTokenUseCase auth.RefreshTokenUseCase) *UserHandler {
+// AvatarThumbnailer builds resized avatar URLs for responses; cloudinary.Service implements it
+type AvatarThumbnailer interface {
+	ThumbnailURLs(publicID string) map[int]string
+}
+
+// NewUserHandler creates a new user handler. thumbnailer may be nil to leave out avatar thumbnails.
+func NewUserHandler(userUseCase user.UserUseCase, jwtService auth.JWTService, refreshTokenUseCase auth.RefreshTokenUseCase, loginHistoryUseCase auth.LoginHistoryUseCase, oauthUseCase auth.OAuthUseCase, thumbnailer AvatarThumbnailer) *UserHandler {
 	return &UserHandler{
 		userUseCase:         userUseCase,
 		jwtService:          jwtService,
 		refreshTokenUseCase: refreshTokenUseCase,
-		validate:            validator.New(),
+		loginHistoryUseCase: loginHistoryUseCase,
+		oauthUseCase:        oauthUseCase,
+		thumbnailer:         thumbnailer,
+		validate:            utils.Validator(),
 	}
 }
 
@@ -47,7 +69,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userUseCase.Register(c.Request.Context(), req.Email, req.Password, req.Name, req.Phone)
+	user, err := h.userUseCase.Register(c.Request.Context(), req.Email, req.Password, req.Name, req.Username, req.Phone)
 	if err != nil {
 		utils.HandleDomainError(c, err)
 		return
@@ -76,26 +98,36 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
+	h.signIn(c, user, req.RememberMe, "login successful")
+}
+
+// signIn issues an access and refresh token pair for user and responds with them
+func (h *UserHandler) signIn(c *gin.Context, user *entity.User, rememberMe bool, message string) {
 	// Generate access token
-	accessToken, err := h.jwtService.GenerateAccessToken(user.ID)
+	accessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Roles())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate access token", err)
 		return
 	}
 
 	// Generate refresh token
-	refreshToken, err := h.jwtService.GenerateRefreshToken(user.ID)
+	// "Remember me" logins get the longer refresh lifetime; otherwise the configured default applies
+	var refreshTTL time.Duration
+	if rememberMe {
+		refreshTTL = h.jwtService.GetRememberMeRefreshTokenExpiration()
+	}
+	refreshToken, expiresAt, err := h.jwtService.GenerateRefreshToken(user.ID, refreshTTL)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate refresh token", err)
 		return
 	}
 
 	// Store refresh token in database
-	expiresAt := time.Now().Add(h.jwtService.GetRefreshTokenExpiration())
-	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), user.ID, refreshToken, expiresAt); err != nil {
+	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), user.ID, refreshToken, expiresAt, c.Request.UserAgent(), c.ClientIP()); err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to store refresh token", err)
 		return
 	}
+	h.loginHistoryUseCase.RecordLogin(c.Request.Context(), user.ID, entity.LoginMethodPassword, c.ClientIP(), c.Request.UserAgent())
 
 	response := &dto.LoginResponse{
 		AccessToken:  accessToken,
@@ -103,7 +135,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		User:         h.toUserResponse(user),
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "login successful", response)
+	utils.SuccessResponse(c, http.StatusOK, message, response)
 }
 
 // RefreshToken handles token refresh
@@ -121,7 +153,7 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// Validate JWT refresh token
-	claims, err := h.jwtService.ValidateToken(req.RefreshToken, auth.RefreshToken)
+	claims, err := h.jwtService.ValidateToken(c.Request.Context(), req.RefreshToken, auth.RefreshToken)
 	if err != nil {
 		utils.HandleDomainError(c, err)
 		return
@@ -140,30 +172,39 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// Load the user so the new access token reflects their current roles
+	user, err := h.userUseCase.GetByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
 	// Generate new access token
-	newAccessToken, err := h.jwtService.GenerateAccessToken(claims.UserID)
+	newAccessToken, err := h.jwtService.GenerateAccessToken(user.ID, user.Roles())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate access token", err)
 		return
 	}
 
 	// Generate new refresh token
-	newRefreshToken, err := h.jwtService.GenerateRefreshToken(claims.UserID)
+	// Keep the lifetime of the token being replaced so "remember me" sessions stay long-lived
+	var refreshTTL time.Duration
+	if claims.ExpiresAt != nil && claims.IssuedAt != nil {
+		refreshTTL = claims.ExpiresAt.Sub(claims.IssuedAt.Time)
+	}
+	newRefreshToken, expiresAt, err := h.jwtService.GenerateRefreshToken(claims.UserID, refreshTTL)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to generate refresh token", err)
 		return
 	}
 
-	// Revoke old refresh token
-	if err := h.refreshTokenUseCase.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke old token", err)
-		return
-	}
-
-	// Store new refresh token
-	expiresAt := time.Now().Add(h.jwtService.GetRefreshTokenExpiration())
-	if err := h.refreshTokenUseCase.CreateRefreshToken(c.Request.Context(), claims.UserID, newRefreshToken, expiresAt); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to store refresh token", err)
+	// Revoke old refresh token and store its replacement in the same family
+	if err := h.refreshTokenUseCase.RotateRefreshToken(c.Request.Context(), storedToken, newRefreshToken, expiresAt, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		if errors.Is(err, domainErrors.ErrTokenRevoked) {
+			utils.HandleDomainError(c, err)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to rotate refresh token", err)
 		return
 	}
 
@@ -175,7 +216,7 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "token refreshed successfully", response)
 }
 
-// Logout handles user logout by revoking all refresh tokens
+// Logout handles user logout by revoking all refresh tokens and the current access token
 func (h *UserHandler) Logout(c *gin.Context) {
 	userID := c.GetString("userID")
 
@@ -184,10 +225,185 @@ func (h *UserHandler) Logout(c *gin.Context) {
 		return
 	}
 
+	// Blacklist the access token used for this request so it can't be reused
+	if claims, ok := c.Get("claims"); ok {
+		if err := h.jwtService.RevokeToken(c.Request.Context(), claims.(*auth.JWTClaims)); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke access token", err)
+			return
+		}
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "logout successful", nil)
 }
 
-// GetProfile retrieves the authenticated user's profile
+// LogoutDevice handles logging out the current device by revoking a single refresh token
+// and the current access token, leaving the user's other sessions intact
+func (h *UserHandler) LogoutDevice(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	claims, err := h.jwtService.ValidateToken(c.Request.Context(), req.RefreshToken, auth.RefreshToken)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	// A user may only log out their own sessions
+	if claims.UserID != userID {
+		utils.ErrorResponse(c, http.StatusForbidden, "refresh token belongs to a different user", nil)
+		return
+	}
+
+	if err := h.refreshTokenUseCase.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to logout", err)
+		return
+	}
+
+	if accessClaims, ok := c.Get("claims"); ok {
+		if err := h.jwtService.RevokeToken(c.Request.Context(), accessClaims.(*auth.JWTClaims)); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke access token", err)
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "logout successful", nil)
+}
+
+// DeleteAccount schedules the authenticated user's account for deletion and logs them out everywhere.
+// Logging back in during the grace period reactivates the account.
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := h.userUseCase.RequestDeletion(c.Request.Context(), userID); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	if err := h.refreshTokenUseCase.RevokeAllUserTokens(c.Request.Context(), userID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke sessions", err)
+		return
+	}
+
+	if claims, ok := c.Get("claims"); ok {
+		if err := h.jwtService.RevokeToken(c.Request.Context(), claims.(*auth.JWTClaims)); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke access token", err)
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "account scheduled for deletion, log in again to cancel", nil)
+}
+
+// DeactivateAccount deactivates the authenticated user's account and logs them out everywhere.
+// The account is kept and can be reactivated with ReactivateAccount.
+func (h *UserHandler) DeactivateAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := h.userUseCase.Deactivate(c.Request.Context(), userID); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	if err := h.refreshTokenUseCase.RevokeAllUserTokens(c.Request.Context(), userID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke sessions", err)
+		return
+	}
+
+	if claims, ok := c.Get("claims"); ok {
+		if err := h.jwtService.RevokeToken(c.Request.Context(), claims.(*auth.JWTClaims)); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "failed to revoke access token", err)
+			return
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "account deactivated", nil)
+}
+
+// ReactivateAccount reactivates a deactivated account with the user's credentials and logs them in
+func (h *UserHandler) ReactivateAccount(c *gin.Context) {
+	var req dto.LoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	user, err := h.userUseCase.Reactivate(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	h.signIn(c, user, req.RememberMe, "account reactivated")
+}
+
+// ListSessions lists the authenticated user's active sessions
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	sessions, err := h.refreshTokenUseCase.ListActiveSessions(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	// Expose the row id, never the refresh token itself
+	responses := make([]*dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = &dto.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "sessions retrieved successfully", responses)
+}
+
+// GetLoginHistory lists the authenticated user's most recent logins
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	events, err := h.loginHistoryUseCase.ListLogins(c.Request.Context(), userID, limit)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	response := make([]dto.LoginEventResponse, len(events))
+	for i, event := range events {
+		response[i] = dto.LoginEventResponse{
+			ID:        event.ID,
+			Method:    event.Method,
+			IPAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		}
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "success", response)
+}
+
+// GetProfile retrieves the authenticated user's profile, including the OAuth providers linked to it
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID := c.GetString("userID")
 
@@ -197,7 +413,15 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "profile retrieved successfully", h.toUserResponse(user))
+	providers, err := h.oauthUseCase.LinkedProviders(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	response := h.toUserResponse(user)
+	response.LinkedProviders = providers
+	utils.SuccessResponse(c, http.StatusOK, "profile retrieved successfully", response)
 }
 
 // UpdateProfile updates the authenticated user's profile
@@ -215,7 +439,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userUseCase.Update(c.Request.Context(), userID, req.Name, req.Phone)
+	user, err := h.userUseCase.Update(c.Request.Context(), userID, req.Name, req.Username, req.Phone)
 	if err != nil {
 		utils.HandleDomainError(c, err)
 		return
@@ -224,6 +448,51 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "profile updated successfully", h.toUserResponse(user))
 }
 
+// RequestEmailChange sends a confirmation link to the authenticated user's new email address
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	userID := c.GetString("userID")
+	var req dto.ChangeEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	if err := h.userUseCase.RequestEmailChange(c.Request.Context(), userID, req.Email, req.CurrentPassword); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "confirmation email sent to the new address", nil)
+}
+
+// ConfirmEmailChange switches the account to the new email address the token was issued for
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	var req dto.ConfirmEmailChangeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	if err := h.userUseCase.ConfirmEmailChange(c.Request.Context(), req.Token); err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "email changed successfully", nil)
+}
+
 // GetUserByID retrieves a user by ID
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	id := c.Param("id")
@@ -239,22 +508,30 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 
 // ListUsers retrieves a list of users with pagination
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, offset := utils.ParsePagination(c)
 
-	users, err := h.userUseCase.List(c.Request.Context(), limit, offset)
+	users, total, err := h.userUseCase.List(c.Request.Context(), limit, offset)
 	if err != nil {
 		utils.HandleDomainError(c, err)
 		return
 	}
 
-	response := &dto.ListUsersResponse{
-		Users:  h.toUserResponseList(users),
-		Total:  len(users),
-		Limit:  limit,
-		Offset: offset,
+	response := utils.NewPaginatedResponse(h.toUserResponseList(users), total, limit, offset)
+	utils.SuccessResponse(c, http.StatusOK, "users retrieved successfully", response)
+}
+
+// SearchUsers searches users by partial name or email with pagination
+func (h *UserHandler) SearchUsers(c *gin.Context) {
+	query := c.Query("q")
+	limit, offset := utils.ParsePagination(c)
+
+	users, total, err := h.userUseCase.Search(c.Request.Context(), query, limit, offset)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
 	}
 
+	response := utils.NewPaginatedResponse(h.toUserResponseList(users), total, limit, offset)
 	utils.SuccessResponse(c, http.StatusOK, "users retrieved successfully", response)
 }
 
@@ -274,6 +551,19 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 func (h *UserHandler) UpdateAvatar(c *gin.Context) {
 	userID := c.GetString("userID")
 
+	// Cap the request body and keep little of it in memory: larger parts spill to a temp file.
+	// The file size itself is enforced while streaming it to Cloudinary.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarRequestSize)
+	if err := c.Request.ParseMultipartForm(avatarFormMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "file size exceeds 5MB limit", nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusBadRequest, "invalid multipart form", err)
+		return
+	}
+
 	// Get file from request
 	file, header, err := c.Request.FormFile("avatar")
 	if err != nil {
@@ -282,13 +572,6 @@ func (h *UserHandler) UpdateAvatar(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// Validate file size (max 5MB)
-	const maxFileSize = 5 * 1024 * 1024 // 5MB
-	if header.Size > maxFileSize {
-		utils.ErrorResponse(c, http.StatusBadRequest, "file size exceeds 5MB limit", nil)
-		return
-	}
-
 	// Validate file type
 	contentType := header.Header.Get("Content-Type")
 	allowedTypes := map[string]bool{
@@ -303,43 +586,95 @@ func (h *UserHandler) UpdateAvatar(c *gin.Context) {
 		return
 	}
 
+	// The declared type is client-controlled, so check the file's content as well
+	detectedType, err := detectContentType(file)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "failed to read avatar file", err)
+		return
+	}
+	if !allowedTypes[detectedType] {
+		utils.ErrorResponse(c, http.StatusBadRequest, "file content is not a supported image. Allowed: jpeg, jpg, png, gif, webp", nil)
+		return
+	}
+
 	// Update avatar
 	user, err := h.userUseCase.UpdateAvatar(c.Request.Context(), userID, file)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error(), err)
+		utils.HandleDomainError(c, err)
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "avatar updated successfully", h.toUserResponse(user))
 }
 
+// detectContentType sniffs the file type from its first 512 bytes and rewinds the file for the upload
+func detectContentType(file multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// DeleteAvatar removes the authenticated user's avatar
+func (h *UserHandler) DeleteAvatar(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	user, err := h.userUseCase.DeleteAvatar(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleDomainError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "avatar deleted successfully", h.toUserResponse(user))
+}
+
 // toUserResponse converts entity to response DTO
 func (h *UserHandler) toUserResponse(user *entity.User) *dto.UserResponse {
 	response := &dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		Name:      user.Name,
-		Phone:     user.Phone,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		Username:      user.Username,
+		Phone:         user.Phone,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+	if !user.LastLoginAt.IsZero() {
+		lastLoginAt := user.LastLoginAt
+		response.LastLoginAt = &lastLoginAt
 	}
 
 	// Convert Avatar entity to AvatarDTO if exists
 	if user.Avatar != nil {
 		response.Avatar = &dto.AvatarDTO{
-			ID:        user.Avatar.ID,
-			UserID:    user.Avatar.UserID,
-			PublicID:  user.Avatar.PublicID,
-			PublicURL: user.Avatar.PublicURL,
-			SecureURL: user.Avatar.SecureURL,
-			CreatedAt: user.Avatar.CreatedAt,
-			UpdatedAt: user.Avatar.UpdatedAt,
+			ID:         user.Avatar.ID,
+			UserID:     user.Avatar.UserID,
+			PublicID:   user.Avatar.PublicID,
+			PublicURL:  user.Avatar.PublicURL,
+			SecureURL:  user.Avatar.SecureURL,
+			Thumbnails: avatarThumbnails(h.thumbnailer, user.Avatar),
+			CreatedAt:  user.Avatar.CreatedAt,
+			UpdatedAt:  user.Avatar.UpdatedAt,
 		}
 	}
 
 	return response
 }
 
+// avatarThumbnails returns the avatar's thumbnail URLs keyed by size, or nil when there are none
+func avatarThumbnails(thumbnailer AvatarThumbnailer, avatar *entity.Avatar) map[int]string {
+	if thumbnailer == nil {
+		return nil
+	}
+	return thumbnailer.ThumbnailURLs(avatar.PublicID)
+}
+
 // toUserResponseList converts entity list to response DTO list
 func (h *UserHandler) toUserResponseList(users []*entity.User) []*dto.UserResponse {
 	responses := make([]*dto.UserResponse, len(users))
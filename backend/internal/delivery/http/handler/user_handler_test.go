@@ -0,0 +1,332 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"backend/internal/delivery/http/handler"
+	"backend/internal/domain/entity"
+	"backend/internal/usecase/auth"
+	"backend/internal/usecase/user"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is the start of a PNG file, enough for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+// avatarUseCase records the uploaded avatar; other UserUseCase methods are not used by UpdateAvatar
+type avatarUseCase struct {
+	user.UserUseCase
+	uploaded []byte
+}
+
+func (uc *avatarUseCase) UpdateAvatar(ctx context.Context, userID string, file multipart.File) (*entity.User, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	uc.uploaded = data
+	return &entity.User{ID: userID}, nil
+}
+
+// profileUseCase returns a fixed user from GetByID
+type profileUseCase struct {
+	user.UserUseCase
+	user *entity.User
+}
+
+func (uc *profileUseCase) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	return uc.user, nil
+}
+
+// providersUseCase returns fixed linked providers; other OAuthUseCase methods are not used by GetProfile
+type providersUseCase struct {
+	auth.OAuthUseCase
+	providers []string
+}
+
+func (uc *providersUseCase) LinkedProviders(ctx context.Context, userID string) ([]string, error) {
+	return uc.providers, nil
+}
+
+// sizeThumbnailer builds fake thumbnail URLs from the public ID
+type sizeThumbnailer []int
+
+func (t sizeThumbnailer) ThumbnailURLs(publicID string) map[int]string {
+	if publicID == "" {
+		return nil
+	}
+	urls := make(map[int]string, len(t))
+	for _, size := range t {
+		urls[size] = fmt.Sprintf("https://cdn.example.com/w_%d/%s", size, publicID)
+	}
+	return urls
+}
+
+// uploadAvatar posts content as the avatar form file, declaring contentType
+func uploadAvatar(t *testing.T, uc user.UserUseCase, contentType string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.png"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/users/me/avatar", &body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	c.Set("userID", "123")
+
+	handler.NewUserHandler(uc, nil, nil, nil, nil, nil).UpdateAvatar(c)
+	return w
+}
+
+func TestUpdateAvatar_UploadsImageFromStart(t *testing.T) {
+	uc := &avatarUseCase{}
+	content := append(append([]byte{}, pngHeader...), make([]byte, 1024)...)
+
+	w := uploadAvatar(t, uc, "image/png", content)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// Sniffing the type must not consume the bytes sent to the upload
+	assert.Equal(t, content, uc.uploaded)
+}
+
+func TestUpdateAvatar_RejectsContentNotMatchingImage(t *testing.T) {
+	files := map[string][]byte{
+		"executable": []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00"),
+		"html":       []byte("<html><script>alert(1)</script></html>"),
+		"empty":      {},
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			uc := &avatarUseCase{}
+
+			w := uploadAvatar(t, uc, "image/png", content)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var body utils.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Contains(t, body.Message, "not a supported image")
+			assert.Nil(t, uc.uploaded)
+		})
+	}
+}
+
+func TestUpdateAvatar_RejectsOversizedRequest(t *testing.T) {
+	uc := &avatarUseCase{}
+	content := append(append([]byte{}, pngHeader...), make([]byte, 2*entity.MaxAvatarSize)...)
+
+	w := uploadAvatar(t, uc, "image/png", content)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var body utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "file size exceeds 5MB limit", body.Message)
+	assert.Nil(t, uc.uploaded)
+}
+
+func TestGetProfile_IncludesAvatarThumbnails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &profileUseCase{user: &entity.User{
+		ID:     "123",
+		Avatar: entity.NewAvatar("123", "avatars/user_123", "http://cdn.example.com/a.png", "https://cdn.example.com/a.png"),
+	}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	c.Set("userID", "123")
+
+	handler.NewUserHandler(uc, nil, nil, nil, &providersUseCase{}, sizeThumbnailer{64, 128}).GetProfile(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data struct {
+			Avatar struct {
+				Thumbnails map[string]string `json:"thumbnails"`
+			} `json:"avatar"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, map[string]string{
+		"64":  "https://cdn.example.com/w_64/avatars/user_123",
+		"128": "https://cdn.example.com/w_128/avatars/user_123",
+	}, body.Data.Avatar.Thumbnails)
+}
+
+func TestGetProfile_IncludesVerificationAndLinkedProviders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &profileUseCase{user: &entity.User{
+		ID:                 "123",
+		Email:              "alice@example.com",
+		Password:           "$2a$10$hashed",
+		EmailVerified:      true,
+		VerificationToken:  "verification-token",
+		ResetPasswordToken: "reset-token",
+		OAuthProvider:      "google",
+		OAuthID:            "google-123",
+	}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	c.Set("userID", "123")
+
+	handler.NewUserHandler(uc, nil, nil, nil, &providersUseCase{providers: []string{"google", "github"}}, nil).GetProfile(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.JSONEq(t, `true`, string(body.Data["email_verified"]))
+	assert.JSONEq(t, `["google","github"]`, string(body.Data["linked_providers"]))
+	for _, secret := range []string{"$2a$10$hashed", "verification-token", "reset-token", "google-123"} {
+		assert.NotContains(t, w.Body.String(), secret)
+	}
+}
+
+// listUseCase serves a fixed number of users and records the requested page
+type listUseCase struct {
+	user.UserUseCase
+	total         int64
+	limit, offset int
+}
+
+func (uc *listUseCase) List(ctx context.Context, limit, offset int) ([]*entity.User, int64, error) {
+	uc.limit, uc.offset = limit, offset
+	users := make([]*entity.User, 0, limit)
+	for i := offset; i < offset+limit && int64(i) < uc.total; i++ {
+		users = append(users, &entity.User{ID: fmt.Sprintf("user-%d", i)})
+	}
+	return users, uc.total, nil
+}
+
+func TestListUsers_ReturnsPaginationMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &listUseCase{total: 25}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=10&offset=10", nil)
+
+	handler.NewUserHandler(uc, nil, nil, nil, nil, nil).ListUsers(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data struct {
+			Items   []json.RawMessage `json:"items"`
+			Total   int64             `json:"total"`
+			Limit   int               `json:"limit"`
+			Offset  int               `json:"offset"`
+			HasNext bool              `json:"has_next"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Data.Items, 10)
+	assert.Equal(t, int64(25), body.Data.Total)
+	assert.Equal(t, 10, body.Data.Limit)
+	assert.Equal(t, 10, body.Data.Offset)
+	assert.True(t, body.Data.HasNext)
+}
+
+func TestListUsers_ClampsLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uc := &listUseCase{}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=5000&offset=-1", nil)
+
+	handler.NewUserHandler(uc, nil, nil, nil, nil, nil).ListUsers(c)
+
+	assert.Equal(t, utils.MaxPageLimit, uc.limit)
+	assert.Equal(t, 0, uc.offset)
+}
+
+// tokenJWTService maps refresh tokens to their owner and records revoked access tokens
+type tokenJWTService struct {
+	auth.JWTService
+	owners  map[string]string
+	revoked []*auth.JWTClaims
+}
+
+func (s *tokenJWTService) ValidateToken(ctx context.Context, tokenString string, expectedType auth.TokenType) (*auth.JWTClaims, error) {
+	return &auth.JWTClaims{UserID: s.owners[tokenString], TokenType: expectedType}, nil
+}
+
+func (s *tokenJWTService) RevokeToken(ctx context.Context, claims *auth.JWTClaims) error {
+	s.revoked = append(s.revoked, claims)
+	return nil
+}
+
+// revokingUseCase records revoked refresh tokens
+type revokingUseCase struct {
+	auth.RefreshTokenUseCase
+	revoked []string
+}
+
+func (uc *revokingUseCase) RevokeRefreshToken(ctx context.Context, token string) error {
+	uc.revoked = append(uc.revoked, token)
+	return nil
+}
+
+// logoutDevice posts refreshToken to LogoutDevice as user-1
+func logoutDevice(jwt *tokenJWTService, uc *revokingUseCase, refreshToken string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body := bytes.NewBufferString(fmt.Sprintf(`{"refresh_token":%q}`, refreshToken))
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout-device", body)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", "user-1")
+	c.Set("claims", &auth.JWTClaims{UserID: "user-1", TokenType: auth.AccessToken})
+
+	handler.NewUserHandler(nil, jwt, uc, nil, nil, nil).LogoutDevice(c)
+	return w
+}
+
+func TestLogoutDevice_RevokesOwnSession(t *testing.T) {
+	jwt := &tokenJWTService{owners: map[string]string{"own-token": "user-1"}}
+	uc := &revokingUseCase{}
+
+	w := logoutDevice(jwt, uc, "own-token")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"own-token"}, uc.revoked)
+	require.Len(t, jwt.revoked, 1)
+	assert.Equal(t, "user-1", jwt.revoked[0].UserID)
+}
+
+func TestLogoutDevice_RejectsAnotherUsersSession(t *testing.T) {
+	jwt := &tokenJWTService{owners: map[string]string{"other-token": "user-2"}}
+	uc := &revokingUseCase{}
+
+	w := logoutDevice(jwt, uc, "other-token")
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, uc.revoked)
+	assert.Empty(t, jwt.revoked)
+}
@@ -38,7 +38,7 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		claims, err := m.jwtService.ValidateToken(token, auth.AccessToken)
+		claims, err := m.jwtService.ValidateToken(c.Request.Context(), token, auth.AccessToken)
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired token", err)
 			c.Abort()
@@ -46,6 +46,24 @@ func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 		}
 
 		c.Set("userID", claims.UserID)
+		c.Set("roles", claims.Roles)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
+
+// RequireRole allows the request only if the authenticated token carries the given role.
+// It must run after Authenticate.
+func (m *AuthMiddleware) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, r := range c.GetStringSlice("roles") {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "insufficient permissions", nil)
+		c.Abort()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+	"backend/internal/usecase/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRoleTestRouter(t *testing.T) (*gin.Engine, auth.JWTService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	jwtService, err := auth.NewJWTService(auth.AlgorithmHS256, "test-secret", nil, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	require.NoError(t, err)
+	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+
+	r := gin.New()
+	r.DELETE("/users/:id", authMiddleware.Authenticate(), authMiddleware.RequireRole("admin"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r, jwtService
+}
+
+func doDeleteWithToken(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, "/users/123", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireRole_AdminAllowed(t *testing.T) {
+	r, jwtService := newRoleTestRouter(t)
+	token, err := jwtService.GenerateAccessToken("admin-1", []string{"admin"})
+	require.NoError(t, err)
+
+	w := doDeleteWithToken(r, token)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireRole_MissingRoleForbidden(t *testing.T) {
+	r, jwtService := newRoleTestRouter(t)
+	token, err := jwtService.GenerateAccessToken("user-1", []string{"user"})
+	require.NoError(t, err)
+
+	w := doDeleteWithToken(r, token)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireRole_NoRolesClaimForbidden(t *testing.T) {
+	r, jwtService := newRoleTestRouter(t)
+	token, err := jwtService.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	w := doDeleteWithToken(r, token)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
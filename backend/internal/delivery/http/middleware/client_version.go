@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"backend/internal/domain/errors"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	clientVersionHeader  = "X-Client-Version"
+	clientPlatformHeader = "X-Client-Platform"
+)
+
+// ClientVersionMiddleware rejects clients older than the configured minimum version
+type ClientVersionMiddleware struct {
+	mu          sync.RWMutex
+	minVersions map[string]string
+}
+
+// NewClientVersionMiddleware creates a new client version middleware.
+// minVersions maps a platform (e.g. "ios", "android") to its minimum supported version.
+func NewClientVersionMiddleware(minVersions map[string]string) *ClientVersionMiddleware {
+	m := &ClientVersionMiddleware{}
+	m.SetMinVersions(minVersions)
+	return m
+}
+
+// SetMinVersions replaces the configured minimum versions, e.g. on config reload
+func (m *ClientVersionMiddleware) SetMinVersions(minVersions map[string]string) {
+	versions := make(map[string]string, len(minVersions))
+	for platform, version := range minVersions {
+		versions[strings.ToLower(platform)] = version
+	}
+
+	m.mu.Lock()
+	m.minVersions = versions
+	m.mu.Unlock()
+}
+
+func (m *ClientVersionMiddleware) minVersion(platform string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	version, ok := m.minVersions[strings.ToLower(platform)]
+	return version, ok
+}
+
+// Check returns 426 Upgrade Required when the client version is below the platform minimum.
+// Requests without a version header (e.g. web) are allowed through.
+func (m *ClientVersionMiddleware) Check() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := c.GetHeader(clientVersionHeader)
+		if version == "" {
+			c.Next()
+			return
+		}
+
+		platform := c.GetHeader(clientPlatformHeader)
+		minVersion, ok := m.minVersion(platform)
+		if !ok || minVersion == "" {
+			c.Next()
+			return
+		}
+
+		cmp, err := compareVersions(version, minVersion)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "invalid client version", err)
+			c.Abort()
+			return
+		}
+
+		if cmp < 0 {
+			err := errors.ErrClientUpgradeRequired.WithMessage(
+				fmt.Sprintf("this version of the app is no longer supported, please upgrade to %s or later", minVersion))
+			utils.HandleDomainErrorWithDetails(c, err, gin.H{
+				"platform":    strings.ToLower(platform),
+				"min_version": minVersion,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// compareVersions compares dotted numeric versions such as "1.10.2".
+// It returns -1, 0 or 1 when a is lower than, equal to or greater than b.
+func compareVersions(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x < y {
+			return -1, nil
+		}
+		if x > y {
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func parseVersion(version string) ([]int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	// Ignore pre-release/build suffixes such as "1.2.3-beta+45"
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
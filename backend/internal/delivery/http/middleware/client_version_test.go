@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClientVersionRouter(m *middleware.ClientVersionMiddleware) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Check())
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return r
+}
+
+func doClientVersionRequest(r *gin.Engine, platform, version string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if platform != "" {
+		req.Header.Set("X-Client-Platform", platform)
+	}
+	if version != "" {
+		req.Header.Set("X-Client-Version", version)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestClientVersion_BelowMinimumRejected(t *testing.T) {
+	m := middleware.NewClientVersionMiddleware(map[string]string{"ios": "2.3.0"})
+	r := newClientVersionRouter(m)
+
+	w := doClientVersionRequest(r, "iOS", "2.2.9")
+
+	assert.Equal(t, http.StatusUpgradeRequired, w.Code)
+	assert.Contains(t, w.Body.String(), "CLIENT_UPGRADE_REQUIRED")
+}
+
+func TestClientVersion_BelowMinimumAsProblemDetails(t *testing.T) {
+	m := middleware.NewClientVersionMiddleware(map[string]string{"ios": "2.3.0"})
+	r := newClientVersionRouter(m)
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Accept", utils.ProblemContentType)
+	req.Header.Set("X-Client-Platform", "iOS")
+	req.Header.Set("X-Client-Version", "2.2.9")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUpgradeRequired, w.Code)
+	assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "urn:tkhanchat:problem:client_upgrade_required",
+		"title": "Upgrade Required",
+		"status": 426,
+		"detail": "this version of the app is no longer supported, please upgrade to 2.3.0 or later",
+		"instance": "/ping",
+		"code": "CLIENT_UPGRADE_REQUIRED",
+		"details": {"platform": "ios", "min_version": "2.3.0"}
+	}`, w.Body.String())
+}
+
+func TestClientVersion_AtOrAboveMinimumAllowed(t *testing.T) {
+	m := middleware.NewClientVersionMiddleware(map[string]string{"ios": "2.3.0"})
+	r := newClientVersionRouter(m)
+
+	assert.Equal(t, http.StatusOK, doClientVersionRequest(r, "ios", "2.3.0").Code)
+	assert.Equal(t, http.StatusOK, doClientVersionRequest(r, "ios", "2.10.0").Code)
+}
+
+func TestClientVersion_MissingHeaderPassesThrough(t *testing.T) {
+	m := middleware.NewClientVersionMiddleware(map[string]string{"ios": "2.3.0"})
+	r := newClientVersionRouter(m)
+
+	w := doClientVersionRequest(r, "", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestClientVersion_UnconfiguredPlatformPassesThrough(t *testing.T) {
+	m := middleware.NewClientVersionMiddleware(map[string]string{"ios": "2.3.0"})
+	r := newClientVersionRouter(m)
+
+	w := doClientVersionRequest(r, "android", "0.1.0")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestClientVersion_ReloadedMinimumApplies(t *testing.T) {
+	m := middleware.NewClientVersionMiddleware(map[string]string{"android": "1.0.0"})
+	r := newClientVersionRouter(m)
+
+	assert.Equal(t, http.StatusOK, doClientVersionRequest(r, "android", "1.5.0").Code)
+
+	m.SetMinVersions(map[string]string{"android": "2.0.0"})
+
+	assert.Equal(t, http.StatusUpgradeRequired, doClientVersionRequest(r, "android", "1.5.0").Code)
+}
@@ -1,22 +1,69 @@
 package middleware
 
 import (
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
-// CORS handles Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
+// CORSMiddleware handles Cross-Origin Resource Sharing for the configured origins
+type CORSMiddleware struct {
+	allowAll         bool
+	allowedOrigins   map[string]bool
+	allowedMethods   string
+	allowedHeaders   string
+	allowCredentials bool
+}
+
+// NewCORSMiddleware creates a new CORS middleware.
+// allowedOrigins lists the exact origins (e.g. "https://app.example.com") browsers may call the API from;
+// "*" allows any origin and is meant for development only.
+func NewCORSMiddleware(allowedOrigins, allowedMethods, allowedHeaders []string, allowCredentials bool) *CORSMiddleware {
+	m := &CORSMiddleware{
+		allowedOrigins:   make(map[string]bool, len(allowedOrigins)),
+		allowedMethods:   strings.Join(allowedMethods, ", "),
+		allowedHeaders:   strings.Join(allowedHeaders, ", "),
+		allowCredentials: allowCredentials,
+	}
+	for _, origin := range allowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			m.allowAll = true
+		}
+		m.allowedOrigins[strings.ToLower(origin)] = true
+	}
+	return m
+}
+
+// Handle adds the CORS headers for allowed origins and answers preflight requests.
+// Requests from other origins get no CORS headers, so browsers refuse to expose the response.
+func (m *CORSMiddleware) Handle() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
+		origin := c.GetHeader("Origin")
+		// The response depends on the Origin header, so caches must not share it across origins
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if origin != "" && m.isAllowed(origin) {
+			// The origin is echoed rather than "*", which browsers reject on credentialed requests
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			if m.allowCredentials {
+				c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			c.Writer.Header().Set("Access-Control-Allow-Headers", m.allowedHeaders)
+			c.Writer.Header().Set("Access-Control-Allow-Methods", m.allowedMethods)
+			c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
 		c.Next()
 	}
 }
+
+func (m *CORSMiddleware) isAllowed(origin string) bool {
+	return m.allowAll || m.allowedOrigins[strings.ToLower(origin)]
+}
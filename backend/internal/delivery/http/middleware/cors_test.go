@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func doCORSRequest(m *middleware.CORSMiddleware, method, origin string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Handle())
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+
+	req := httptest.NewRequest(method, "/ping", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_AllowedOriginEchoed(t *testing.T) {
+	m := middleware.NewCORSMiddleware([]string{"https://app.example.com/"}, []string{"GET", "POST"}, []string{"Authorization"}, true)
+
+	w := doCORSRequest(m, http.MethodGet, "https://app.example.com")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+}
+
+func TestCORS_DisallowedOriginGetsNoHeaders(t *testing.T) {
+	m := middleware.NewCORSMiddleware([]string{"https://app.example.com"}, []string{"GET"}, nil, true)
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := doCORSRequest(m, method, "https://evil.example.com")
+
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	}
+}
+
+func TestCORS_PreflightAnswered(t *testing.T) {
+	m := middleware.NewCORSMiddleware([]string{"https://app.example.com"}, []string{"GET"}, nil, false)
+
+	w := doCORSRequest(m, http.MethodOptions, "https://app.example.com")
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_WildcardAllowsAnyOrigin(t *testing.T) {
+	m := middleware.NewCORSMiddleware([]string{"*"}, []string{"GET"}, nil, true)
+
+	w := doCORSRequest(m, http.MethodGet, "http://localhost:3000")
+
+	assert.Equal(t, "http://localhost:3000", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_NoOriginsAllowsNone(t *testing.T) {
+	m := middleware.NewCORSMiddleware(nil, []string{"GET"}, nil, true)
+
+	w := doCORSRequest(m, http.MethodGet, "http://localhost:3000")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
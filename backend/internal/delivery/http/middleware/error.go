@@ -1,20 +1,44 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"runtime/debug"
 
+	"backend/internal/infrastructure/logger"
 	"backend/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-// ErrorHandler handles panics and errors
+// ErrorHandler handles panics: the panic value and stack trace are logged with the request ID,
+// while the client only gets a generic 500 response
 func ErrorHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				utils.ErrorResponse(c, http.StatusInternalServerError, "internal server error", nil)
+			recovered := recover()
+			if recovered == nil {
+				return
 			}
+			// net/http uses this panic to abort a response on purpose
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			logger.ErrorContext(c.Request.Context(), "Panic recovered", fmt.Errorf("%v", recovered),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("stack", string(debug.Stack())),
+			)
+
+			if c.Writer.Written() {
+				// The response has started; all that can be done is to stop it
+				c.Abort()
+				return
+			}
+			utils.ErrorResponse(c, http.StatusInternalServerError, "internal server error", nil)
+			c.Abort()
 		}()
 		c.Next()
 	}
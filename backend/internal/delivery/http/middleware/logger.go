@@ -1,16 +1,24 @@
 package middleware
 
 import (
+	"net/http"
 	"time"
 
 	"backend/internal/infrastructure/logger"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-// Logger returns a gin middleware for logging requests
-func Logger() gin.HandlerFunc {
+// Logger returns a gin middleware for logging requests. Server errors are logged at error level
+// and client errors at warn level. Requests to skipPaths (e.g. health probes) are not logged.
+func Logger(skipPaths ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, path := range skipPaths {
+		skip[path] = true
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -18,18 +26,38 @@ func Logger() gin.HandlerFunc {
 
 		c.Next()
 
+		if skip[path] {
+			return
+		}
+
 		latency := time.Since(start)
 		statusCode := c.Writer.Status()
 		clientIP := c.ClientIP()
 		method := c.Request.Method
 
-		logger.Info("HTTP Request",
+		level := zapcore.InfoLevel
+		switch {
+		case statusCode >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case statusCode >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		}
+
+		fields := []zap.Field{
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.String("query", query),
 			zap.Int("status", statusCode),
 			zap.Duration("latency", latency),
 			zap.String("ip", clientIP),
-		)
+			zap.Int("size", max(c.Writer.Size(), 0)),
+		}
+		// Set by Authenticate on protected routes
+		if userID := c.GetString("userID"); userID != "" {
+			fields = append(fields, zap.String("user_id", userID))
+		}
+
+		// The request-scoped logger adds the request ID
+		logger.FromContext(c.Request.Context()).Log(level, "HTTP Request", fields...)
 	}
 }
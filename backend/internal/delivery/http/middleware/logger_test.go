@@ -0,0 +1,91 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+	"backend/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeLogs routes the logger to an in-memory recorder for the rest of the test
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger.Set(zap.New(core))
+	t.Cleanup(func() { logger.Init("release") })
+	return logs
+}
+
+func newLoggerRouter(skipPaths ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Logger(skipPaths...))
+	r.GET("/health", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/status/:code", func(c *gin.Context) {
+		c.Set("userID", "user-1")
+		switch c.Param("code") {
+		case "500":
+			c.String(http.StatusInternalServerError, "boom")
+		case "404":
+			c.String(http.StatusNotFound, "missing")
+		default:
+			c.String(http.StatusOK, "pong")
+		}
+	})
+	return r
+}
+
+func TestLogger_LevelFollowsStatus(t *testing.T) {
+	logs := observeLogs(t)
+	r := newLoggerRouter()
+
+	levels := map[string]zapcore.Level{
+		"200": zapcore.InfoLevel,
+		"404": zapcore.WarnLevel,
+		"500": zapcore.ErrorLevel,
+	}
+	for code, level := range levels {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status/"+code, nil))
+
+		entries := logs.TakeAll()
+		require.Len(t, entries, 1, code)
+		assert.Equal(t, level, entries[0].Level, code)
+	}
+}
+
+func TestLogger_IncludesUserRequestIDAndSize(t *testing.T) {
+	logs := observeLogs(t)
+	r := newLoggerRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/status/200", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "user-1", fields["user_id"])
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, int64(len("pong")), fields["size"])
+}
+
+func TestLogger_SkipsConfiguredPaths(t *testing.T) {
+	logs := observeLogs(t)
+	r := newLoggerRouter("/health")
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status/200", nil))
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "/status/200", logs.All()[0].ContextMap()["path"])
+}
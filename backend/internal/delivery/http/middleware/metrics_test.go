@@ -0,0 +1,39 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_RecordsRequestsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := middleware.NewMetricsMiddleware(prometheus.NewRegistry())
+	r := gin.New()
+	r.Use(m.Handle())
+	r.GET("/metrics", m.Serve())
+	r.GET("/messages/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, path := range []string{"/messages/1", "/messages/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/messages/:id",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/messages/:id"} 2`)
+	// Only the /metrics request itself is in flight while it is served
+	assert.Contains(t, body, "http_requests_in_flight 1")
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/infrastructure/logger"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimit is a token bucket: Burst requests may be made at once, refilled at Rate requests per second.
+// A zero Rate or Burst disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// RateLimitStore keeps the token buckets. The in-memory store limits each instance separately;
+// a shared store (e.g. Redis) makes the limits apply across instances.
+type RateLimitStore interface {
+	// Take removes a token from key's bucket. When the bucket is empty it reports false
+	// and how long until a token is available.
+	Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// RateLimitMiddleware rejects clients making requests faster than the configured limits
+type RateLimitMiddleware struct {
+	store  RateLimitStore
+	global RateLimit
+	auth   RateLimit
+	user   RateLimit
+}
+
+// NewRateLimitMiddleware creates a new rate limit middleware.
+// global applies per client IP to every request, auth per client IP to the authentication routes
+// and user per authenticated user.
+func NewRateLimitMiddleware(store RateLimitStore, global, auth, user RateLimit) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		store:  store,
+		global: global,
+		auth:   auth,
+		user:   user,
+	}
+}
+
+// PerIP limits every request by client IP
+func (m *RateLimitMiddleware) PerIP() gin.HandlerFunc {
+	return m.limit(m.global, func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	})
+}
+
+// Auth applies the stricter limit for the authentication routes by client IP, to slow down password guessing
+func (m *RateLimitMiddleware) Auth() gin.HandlerFunc {
+	return m.limit(m.auth, func(c *gin.Context) string {
+		return "auth:" + c.ClientIP()
+	})
+}
+
+// PerUser limits requests by authenticated user. It must run after Authenticate.
+func (m *RateLimitMiddleware) PerUser() gin.HandlerFunc {
+	return m.limit(m.user, func(c *gin.Context) string {
+		return "user:" + c.GetString("userID")
+	})
+}
+
+func (m *RateLimitMiddleware) limit(limit RateLimit, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.enabled() {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter, err := m.store.Take(c.Request.Context(), key(c), limit)
+		if err != nil {
+			// An unavailable store must not take the API down with it
+			logger.WarnContext(c.Request.Context(), "Rate limit store failed, allowing request", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			utils.HandleDomainError(c, errors.ErrRateLimited)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// memoryRateLimitStore keeps token buckets in process memory
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   RateLimit
+}
+
+// rateLimitPruneInterval is how often buckets that have refilled are dropped from the in-memory store
+const rateLimitPruneInterval = time.Minute
+
+// NewMemoryRateLimitStore creates a rate limit store that keeps its buckets in memory
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+	}
+}
+
+func (s *memoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPrune) >= rateLimitPruneInterval {
+		s.prune(now)
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = bucket
+	}
+	bucket.limit = limit
+	bucket.refill(now)
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+		return false, wait, nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+// prune drops buckets that have refilled completely; they behave exactly like a new bucket
+func (s *memoryRateLimitStore) prune(now time.Time) {
+	for key, bucket := range s.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= float64(bucket.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastPrune = now
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate)
+	b.updated = now
+}
@@ -0,0 +1,109 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitRouter(handler gin.HandlerFunc, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set("userID", userID)
+		}
+	}, handler)
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return r
+}
+
+func doRateLimitRequest(r *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// slow refills a token per hour, so only the burst is available during a test
+func slow(burst int) middleware.RateLimit {
+	return middleware.RateLimit{Rate: 1.0 / 3600, Burst: burst}
+}
+
+func TestRateLimit_TripsAfterBurst(t *testing.T) {
+	m := middleware.NewRateLimitMiddleware(middleware.NewMemoryRateLimitStore(), slow(3), middleware.RateLimit{}, middleware.RateLimit{})
+	r := newRateLimitRouter(m.PerIP(), "")
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1:1234").Code, "request %d", i+1)
+	}
+	w := doRateLimitRequest(r, "10.0.0.1:1234")
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 3600, retryAfter, 1)
+
+	// Other clients have their own bucket
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.2:1234").Code)
+}
+
+func TestRateLimit_ProblemDetailsWhenEnabled(t *testing.T) {
+	utils.UseProblemDetails(true)
+	t.Cleanup(func() { utils.UseProblemDetails(false) })
+	m := middleware.NewRateLimitMiddleware(middleware.NewMemoryRateLimitStore(), slow(1), middleware.RateLimit{}, middleware.RateLimit{})
+	r := newRateLimitRouter(m.PerIP(), "")
+
+	require.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1:1234").Code)
+	w := doRateLimitRequest(r, "10.0.0.1:1234")
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem utils.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "RATE_LIMITED", problem.Code)
+	assert.Equal(t, http.StatusTooManyRequests, problem.Status)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestRateLimit_AuthLimitSeparateFromGlobal(t *testing.T) {
+	store := middleware.NewMemoryRateLimitStore()
+	m := middleware.NewRateLimitMiddleware(store, slow(5), slow(1), middleware.RateLimit{})
+	global := newRateLimitRouter(m.PerIP(), "")
+	auth := newRateLimitRouter(m.Auth(), "")
+
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(auth, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitRequest(auth, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(global, "10.0.0.1:1234").Code)
+}
+
+func TestRateLimit_PerUserAcrossAddresses(t *testing.T) {
+	m := middleware.NewRateLimitMiddleware(middleware.NewMemoryRateLimitStore(), middleware.RateLimit{}, middleware.RateLimit{}, slow(2))
+	r := newRateLimitRouter(m.PerUser(), "user-1")
+
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.2:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, doRateLimitRequest(r, "10.0.0.3:1234").Code)
+}
+
+func TestRateLimit_ZeroLimitDisabled(t *testing.T) {
+	m := middleware.NewRateLimitMiddleware(middleware.NewMemoryRateLimitStore(), middleware.RateLimit{}, middleware.RateLimit{}, middleware.RateLimit{})
+	r := newRateLimitRouter(m.PerIP(), "")
+
+	for i := 0; i < 100; i++ {
+		require.Equal(t, http.StatusOK, doRateLimitRequest(r, "10.0.0.1:1234").Code)
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"backend/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader carries the request ID in requests and responses
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDKey is the gin context key holding the request ID
+	RequestIDKey = "requestID"
+
+	// maxRequestIDLength bounds request IDs accepted from clients, which end up in every log line
+	maxRequestIDLength = 128
+)
+
+// RequestID identifies each request with the caller's X-Request-ID, or a new UUID when it has none,
+// so the request can be traced across log lines and services. The ID is echoed in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set(RequestIDKey, requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts short IDs of printable ASCII, so client input cannot forge log entries
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/delivery/http/middleware"
+	"backend/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// doRequestIDRequest sends a request with the given X-Request-ID and returns the response
+// along with the request ID seen by the handler through the request context
+func doRequestIDRequest(requestID string) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID())
+
+	var seen string
+	r.GET("/ping", func(c *gin.Context) {
+		seen = logger.RequestID(c.Request.Context())
+		c.String(http.StatusOK, "pong")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w, seen
+}
+
+func TestRequestID_KeepsCallerID(t *testing.T) {
+	w, seen := doRequestIDRequest("abc-123")
+
+	assert.Equal(t, "abc-123", w.Header().Get(middleware.RequestIDHeader))
+	assert.Equal(t, "abc-123", seen)
+}
+
+func TestRequestID_GeneratedWhenMissingOrInvalid(t *testing.T) {
+	for _, requestID := range []string{"", "bad id\nforged log line", strings.Repeat("a", 129)} {
+		w, seen := doRequestIDRequest(requestID)
+
+		generated := w.Header().Get(middleware.RequestIDHeader)
+		_, err := uuid.Parse(generated)
+		assert.NoError(t, err)
+		assert.Equal(t, generated, seen)
+	}
+}
@@ -3,16 +3,28 @@ package router
 import (
 	"backend/internal/delivery/http/handler"
 	"backend/internal/delivery/http/middleware"
+	"backend/internal/domain/entity"
+	"backend/internal/infrastructure/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Router manages all HTTP routes
 type Router struct {
-	userHandler    *handler.UserHandler
-	oauthHandler   *handler.OAuthHandler
-	authHandler    *handler.AuthHandler
-	authMiddleware *middleware.AuthMiddleware
+	userHandler         *handler.UserHandler
+	oauthHandler        *handler.OAuthHandler
+	authHandler         *handler.AuthHandler
+	conversationHandler *handler.ConversationHandler
+	messageHandler      *handler.MessageHandler
+	searchHandler       *handler.SearchHandler
+	healthHandler       *handler.HealthHandler
+	authMiddleware      *middleware.AuthMiddleware
+	clientVersion       *middleware.ClientVersionMiddleware
+	cors                *middleware.CORSMiddleware
+	rateLimit           *middleware.RateLimitMiddleware
+	metrics             *middleware.MetricsMiddleware
+	logSkipPaths        []string
+	trustedProxies      []string
 }
 
 // NewRouter creates a new router
@@ -20,36 +32,70 @@ func NewRouter(
 	userHandler *handler.UserHandler,
 	oauthHandler *handler.OAuthHandler,
 	authHandler *handler.AuthHandler,
+	conversationHandler *handler.ConversationHandler,
+	messageHandler *handler.MessageHandler,
+	searchHandler *handler.SearchHandler,
+	healthHandler *handler.HealthHandler,
 	authMiddleware *middleware.AuthMiddleware,
+	clientVersion *middleware.ClientVersionMiddleware,
+	cors *middleware.CORSMiddleware,
+	rateLimit *middleware.RateLimitMiddleware,
+	metrics *middleware.MetricsMiddleware, // nil disables metrics
+	logSkipPaths []string,
+	trustedProxies []string, // empty trusts no proxy's X-Forwarded-For
 ) *Router {
 	return &Router{
-		userHandler:    userHandler,
-		oauthHandler:   oauthHandler,
-		authHandler:    authHandler,
-		authMiddleware: authMiddleware,
+		userHandler:         userHandler,
+		oauthHandler:        oauthHandler,
+		authHandler:         authHandler,
+		conversationHandler: conversationHandler,
+		messageHandler:      messageHandler,
+		searchHandler:       searchHandler,
+		healthHandler:       healthHandler,
+		authMiddleware:      authMiddleware,
+		clientVersion:       clientVersion,
+		cors:                cors,
+		rateLimit:           rateLimit,
+		metrics:             metrics,
+		logSkipPaths:        logSkipPaths,
+		trustedProxies:      trustedProxies,
 	}
 }
 
 // Setup configures all routes
 func (r *Router) Setup() *gin.Engine {
 	router := gin.New()
+	// Gin trusts every proxy by default, letting any client choose its IP via X-Forwarded-For and
+	// dodge the per-IP rate limits. The entries are checked by config validation.
+	if err := router.SetTrustedProxies(r.trustedProxies); err != nil {
+		logger.Error("Invalid trusted proxies, trusting none", err)
+		_ = router.SetTrustedProxies(nil)
+	}
 
 	// Global middleware
 	router.Use(gin.Recovery())
-	router.Use(middleware.Logger())
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Logger(r.logSkipPaths...))
 	router.Use(middleware.ErrorHandler())
-	router.Use(middleware.CORS())
+	// Metrics come before CORS and rate limiting so preflights and rejected requests are counted too
+	if r.metrics != nil {
+		router.Use(r.metrics.Handle())
+		router.GET("/metrics", r.metrics.Serve())
+	}
+	router.Use(r.cors.Handle())
+	router.Use(r.rateLimit.PerIP())
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	// Health checks: liveness, and readiness to serve requests
+	router.GET("/health", r.healthHandler.Live)
+	router.GET("/health/ready", r.healthHandler.Ready)
 
 	// API v1
 	v1 := router.Group("/api/v1")
+	v1.Use(r.clientVersion.Check())
 	{
 		// Public routes - Authentication
 		auth := v1.Group("/auth")
+		auth.Use(r.rateLimit.Auth())
 		{
 			// Standard auth routes
 			auth.POST("/register", r.authHandler.Register)
@@ -59,32 +105,92 @@ func (r *Router) Setup() *gin.Engine {
 			auth.POST("/resend-verification", r.authHandler.ResendVerification)
 			auth.POST("/forgot-password", r.authHandler.ForgotPassword)
 			auth.POST("/reset-password", r.authHandler.ResetPassword)
-			
+			auth.POST("/confirm-email-change", r.userHandler.ConfirmEmailChange)
+			auth.POST("/reactivate", r.userHandler.ReactivateAccount)
+
 			// Google OAuth routes
 			auth.GET("/google", r.oauthHandler.GetGoogleAuthURL)
 			auth.GET("/google/callback", r.oauthHandler.HandleGoogleCallback)
+			auth.POST("/google/token", r.oauthHandler.LoginWithGoogleIDToken)
+
+			// GitHub OAuth routes
+			auth.GET("/github", r.oauthHandler.GetGitHubAuthURL)
+			auth.GET("/github/callback", r.oauthHandler.HandleGitHubCallback)
 		}
 
 		// Protected auth routes
 		authProtected := v1.Group("/auth")
-		authProtected.Use(r.authMiddleware.Authenticate())
+		authProtected.Use(r.authMiddleware.Authenticate(), r.rateLimit.PerUser())
 		{
 			authProtected.POST("/logout", r.userHandler.Logout)
+			authProtected.POST("/logout-device", r.userHandler.LogoutDevice)
+			authProtected.POST("/resend-verification-me", r.authHandler.ResendVerificationMe)
 		}
 
 		// Protected routes - User profile
 		users := v1.Group("/users")
-		users.Use(r.authMiddleware.Authenticate())
+		users.Use(r.authMiddleware.Authenticate(), r.rateLimit.PerUser())
 		{
 			users.GET("/me", r.userHandler.GetProfile)
 			users.PUT("/me", r.userHandler.UpdateProfile)
+			users.POST("/me/email", r.userHandler.RequestEmailChange)
 			users.PUT("/me/avatar", r.userHandler.UpdateAvatar)
+			users.DELETE("/me/avatar", r.userHandler.DeleteAvatar)
+			users.DELETE("/me", r.userHandler.DeleteAccount)
+			users.POST("/me/deactivate", r.userHandler.DeactivateAccount)
+			users.GET("/me/sessions", r.userHandler.ListSessions)
+			users.GET("/me/login-history", r.userHandler.GetLoginHistory)
+			users.POST("/me/clear-history", r.messageHandler.ClearHistory)
+			users.DELETE("/me/oauth/:provider", r.oauthHandler.UnlinkProvider)
+			users.GET("/search", r.authMiddleware.RequireRole(entity.RoleAdmin), r.userHandler.SearchUsers)
 			users.GET("/:id", r.userHandler.GetUserByID)
 			users.GET("", r.userHandler.ListUsers)
-			users.DELETE("/:id", r.userHandler.DeleteUser)
+			users.DELETE("/:id", r.authMiddleware.RequireRole(entity.RoleAdmin), r.userHandler.DeleteUser)
+		}
+
+		// Protected routes - Conversations
+		conversations := v1.Group("/conversations")
+		conversations.Use(r.authMiddleware.Authenticate(), r.rateLimit.PerUser())
+		{
+			conversations.POST("", r.conversationHandler.CreateConversation)
+			conversations.GET("", r.conversationHandler.ListConversations)
+			conversations.GET("/:id", r.conversationHandler.GetConversation)
+			conversations.POST("/:id/mute", r.conversationHandler.MuteConversation)
+			conversations.POST("/:id/unmute", r.conversationHandler.UnmuteConversation)
+			conversations.POST("/:id/mark-unread", r.conversationHandler.MarkUnread)
+			conversations.POST("/:id/messages", r.messageHandler.SendMessage)
+			conversations.POST("/:id/messages/attachment", r.messageHandler.SendAttachment)
+			conversations.GET("/:id/messages/search", r.messageHandler.SearchMessages)
+			conversations.GET("/:id/messages", r.messageHandler.ListMessages)
+			conversations.GET("/:id/media", r.messageHandler.ListMedia)
+			conversations.POST("/:id/read", r.messageHandler.MarkRead)
+		}
+
+		// Protected routes - Messages
+		messages := v1.Group("/messages")
+		messages.Use(r.authMiddleware.Authenticate(), r.rateLimit.PerUser())
+		{
+			messages.PUT("/:id", r.messageHandler.EditMessage)
+			messages.DELETE("/:id", r.messageHandler.DeleteMessage)
+			messages.POST("/:id/reactions", r.messageHandler.ReactToMessage)
+		}
+
+		// Protected routes - Mentions
+		mentions := v1.Group("/mentions")
+		mentions.Use(r.authMiddleware.Authenticate(), r.rateLimit.PerUser())
+		{
+			mentions.GET("", r.messageHandler.ListMentions)
+		}
+
+		// Protected routes - Search
+		search := v1.Group("/search")
+		search.Use(r.authMiddleware.Authenticate(), r.rateLimit.PerUser())
+		{
+			search.GET("", r.searchHandler.Search)
+			search.GET("/recent", r.searchHandler.ListRecentSearches)
+			search.DELETE("/recent", r.searchHandler.ClearRecentSearches)
 		}
 	}
 
 	return router
 }
-
@@ -0,0 +1,91 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/delivery/http/handler"
+	"backend/internal/delivery/http/middleware"
+	"backend/internal/delivery/http/router"
+	"backend/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRouter wires the routes with handlers that have no use cases, limiting each client IP to global
+func newRouter(global middleware.RateLimit, trustedProxies []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger.Init("release")
+
+	return router.NewRouter(
+		handler.NewUserHandler(nil, nil, nil, nil, nil, nil),
+		handler.NewOAuthHandler(nil, nil, nil, nil, nil),
+		handler.NewAuthHandler(nil, nil, nil, nil, nil),
+		handler.NewConversationHandler(nil),
+		handler.NewMessageHandler(nil, nil, 0),
+		handler.NewSearchHandler(nil, nil),
+		handler.NewHealthHandler(nil, time.Second),
+		middleware.NewAuthMiddleware(nil),
+		middleware.NewClientVersionMiddleware(nil),
+		middleware.NewCORSMiddleware(nil, nil, nil, false),
+		middleware.NewRateLimitMiddleware(middleware.NewMemoryRateLimitStore(), global, middleware.RateLimit{}, middleware.RateLimit{}),
+		nil,
+		nil,
+		trustedProxies,
+	).Setup()
+}
+
+// ping sends a liveness probe from remoteAddr claiming to forward for forwardedFor
+func ping(r *gin.Engine, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSetup_AuthRoutesRegistered(t *testing.T) {
+	// Requests with an empty body fail binding before any use case is reached
+	r := newRouter(middleware.RateLimit{}, nil)
+
+	paths := []string{
+		"/api/v1/auth/verify-email",
+		"/api/v1/auth/resend-verification",
+		"/api/v1/auth/forgot-password",
+		"/api/v1/auth/reset-password",
+		"/api/v1/auth/confirm-email-change",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.NotEqual(t, http.StatusNotFound, w.Code)
+		})
+	}
+}
+
+func TestSetup_SpoofedForwardedForDoesNotChangeRateLimitKey(t *testing.T) {
+	// One request per hour per IP
+	r := newRouter(middleware.RateLimit{Rate: 1.0 / 3600, Burst: 1}, nil)
+
+	assert.Equal(t, http.StatusOK, ping(r, "203.0.113.7:1234", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, ping(r, "203.0.113.7:1234", "198.51.100.2"))
+}
+
+func TestSetup_TrustedProxyForwardsClientIP(t *testing.T) {
+	r := newRouter(middleware.RateLimit{Rate: 1.0 / 3600, Burst: 1}, []string{"10.0.0.0/8"})
+
+	assert.Equal(t, http.StatusOK, ping(r, "10.0.0.2:1234", "198.51.100.1"))
+	assert.Equal(t, http.StatusOK, ping(r, "10.0.0.2:1234", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, ping(r, "10.0.0.2:1234", "198.51.100.1"))
+}
@@ -6,6 +6,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxAvatarSize is the largest avatar image accepted, in bytes
+const MaxAvatarSize = 5 * 1024 * 1024
+
 // Avatar represents the avatar domain entity
 type Avatar struct {
 	ID        string
@@ -29,3 +32,9 @@ func NewAvatar(userID, publicID, publicURL, secureURL string) *Avatar {
 		UpdatedAt: time.Now(),
 	}
 }
+
+// NewExternalAvatar creates an avatar that points at an image hosted outside
+// Cloudinary, such as an OAuth provider's profile picture
+func NewExternalAvatar(userID, url string) *Avatar {
+	return NewAvatar(userID, "", url, url)
+}
@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation types
+const (
+	ConversationTypeDirect = "direct" // one-to-one conversation between two users
+	ConversationTypeGroup  = "group"
+)
+
+// Conversation represents a chat between its participants
+type Conversation struct {
+	ID             string
+	Type           string
+	Name           string // only set for group conversations
+	ParticipantIDs []string
+	// MutedParticipantIDs are the participants who muted the conversation's notifications.
+	// They still receive its messages but are left out of notification fan-out.
+	MutedParticipantIDs []string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// NewConversation creates a new conversation entity
+func NewConversation(conversationType, name string, participantIDs []string) *Conversation {
+	now := time.Now()
+	return &Conversation{
+		ID:             uuid.New().String(),
+		Type:           conversationType,
+		Name:           name,
+		ParticipantIDs: participantIDs,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// HasParticipant checks whether the user takes part in the conversation
+func (c *Conversation) HasParticipant(userID string) bool {
+	for _, id := range c.ParticipantIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMutedBy checks whether the participant muted the conversation's notifications
+func (c *Conversation) IsMutedBy(userID string) bool {
+	for _, id := range c.MutedParticipantIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMuted records whether the participant muted the conversation's notifications
+func (c *Conversation) SetMuted(userID string, muted bool) {
+	if muted == c.IsMutedBy(userID) {
+		return
+	}
+	if muted {
+		c.MutedParticipantIDs = append(c.MutedParticipantIDs, userID)
+		return
+	}
+
+	remaining := make([]string, 0, len(c.MutedParticipantIDs)-1)
+	for _, id := range c.MutedParticipantIDs {
+		if id != userID {
+			remaining = append(remaining, id)
+		}
+	}
+	c.MutedParticipantIDs = remaining
+}
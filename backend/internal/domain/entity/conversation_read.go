@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// ConversationRead records how far a participant has read a conversation
+type ConversationRead struct {
+	ConversationID    string
+	UserID            string
+	LastReadMessageID string    // empty while the participant has read nothing
+	LastReadAt        time.Time // creation time of the last read message
+	// MarkedUnread is set when the participant marks the conversation unread, until they next read or send in it
+	MarkedUnread bool
+	UpdatedAt    time.Time
+}
+
+// NewConversationRead marks the message as the last one the user has read
+func NewConversationRead(userID string, message *Message) *ConversationRead {
+	return &ConversationRead{
+		ConversationID:    message.ConversationID,
+		UserID:            userID,
+		LastReadMessageID: message.ID,
+		LastReadAt:        message.CreatedAt,
+		UpdatedAt:         time.Now(),
+	}
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginMethodPassword is the LoginEvent method for email and password logins;
+// OAuth logins use the provider name (e.g., "google", "github")
+const LoginMethodPassword = "password"
+
+// LoginEvent records a successful login
+type LoginEvent struct {
+	ID        string
+	UserID    string
+	Method    string // LoginMethodPassword or the OAuth provider
+	IPAddress string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+// NewLoginEvent creates a new login event entity
+func NewLoginEvent(userID, method, ipAddress, userAgent string) *LoginEvent {
+	return &LoginEvent{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Method:    method,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	}
+}
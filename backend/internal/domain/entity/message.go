@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MaxMessageLength is the longest message body accepted, in characters
+	MaxMessageLength = 4000
+	// DeletedMessageBody replaces the body of deleted messages in responses
+	DeletedMessageBody = "message deleted"
+	// MaxAttachmentSize is the largest chat attachment accepted, in bytes
+	MaxAttachmentSize = 20 * 1024 * 1024
+	// MaxReactionLength is the longest reaction accepted, in bytes; enough for multi-codepoint emoji
+	MaxReactionLength = 32
+)
+
+// Media types a conversation's attachments can be filtered by, derived from their content type
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+	MediaTypeAudio = "audio"
+	MediaTypeFile  = "file" // any other content type, such as documents and archives
+)
+
+// Message represents a message sent to a conversation
+type Message struct {
+	ID             string
+	ConversationID string
+	SenderID       string
+	Body           string
+	Attachments    []*Attachment
+	Reactions      []*ReactionCount
+	Mentions       []string // IDs of the participants mentioned with @username
+	CreatedAt      time.Time
+	EditedAt       *time.Time
+	DeletedAt      *time.Time
+}
+
+// Attachment is a file sent with a message, stored in Cloudinary
+type Attachment struct {
+	ID           string
+	MessageID    string
+	PublicID     string // Cloudinary public_id
+	ResourceType string // Cloudinary resource type, needed to delete the asset
+	URL          string // Cloudinary secure URL (HTTPS)
+	FileName     string
+	ContentType  string
+	Size         int64
+	CreatedAt    time.Time
+}
+
+// NewAttachment creates a new attachment entity for the message
+func NewAttachment(messageID, publicID, resourceType, url, fileName, contentType string, size int64) *Attachment {
+	return &Attachment{
+		ID:           uuid.New().String(),
+		MessageID:    messageID,
+		PublicID:     publicID,
+		ResourceType: resourceType,
+		URL:          url,
+		FileName:     fileName,
+		ContentType:  contentType,
+		Size:         size,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// MessageReaction is a user's emoji reaction to a message
+type MessageReaction struct {
+	MessageID string
+	UserID    string
+	Emoji     string
+	CreatedAt time.Time
+}
+
+// ReactionCount is how many users reacted to a message with an emoji
+type ReactionCount struct {
+	Emoji string
+	Count int64
+}
+
+// NewMessageReaction creates a new reaction entity
+func NewMessageReaction(messageID, userID, emoji string) *MessageReaction {
+	return &MessageReaction{
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now(),
+	}
+}
+
+// NewMessage creates a new message entity
+func NewMessage(conversationID, senderID, body string) *Message {
+	return &Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Body:           body,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// IsDeleted checks if the sender has deleted the message
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
+
+// Edit replaces the body and records when it was changed
+func (m *Message) Edit(body string) {
+	now := time.Now()
+	m.Body = body
+	m.EditedAt = &now
+}
+
+// MarkDeleted deletes the message, dropping its body, attachments and mentions but keeping it in the history
+func (m *Message) MarkDeleted() {
+	now := time.Now()
+	m.Body = ""
+	m.Attachments = nil
+	m.Mentions = nil
+	m.DeletedAt = &now
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"backend/internal/domain/errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordPolicy describes the minimum strength required for new passwords
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// ValidatePasswordStrength checks password against policy and returns errors.ErrWeakPassword
+// with a message listing every unmet requirement
+func ValidatePasswordStrength(password string, policy PasswordPolicy) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if len([]rune(password)) < policy.MinLength {
+		missing = append(missing, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+	if policy.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if policy.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return errors.ErrWeakPassword.WithMessage("password must contain " + strings.Join(missing, ", "))
+}
+
+// ValidateBcryptCost checks that cost is accepted by bcrypt, so a misconfigured cost
+// fails at startup instead of on the first password hash
+func ValidateBcryptCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+	return nil
+}
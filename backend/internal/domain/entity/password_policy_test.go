@@ -0,0 +1,45 @@
+package entity_test
+
+import (
+	"testing"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var strictPolicy = entity.PasswordPolicy{
+	MinLength:     10,
+	RequireUpper:  true,
+	RequireLower:  true,
+	RequireDigit:  true,
+	RequireSymbol: true,
+}
+
+func TestValidatePasswordStrength_Strong(t *testing.T) {
+	assert.NoError(t, entity.ValidatePasswordStrength("Correct-Horse9", strictPolicy))
+}
+
+func TestValidatePasswordStrength_ListsUnmetRequirements(t *testing.T) {
+	err := entity.ValidatePasswordStrength("12345678", strictPolicy)
+
+	assert.ErrorIs(t, err, errors.ErrWeakPassword)
+	var domainErr *errors.DomainError
+	assert.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, "password must contain at least 10 characters, an uppercase letter, a lowercase letter, a symbol", domainErr.Message)
+}
+
+func TestValidatePasswordStrength_OnlyEnforcesEnabledRules(t *testing.T) {
+	assert.NoError(t, entity.ValidatePasswordStrength("12345678", entity.PasswordPolicy{MinLength: 8}))
+	assert.Error(t, entity.ValidatePasswordStrength("1234567", entity.PasswordPolicy{MinLength: 8}))
+}
+
+func TestValidateBcryptCost(t *testing.T) {
+	assert.NoError(t, entity.ValidateBcryptCost(bcrypt.MinCost))
+	assert.NoError(t, entity.ValidateBcryptCost(bcrypt.DefaultCost))
+	assert.NoError(t, entity.ValidateBcryptCost(bcrypt.MaxCost))
+	assert.Error(t, entity.ValidateBcryptCost(bcrypt.MinCost-1))
+	assert.Error(t, entity.ValidateBcryptCost(bcrypt.MaxCost+1))
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// RecentSearch is a query the user searched for, kept to suggest it again in the search box
+type RecentSearch struct {
+	UserID     string
+	Query      string
+	SearchedAt time.Time
+}
+
+// NewRecentSearch creates a new recent search entity searched now
+func NewRecentSearch(userID, query string) *RecentSearch {
+	return &RecentSearch{
+		UserID:     userID,
+		Query:      query,
+		SearchedAt: time.Now(),
+	}
+}
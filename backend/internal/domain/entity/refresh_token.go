@@ -8,22 +8,32 @@ import (
 
 // RefreshToken represents the refresh token domain entity
 type RefreshToken struct {
-	ID        string
-	UserID    string
-	Token     string
-	ExpiresAt time.Time
-	CreatedAt time.Time
-	RevokedAt *time.Time
+	ID         string
+	UserID     string
+	FamilyID   string // shared by every token rotated from the same login
+	Token      string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  *time.Time
+	UserAgent  string // client that created the session
+	IPAddress  string
 }
 
 // NewRefreshToken creates a new refresh token entity
-func NewRefreshToken(userID, token string, expiresAt time.Time) *RefreshToken {
+func NewRefreshToken(userID, token string, expiresAt time.Time, userAgent, ipAddress string) *RefreshToken {
+	now := time.Now()
+	id := uuid.New().String()
 	return &RefreshToken{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		Token:     token,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
+		ID:         id,
+		UserID:     userID,
+		FamilyID:   id,
+		Token:      token,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
 	}
 }
 
@@ -32,6 +42,19 @@ func (rt *RefreshToken) IsValid() bool {
 	return rt.RevokedAt == nil && time.Now().Before(rt.ExpiresAt)
 }
 
+// IsIdle checks if the refresh token has not been used within the idle timeout.
+// A zero or negative timeout disables the check.
+func (rt *RefreshToken) IsIdle(idleTimeout time.Duration) bool {
+	if idleTimeout <= 0 {
+		return false
+	}
+	lastUsedAt := rt.LastUsedAt
+	if lastUsedAt.IsZero() {
+		lastUsedAt = rt.CreatedAt
+	}
+	return time.Since(lastUsedAt) > idleTimeout
+}
+
 // Revoke marks the refresh token as revoked
 func (rt *RefreshToken) Revoke() {
 	now := time.Now()
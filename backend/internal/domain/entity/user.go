@@ -1,83 +1,120 @@
 package entity
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Username length limits, in characters
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 30
+)
+
+// usernamePattern matches a normalized username: lowercase letters, digits and underscores
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 // User represents the user domain entity
 type User struct {
-	ID                           string
-	Email                        string
-	Password                     string // bcrypt hashed (optional for OAuth users)
-	Name                         string
-	Avatar                       *Avatar // Avatar entity (optional)
-	Phone                        string
-	OAuthProvider                string // e.g., "google", "facebook", etc.
-	OAuthID                      string // OAuth provider's user ID
-	EmailVerified                bool
-	VerificationToken            string
-	VerificationTokenExpiresAt   time.Time
-	ResetPasswordToken           string
-	ResetPasswordTokenExpiresAt  time.Time
-	CreatedAt                    time.Time
-	UpdatedAt                    time.Time
+	ID                          string
+	Email                       string
+	Password                    string // bcrypt hashed (optional for OAuth users)
+	Name                        string
+	Username                    string  // unique handle for @-mentions, empty until the user picks one
+	Avatar                      *Avatar // Avatar entity (optional)
+	Phone                       string  // E.164, empty if the user has not given one
+	Role                        string  // RoleUser or RoleAdmin
+	OAuthProvider               string  // provider the account signed up with; linked providers are UserOAuthIdentity
+	OAuthID                     string  // OAuth provider's user ID
+	EmailVerified               bool
+	VerificationToken           string
+	VerificationTokenExpiresAt  time.Time
+	ResetPasswordToken          string
+	ResetPasswordTokenExpiresAt time.Time
+	PendingEmail                string // new address awaiting confirmation, empty unless an email change is pending
+	EmailChangeToken            string
+	EmailChangeTokenExpiresAt   time.Time
+	DeletionRequestedAt         time.Time // zero unless the account is pending deletion
+	DeactivatedAt               time.Time // zero unless the user has deactivated the account
+	NameChangedAt               time.Time // last display name change, zero if never changed
+	AvatarChangedAt             time.Time // last avatar upload, zero if never changed
+	LastLoginAt                 time.Time // zero if the user never logged in
+	CreatedAt                   time.Time
+	UpdatedAt                   time.Time
+}
+
+// NormalizeEmail trims and lowercases an email so lookups are case-insensitive
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername trims and lowercases a username so @Alice and @alice are the same user
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// IsValidUsername checks that a normalized username has an allowed length and characters
+func IsValidUsername(username string) bool {
+	return len(username) >= UsernameMinLength && len(username) <= UsernameMaxLength && usernamePattern.MatchString(username)
 }
 
 // NewUser creates a new user entity
-func NewUser(email, password, name, phone string) *User {
+func NewUser(email, password, name, username, phone string) *User {
 	return &User{
-		ID:                           uuid.New().String(),
-		Email:                        email,
-		Password:                     password,
-		Name:                         name,
-		Avatar:                       nil,
-		Phone:                        phone,
-		OAuthProvider:                "",
-		OAuthID:                      "",
-		EmailVerified:                false,
-		VerificationToken:            "",
-		VerificationTokenExpiresAt:   time.Time{},
-		ResetPasswordToken:           "",
-		ResetPasswordTokenExpiresAt:  time.Time{},
-		CreatedAt:                    time.Now(),
-		UpdatedAt:                    time.Now(),
+		ID:                          uuid.New().String(),
+		Email:                       NormalizeEmail(email),
+		Password:                    password,
+		Name:                        name,
+		Username:                    NormalizeUsername(username),
+		Avatar:                      nil,
+		Phone:                       phone,
+		Role:                        RoleUser,
+		OAuthProvider:               "",
+		OAuthID:                     "",
+		EmailVerified:               false,
+		VerificationToken:           "",
+		VerificationTokenExpiresAt:  time.Time{},
+		ResetPasswordToken:          "",
+		ResetPasswordTokenExpiresAt: time.Time{},
+		CreatedAt:                   time.Now(),
+		UpdatedAt:                   time.Now(),
 	}
 }
 
 // NewOAuthUser creates a new OAuth user entity
 func NewOAuthUser(email, name, avatarURL, provider, oauthID string) *User {
-	var avatar *Avatar
-	if avatarURL != "" {
-		// For OAuth users, we'll create a simple avatar entity
-		// The actual Cloudinary upload will happen later if needed
-		avatar = &Avatar{
-			ID:        uuid.New().String(),
-			PublicID:  "", // OAuth avatars don't have Cloudinary public_id initially
-			PublicURL: avatarURL,
-			SecureURL: avatarURL,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+	user := &User{
+		ID:                          uuid.New().String(),
+		Email:                       NormalizeEmail(email),
+		Password:                    "", // No password for OAuth users
+		Name:                        name,
+		Avatar:                      nil,
+		Phone:                       "",
+		Role:                        RoleUser,
+		OAuthProvider:               provider,
+		OAuthID:                     oauthID,
+		EmailVerified:               true, // OAuth users are auto-verified
+		VerificationToken:           "",
+		VerificationTokenExpiresAt:  time.Time{},
+		ResetPasswordToken:          "",
+		ResetPasswordTokenExpiresAt: time.Time{},
+		CreatedAt:                   time.Now(),
+		UpdatedAt:                   time.Now(),
 	}
-	return &User{
-		ID:                           uuid.New().String(),
-		Email:                        email,
-		Password:                     "", // No password for OAuth users
-		Name:                         name,
-		Avatar:                       avatar,
-		Phone:                        "",
-		OAuthProvider:                provider,
-		OAuthID:                      oauthID,
-		EmailVerified:                true, // OAuth users are auto-verified
-		VerificationToken:            "",
-		VerificationTokenExpiresAt:   time.Time{},
-		ResetPasswordToken:           "",
-		ResetPasswordTokenExpiresAt:  time.Time{},
-		CreatedAt:                    time.Now(),
-		UpdatedAt:                    time.Now(),
+	if avatarURL != "" {
+		// OAuth avatars have no Cloudinary public_id
+		user.Avatar = NewExternalAvatar(user.ID, avatarURL)
 	}
+	return user
 }
 
 // IsOAuthUser checks if the user is an OAuth user
@@ -85,3 +122,54 @@ func (u *User) IsOAuthUser() bool {
 	return u.OAuthProvider != "" && u.OAuthID != ""
 }
 
+// Roles returns the roles granted to the user
+func (u *User) Roles() []string {
+	if u.Role == "" {
+		return []string{RoleUser}
+	}
+	return []string{u.Role}
+}
+
+// IsAdmin checks if the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsPendingDeletion checks if the user has requested account deletion
+func (u *User) IsPendingDeletion() bool {
+	return !u.DeletionRequestedAt.IsZero()
+}
+
+// RequestDeletion marks the account for deletion once the grace period has passed
+func (u *User) RequestDeletion() {
+	u.DeletionRequestedAt = time.Now()
+	u.UpdatedAt = time.Now()
+}
+
+// CancelDeletion clears a pending deletion request
+func (u *User) CancelDeletion() {
+	u.DeletionRequestedAt = time.Time{}
+	u.UpdatedAt = time.Now()
+}
+
+// DeletionDue checks if a pending deletion has outlived its grace period
+func (u *User) DeletionDue(gracePeriod time.Duration) bool {
+	return u.IsPendingDeletion() && time.Now().After(u.DeletionRequestedAt.Add(gracePeriod))
+}
+
+// IsDeactivated checks if the user has deactivated the account
+func (u *User) IsDeactivated() bool {
+	return !u.DeactivatedAt.IsZero()
+}
+
+// Deactivate disables the account until the user reactivates it
+func (u *User) Deactivate() {
+	u.DeactivatedAt = time.Now()
+	u.UpdatedAt = time.Now()
+}
+
+// Reactivate clears the deactivation
+func (u *User) Reactivate() {
+	u.DeactivatedAt = time.Time{}
+	u.UpdatedAt = time.Now()
+}
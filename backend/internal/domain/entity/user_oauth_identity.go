@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserOAuthIdentity represents an OAuth provider account linked to a user.
+// A user can link one account per provider.
+type UserOAuthIdentity struct {
+	ID             string
+	UserID         string
+	Provider       string // e.g., "google", "github"
+	ProviderUserID string // OAuth provider's user ID
+	CreatedAt      time.Time
+}
+
+// NewUserOAuthIdentity creates a new OAuth identity entity
+func NewUserOAuthIdentity(userID, provider, providerUserID string) *UserOAuthIdentity {
+	return &UserOAuthIdentity{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Provider:       provider,
+		ProviderUserID: providerUserID,
+		CreatedAt:      time.Now(),
+	}
+}
@@ -1,6 +1,11 @@
 package errors
 
-import "fmt"
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+)
 
 // DomainError represents a domain-specific error
 type DomainError struct {
@@ -20,6 +25,18 @@ func (e *DomainError) Unwrap() error {
 	return e.Err
 }
 
+// Is reports whether target is a domain error with the same code, so errors given a specific
+// message with WithMessage still match the error they were derived from
+func (e *DomainError) Is(target error) bool {
+	t, ok := target.(*DomainError)
+	return ok && t.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with a more specific message
+func (e *DomainError) WithMessage(message string) *DomainError {
+	return &DomainError{Code: e.Code, Message: message, Err: e.Err}
+}
+
 // Common domain errors
 var (
 	ErrUserNotFound              = &DomainError{Code: "USER_NOT_FOUND", Message: "user not found"}
@@ -32,8 +49,57 @@ var (
 	ErrTokenExpired              = &DomainError{Code: "TOKEN_EXPIRED", Message: "token has expired"}
 	ErrRefreshTokenNotFound      = &DomainError{Code: "REFRESH_TOKEN_NOT_FOUND", Message: "refresh token not found"}
 	ErrEmailNotVerified          = &DomainError{Code: "EMAIL_NOT_VERIFIED", Message: "email not verified, please check your email for verification link"}
+	ErrAccountDeactivated        = &DomainError{Code: "ACCOUNT_DEACTIVATED", Message: "account is deactivated, reactivate it to log in"}
+	ErrPasswordLoginUnavailable  = &DomainError{Code: "PASSWORD_LOGIN_UNAVAILABLE", Message: "this account signs in with a linked provider, please log in with it"}
+	ErrWeakPassword              = &DomainError{Code: "WEAK_PASSWORD", Message: "password does not meet the strength requirements"}
+	ErrEmailAlreadyVerified      = &DomainError{Code: "EMAIL_ALREADY_VERIFIED", Message: "email is already verified"}
+	ErrVerificationResendTooSoon = &DomainError{Code: "VERIFICATION_RESEND_TOO_SOON", Message: "verification email was sent recently, please wait before requesting another"}
 	ErrInvalidVerificationToken  = &DomainError{Code: "INVALID_VERIFICATION_TOKEN", Message: "invalid verification token"}
 	ErrVerificationTokenExpired  = &DomainError{Code: "VERIFICATION_TOKEN_EXPIRED", Message: "verification token has expired"}
 	ErrInvalidResetToken         = &DomainError{Code: "INVALID_RESET_TOKEN", Message: "invalid password reset token"}
 	ErrResetTokenExpired         = &DomainError{Code: "RESET_TOKEN_EXPIRED", Message: "password reset token has expired"}
+	ErrOAuthProviderNotSupported = &DomainError{Code: "OAUTH_PROVIDER_NOT_SUPPORTED", Message: "oauth provider is not supported"}
+	ErrInvalidOAuthState         = &DomainError{Code: "INVALID_OAUTH_STATE", Message: "invalid or expired oauth state"}
+	ErrOAuthProviderNotLinked    = &DomainError{Code: "OAUTH_PROVIDER_NOT_LINKED", Message: "oauth provider is not linked to this account"}
+	ErrCannotUnlinkLastLogin     = &DomainError{Code: "CANNOT_UNLINK_LAST_LOGIN", Message: "cannot unlink the only sign-in method, set a password first"}
+	ErrTooManyRequests           = &DomainError{Code: "TOO_MANY_REQUESTS", Message: "too many emails requested, please try again later"}
+	ErrInvalidPhoneNumber        = &DomainError{Code: "INVALID_PHONE_NUMBER", Message: "phone number must be in international format, e.g. +15551234567"}
+	ErrInvalidUsername           = &DomainError{Code: "INVALID_USERNAME", Message: "username must be 3 to 30 letters, digits or underscores"}
+	ErrProfileUpdateCooldown     = &DomainError{Code: "PROFILE_UPDATE_COOLDOWN", Message: "this was changed recently, please try again later"}
+	ErrUsernameTaken             = &DomainError{Code: "USERNAME_TAKEN", Message: "username is already taken"}
+	ErrEmailAlreadyInUse         = &DomainError{Code: "EMAIL_ALREADY_IN_USE", Message: "email is already in use by another account"}
+	ErrInvalidEmailChangeToken   = &DomainError{Code: "INVALID_EMAIL_CHANGE_TOKEN", Message: "invalid email change token"}
+	ErrEmailChangeTokenExpired   = &DomainError{Code: "EMAIL_CHANGE_TOKEN_EXPIRED", Message: "email change token has expired"}
+	ErrAvatarTooLarge            = &DomainError{Code: "AVATAR_TOO_LARGE", Message: "file size exceeds 5MB limit"}
+	ErrAttachmentTooLarge        = &DomainError{Code: "ATTACHMENT_TOO_LARGE", Message: "file size exceeds 20MB limit"}
+	ErrConversationNotFound      = &DomainError{Code: "CONVERSATION_NOT_FOUND", Message: "conversation not found"}
+	ErrConversationExists        = &DomainError{Code: "CONVERSATION_EXISTS", Message: "a direct conversation between these users already exists"}
+	ErrNotConversationMember     = &DomainError{Code: "NOT_CONVERSATION_MEMBER", Message: "you are not a participant in this conversation"}
+	ErrInvalidParticipants       = &DomainError{Code: "INVALID_PARTICIPANTS", Message: "conversation participants are invalid"}
+	ErrInvalidMessageBody        = &DomainError{Code: "INVALID_MESSAGE_BODY", Message: "message must not be empty or longer than 4000 characters"}
+	ErrMessageNotFound           = &DomainError{Code: "MESSAGE_NOT_FOUND", Message: "message not found"}
+	ErrNotMessageSender          = &DomainError{Code: "NOT_MESSAGE_SENDER", Message: "only the sender can change this message"}
+	ErrInvalidCursor             = &DomainError{Code: "INVALID_CURSOR", Message: "invalid pagination cursor"}
+	ErrInvalidReaction           = &DomainError{Code: "INVALID_REACTION", Message: "reaction must be a single emoji"}
+	ErrInvalidSearchQuery        = &DomainError{Code: "INVALID_SEARCH_QUERY", Message: "search query must not be empty or longer than 100 characters"}
+	ErrInvalidAttachmentCount    = &DomainError{Code: "INVALID_ATTACHMENT_COUNT", Message: "a message must carry between 1 and 10 attachments"}
+	ErrInvalidMediaType          = &DomainError{Code: "INVALID_MEDIA_TYPE", Message: "media type must be image, video, audio or file"}
+	ErrInvalidIdempotencyKey     = &DomainError{Code: "INVALID_IDEMPOTENCY_KEY", Message: "idempotency key must be at most 255 printable ASCII characters"}
+	ErrIdempotencyKeyReused      = &DomainError{Code: "IDEMPOTENCY_KEY_REUSED", Message: "idempotency key was already used for a different request"}
+	ErrUpstreamTimeout           = &DomainError{Code: "UPSTREAM_TIMEOUT", Message: "an external service did not respond in time, please try again"}
+	ErrRateLimited               = &DomainError{Code: "RATE_LIMITED", Message: "too many requests, please try again later"}
+	ErrClientUpgradeRequired     = &DomainError{Code: "CLIENT_UPGRADE_REQUIRED", Message: "this version of the app is no longer supported, please upgrade"}
 )
+
+// WrapTimeout marks err with ErrUpstreamTimeout when it comes from an expired deadline, whether of a
+// context or of a network connection, so that it is reported as a timeout. Other errors are returned unchanged.
+func WrapTimeout(err error) error {
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	if stderrors.Is(err, context.DeadlineExceeded) || (stderrors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrUpstreamTimeout, err)
+	}
+	return err
+}
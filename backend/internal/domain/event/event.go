@@ -0,0 +1,83 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// Event names, in the form "<aggregate>.<past-tense verb>"
+const (
+	UserRegistered = "user.registered"
+	UserVerified   = "user.verified"
+	MessageSent    = "message.sent"
+	UserMentioned  = "user.mentioned"
+)
+
+// Event is something that happened in the domain, published after it has been persisted
+type Event interface {
+	// Name identifies the kind of event, e.g. UserRegistered
+	Name() string
+}
+
+// Handler reacts to a published event. Its error is logged, never returned to the publisher.
+type Handler func(ctx context.Context, e Event) error
+
+// EventBus delivers domain events to their subscribers.
+// Publish never fails the caller: by the time an event is published the operation has already succeeded,
+// so delivery problems are the bus's to report.
+type EventBus interface {
+	Publish(ctx context.Context, e Event)
+}
+
+// NopBus discards every event; it is the default when no bus is configured
+type NopBus struct{}
+
+// Publish does nothing
+func (NopBus) Publish(ctx context.Context, e Event) {}
+
+// UserRegisteredEvent is published when an account is created, by password sign-up or a first OAuth login
+type UserRegisteredEvent struct {
+	UserID string
+	Email  string
+	// Provider is the OAuth provider the account signed up with; empty for password sign-ups
+	Provider   string
+	OccurredAt time.Time
+}
+
+// Name returns UserRegistered
+func (UserRegisteredEvent) Name() string { return UserRegistered }
+
+// UserVerifiedEvent is published when a user confirms their email address
+type UserVerifiedEvent struct {
+	UserID     string
+	Email      string
+	UserName   string
+	OccurredAt time.Time
+}
+
+// Name returns UserVerified
+func (UserVerifiedEvent) Name() string { return UserVerified }
+
+// MessageSentEvent is published when a message is posted to a conversation
+type MessageSentEvent struct {
+	MessageID      string
+	ConversationID string
+	SenderID       string
+	OccurredAt     time.Time
+}
+
+// Name returns MessageSent
+func (MessageSentEvent) Name() string { return MessageSent }
+
+// UserMentionedEvent is published for each participant mentioned with @username in a new or edited message.
+// The sender mentioning themselves is not announced.
+type UserMentionedEvent struct {
+	MessageID       string
+	ConversationID  string
+	SenderID        string
+	MentionedUserID string
+	OccurredAt      time.Time
+}
+
+// Name returns UserMentioned
+func (UserMentionedEvent) Name() string { return UserMentioned }
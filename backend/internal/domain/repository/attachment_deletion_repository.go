@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+)
+
+// AttachmentDeletionRepository tracks Cloudinary attachments that could not be deleted,
+// so they can be retried instead of leaking. Only the PublicID and ResourceType of the
+// attachments are stored.
+type AttachmentDeletionRepository interface {
+	Add(ctx context.Context, attachment *entity.Attachment) error
+	List(ctx context.Context, limit int) ([]*entity.Attachment, error)
+	Remove(ctx context.Context, publicID string) error
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// AvatarDeletionRepository tracks Cloudinary avatars that could not be deleted,
+// so they can be retried instead of leaking
+type AvatarDeletionRepository interface {
+	Add(ctx context.Context, publicID string) error
+	List(ctx context.Context, limit int) ([]string, error)
+	Remove(ctx context.Context, publicID string) error
+}
@@ -10,6 +10,7 @@ import (
 type AvatarRepository interface {
 	Create(ctx context.Context, avatar *entity.Avatar) error
 	GetByUserID(ctx context.Context, userID string) (*entity.Avatar, error)
+	GetByUserIDs(ctx context.Context, userIDs []string) (map[string]*entity.Avatar, error)
 	Update(ctx context.Context, avatar *entity.Avatar) error
 	Delete(ctx context.Context, userID string) error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+)
+
+// ConversationRepository defines the interface for conversation data access
+type ConversationRepository interface {
+	Create(ctx context.Context, conversation *entity.Conversation) error
+	GetByID(ctx context.Context, id string) (*entity.Conversation, error)
+	ListForUser(ctx context.Context, userID string) ([]*entity.Conversation, error)
+	// FindDirectBetween returns the direct conversation between the two users, in either order,
+	// or ErrConversationNotFound if they have none
+	FindDirectBetween(ctx context.Context, userA, userB string) (*entity.Conversation, error)
+	// SetMuted stores whether the participant muted the conversation's notifications
+	SetMuted(ctx context.Context, conversationID, userID string, muted bool) error
+}
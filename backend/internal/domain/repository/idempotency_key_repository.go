@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyKeyRepository defines the interface for idempotency key data access
+type IdempotencyKeyRepository interface {
+	// Claim binds the user's key to messageID until expiresAt and returns the message ID the key is bound to.
+	// A key still held by an earlier request keeps its message ID, which is then returned instead of messageID.
+	Claim(ctx context.Context, userID, key, messageID string, expiresAt time.Time) (string, error)
+	// DeleteExpired removes expired keys and returns how many were deleted
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+)
+
+// LoginEventRepository defines the interface for login history data access
+type LoginEventRepository interface {
+	// Record stores the event and sets it as the user's last login
+	Record(ctx context.Context, event *entity.LoginEvent) error
+	// ListByUserID returns the user's most recent logins, newest first
+	ListByUserID(ctx context.Context, userID string, limit int) ([]*entity.LoginEvent, error)
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/entity"
+)
+
+// MessageRepository defines the interface for message data access
+type MessageRepository interface {
+	// Create stores the message with its attachments and mentions, and marks its conversation as updated at the message's time.
+	// It clears the sender's unread mark on the conversation.
+	Create(ctx context.Context, message *entity.Message) error
+	GetByID(ctx context.Context, id string) (*entity.Message, error)
+	// Update stores the message's body and state, replacing its mentions. The attachments of a deleted
+	// message are removed; deleting their Cloudinary assets is up to the caller.
+	Update(ctx context.Context, message *entity.Message) error
+	// DeleteSentBatch deletes up to limit of the messages senderID sent that are not deleted yet, oldest first,
+	// dropping their body, attachments and mentions like Update does for a deleted message. It returns how many
+	// messages were deleted and the attachments they carried; deleting their Cloudinary assets is up to the caller.
+	DeleteSentBatch(ctx context.Context, senderID string, limit int) (int, []*entity.Attachment, error)
+	// ListAttachmentsBySender returns the attachments of every message senderID sent
+	ListAttachmentsBySender(ctx context.Context, senderID string) ([]*entity.Attachment, error)
+	// ListByConversation returns up to limit messages older than the (before, beforeID) position, newest first.
+	// A zero before starts from the latest message.
+	ListByConversation(ctx context.Context, conversationID string, before time.Time, beforeID string, limit int) ([]*entity.Message, error)
+	// ListMedia returns up to limit attachments of the conversation's messages older than the (before, beforeID)
+	// position, newest first. A non-empty mediaType keeps only attachments of that entity.MediaType*;
+	// a zero before starts from the latest attachment. Attachments of deleted messages are never returned.
+	ListMedia(ctx context.Context, conversationID, mediaType string, before time.Time, beforeID string, limit int) ([]*entity.Attachment, error)
+	// Search returns up to limit messages of the conversation whose body contains query, case-insensitively,
+	// newest first. Deleted messages are never returned.
+	Search(ctx context.Context, conversationID, query string, limit int) ([]*entity.Message, error)
+	// SearchForUser returns up to limit messages whose body contains query, case-insensitively, across
+	// the conversations userID takes part in, newest first. Deleted messages are never returned.
+	SearchForUser(ctx context.Context, userID, query string, limit int) ([]*entity.Message, error)
+	// ToggleReaction adds the reaction, or removes it if the user already reacted with that emoji.
+	// It reports whether the reaction was added.
+	ToggleReaction(ctx context.Context, reaction *entity.MessageReaction) (bool, error)
+	// ListUnseenMentions returns up to limit messages mentioning the user, in conversations they take part in,
+	// that are past their read position and older than the (before, beforeID) position, newest first.
+	// A zero before starts from the latest mention. Deleted messages are never returned.
+	ListUnseenMentions(ctx context.Context, userID string, before time.Time, beforeID string, limit int) ([]*entity.Message, error)
+	// MarkRead stores the user's read position, ignoring positions older than the one already stored,
+	// and clears the user's unread mark on the conversation
+	MarkRead(ctx context.Context, read *entity.ConversationRead) error
+	// MarkUnread marks the conversation unread for the user, keeping their read position
+	MarkUnread(ctx context.Context, userID, conversationID string) error
+	// ReadPositions returns the user's read position and unread mark in each conversation in one query;
+	// conversations the user has neither read nor marked unread are omitted
+	ReadPositions(ctx context.Context, userID string, conversationIDs []string) (map[string]*entity.ConversationRead, error)
+	// UnreadCount counts the messages from other participants after the user's read position
+	UnreadCount(ctx context.Context, userID, conversationID string) (int64, error)
+	// UnreadCounts returns UnreadCount for each conversation in one query; conversations without unread messages are omitted
+	UnreadCounts(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error)
+}
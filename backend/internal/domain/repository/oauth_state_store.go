@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// OAuthStateStore defines the interface for pending OAuth authorization requests.
+// A state is valid until expiresAt and can be consumed at most once.
+type OAuthStateStore interface {
+	Save(ctx context.Context, state, provider, codeVerifier string, expiresAt time.Time) error
+	Consume(ctx context.Context, state string) (provider, codeVerifier string, err error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+)
+
+// RecentSearchRepository defines the interface for the queries users searched for recently
+type RecentSearchRepository interface {
+	// Record stores the search as the user's latest one, replacing an earlier search for the same query
+	// regardless of case, then drops the user's older searches beyond the newest keep
+	Record(ctx context.Context, search *entity.RecentSearch, keep int) error
+	// ListByUserID returns the user's most recent searches, newest first
+	ListByUserID(ctx context.Context, userID string, limit int) ([]*entity.RecentSearch, error)
+	// DeleteByUserID removes every search the user made
+	DeleteByUserID(ctx context.Context, userID string) error
+}
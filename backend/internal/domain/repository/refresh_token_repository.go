@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"backend/internal/domain/entity"
 )
@@ -12,6 +13,10 @@ type RefreshTokenRepository interface {
 	GetByToken(ctx context.Context, token string) (*entity.RefreshToken, error)
 	GetByUserID(ctx context.Context, userID string) ([]*entity.RefreshToken, error)
 	Revoke(ctx context.Context, token string) error
+	// RevokeActive revokes token only if it isn't revoked yet and reports whether it did
+	RevokeActive(ctx context.Context, token string) (bool, error)
+	UpdateLastUsed(ctx context.Context, token string, usedAt time.Time) error
 	RevokeAllByUserID(ctx context.Context, userID string) error
-	DeleteExpired(ctx context.Context) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	DeleteExpired(ctx context.Context) (int64, error)
 }
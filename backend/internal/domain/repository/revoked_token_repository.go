@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RevokedTokenRepository defines the interface for access token blacklist storage.
+// Implementations only need to remember a token ID until expiresAt.
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
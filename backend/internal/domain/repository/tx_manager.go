@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// TxManager runs several repository calls as one unit of work
+type TxManager interface {
+	// WithTx runs fn in a transaction, committing when it returns nil and rolling back otherwise.
+	// Repository calls made with the ctx passed to fn join the transaction; calling WithTx again
+	// inside fn reuses the outer transaction.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+)
+
+// UserOAuthIdentityRepository defines the interface for linked OAuth identity data access
+type UserOAuthIdentityRepository interface {
+	Create(ctx context.Context, identity *entity.UserOAuthIdentity) error
+	GetByProvider(ctx context.Context, provider, providerUserID string) (*entity.UserOAuthIdentity, error)
+	ListByUserID(ctx context.Context, userID string) ([]*entity.UserOAuthIdentity, error)
+	Delete(ctx context.Context, userID, provider string) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"backend/internal/domain/entity"
 )
@@ -11,11 +12,24 @@ type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	GetByID(ctx context.Context, id string) (*entity.User, error)
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
+	GetByUsername(ctx context.Context, username string) (*entity.User, error)
+	ListByUsernames(ctx context.Context, usernames []string) ([]*entity.User, error)
 	GetByOAuthID(ctx context.Context, provider, oauthID string) (*entity.User, error)
 	GetByVerificationToken(ctx context.Context, token string) (*entity.User, error)
 	GetByResetPasswordToken(ctx context.Context, token string) (*entity.User, error)
+	GetByEmailChangeToken(ctx context.Context, token string) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]*entity.User, error) 
+	List(ctx context.Context, limit, offset int) ([]*entity.User, error)
+	Count(ctx context.Context) (int64, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]*entity.User, int64, error)
+	// SearchContactable returns up to limit users other than excludeID whose name or username contains query,
+	// case-insensitively, ordered by name. Deactivated users and users pending deletion can't be contacted
+	// and are left out; emails are not searched, so they aren't revealed.
+	SearchContactable(ctx context.Context, query, excludeID string, limit int) ([]*entity.User, error)
+	ListPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]*entity.User, error)
+	// DeletePendingDeletion deletes the user only if their deletion is still requested before requestedBefore,
+	// reporting whether they were deleted
+	DeletePendingDeletion(ctx context.Context, id string, requestedBefore time.Time) (bool, error)
 }
 
@@ -3,56 +3,89 @@ package cloudinary
 import (
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+	"github.com/google/uuid"
 )
 
 // Service defines the interface for Cloudinary operations
 type Service interface {
 	UploadAvatar(ctx context.Context, file multipart.File, userID string) (*UploadResult, error)
 	DeleteAvatar(ctx context.Context, publicID string) error
+	ThumbnailURLs(publicID string) map[int]string
+	UploadAttachment(ctx context.Context, file io.Reader, conversationID string) (*UploadResult, error)
+	DeleteAttachment(ctx context.Context, publicID, resourceType string) error
 }
 
 // UploadResult contains the result of a Cloudinary upload
 type UploadResult struct {
-	PublicID  string
-	PublicURL string
-	SecureURL string
+	PublicID     string
+	PublicURL    string
+	SecureURL    string
+	ResourceType string // "image", "video" or "raw"
+	Bytes        int64
 }
 
 type service struct {
-	cld *cloudinary.Cloudinary
+	cld            *cloudinary.Cloudinary
+	thumbnailSizes []int
+	timeout        time.Duration
 }
 
-// NewService creates a new Cloudinary service
-func NewService(cloudName, apiKey, apiSecret string) (Service, error) {
+// NewService creates a new Cloudinary service.
+// thumbnailSizes are the square sizes, in pixels, that ThumbnailURLs returns.
+// Each upload or delete must complete within timeout; timeouts are reported as errors.ErrUpstreamTimeout.
+func NewService(cloudName, apiKey, apiSecret string, thumbnailSizes []int, timeout time.Duration) (Service, error) {
 	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Cloudinary: %w", err)
 	}
 
+	for _, size := range thumbnailSizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid thumbnail size %d", size)
+		}
+	}
+
 	return &service{
-		cld: cld,
+		cld:            cld,
+		thumbnailSizes: thumbnailSizes,
+		timeout:        timeout,
 	}, nil
 }
 
-// UploadAvatar uploads an avatar image to Cloudinary
+// UploadAvatar streams an avatar image to Cloudinary without buffering it.
+// Files over entity.MaxAvatarSize are rejected with errors.ErrAvatarTooLarge as soon as the limit is crossed.
+// Each upload gets its own public ID, so the previous avatar stays intact until the caller deletes it.
 func (s *service) UploadAvatar(ctx context.Context, file multipart.File, userID string) (*UploadResult, error) {
-	overwrite := true
+	overwrite := false
 	// Upload the file to Cloudinary
 	uploadParams := uploader.UploadParams{
 		Folder:         "avatars",
-		PublicID:       fmt.Sprintf("user_%s", userID),
+		PublicID:       fmt.Sprintf("user_%s_%s", userID, uuid.NewString()),
 		Overwrite:      &overwrite,
 		ResourceType:   "image",
 		Transformation: "c_fill,g_face,h_400,w_400", // Crop to 400x400 focusing on face
 	}
 
-	result, err := s.cld.Upload.Upload(ctx, file, uploadParams)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// Passed as a plain io.Reader, the SDK copies the file straight into the request body
+	reader := newSizeLimitedReader(file, entity.MaxAvatarSize, errors.ErrAvatarTooLarge)
+	result, err := s.cld.Upload.Upload(ctx, reader, uploadParams)
+	if reader.exceeded {
+		return nil, errors.ErrAvatarTooLarge
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload avatar: %w", err)
+		return nil, fmt.Errorf("failed to upload avatar: %w", errors.WrapTimeout(err))
 	}
 
 	return &UploadResult{
@@ -62,19 +95,124 @@ func (s *service) UploadAvatar(ctx context.Context, file multipart.File, userID
 	}, nil
 }
 
+// UploadAttachment streams a chat attachment of any supported type to the conversation's folder under chat/.
+// Files over entity.MaxAttachmentSize are rejected with errors.ErrAttachmentTooLarge as soon as the limit is crossed.
+func (s *service) UploadAttachment(ctx context.Context, file io.Reader, conversationID string) (*UploadResult, error) {
+	// Without a public ID Cloudinary assigns a unique one, so attachments never overwrite each other
+	uploadParams := uploader.UploadParams{
+		Folder:       fmt.Sprintf("chat/%s", conversationID),
+		ResourceType: "auto",
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	reader := newSizeLimitedReader(file, entity.MaxAttachmentSize, errors.ErrAttachmentTooLarge)
+	result, err := s.cld.Upload.Upload(ctx, reader, uploadParams)
+	if reader.exceeded {
+		return nil, errors.ErrAttachmentTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", errors.WrapTimeout(err))
+	}
+	if result.Error.Message != "" {
+		return nil, fmt.Errorf("failed to upload attachment: %s", result.Error.Message)
+	}
+
+	return &UploadResult{
+		PublicID:     result.PublicID,
+		PublicURL:    result.URL,
+		SecureURL:    result.SecureURL,
+		ResourceType: result.ResourceType,
+		Bytes:        int64(result.Bytes),
+	}, nil
+}
+
+// DeleteAttachment deletes a chat attachment from Cloudinary
+func (s *service) DeleteAttachment(ctx context.Context, publicID, resourceType string) error {
+	if publicID == "" {
+		return nil // Nothing to delete
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		ResourceType: resourceType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", errors.WrapTimeout(err))
+	}
+
+	return nil
+}
+
+// ThumbnailURLs returns the URL of the image cropped to each thumbnail size, keyed by size.
+// Cloudinary generates each size from the original on first request, so nothing is re-uploaded.
+func (s *service) ThumbnailURLs(publicID string) map[int]string {
+	if publicID == "" || len(s.thumbnailSizes) == 0 {
+		return nil
+	}
+
+	urls := make(map[int]string, len(s.thumbnailSizes))
+	for _, size := range s.thumbnailSizes {
+		image, err := s.cld.Image(publicID)
+		if err != nil {
+			continue
+		}
+		image.Transformation = fmt.Sprintf("c_fill,g_face,h_%d,w_%d", size, size)
+
+		url, err := image.String()
+		if err != nil || url == "" {
+			continue
+		}
+		urls[size] = url
+	}
+	return urls
+}
+
+// sizeLimitedReader fails the read that takes the total past limit, so an oversized
+// upload is aborted mid-stream whatever size the client claimed
+type sizeLimitedReader struct {
+	reader   io.Reader
+	limit    int64
+	err      error
+	read     int64
+	exceeded bool
+}
+
+// newSizeLimitedReader reads at most one byte past limit from r, failing with err once limit is crossed
+func newSizeLimitedReader(r io.Reader, limit int64, err error) *sizeLimitedReader {
+	return &sizeLimitedReader{reader: io.LimitReader(r, limit+1), limit: limit, err: err}
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		r.exceeded = true
+		return 0, r.err
+	}
+	return n, err
+}
+
 // DeleteAvatar deletes an avatar from Cloudinary
 func (s *service) DeleteAvatar(ctx context.Context, publicID string) error {
 	if publicID == "" {
 		return nil // Nothing to delete
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
 	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
 		PublicID:     publicID,
 		ResourceType: "image",
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to delete avatar: %w", err)
+		return fmt.Errorf("failed to delete avatar: %w", errors.WrapTimeout(err))
 	}
 
 	return nil
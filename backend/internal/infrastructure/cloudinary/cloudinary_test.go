@@ -0,0 +1,199 @@
+package cloudinary_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/infrastructure/cloudinary"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThumbnailURLs(t *testing.T) {
+	service, err := cloudinary.NewService("demo", "key", "secret", []int{64, 128}, time.Second)
+	require.NoError(t, err)
+
+	urls := service.ThumbnailURLs("avatars/user_123")
+
+	require.Len(t, urls, 2)
+	assert.True(t, strings.HasPrefix(urls[64], "https://res.cloudinary.com/demo/image/upload/c_fill,g_face,h_64,w_64/"), urls[64])
+	assert.True(t, strings.HasPrefix(urls[128], "https://res.cloudinary.com/demo/image/upload/c_fill,g_face,h_128,w_128/"), urls[128])
+	assert.Contains(t, urls[64], "/avatars/user_123")
+}
+
+func TestThumbnailURLs_ExternalAvatar(t *testing.T) {
+	service, err := cloudinary.NewService("demo", "key", "secret", []int{64}, time.Second)
+	require.NoError(t, err)
+
+	// Avatars hosted elsewhere have no public_id and cannot be transformed
+	assert.Nil(t, service.ThumbnailURLs(""))
+}
+
+func TestNewService_RejectsInvalidThumbnailSize(t *testing.T) {
+	_, err := cloudinary.NewService("demo", "key", "secret", []int{64, 0}, time.Second)
+
+	assert.ErrorContains(t, err, "invalid thumbnail size 0")
+}
+
+// countingFile is an in-memory multipart.File that records how many bytes were read from it
+type countingFile struct {
+	*bytes.Reader
+	read int64
+}
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	n, err := f.Reader.Read(p)
+	f.read += int64(n)
+	return n, err
+}
+
+func (f *countingFile) Close() error { return nil }
+
+// newUploadServer fakes the Cloudinary upload API, recording how many bytes each request body carried
+func newUploadServer(t *testing.T) (*httptest.Server, *int64) {
+	t.Helper()
+	var received int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := io.Copy(io.Discard, r.Body)
+		received += n
+		if err != nil {
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"public_id":  "avatars/user_123",
+			"url":        "http://res.cloudinary.com/demo/image/upload/avatars/user_123.png",
+			"secure_url": "https://res.cloudinary.com/demo/image/upload/avatars/user_123.png",
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestUploadAvatar_StreamsFile(t *testing.T) {
+	server, received := newUploadServer(t)
+	service, err := cloudinary.NewService("demo", "key", "secret", nil, time.Second)
+	require.NoError(t, err)
+	cloudinary.SetUploadPrefix(service, server.URL)
+
+	file := &countingFile{Reader: bytes.NewReader(bytes.Repeat([]byte("a"), 1024))}
+	result, err := service.UploadAvatar(context.Background(), file, "123")
+
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/user_123", result.PublicID)
+	assert.Equal(t, "https://res.cloudinary.com/demo/image/upload/avatars/user_123.png", result.SecureURL)
+	assert.Equal(t, int64(1024), file.read)
+	assert.Greater(t, *received, int64(1024))
+}
+
+func TestUploadAvatar_UsesUniquePublicIDs(t *testing.T) {
+	var publicIDs, overwrites []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publicIDs = append(publicIDs, r.FormValue("public_id"))
+		overwrites = append(overwrites, r.FormValue("overwrite"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"public_id": "avatars/" + r.FormValue("public_id")})
+	}))
+	t.Cleanup(server.Close)
+	service, err := cloudinary.NewService("demo", "key", "secret", nil, time.Second)
+	require.NoError(t, err)
+	cloudinary.SetUploadPrefix(service, server.URL)
+
+	for range 2 {
+		_, err := service.UploadAvatar(context.Background(), &countingFile{Reader: bytes.NewReader(make([]byte, 1024))}, "123")
+		require.NoError(t, err)
+	}
+
+	// A replacement must not overwrite the avatar the user still has until the new one is saved
+	require.Len(t, publicIDs, 2)
+	assert.True(t, strings.HasPrefix(publicIDs[0], "user_123_"))
+	assert.NotEqual(t, publicIDs[0], publicIDs[1])
+	assert.NotContains(t, overwrites, "true")
+}
+
+func TestUploadAvatar_RejectsOversizedFileMidStream(t *testing.T) {
+	server, _ := newUploadServer(t)
+	service, err := cloudinary.NewService("demo", "key", "secret", nil, time.Second)
+	require.NoError(t, err)
+	cloudinary.SetUploadPrefix(service, server.URL)
+
+	content := make([]byte, 3*entity.MaxAvatarSize)
+	file := &countingFile{Reader: bytes.NewReader(content)}
+	result, err := service.UploadAvatar(context.Background(), file, "123")
+
+	assert.ErrorIs(t, err, errors.ErrAvatarTooLarge)
+	assert.Nil(t, result)
+	// Reading stops one byte past the limit instead of consuming the whole file
+	assert.LessOrEqual(t, file.read, int64(entity.MaxAvatarSize+1))
+}
+
+func TestUploadAttachment_UsesChatFolder(t *testing.T) {
+	var folder, resourcePath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resourcePath = r.URL.Path
+		folder = r.FormValue("folder")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"public_id":     "chat/conv-1/x7k2",
+			"secure_url":    "https://res.cloudinary.com/demo/raw/upload/chat/conv-1/x7k2",
+			"resource_type": "raw",
+			"bytes":         1024,
+		})
+	}))
+	t.Cleanup(server.Close)
+	service, err := cloudinary.NewService("demo", "key", "secret", nil, time.Second)
+	require.NoError(t, err)
+	cloudinary.SetUploadPrefix(service, server.URL)
+
+	result, err := service.UploadAttachment(context.Background(), bytes.NewReader(make([]byte, 1024)), "conv-1")
+
+	require.NoError(t, err)
+	// Attachments live apart from avatars and let Cloudinary detect the resource type
+	assert.Equal(t, "chat/conv-1", folder)
+	assert.Contains(t, resourcePath, "/auto/upload")
+	assert.Equal(t, "chat/conv-1/x7k2", result.PublicID)
+	assert.Equal(t, "raw", result.ResourceType)
+	assert.Equal(t, int64(1024), result.Bytes)
+}
+
+func TestUploadAttachment_RejectsOversizedFileMidStream(t *testing.T) {
+	server, _ := newUploadServer(t)
+	service, err := cloudinary.NewService("demo", "key", "secret", nil, time.Second)
+	require.NoError(t, err)
+	cloudinary.SetUploadPrefix(service, server.URL)
+
+	file := &countingFile{Reader: bytes.NewReader(make([]byte, 2*entity.MaxAttachmentSize))}
+	result, err := service.UploadAttachment(context.Background(), file, "conv-1")
+
+	assert.ErrorIs(t, err, errors.ErrAttachmentTooLarge)
+	assert.Nil(t, result)
+	assert.LessOrEqual(t, file.read, int64(entity.MaxAttachmentSize+1))
+}
+
+func TestUploadAvatar_TimesOutOnSlowServer(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		// Hang until the test ends, well past the client's timeout
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	service, err := cloudinary.NewService("demo", "key", "secret", nil, 100*time.Millisecond)
+	require.NoError(t, err)
+	cloudinary.SetUploadPrefix(service, server.URL)
+
+	start := time.Now()
+	result, err := service.UploadAvatar(context.Background(), &countingFile{Reader: bytes.NewReader(make([]byte, 1024))}, "123")
+
+	assert.ErrorIs(t, err, errors.ErrUpstreamTimeout)
+	assert.Nil(t, result)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,6 @@
+package cloudinary
+
+// SetUploadPrefix points the service's uploads at prefix instead of the Cloudinary API
+func SetUploadPrefix(s Service, prefix string) {
+	s.(*service).cld.Upload.Config.API.UploadPrefix = prefix
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -16,12 +17,42 @@ type Config struct {
 	OAuth      OAuthConfig
 	Cloudinary CloudinaryConfig
 	Email      EmailConfig
+	Client     ClientConfig
+	Account    AccountConfig
+	Profile    ProfileConfig
+	Password   PasswordConfig
+	Message    MessageConfig
+	Search     SearchConfig
+	Cleanup    CleanupConfig
+	CORS       CORSConfig
+	RateLimit  RateLimitConfig `mapstructure:"rate_limit"`
+	Metrics    MetricsConfig
+	Webhook    WebhookConfig
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Mode string
+	Port         string
+	Mode         string
+	LogSkipPaths []string `mapstructure:"log_skip_paths"` // request paths left out of the request log, e.g. health probes
+	// TrustedProxies are the IPs or CIDRs of reverse proxies whose X-Forwarded-For header is believed when
+	// resolving the client IP. Empty trusts none, so clients cannot pick their own IP for rate limiting.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// TLSCertFile and TLSKeyFile serve HTTPS directly when both are set; otherwise plain HTTP is served,
+	// e.g. behind a TLS-terminating proxy
+	TLSCertFile string `mapstructure:"tls_cert_file"`
+	TLSKeyFile  string `mapstructure:"tls_key_file"`
+	// ProblemDetails sends every error as RFC 7807 application/problem+json;
+	// otherwise only clients that ask for it in their Accept header get that format
+	ProblemDetails bool `mapstructure:"problem_details"`
+	// ShutdownTimeoutSeconds bounds the whole graceful shutdown: draining requests, background jobs,
+	// event handlers, webhook deliveries and the email queue
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
+}
+
+// TLSEnabled reports whether the server terminates TLS itself
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
 }
 
 // DatabaseConfig holds database configuration
@@ -36,9 +67,25 @@ type DatabaseConfig struct {
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	Secret                 string `mapstructure:"secret"`
-	AccessTokenExpireMinutes int    `mapstructure:"access_token_expire_minutes"`
-	RefreshTokenExpireDays   int    `mapstructure:"refresh_token_expire_days"`
+	Algorithm                      string         `mapstructure:"algorithm"` // HS256 or RS256
+	Secret                         string         `mapstructure:"secret"`
+	Keys                           []JWTKeyConfig `mapstructure:"keys"`
+	Issuer                         string         `mapstructure:"issuer"`
+	Audience                       string         `mapstructure:"audience"`
+	AllowLegacyTokens              bool           `mapstructure:"allow_legacy_tokens"` // accept tokens without iss/aud
+	AccessTokenExpireMinutes       int            `mapstructure:"access_token_expire_minutes"`
+	RefreshTokenExpireDays         int            `mapstructure:"refresh_token_expire_days"`
+	RememberMeExpireDays           int            `mapstructure:"remember_me_expire_days"` // refresh expiry for "remember me" logins
+	RefreshTokenIdleTimeoutMinutes int            `mapstructure:"refresh_token_idle_timeout_minutes"`
+}
+
+// JWTKeyConfig holds an RS256 key pair identified by kid.
+// During rotation, add the new key and set verify_only on the old one.
+type JWTKeyConfig struct {
+	KID            string `mapstructure:"kid"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+	VerifyOnly     bool   `mapstructure:"verify_only"`
 }
 
 // OAuthConfig holds OAuth configuration
@@ -46,6 +93,11 @@ type OAuthConfig struct {
 	GoogleClientID     string `mapstructure:"google_client_id"`
 	GoogleClientSecret string `mapstructure:"google_client_secret"`
 	GoogleRedirectURL  string `mapstructure:"google_redirect_url"`
+	GitHubClientID     string `mapstructure:"github_client_id"`
+	GitHubClientSecret string `mapstructure:"github_client_secret"`
+	GitHubRedirectURL  string `mapstructure:"github_redirect_url"`
+	// TimeoutSeconds bounds each call to a provider: exchanging the code, fetching the profile and its keys
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
 }
 
 // CloudinaryConfig holds Cloudinary configuration
@@ -53,19 +105,120 @@ type CloudinaryConfig struct {
 	CloudName string `mapstructure:"cloud_name"`
 	APIKey    string `mapstructure:"api_key"`
 	APISecret string `mapstructure:"api_secret"`
+	// ThumbnailSizes are the square avatar sizes, in pixels, returned alongside each avatar
+	ThumbnailSizes []int `mapstructure:"thumbnail_sizes"`
+	// TimeoutSeconds bounds each upload or delete
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
 }
 
 // EmailConfig holds email configuration
 type EmailConfig struct {
-	SMTPHost     string `mapstructure:"smtp_host"`
-	SMTPPort     string `mapstructure:"smtp_port"`
-	SMTPUsername string `mapstructure:"smtp_username"`
-	SMTPPassword string `mapstructure:"smtp_password"`
-	FromEmail    string `mapstructure:"from_email"`
-	FromName     string `mapstructure:"from_name"`
-	FrontendURL  string `mapstructure:"frontend_url"`
+	Provider       string `mapstructure:"provider"` // smtp, sendgrid or mock
+	SMTPHost       string `mapstructure:"smtp_host"`
+	SMTPPort       string `mapstructure:"smtp_port"`
+	SMTPUsername   string `mapstructure:"smtp_username"`
+	SMTPPassword   string `mapstructure:"smtp_password"`
+	SMTPTLSMode    string `mapstructure:"smtp_tls_mode"` // none, starttls or tls
+	SendGridAPIKey string `mapstructure:"sendgrid_api_key"`
+	FromEmail      string `mapstructure:"from_email"`
+	FromName       string `mapstructure:"from_name"`
+	FrontendURL    string `mapstructure:"frontend_url"`
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"` // bounds sending one email, whatever the provider
+
+	// Registration and welcome emails are sent through a background queue that retries failed sends
+	QueueSize           int `mapstructure:"queue_size"`
+	MaxAttempts         int `mapstructure:"max_attempts"`
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"` // wait after the first failure, doubled after each further one
+	// The queue sends at most SendRatePerSecond emails per second to the provider, after an initial burst
+	// of SendBurst; excess emails wait in the queue. A zero rate or burst disables the limit.
+	SendRatePerSecond float64 `mapstructure:"send_rate_per_second"`
+	SendBurst         int     `mapstructure:"send_burst"`
+
+	// Verification resends and password reset emails allowed per address within the window; 0 disables the limit
+	RateLimitMaxSends      int `mapstructure:"rate_limit_max_sends"`
+	RateLimitWindowMinutes int `mapstructure:"rate_limit_window_minutes"`
+}
+
+// ClientConfig holds client compatibility configuration
+type ClientConfig struct {
+	MinVersions map[string]string `mapstructure:"min_versions"` // platform -> minimum supported version
 }
 
+// AccountConfig holds account lifecycle configuration
+type AccountConfig struct {
+	DeletionGracePeriodDays int `mapstructure:"deletion_grace_period_days"` // days a deleted account can be reactivated
+}
+
+// ProfileConfig holds profile update limits. A zero cooldown disables the check; admins are exempt.
+// PhoneDefaultRegion is the ISO 3166-1 region assumed for phone numbers given without a +country code;
+// when empty such numbers are rejected.
+type ProfileConfig struct {
+	NameChangeCooldownMinutes   int    `mapstructure:"name_change_cooldown_minutes"`
+	AvatarChangeCooldownMinutes int    `mapstructure:"avatar_change_cooldown_minutes"`
+	PhoneDefaultRegion          string `mapstructure:"phone_default_region"`
+}
+
+// PasswordConfig holds the password strength policy applied to new passwords and the cost they are hashed with
+type PasswordConfig struct {
+	MinLength     int  `mapstructure:"min_length"`
+	RequireUpper  bool `mapstructure:"require_upper"`
+	RequireLower  bool `mapstructure:"require_lower"`
+	RequireDigit  bool `mapstructure:"require_digit"`
+	RequireSymbol bool `mapstructure:"require_symbol"`
+	BcryptCost    int  `mapstructure:"bcrypt_cost"` // between 4 and 31, each step doubles hashing time
+}
+
+// MessageConfig holds chat message limits
+type MessageConfig struct {
+	MaxAttachments int `mapstructure:"max_attachments"` // files a single message can carry
+}
+
+// SearchConfig holds search suggestion settings
+type SearchConfig struct {
+	RecentLimit int `mapstructure:"recent_limit"` // queries kept per user as recent searches; 0 turns them off
+}
+
+// CleanupConfig holds background cleanup configuration
+type CleanupConfig struct {
+	IntervalMinutes int `mapstructure:"interval_minutes"`
+}
+
+// CORSConfig holds the browser origins allowed to call the API.
+// With no allowed origins, any origin is allowed in debug mode and none in release mode.
+type CORSConfig struct {
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowedMethods   []string `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+}
+
+// RateLimitConfig holds the request rate limits. Each limit allows a burst of requests, refilled at
+// a steady rate; a zero rate or burst disables that limit.
+type RateLimitConfig struct {
+	RequestsPerSecond     float64 `mapstructure:"requests_per_second"` // per client IP, across the API
+	Burst                 int     `mapstructure:"burst"`
+	AuthRequestsPerMinute float64 `mapstructure:"auth_requests_per_minute"` // per client IP, on the authentication routes
+	AuthBurst             int     `mapstructure:"auth_burst"`
+	UserRequestsPerSecond float64 `mapstructure:"user_requests_per_second"` // per authenticated user
+	UserBurst             int     `mapstructure:"user_burst"`
+}
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"` // serve /metrics; disable where it must not be exposed
+}
+
+// WebhookConfig holds the external endpoints notified of account events; with no URLs webhooks are disabled
+type WebhookConfig struct {
+	URLs           []string `mapstructure:"urls"`
+	Secret         string   `mapstructure:"secret"`          // key of the HMAC-SHA256 signature sent with each payload
+	TimeoutSeconds int      `mapstructure:"timeout_seconds"` // bounds one delivery attempt
+
+	// Deliveries are queued in memory and retried like emails
+	QueueSize           int `mapstructure:"queue_size"`
+	MaxAttempts         int `mapstructure:"max_attempts"`
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"` // wait after the first failure, doubled after each further one
+}
 
 // Load reads configuration from file and environment variables
 func Load() (*Config, error) {
@@ -88,6 +241,9 @@ func Load() (*Config, error) {
 	viper.BindEnv("oauth.google_client_id", "APP_GOOGLE_CLIENT_ID")
 	viper.BindEnv("oauth.google_client_secret", "APP_GOOGLE_CLIENT_SECRET")
 	viper.BindEnv("oauth.google_redirect_url", "APP_GOOGLE_REDIRECT_URL")
+	viper.BindEnv("oauth.github_client_id", "APP_GITHUB_CLIENT_ID")
+	viper.BindEnv("oauth.github_client_secret", "APP_GITHUB_CLIENT_SECRET")
+	viper.BindEnv("oauth.github_redirect_url", "APP_GITHUB_REDIRECT_URL")
 
 	// Bind specific environment variables for Cloudinary
 	viper.BindEnv("cloudinary.cloud_name", "APP_CLOUDINARY_CLOUD_NAME")
@@ -99,15 +255,76 @@ func Load() (*Config, error) {
 	viper.BindEnv("email.smtp_port", "APP_EMAIL_SMTP_PORT")
 	viper.BindEnv("email.smtp_username", "APP_EMAIL_SMTP_USERNAME")
 	viper.BindEnv("email.smtp_password", "APP_EMAIL_SMTP_PASSWORD")
+	viper.BindEnv("email.smtp_tls_mode", "APP_EMAIL_SMTP_TLS_MODE")
+	viper.BindEnv("email.provider", "APP_EMAIL_PROVIDER")
+	viper.BindEnv("email.sendgrid_api_key", "APP_EMAIL_SENDGRID_API_KEY")
 	viper.BindEnv("email.from_email", "APP_EMAIL_FROM_EMAIL")
 	viper.BindEnv("email.from_name", "APP_EMAIL_FROM_NAME")
 	viper.BindEnv("email.frontend_url", "APP_EMAIL_FRONTEND_URL")
 
+	// Bind specific environment variables for webhooks
+	viper.BindEnv("webhook.urls", "APP_WEBHOOK_URLS")
+	viper.BindEnv("webhook.secret", "APP_WEBHOOK_SECRET")
+
 	// Set defaults
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.log_skip_paths", []string{"/health", "/health/ready", "/metrics"})
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("server.problem_details", false)
+	viper.SetDefault("server.shutdown_timeout_seconds", 15)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("jwt.issuer", "tkhanchat")
+	viper.SetDefault("jwt.audience", "tkhanchat-api")
+	viper.SetDefault("jwt.allow_legacy_tokens", false)
 	viper.SetDefault("jwt.access_token_expire_minutes", 15)
 	viper.SetDefault("jwt.refresh_token_expire_days", 7)
+	viper.SetDefault("jwt.remember_me_expire_days", 30)
+	viper.SetDefault("jwt.refresh_token_idle_timeout_minutes", 0)
+	viper.SetDefault("oauth.timeout_seconds", 10)
+	viper.SetDefault("cloudinary.thumbnail_sizes", []int{64, 128, 256})
+	viper.SetDefault("cloudinary.timeout_seconds", 30)
+	viper.SetDefault("email.timeout_seconds", 10)
+	viper.SetDefault("email.smtp_tls_mode", "starttls")
+	viper.SetDefault("email.queue_size", 100)
+	viper.SetDefault("email.max_attempts", 5)
+	viper.SetDefault("email.retry_backoff_seconds", 2)
+	viper.SetDefault("email.send_rate_per_second", 10)
+	viper.SetDefault("email.send_burst", 20)
+	viper.SetDefault("email.rate_limit_max_sends", 3)
+	viper.SetDefault("email.rate_limit_window_minutes", 15)
+	viper.SetDefault("account.deletion_grace_period_days", 30)
+	viper.SetDefault("cleanup.interval_minutes", 60)
+	viper.SetDefault("profile.name_change_cooldown_minutes", 0)
+	viper.SetDefault("profile.avatar_change_cooldown_minutes", 0)
+	viper.SetDefault("profile.phone_default_region", "")
+	viper.SetDefault("password.min_length", 8)
+	viper.SetDefault("password.require_upper", true)
+	viper.SetDefault("password.require_lower", true)
+	viper.SetDefault("password.require_digit", true)
+	viper.SetDefault("password.require_symbol", false)
+	viper.SetDefault("password.bcrypt_cost", 10)
+	viper.SetDefault("message.max_attachments", 10)
+	viper.SetDefault("search.recent_limit", 10)
+	viper.SetDefault("cors.allowed_origins", []string{})
+	viper.SetDefault("cors.allowed_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"})
+	viper.SetDefault("cors.allowed_headers", []string{
+		"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Origin",
+		"Cache-Control", "X-Requested-With", "X-Client-Version", "X-Client-Platform", "Idempotency-Key",
+	})
+	viper.SetDefault("cors.allow_credentials", true)
+	viper.SetDefault("rate_limit.requests_per_second", 20)
+	viper.SetDefault("rate_limit.burst", 40)
+	viper.SetDefault("rate_limit.auth_requests_per_minute", 10)
+	viper.SetDefault("rate_limit.auth_burst", 5)
+	viper.SetDefault("rate_limit.user_requests_per_second", 10)
+	viper.SetDefault("rate_limit.user_burst", 20)
+	viper.SetDefault("webhook.urls", []string{})
+	viper.SetDefault("webhook.timeout_seconds", 10)
+	viper.SetDefault("webhook.queue_size", 100)
+	viper.SetDefault("webhook.max_attempts", 5)
+	viper.SetDefault("webhook.retry_backoff_seconds", 2)
 
 	if err := viper.ReadInConfig(); err != nil {
 		// Config file not found, use defaults and env vars
@@ -121,3 +338,21 @@ func Load() (*Config, error) {
 
 	return &config, nil
 }
+
+// Watch reloads the configuration whenever the config file changes and
+// passes the new values to onChange
+func Watch(onChange func(*Config)) {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		var config Config
+		if err := viper.Unmarshal(&config); err != nil {
+			fmt.Printf("Failed to reload config: %v\n", err)
+			return
+		}
+		if err := config.Validate(); err != nil {
+			fmt.Printf("Ignoring config change: %v\n", err)
+			return
+		}
+		onChange(&config)
+	})
+	viper.WatchConfig()
+}
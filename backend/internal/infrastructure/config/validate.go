@@ -0,0 +1,204 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"backend/pkg/phone"
+)
+
+const (
+	// DefaultJWTSecret is the placeholder secret shipped in config/config.yaml
+	DefaultJWTSecret = "your-secret-key-change-this-in-production"
+	// MinJWTSecretLength is the shortest HS256 secret accepted in release mode, in bytes
+	MinJWTSecretLength = 32
+)
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks that the settings the application can't run without are present and valid,
+// so a misconfiguration fails at startup instead of on first use. Release mode is stricter
+// about secrets. The returned error is a *ValidationError listing all problems at once.
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	release := c.Server.Mode == "release"
+
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		addf("server.mode must be debug, release or test, got %q", c.Server.Mode)
+	}
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		addf("server.port must be a port number, got %q", c.Server.Port)
+	}
+
+	if c.Server.ShutdownTimeoutSeconds <= 0 {
+		addf("server.shutdown_timeout_seconds must be positive")
+	}
+
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		addf("server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	if c.Server.TLSEnabled() {
+		for _, file := range []string{c.Server.TLSCertFile, c.Server.TLSKeyFile} {
+			if _, err := os.Stat(file); err != nil {
+				addf("server TLS file %q is not readable: %v", file, err)
+			}
+		}
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				addf("server.trusted_proxies entry %q is not an IP or CIDR", proxy)
+			}
+		}
+	}
+
+	for _, field := range []struct{ name, value string }{
+		{"database.host", c.Database.Host},
+		{"database.port", c.Database.Port},
+		{"database.user", c.Database.User},
+		{"database.dbname", c.Database.DBName},
+	} {
+		if field.value == "" {
+			addf("%s is required", field.name)
+		}
+	}
+	if release && c.Database.Password == "" {
+		addf("database.password is required in release mode")
+	}
+
+	switch c.JWT.Algorithm {
+	case "", "HS256":
+		if c.JWT.Secret == "" {
+			addf("jwt.secret is required with HS256")
+		}
+	case "RS256":
+		if len(c.JWT.Keys) == 0 {
+			addf("jwt.keys is required with RS256")
+		}
+		signing := 0
+		for i, key := range c.JWT.Keys {
+			if key.KID == "" {
+				addf("jwt.keys[%d].kid is required", i)
+			}
+			if key.VerifyOnly {
+				if key.PublicKeyFile == "" && key.PrivateKeyFile == "" {
+					addf("jwt.keys[%d] needs a public_key_file", i)
+				}
+				continue
+			}
+			signing++
+			if key.PrivateKeyFile == "" {
+				addf("jwt.keys[%d].private_key_file is required unless the key is verify_only", i)
+			}
+		}
+		if len(c.JWT.Keys) > 0 && signing != 1 {
+			addf("jwt.keys must have exactly one key that is not verify_only, got %d", signing)
+		}
+	default:
+		addf("jwt.algorithm must be HS256 or RS256, got %q", c.JWT.Algorithm)
+	}
+	// With RS256 the secret still validates older HS256 tokens, so it must be strong whenever it is set
+	if release && c.JWT.Secret != "" {
+		if c.JWT.Secret == DefaultJWTSecret {
+			addf("jwt.secret must be changed from the default in release mode")
+		} else if len(c.JWT.Secret) < MinJWTSecretLength {
+			addf("jwt.secret must be at least %d bytes in release mode", MinJWTSecretLength)
+		}
+	}
+	if c.JWT.AccessTokenExpireMinutes <= 0 {
+		addf("jwt.access_token_expire_minutes must be positive")
+	}
+	if c.JWT.RefreshTokenExpireDays <= 0 {
+		addf("jwt.refresh_token_expire_days must be positive")
+	}
+
+	// An explicitly chosen provider must be usable rather than quietly falling back to the mock
+	switch c.Email.Provider {
+	case "smtp":
+		if c.Email.SMTPHost == "" || c.Email.SMTPUsername == "" || c.Email.SMTPPassword == "" {
+			addf("email.smtp_host, email.smtp_username and email.smtp_password are required with the smtp provider")
+		}
+	case "sendgrid":
+		if c.Email.SendGridAPIKey == "" {
+			addf("email.sendgrid_api_key is required with the sendgrid provider")
+		}
+	case "", "mock":
+	default:
+		addf("email.provider must be smtp, sendgrid or mock, got %q", c.Email.Provider)
+	}
+	switch c.Email.SMTPTLSMode {
+	case "none", "starttls", "tls":
+	default:
+		addf("email.smtp_tls_mode must be none, starttls or tls, got %q", c.Email.SMTPTLSMode)
+	}
+
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"email.timeout_seconds", c.Email.TimeoutSeconds},
+		{"email.queue_size", c.Email.QueueSize},
+		{"email.max_attempts", c.Email.MaxAttempts},
+		{"email.retry_backoff_seconds", c.Email.RetryBackoffSeconds},
+		{"cleanup.interval_minutes", c.Cleanup.IntervalMinutes},
+		{"message.max_attachments", c.Message.MaxAttachments},
+		{"cloudinary.timeout_seconds", c.Cloudinary.TimeoutSeconds},
+		{"oauth.timeout_seconds", c.OAuth.TimeoutSeconds},
+	} {
+		if field.value <= 0 {
+			addf("%s must be positive", field.name)
+		}
+	}
+
+	if c.RateLimit.RequestsPerSecond < 0 || c.RateLimit.Burst < 0 ||
+		c.RateLimit.AuthRequestsPerMinute < 0 || c.RateLimit.AuthBurst < 0 ||
+		c.RateLimit.UserRequestsPerSecond < 0 || c.RateLimit.UserBurst < 0 {
+		addf("rate_limit values must not be negative")
+	}
+	if c.Email.SendRatePerSecond < 0 || c.Email.SendBurst < 0 {
+		addf("email.send_rate_per_second and email.send_burst must not be negative")
+	}
+	if c.Search.RecentLimit < 0 {
+		addf("search.recent_limit must not be negative")
+	}
+
+	if len(c.Webhook.URLs) > 0 {
+		if c.Webhook.Secret == "" {
+			addf("webhook.secret is required when webhook.urls is set")
+		}
+		for _, endpoint := range c.Webhook.URLs {
+			if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				addf("webhook.urls must be http or https URLs, got %q", endpoint)
+			}
+		}
+		if c.Webhook.TimeoutSeconds <= 0 || c.Webhook.QueueSize <= 0 || c.Webhook.MaxAttempts <= 0 {
+			addf("webhook.timeout_seconds, webhook.queue_size and webhook.max_attempts must be positive")
+		}
+	}
+
+	if c.Profile.PhoneDefaultRegion != "" && !phone.IsRegion(c.Profile.PhoneDefaultRegion) {
+		addf("profile.phone_default_region must be a supported region code such as US, got %q", c.Profile.PhoneDefaultRegion)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
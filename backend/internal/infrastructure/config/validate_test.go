@@ -0,0 +1,190 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend/internal/infrastructure/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a debug-mode configuration that passes validation
+func validConfig() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{Port: "8080", Mode: "debug", ShutdownTimeoutSeconds: 15},
+		Database: config.DatabaseConfig{
+			Host: "localhost", Port: "5432", User: "postgres", Password: "postgres", DBName: "tkhanchat",
+		},
+		JWT: config.JWTConfig{
+			Algorithm:                "HS256",
+			Secret:                   config.DefaultJWTSecret,
+			AccessTokenExpireMinutes: 15,
+			RefreshTokenExpireDays:   7,
+		},
+		OAuth:      config.OAuthConfig{TimeoutSeconds: 10},
+		Cloudinary: config.CloudinaryConfig{TimeoutSeconds: 30},
+		Email: config.EmailConfig{
+			SMTPTLSMode: "starttls", TimeoutSeconds: 10, QueueSize: 100, MaxAttempts: 5, RetryBackoffSeconds: 2,
+		},
+		Message: config.MessageConfig{MaxAttachments: 10},
+		Cleanup: config.CleanupConfig{IntervalMinutes: 60},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	strongSecret := strings.Repeat("s", config.MinJWTSecretLength)
+
+	tests := []struct {
+		name    string
+		modify  func(c *config.Config)
+		problem string // expected in the error; empty for a valid configuration
+	}{
+		{"valid debug config", func(c *config.Config) {}, ""},
+		{"default secret allowed in debug", func(c *config.Config) { c.JWT.Secret = config.DefaultJWTSecret }, ""},
+		{"valid release config", func(c *config.Config) {
+			c.Server.Mode = "release"
+			c.JWT.Secret = strongSecret
+		}, ""},
+		{"unknown mode", func(c *config.Config) { c.Server.Mode = "prod" }, "server.mode"},
+		{"invalid port", func(c *config.Config) { c.Server.Port = "http" }, "server.port"},
+		{"port out of range", func(c *config.Config) { c.Server.Port = "70000" }, "server.port"},
+		{"trusted proxy IP and CIDR", func(c *config.Config) { c.Server.TrustedProxies = []string{"10.0.0.1", "172.16.0.0/12"} }, ""},
+		{"invalid trusted proxy", func(c *config.Config) { c.Server.TrustedProxies = []string{"proxy.internal"} }, "server.trusted_proxies"},
+		{"no shutdown timeout", func(c *config.Config) { c.Server.ShutdownTimeoutSeconds = 0 }, "server.shutdown_timeout_seconds"},
+		{"no email timeout", func(c *config.Config) { c.Email.TimeoutSeconds = 0 }, "email.timeout_seconds"},
+		{"no email queue", func(c *config.Config) { c.Email.QueueSize = 0 }, "email.queue_size"},
+		{"no email attempts", func(c *config.Config) { c.Email.MaxAttempts = 0 }, "email.max_attempts"},
+		{"negative email retry backoff", func(c *config.Config) { c.Email.RetryBackoffSeconds = -1 }, "email.retry_backoff_seconds"},
+		{"no cleanup interval", func(c *config.Config) { c.Cleanup.IntervalMinutes = 0 }, "cleanup.interval_minutes"},
+		{"no attachments per message", func(c *config.Config) { c.Message.MaxAttachments = 0 }, "message.max_attachments"},
+		{"recent searches off", func(c *config.Config) { c.Search.RecentLimit = 0 }, ""},
+		{"negative recent searches", func(c *config.Config) { c.Search.RecentLimit = -1 }, "search.recent_limit"},
+		{"no cloudinary timeout", func(c *config.Config) { c.Cloudinary.TimeoutSeconds = 0 }, "cloudinary.timeout_seconds"},
+		{"negative oauth timeout", func(c *config.Config) { c.OAuth.TimeoutSeconds = -1 }, "oauth.timeout_seconds"},
+		{"webhooks disabled without settings", func(c *config.Config) { c.Webhook = config.WebhookConfig{} }, ""},
+		{"valid webhooks", func(c *config.Config) {
+			c.Webhook = config.WebhookConfig{
+				URLs: []string{"https://hooks.example.com/tkhan"}, Secret: "signing-secret",
+				TimeoutSeconds: 10, QueueSize: 100, MaxAttempts: 5,
+			}
+		}, ""},
+		{"webhooks without secret", func(c *config.Config) {
+			c.Webhook = config.WebhookConfig{URLs: []string{"https://hooks.example.com"}, TimeoutSeconds: 10, QueueSize: 100, MaxAttempts: 5}
+		}, "webhook.secret is required"},
+		{"webhook URL without scheme", func(c *config.Config) {
+			c.Webhook = config.WebhookConfig{URLs: []string{"hooks.example.com"}, Secret: "s", TimeoutSeconds: 10, QueueSize: 100, MaxAttempts: 5}
+		}, "webhook.urls must be http or https URLs"},
+		{"webhooks without timeout", func(c *config.Config) {
+			c.Webhook = config.WebhookConfig{URLs: []string{"https://hooks.example.com"}, Secret: "s", QueueSize: 100, MaxAttempts: 5}
+		}, "webhook.timeout_seconds"},
+		{"missing database host", func(c *config.Config) { c.Database.Host = "" }, "database.host is required"},
+		{"missing database user", func(c *config.Config) { c.Database.User = "" }, "database.user is required"},
+		{"missing database name", func(c *config.Config) { c.Database.DBName = "" }, "database.dbname is required"},
+		{"empty database password allowed in debug", func(c *config.Config) { c.Database.Password = "" }, ""},
+		{"empty database password in release", func(c *config.Config) {
+			c.Server.Mode = "release"
+			c.JWT.Secret = strongSecret
+			c.Database.Password = ""
+		}, "database.password is required in release mode"},
+		{"missing secret", func(c *config.Config) { c.JWT.Secret = "" }, "jwt.secret is required"},
+		{"default secret in release", func(c *config.Config) { c.Server.Mode = "release" }, "changed from the default"},
+		{"short secret in release", func(c *config.Config) {
+			c.Server.Mode = "release"
+			c.JWT.Secret = "short"
+		}, "at least 32 bytes"},
+		{"unknown algorithm", func(c *config.Config) { c.JWT.Algorithm = "none" }, "jwt.algorithm"},
+		{"RS256 without keys", func(c *config.Config) { c.JWT.Algorithm = "RS256" }, "jwt.keys is required"},
+		{"RS256 with a signing key", func(c *config.Config) {
+			c.JWT.Algorithm = "RS256"
+			c.JWT.Keys = []config.JWTKeyConfig{
+				{KID: "new", PrivateKeyFile: "new.pem"},
+				{KID: "old", PublicKeyFile: "old.pub", VerifyOnly: true},
+			}
+		}, ""},
+		{"RS256 signing key without private key", func(c *config.Config) {
+			c.JWT.Algorithm = "RS256"
+			c.JWT.Keys = []config.JWTKeyConfig{{KID: "new", PublicKeyFile: "new.pub"}}
+		}, "jwt.keys[0].private_key_file"},
+		{"RS256 with two signing keys", func(c *config.Config) {
+			c.JWT.Algorithm = "RS256"
+			c.JWT.Keys = []config.JWTKeyConfig{
+				{KID: "a", PrivateKeyFile: "a.pem"},
+				{KID: "b", PrivateKeyFile: "b.pem"},
+			}
+		}, "exactly one key"},
+		{"non-positive access token expiry", func(c *config.Config) { c.JWT.AccessTokenExpireMinutes = 0 }, "jwt.access_token_expire_minutes"},
+		{"smtp provider with credentials", func(c *config.Config) {
+			c.Email.Provider = "smtp"
+			c.Email.SMTPHost, c.Email.SMTPUsername, c.Email.SMTPPassword = "smtp.example.com", "mailer", "secret"
+		}, ""},
+		{"smtp provider without credentials", func(c *config.Config) {
+			c.Email.Provider = "smtp"
+			c.Email.SMTPHost = "smtp.example.com"
+		}, "email.smtp_password are required with the smtp provider"},
+		{"sendgrid provider with API key", func(c *config.Config) {
+			c.Email.Provider = "sendgrid"
+			c.Email.SendGridAPIKey = "SG.key"
+		}, ""},
+		{"sendgrid provider without API key", func(c *config.Config) { c.Email.Provider = "sendgrid" }, "email.sendgrid_api_key is required"},
+		{"email pacing disabled", func(c *config.Config) { c.Email.SendRatePerSecond, c.Email.SendBurst = 0, 0 }, ""},
+		{"negative email send rate", func(c *config.Config) { c.Email.SendRatePerSecond = -1 }, "email.send_rate_per_second"},
+		{"unknown email provider", func(c *config.Config) { c.Email.Provider = "ses" }, "email.provider"},
+		{"unknown TLS mode", func(c *config.Config) { c.Email.SMTPTLSMode = "ssl" }, "email.smtp_tls_mode"},
+		{"TLS certificate without key", func(c *config.Config) { c.Server.TLSCertFile = "cert.pem" }, "must be set together"},
+		{"missing TLS files", func(c *config.Config) {
+			c.Server.TLSCertFile = "missing-cert.pem"
+			c.Server.TLSKeyFile = "missing-key.pem"
+		}, "missing-cert.pem"},
+		{"negative rate limit", func(c *config.Config) { c.RateLimit.Burst = -1 }, "rate_limit"},
+		{"phone default region", func(c *config.Config) { c.Profile.PhoneDefaultRegion = "gb" }, ""},
+		{"unknown phone default region", func(c *config.Config) { c.Profile.PhoneDefaultRegion = "XX" }, "profile.phone_default_region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+
+			if tt.problem == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.problem)
+		})
+	}
+}
+
+func TestValidate_TLSFilesPresent(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, []byte("cert"), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, []byte("key"), 0o600))
+
+	cfg := validConfig()
+	cfg.Server.TLSCertFile = certFile
+	cfg.Server.TLSKeyFile = keyFile
+
+	assert.NoError(t, cfg.Validate())
+	assert.True(t, cfg.Server.TLSEnabled())
+}
+
+func TestValidate_ListsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.Host = ""
+	cfg.JWT.Secret = ""
+	cfg.Server.Port = ""
+
+	err := cfg.Validate()
+
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 3)
+}
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"backend/internal/infrastructure/config"
+	pgrepo "backend/internal/repository/postgres"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -27,17 +29,35 @@ func NewPostgresDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	return db, nil
 }
 
-// AutoMigrate runs database migrations
-func AutoMigrate(db *gorm.DB) error {
-	// Import repository models
-	type UserModel struct {
-		ID        string `gorm:"primaryKey;type:uuid"`
-		Email     string `gorm:"uniqueIndex;not null"`
-		Password  string `gorm:"not null"`
-		Name      string `gorm:"not null"`
-		CreatedAt int64  `gorm:"autoCreateTime:milli"`
-		UpdatedAt int64  `gorm:"autoUpdateTime:milli"`
+// Ping checks that the database accepts connections
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
 	}
+	return sqlDB.PingContext(ctx)
+}
 
-	return db.AutoMigrate(&UserModel{})
+// AutoMigrate runs database migrations for the repository models
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&pgrepo.UserModel{},
+		&pgrepo.AvatarModel{},
+		&pgrepo.RefreshTokenModel{},
+		&pgrepo.RevokedTokenModel{},
+		&pgrepo.OAuthStateModel{},
+		&pgrepo.UserOAuthIdentityModel{},
+		&pgrepo.LoginEventModel{},
+		&pgrepo.PendingAvatarDeletionModel{},
+		&pgrepo.ConversationModel{},
+		&pgrepo.ConversationParticipantModel{},
+		&pgrepo.MessageModel{},
+		&pgrepo.MessageAttachmentModel{},
+		&pgrepo.PendingAttachmentDeletionModel{},
+		&pgrepo.MessageReactionModel{},
+		&pgrepo.MessageMentionModel{},
+		&pgrepo.ConversationReadModel{},
+		&pgrepo.IdempotencyKeyModel{},
+		&pgrepo.RecentSearchModel{},
+	)
 }
@@ -0,0 +1,113 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/infrastructure/database"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// expectedColumns lists the columns created by the SQL migrations in /migrations
+var expectedColumns = map[string][]string{
+	"users": {
+		"id", "email", "password", "name", "username", "phone", "role",
+		"oauth_provider", "oauth_id", "email_verified",
+		"verification_token", "verification_token_expires_at",
+		"reset_password_token", "reset_password_token_expires_at",
+		"pending_email", "email_change_token", "email_change_token_expires_at",
+		"deletion_requested_at", "deactivated_at", "name_changed_at", "avatar_changed_at",
+		"last_login_at", "created_at", "updated_at",
+	},
+	"avatars": {
+		"id", "user_id", "public_id", "public_url", "secure_url", "created_at", "updated_at",
+	},
+	"refresh_tokens": {
+		"id", "user_id", "family_id", "token", "expires_at", "created_at",
+		"last_used_at", "revoked_at", "user_agent", "ip_address",
+	},
+	"revoked_tokens": {
+		"token_id", "expires_at", "created_at",
+	},
+	"oauth_states": {
+		"state", "provider", "code_verifier", "expires_at", "created_at",
+	},
+	"user_oauth_identities": {
+		"id", "user_id", "provider", "provider_user_id", "created_at",
+	},
+	"login_events": {
+		"id", "user_id", "method", "ip_address", "user_agent", "created_at",
+	},
+	"pending_avatar_deletions": {
+		"public_id", "created_at",
+	},
+	"conversations": {
+		"id", "type", "name", "created_at", "updated_at",
+	},
+	"conversation_participants": {
+		"conversation_id", "user_id", "muted", "created_at",
+	},
+	"messages": {
+		"id", "conversation_id", "sender_id", "body", "created_at", "edited_at", "deleted_at",
+	},
+	"message_attachments": {
+		"id", "message_id", "public_id", "resource_type", "url", "file_name", "content_type", "size", "created_at",
+	},
+	"message_reactions": {
+		"message_id", "user_id", "emoji", "created_at",
+	},
+	"message_mentions": {
+		"message_id", "user_id",
+	},
+	"conversation_reads": {
+		"conversation_id", "user_id", "last_read_message_id", "last_read_at", "marked_unread", "updated_at",
+	},
+	"idempotency_keys": {
+		"user_id", "key", "message_id", "expires_at", "created_at",
+	},
+	"recent_searches": {
+		"user_id", "query_key", "query", "searched_at",
+	},
+}
+
+func TestAutoMigrate_CreatesExpectedColumns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // every connection to :memory: is a separate database
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, database.AutoMigrate(db))
+	// Running it again against an up-to-date schema must be a no-op
+	require.NoError(t, database.AutoMigrate(db))
+
+	migrator := db.Migrator()
+	for table, columns := range expectedColumns {
+		require.True(t, migrator.HasTable(table), "table %s", table)
+		for _, column := range columns {
+			assert.True(t, migrator.HasColumn(table, column), "column %s.%s", table, column)
+		}
+	}
+}
+
+func TestPing_FailsOnceClosed(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, database.Ping(context.Background(), db))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+	assert.Error(t, database.Ping(context.Background(), db))
+}
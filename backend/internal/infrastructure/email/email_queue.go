@@ -0,0 +1,173 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"backend/internal/infrastructure/logger"
+	"backend/internal/infrastructure/retryqueue"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrQueueFull is returned when an email is enqueued while every queue slot is taken
+	ErrQueueFull = errors.New("email queue is full")
+	// ErrQueueClosed is returned when an email is enqueued after the queue was stopped
+	ErrQueueClosed = errors.New("email queue is closed")
+)
+
+// emailJob is a queued email, sent through the wrapped service
+type emailJob struct {
+	kind string
+	to   string
+	send func(context.Context, EmailService) error
+}
+
+// EmailQueue sends emails in the background, retrying failed sends with exponential backoff.
+// It implements EmailService: its Send methods enqueue the email and return without waiting for delivery,
+// so their ctx only covers enqueuing and delivery outlives the caller's request.
+type EmailQueue struct {
+	queue *retryqueue.Queue[emailJob]
+}
+
+// NewEmailQueue creates a queue holding up to capacity pending emails.
+// Each email is attempted up to maxAttempts times, waiting initialBackoff after the first failure
+// and doubling the wait after each further failure. Send attempts, retries included, are paced to
+// sendRate per second with up to sendBurst at once; emails over the rate wait in the queue.
+// A zero sendRate or sendBurst disables the pacing.
+func NewEmailQueue(service EmailService, capacity, maxAttempts int, initialBackoff time.Duration, sendRate float64, sendBurst int) *EmailQueue {
+	limiter := newSendLimiter(sendRate, sendBurst)
+	return &EmailQueue{
+		queue: retryqueue.New(retryqueue.Options[emailJob]{
+			Capacity:       capacity,
+			MaxAttempts:    maxAttempts,
+			InitialBackoff: initialBackoff,
+			// Each attempt is bounded by the service's own timeout
+			Run:           func(ctx context.Context, job emailJob) error { return job.send(ctx, service) },
+			BeforeAttempt: limiter.wait,
+			OnRetry: func(job emailJob, attempt int, backoff time.Duration, err error) {
+				logger.Warn("Email send failed, retrying",
+					zap.String("email", job.kind),
+					zap.Int("attempt", attempt),
+					zap.Duration("backoff", backoff),
+					zap.Error(err),
+				)
+			},
+			OnFailure: func(job emailJob, attempts int, err error) {
+				logger.Error("Email failed after retries", err,
+					zap.String("email", job.kind),
+					zap.String("to", job.to),
+					zap.Int("attempts", attempts),
+				)
+			},
+			ErrFull:   ErrQueueFull,
+			ErrClosed: ErrQueueClosed,
+		}),
+	}
+}
+
+// SendVerificationEmail enqueues an email verification link
+func (q *EmailQueue) SendVerificationEmail(ctx context.Context, to, name, token string) error {
+	return q.enqueue(emailJob{
+		kind: "verification",
+		to:   to,
+		send: func(ctx context.Context, s EmailService) error { return s.SendVerificationEmail(ctx, to, name, token) },
+	})
+}
+
+// SendPasswordResetEmail enqueues a password reset link
+func (q *EmailQueue) SendPasswordResetEmail(ctx context.Context, to, name, token string) error {
+	return q.enqueue(emailJob{
+		kind: "password_reset",
+		to:   to,
+		send: func(ctx context.Context, s EmailService) error { return s.SendPasswordResetEmail(ctx, to, name, token) },
+	})
+}
+
+// SendWelcomeEmail enqueues a welcome email
+func (q *EmailQueue) SendWelcomeEmail(ctx context.Context, to, name string) error {
+	return q.enqueue(emailJob{
+		kind: "welcome",
+		to:   to,
+		send: func(ctx context.Context, s EmailService) error { return s.SendWelcomeEmail(ctx, to, name) },
+	})
+}
+
+// SendEmailChangeConfirmation enqueues an email change confirmation link
+func (q *EmailQueue) SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error {
+	return q.enqueue(emailJob{
+		kind: "email_change",
+		to:   to,
+		send: func(ctx context.Context, s EmailService) error {
+			return s.SendEmailChangeConfirmation(ctx, to, name, token)
+		},
+	})
+}
+
+// Pending returns the number of emails waiting to be sent
+func (q *EmailQueue) Pending() int {
+	return q.queue.Pending()
+}
+
+// Failed returns the number of emails dropped after exhausting their attempts
+func (q *EmailQueue) Failed() int64 {
+	return q.queue.Failed()
+}
+
+// Start runs the worker that sends queued emails until Stop is called
+func (q *EmailQueue) Start(ctx context.Context) {
+	q.queue.Start(ctx)
+}
+
+// Stop stops accepting emails and waits for the queued ones to be sent.
+// See retryqueue.Queue.Stop for what happens when ctx expires first.
+func (q *EmailQueue) Stop(ctx context.Context) {
+	q.queue.Stop(ctx)
+}
+
+func (q *EmailQueue) enqueue(job emailJob) error {
+	return q.queue.Enqueue(job)
+}
+
+// sendLimiter is a token bucket pacing the worker's sends to the provider. Only the worker uses it,
+// so it needs no locking.
+type sendLimiter struct {
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newSendLimiter(rate float64, burst int) *sendLimiter {
+	return &sendLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a send is allowed. It returns at once when the limiter is disabled or ctx is done,
+// so the last attempts made after Stop cancels ctx aren't held back.
+func (l *sendLimiter) wait(ctx context.Context) {
+	if l.rate <= 0 || l.burst <= 0 {
+		return
+	}
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return
+	}
+
+	delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	timer := time.NewTimer(delay)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	// The token that arrived during the wait is spent on this send
+	l.tokens = 0
+	l.last = time.Now()
+}
@@ -0,0 +1,149 @@
+package email_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/infrastructure/email"
+	"backend/internal/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyEmailService fails its first `failures` sends, then succeeds
+type flakyEmailService struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	sent     []string
+	sentAt   []time.Time
+}
+
+func (s *flakyEmailService) SendVerificationEmail(ctx context.Context, to, name, token string) error {
+	return s.send(to)
+}
+
+func (s *flakyEmailService) SendPasswordResetEmail(ctx context.Context, to, name, token string) error {
+	return s.send(to)
+}
+
+func (s *flakyEmailService) SendWelcomeEmail(ctx context.Context, to, name string) error {
+	return s.send(to)
+}
+
+func (s *flakyEmailService) SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error {
+	return s.send(to)
+}
+
+func (s *flakyEmailService) send(to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return fmt.Errorf("smtp unavailable")
+	}
+	s.sent = append(s.sent, to)
+	s.sentAt = append(s.sentAt, time.Now())
+	return nil
+}
+
+func newTestQueue(service email.EmailService, maxAttempts int) *email.EmailQueue {
+	logger.Init("release")
+	queue := email.NewEmailQueue(service, 10, maxAttempts, time.Millisecond, 0, 0)
+	queue.Start(context.Background())
+	return queue
+}
+
+func TestEmailQueue_RetriesUntilSent(t *testing.T) {
+	service := &flakyEmailService{failures: 2}
+	queue := newTestQueue(service, 5)
+
+	assert.NoError(t, queue.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token"))
+	queue.Stop(context.Background())
+
+	assert.Equal(t, 3, service.attempts)
+	assert.Equal(t, []string{"alice@example.com"}, service.sent)
+	assert.Equal(t, int64(0), queue.Failed())
+}
+
+func TestEmailQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	service := &flakyEmailService{failures: 10}
+	queue := newTestQueue(service, 3)
+
+	assert.NoError(t, queue.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token"))
+	queue.Stop(context.Background())
+
+	assert.Equal(t, 3, service.attempts)
+	assert.Empty(t, service.sent)
+	assert.Equal(t, int64(1), queue.Failed())
+}
+
+func TestEmailQueue_StopDrainsPendingEmails(t *testing.T) {
+	service := &flakyEmailService{}
+	queue := newTestQueue(service, 1)
+
+	for _, to := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		assert.NoError(t, queue.SendPasswordResetEmail(context.Background(), to, "User", "token"))
+	}
+	queue.Stop(context.Background())
+
+	assert.Equal(t, []string{"a@example.com", "b@example.com", "c@example.com"}, service.sent)
+}
+
+func TestEmailQueue_StopAbandonsRetriesAtDeadline(t *testing.T) {
+	logger.Init("release")
+	service := &flakyEmailService{failures: 10}
+	queue := email.NewEmailQueue(service, 10, 5, time.Hour, 0, 0)
+	queue.Start(context.Background())
+
+	assert.NoError(t, queue.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	queue.Stop(ctx)
+
+	assert.Equal(t, int64(1), queue.Failed())
+}
+
+func TestEmailQueue_RejectsAfterStop(t *testing.T) {
+	queue := newTestQueue(&flakyEmailService{}, 1)
+	queue.Stop(context.Background())
+
+	err := queue.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token")
+
+	assert.ErrorIs(t, err, email.ErrQueueClosed)
+}
+
+func TestEmailQueue_RejectsWhenFull(t *testing.T) {
+	// Not started, so nothing drains the queue
+	queue := email.NewEmailQueue(&flakyEmailService{}, 1, 1, time.Millisecond, 0, 0)
+
+	assert.NoError(t, queue.SendVerificationEmail(context.Background(), "a@example.com", "A", "token"))
+	err := queue.SendVerificationEmail(context.Background(), "b@example.com", "B", "token")
+
+	assert.ErrorIs(t, err, email.ErrQueueFull)
+}
+
+func TestEmailQueue_PacesSendsUnderBurst(t *testing.T) {
+	logger.Init("release")
+	service := &flakyEmailService{}
+	// 2 at once, then one every 50ms
+	const rate, burst, emails = 20, 2, 6
+	start := time.Now()
+	queue := email.NewEmailQueue(service, 10, 1, time.Millisecond, rate, burst)
+	queue.Start(context.Background())
+
+	for i := 0; i < emails; i++ {
+		// None is dropped: emails over the rate wait in the queue
+		assert.NoError(t, queue.SendWelcomeEmail(context.Background(), fmt.Sprintf("user%d@example.com", i), "User"))
+	}
+	queue.Stop(context.Background())
+
+	assert.Len(t, service.sent, emails)
+	for i, sentAt := range service.sentAt[burst:] {
+		earliest := time.Duration(i+1) * time.Second / rate
+		assert.GreaterOrEqual(t, sentAt.Sub(start), earliest, "email %d", burst+i)
+	}
+}
@@ -1,117 +1,281 @@
 package email
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
 	"fmt"
+	htmltemplate "html/template"
+	"mime/multipart"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	texttemplate "text/template"
+	"time"
+
+	"backend/internal/domain/errors"
+)
+
+// SMTP TLS modes
+const (
+	TLSModeNone     = "none"     // plaintext, for local relays only
+	TLSModeStartTLS = "starttls" // upgrade a plaintext connection, usually on port 587
+	TLSModeImplicit = "tls"      // TLS from the first byte, usually on port 465
 )
 
+//go:embed templates/*.html templates/*.txt
+var templateFS embed.FS
+
+// The email bodies, parsed once at startup. Every email has an HTML and a plain-text version.
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+)
+
+// templateData is the data passed to every email template
+type templateData struct {
+	Name string
+	URL  string
+}
+
+// emailBody holds the alternative renderings of an email
+type emailBody struct {
+	Text string
+	HTML string
+}
+
 // EmailService defines the interface for email operations
 type EmailService interface {
-	SendVerificationEmail(to, name, token string) error
-	SendPasswordResetEmail(to, name, token string) error
+	SendVerificationEmail(ctx context.Context, to, name, token string) error
+	SendPasswordResetEmail(ctx context.Context, to, name, token string) error
+	SendWelcomeEmail(ctx context.Context, to, name string) error
+	SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error
 }
 
 type emailService struct {
+	templatedEmails
 	smtpHost     string
 	smtpPort     string
 	smtpUsername string
 	smtpPassword string
+	tlsMode      string
 	fromEmail    string
 	fromName     string
-	frontendURL  string
+	timeout      time.Duration
 }
 
-// NewEmailService creates a new email service
+// NewEmailService creates a new email service.
+// tlsMode is one of TLSModeNone, TLSModeStartTLS or TLSModeImplicit; the server certificate is verified against smtpHost.
+// Each email, from connecting to the server to the end of the SMTP exchange, must be sent within timeout.
 func NewEmailService(
-	smtpHost, smtpPort, smtpUsername, smtpPassword, fromEmail, fromName, frontendURL string,
+	smtpHost, smtpPort, smtpUsername, smtpPassword, tlsMode, fromEmail, fromName, frontendURL string,
+	timeout time.Duration,
 ) EmailService {
-	return &emailService{
+	s := &emailService{
 		smtpHost:     smtpHost,
 		smtpPort:     smtpPort,
 		smtpUsername: smtpUsername,
 		smtpPassword: smtpPassword,
+		tlsMode:      tlsMode,
 		fromEmail:    fromEmail,
 		fromName:     fromName,
-		frontendURL:  frontendURL,
+		timeout:      timeout,
 	}
+	s.templatedEmails = templatedEmails{frontendURL: frontendURL, send: s.sendEmail}
+	return s
+}
+
+// templatedEmails implements the EmailService methods by rendering each email's templates,
+// linking to frontendURL, and handing the result to send
+type templatedEmails struct {
+	frontendURL string
+	send        func(ctx context.Context, to, subject string, body emailBody) error
 }
 
 // SendVerificationEmail sends an email verification link to the user
-func (s *emailService) SendVerificationEmail(to, name, token string) error {
-	verificationURL := fmt.Sprintf("%s/verify-email?token=%s", s.frontendURL, token)
-	
-	subject := "Verify Your Email Address"
-	body := fmt.Sprintf(`
-		<html>
-		<body>
-			<h2>Welcome to TkhanChat, %s!</h2>
-			<p>Thank you for signing up. Please verify your email address by clicking the link below:</p>
-			<p><a href="%s" style="background-color: #4CAF50; color: white; padding: 14px 20px; text-decoration: none; border-radius: 4px; display: inline-block;">Verify Email</a></p>
-			<p>Or copy and paste this link into your browser:</p>
-			<p>%s</p>
-			<p>This link will expire in 24 hours.</p>
-			<p>If you didn't create an account, please ignore this email.</p>
-		</body>
-		</html>
-	`, name, verificationURL, verificationURL)
-
-	return s.sendEmail(to, subject, body)
+func (t templatedEmails) SendVerificationEmail(ctx context.Context, to, name, token string) error {
+	return t.sendTemplate(ctx, to, "verification", "Verify Your Email Address", name, t.link("/verify-email", token))
 }
 
 // SendPasswordResetEmail sends a password reset link to the user
-func (s *emailService) SendPasswordResetEmail(to, name, token string) error {
-	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.frontendURL, token)
-	
-	subject := "Reset Your Password"
-	body := fmt.Sprintf(`
-		<html>
-		<body>
-			<h2>Password Reset Request</h2>
-			<p>Hi %s,</p>
-			<p>We received a request to reset your password. Click the link below to reset it:</p>
-			<p><a href="%s" style="background-color: #2196F3; color: white; padding: 14px 20px; text-decoration: none; border-radius: 4px; display: inline-block;">Reset Password</a></p>
-			<p>Or copy and paste this link into your browser:</p>
-			<p>%s</p>
-			<p>This link will expire in 1 hour.</p>
-			<p>If you didn't request a password reset, please ignore this email or contact support if you have concerns.</p>
-		</body>
-		</html>
-	`, name, resetURL, resetURL)
-
-	return s.sendEmail(to, subject, body)
-}
-
-// sendEmail sends an email using SMTP
-func (s *emailService) sendEmail(to, subject, body string) error {
-	// Build email message
-	from := fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail)
-	
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
-
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
-	}
-	message += "\r\n" + body
-
-	// SMTP authentication
-	auth := smtp.PlainAuth("", s.smtpUsername, s.smtpPassword, s.smtpHost)
-
-	// Send email
-	addr := fmt.Sprintf("%s:%s", s.smtpHost, s.smtpPort)
-	err := smtp.SendMail(addr, auth, s.fromEmail, []string{to}, []byte(message))
+func (t templatedEmails) SendPasswordResetEmail(ctx context.Context, to, name, token string) error {
+	return t.sendTemplate(ctx, to, "password_reset", "Reset Your Password", name, t.link("/reset-password", token))
+}
+
+// SendWelcomeEmail confirms to the user that their account is verified
+func (t templatedEmails) SendWelcomeEmail(ctx context.Context, to, name string) error {
+	return t.sendTemplate(ctx, to, "welcome", "Welcome to TkhanChat", name, t.frontendURL)
+}
+
+// SendEmailChangeConfirmation sends a link confirming a change to the new email address
+func (t templatedEmails) SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error {
+	return t.sendTemplate(ctx, to, "email_change", "Confirm Your New Email Address", name, t.link("/confirm-email-change", token))
+}
+
+// link returns the frontend page at path carrying token
+func (t templatedEmails) link(path, token string) string {
+	return fmt.Sprintf("%s%s?token=%s", t.frontendURL, path, token)
+}
+
+// sendTemplate renders the named email for the recipient and sends it
+func (t templatedEmails) sendTemplate(ctx context.Context, to, template, subject, name, url string) error {
+	body, err := render(template, templateData{Name: name, URL: url})
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return err
+	}
+	return t.send(ctx, to, subject, body)
+}
+
+// render executes the plain-text and HTML templates of the named email.
+// Data is escaped in the HTML version only.
+func render(name string, data templateData) (emailBody, error) {
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return emailBody{}, fmt.Errorf("failed to render email template %s.txt: %w", name, err)
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return emailBody{}, fmt.Errorf("failed to render email template %s.html: %w", name, err)
+	}
+	return emailBody{Text: text.String(), HTML: html.String()}, nil
+}
+
+// sendEmail sends an email using SMTP, giving up when the service's timeout or ctx expires.
+// Timeouts are reported as errors.ErrUpstreamTimeout.
+func (s *emailService) sendEmail(ctx context.Context, to, subject string, body emailBody) error {
+	message, err := s.buildMessage(to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", errors.WrapTimeout(err))
+	}
+	defer client.Close()
+
+	if err := s.deliver(client, to, message); err != nil {
+		return fmt.Errorf("failed to send email: %w", errors.WrapTimeout(err))
 	}
 
 	return nil
 }
 
+// dial connects to the SMTP server, securing the connection according to the TLS mode.
+// The connection's deadline is set to ctx's, since the SMTP exchange itself can't be cancelled.
+func (s *emailService) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.smtpHost, s.smtpPort)
+	tlsConfig := &tls.Config{ServerName: s.smtpHost}
+	dialer := &net.Dialer{}
+
+	var conn net.Conn
+	var err error
+	switch s.tlsMode {
+	case TLSModeImplicit:
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	case TLSModeStartTLS, TLSModeNone:
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("unsupported SMTP TLS mode %q", s.tlsMode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.smtpHost)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.tlsMode == TLSModeStartTLS {
+		// Never fall back to plaintext when the upgrade is unavailable
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// deliver authenticates and sends the message over an established connection
+func (s *emailService) deliver(client *smtp.Client, to string, message []byte) error {
+	if s.smtpUsername != "" {
+		auth := smtp.PlainAuth("", s.smtpUsername, s.smtpPassword, s.smtpHost)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.fromEmail); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildMessage builds a multipart/alternative message with the plain-text part first,
+// so clients that render HTML pick the last, richer part
+func (s *emailService) buildMessage(to, subject string, body emailBody) ([]byte, error) {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+
+	alternatives := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", body.Text},
+		{"text/html; charset=UTF-8", body.HTML},
+	}
+	for _, alt := range alternatives {
+		part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {alt.contentType}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+		if _, err := part.Write([]byte(alt.content)); err != nil {
+			return nil, fmt.Errorf("failed to build email: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build email: %w", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s <%s>\r\n", s.fromName, s.fromEmail)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", subject)
+	message.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n", writer.Boundary())
+	message.WriteString("\r\n")
+	message.Write(parts.Bytes())
+	return message.Bytes(), nil
+}
+
 // MockEmailService is a mock implementation for testing/development
 type MockEmailService struct{}
 
@@ -121,13 +285,25 @@ func NewMockEmailService() EmailService {
 }
 
 // SendVerificationEmail logs the verification email instead of sending
-func (m *MockEmailService) SendVerificationEmail(to, name, token string) error {
+func (m *MockEmailService) SendVerificationEmail(ctx context.Context, to, name, token string) error {
 	fmt.Printf("[MOCK EMAIL] Verification email to %s (%s)\nToken: %s\n", to, name, token)
 	return nil
 }
 
 // SendPasswordResetEmail logs the password reset email instead of sending
-func (m *MockEmailService) SendPasswordResetEmail(to, name, token string) error {
+func (m *MockEmailService) SendPasswordResetEmail(ctx context.Context, to, name, token string) error {
 	fmt.Printf("[MOCK EMAIL] Password reset email to %s (%s)\nToken: %s\n", to, name, token)
 	return nil
 }
+
+// SendWelcomeEmail logs the welcome email instead of sending
+func (m *MockEmailService) SendWelcomeEmail(ctx context.Context, to, name string) error {
+	fmt.Printf("[MOCK EMAIL] Welcome email to %s (%s)\n", to, name)
+	return nil
+}
+
+// SendEmailChangeConfirmation logs the email change confirmation instead of sending
+func (m *MockEmailService) SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error {
+	fmt.Printf("[MOCK EMAIL] Email change confirmation to %s (%s)\nToken: %s\n", to, name, token)
+	return nil
+}
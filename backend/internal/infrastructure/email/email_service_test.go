@@ -0,0 +1,238 @@
+package email_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/infrastructure/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpServer is a minimal SMTP server that records the messages it receives
+type smtpServer struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &smtpServer{listener: listener, messages: make(chan string, 1)}
+	go server.serve()
+	return server
+}
+
+func (s *smtpServer) hostPort(t *testing.T) (string, string) {
+	host, port, err := net.SplitHostPort(s.listener.Addr().String())
+	require.NoError(t, err)
+	return host, port
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(textproto.NewConn(conn))
+	}
+}
+
+func (s *smtpServer) handle(conn *textproto.Conn) {
+	defer conn.Close()
+	_ = conn.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+		case "EHLO", "HELO":
+			_ = conn.PrintfLine("250-localhost")
+			_ = conn.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			_ = conn.PrintfLine("235 Authentication successful")
+		case "DATA":
+			_ = conn.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.messages <- string(data)
+			_ = conn.PrintfLine("250 OK")
+		case "QUIT":
+			_ = conn.PrintfLine("221 Bye")
+			return
+		default:
+			_ = conn.PrintfLine("250 OK")
+		}
+	}
+}
+
+// send delivers an email through a fresh server and returns the raw message
+func send(t *testing.T, fn func(email.EmailService) error) string {
+	t.Helper()
+	server := newSMTPServer(t)
+	host, port := server.hostPort(t)
+	service := email.NewEmailService(host, port, "user", "pass", email.TLSModeNone, "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", time.Second)
+
+	require.NoError(t, fn(service))
+	return <-server.messages
+}
+
+// parts parses a multipart/alternative message and returns its bodies by media type
+func parts(t *testing.T, message string) (*mail.Message, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(message))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+	require.NotEmpty(t, params["boundary"])
+
+	bodies := make(map[string]string)
+	var order []string
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		partType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		bodies[partType] = string(content)
+		order = append(order, partType)
+	}
+	// Clients prefer the last alternative they support, so HTML goes last
+	require.Equal(t, []string{"text/plain", "text/html"}, order)
+	return msg, bodies
+}
+
+func TestSendVerificationEmail_EscapesName(t *testing.T) {
+	message := send(t, func(s email.EmailService) error {
+		return s.SendVerificationEmail(context.Background(), "alice@example.com", "<script>alert(1)</script>", "token-1")
+	})
+
+	_, bodies := parts(t, message)
+	assert.NotContains(t, bodies["text/html"], "<script>")
+	assert.Contains(t, bodies["text/html"], "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.Contains(t, bodies["text/html"], "https://app.tkhan.chat/verify-email?token=token-1")
+}
+
+func TestSendPasswordResetEmail_HasTextAndHTMLParts(t *testing.T) {
+	message := send(t, func(s email.EmailService) error {
+		return s.SendPasswordResetEmail(context.Background(), "alice@example.com", "Alice", "token-2")
+	})
+
+	msg, bodies := parts(t, message)
+	assert.Equal(t, "Reset Your Password", msg.Header.Get("Subject"))
+	assert.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+
+	assert.Contains(t, bodies["text/plain"], "Hi Alice,")
+	assert.Contains(t, bodies["text/plain"], "https://app.tkhan.chat/reset-password?token=token-2")
+	assert.NotContains(t, bodies["text/plain"], "<")
+
+	assert.Contains(t, bodies["text/html"], "Hi Alice,")
+	assert.Contains(t, bodies["text/html"], `href="https://app.tkhan.chat/reset-password?token=token-2"`)
+}
+
+func TestSendEmail_StartTLSRequired(t *testing.T) {
+	// The test server does not offer STARTTLS, so the email must not go out in plaintext
+	server := newSMTPServer(t)
+	host, port := server.hostPort(t)
+	service := email.NewEmailService(host, port, "user", "pass", email.TLSModeStartTLS, "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", time.Second)
+
+	err := service.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token-1")
+
+	assert.ErrorContains(t, err, "STARTTLS")
+	assert.Empty(t, server.messages)
+}
+
+func TestSendEmail_ImplicitTLSVerifiesCertificate(t *testing.T) {
+	// The TLS test server presents a certificate from an untrusted CA
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	service := email.NewEmailService(host, port, "user", "pass", email.TLSModeImplicit, "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", time.Second)
+
+	err = service.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token-1")
+
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestSendEmail_UnsupportedTLSMode(t *testing.T) {
+	service := email.NewEmailService("127.0.0.1", "25", "user", "pass", "ssl", "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", time.Second)
+
+	err := service.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token-1")
+
+	assert.ErrorContains(t, err, `unsupported SMTP TLS mode "ssl"`)
+}
+
+func TestSendWelcomeEmail_LinksToApp(t *testing.T) {
+	message := send(t, func(s email.EmailService) error {
+		return s.SendWelcomeEmail(context.Background(), "alice@example.com", "Alice")
+	})
+
+	msg, bodies := parts(t, message)
+	assert.Equal(t, "Welcome to TkhanChat", msg.Header.Get("Subject"))
+	assert.Contains(t, bodies["text/plain"], "You're all set, Alice!")
+	assert.Contains(t, bodies["text/html"], `href="https://app.tkhan.chat"`)
+}
+
+func TestSendEmailChangeConfirmation_LinksToConfirmation(t *testing.T) {
+	message := send(t, func(s email.EmailService) error {
+		return s.SendEmailChangeConfirmation(context.Background(), "alice@new.example.com", "Alice", "token-1")
+	})
+
+	msg, bodies := parts(t, message)
+	assert.Equal(t, "Confirm Your New Email Address", msg.Header.Get("Subject"))
+	assert.Equal(t, "alice@new.example.com", msg.Header.Get("To"))
+	assert.Contains(t, bodies["text/plain"], "https://app.tkhan.chat/confirm-email-change?token=token-1")
+	assert.Contains(t, bodies["text/html"], `href="https://app.tkhan.chat/confirm-email-change?token=token-1"`)
+}
+
+func TestSendEmail_TimesOutOnSilentServer(t *testing.T) {
+	// The server accepts connections but never sends its greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	service := email.NewEmailService(host, port, "user", "pass", email.TLSModeNone, "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", 100*time.Millisecond)
+
+	start := time.Now()
+	err = service.SendVerificationEmail(context.Background(), "alice@example.com", "Alice", "token-1")
+
+	assert.ErrorIs(t, err, errors.ErrUpstreamTimeout)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
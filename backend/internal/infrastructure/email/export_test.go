@@ -0,0 +1,8 @@
+package email
+
+import "time"
+
+// NewSendGridEmailServiceWithURL creates a SendGrid email service that sends to apiURL instead of SendGrid
+func NewSendGridEmailServiceWithURL(apiURL, apiKey, fromEmail, fromName, frontendURL string, timeout time.Duration) EmailService {
+	return newSendGridEmailService(apiURL, apiKey, fromEmail, fromName, frontendURL, timeout)
+}
@@ -0,0 +1,122 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/internal/domain/errors"
+)
+
+// Email providers selectable in the configuration
+const (
+	ProviderSMTP     = "smtp"
+	ProviderSendGrid = "sendgrid"
+	ProviderMock     = "mock"
+)
+
+// sendGridAPIURL is the SendGrid v3 mail send endpoint
+const sendGridAPIURL = "https://api.sendgrid.com/v3/mail/send"
+
+// sendGridAddress is an email address in the SendGrid API
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// sendGridContent is one rendering of the email body
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// sendGridPersonalization lists the recipients of a message
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+// sendGridRequest is the body of a SendGrid mail send request
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+type sendGridEmailService struct {
+	templatedEmails
+	apiURL     string
+	apiKey     string
+	fromEmail  string
+	fromName   string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// NewSendGridEmailService creates an email service that sends through the SendGrid HTTP API.
+// Each API call must complete within timeout.
+func NewSendGridEmailService(apiKey, fromEmail, fromName, frontendURL string, timeout time.Duration) EmailService {
+	return newSendGridEmailService(sendGridAPIURL, apiKey, fromEmail, fromName, frontendURL, timeout)
+}
+
+func newSendGridEmailService(apiURL, apiKey, fromEmail, fromName, frontendURL string, timeout time.Duration) *sendGridEmailService {
+	s := &sendGridEmailService{
+		apiURL:     apiURL,
+		apiKey:     apiKey,
+		fromEmail:  fromEmail,
+		fromName:   fromName,
+		timeout:    timeout,
+		httpClient: &http.Client{},
+	}
+	s.templatedEmails = templatedEmails{frontendURL: frontendURL, send: s.sendEmail}
+	return s
+}
+
+// sendEmail sends an email through the SendGrid API, giving up when the service's timeout or ctx expires.
+// Timeouts are reported as errors.ErrUpstreamTimeout.
+func (s *sendGridEmailService) sendEmail(ctx context.Context, to, subject string, body emailBody) error {
+	payload := sendGridRequest{
+		Personalizations: []sendGridPersonalization{
+			{To: []sendGridAddress{{Email: to}}},
+		},
+		From:    sendGridAddress{Email: s.fromEmail, Name: s.fromName},
+		Subject: subject,
+		// SendGrid requires text/plain to come before text/html
+		Content: []sendGridContent{
+			{Type: "text/plain", Value: body.Text},
+			{Type: "text/html", Value: body.HTML},
+		},
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", errors.WrapTimeout(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to send email: sendgrid returned status code %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package email_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/infrastructure/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sendGridRequest struct {
+	Personalizations []struct {
+		To []struct {
+			Email string `json:"email"`
+		} `json:"to"`
+	} `json:"personalizations"`
+	From struct {
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	} `json:"from"`
+	Subject string `json:"subject"`
+	Content []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"content"`
+}
+
+func TestSendGridEmailService_SendsVerificationEmail(t *testing.T) {
+	var received sendGridRequest
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	service := email.NewSendGridEmailServiceWithURL(server.URL, "sg-key", "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", time.Second)
+
+	err := service.SendVerificationEmail(context.Background(), "alice@example.com", "<b>Alice</b>", "token-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer sg-key", authorization)
+	require.Len(t, received.Personalizations, 1)
+	assert.Equal(t, "alice@example.com", received.Personalizations[0].To[0].Email)
+	assert.Equal(t, "noreply@tkhan.chat", received.From.Email)
+	assert.Equal(t, "TkhanChat", received.From.Name)
+	assert.Equal(t, "Verify Your Email Address", received.Subject)
+
+	require.Len(t, received.Content, 2)
+	assert.Equal(t, "text/plain", received.Content[0].Type)
+	assert.Contains(t, received.Content[0].Value, "https://app.tkhan.chat/verify-email?token=token-1")
+	assert.Equal(t, "text/html", received.Content[1].Type)
+	assert.Contains(t, received.Content[1].Value, "&lt;b&gt;Alice&lt;/b&gt;")
+}
+
+func TestSendGridEmailService_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"invalid api key"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	service := email.NewSendGridEmailServiceWithURL(server.URL, "bad-key", "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", time.Second)
+
+	err := service.SendPasswordResetEmail(context.Background(), "alice@example.com", "Alice", "token-2")
+
+	assert.ErrorContains(t, err, "status code 401")
+	assert.ErrorContains(t, err, "invalid api key")
+}
+
+func TestSendGridEmailService_TimesOutOnSlowServer(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the test ends, well past the client's timeout
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	service := email.NewSendGridEmailServiceWithURL(server.URL, "sg-key", "noreply@tkhan.chat", "TkhanChat", "https://app.tkhan.chat", 100*time.Millisecond)
+
+	start := time.Now()
+	err := service.SendWelcomeEmail(context.Background(), "alice@example.com", "Alice")
+
+	assert.ErrorIs(t, err, errors.ErrUpstreamTimeout)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,73 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"backend/internal/domain/event"
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// handlerTimeout bounds a single subscriber's handling of an event
+const handlerTimeout = 30 * time.Second
+
+// Bus is an in-process event bus. Each subscriber runs in its own goroutine,
+// so a slow or failing subscriber delays neither the publisher nor the other subscribers.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]event.Handler
+	pending  sync.WaitGroup
+}
+
+// New creates an event bus without subscribers
+func New() *Bus {
+	return &Bus{handlers: make(map[string][]event.Handler)}
+}
+
+// Subscribe registers handler for events with the given name
+func (b *Bus) Subscribe(name string, handler event.Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish hands the event to every subscriber of its name and returns without waiting for them.
+// Handler errors and panics are logged.
+func (b *Bus) Publish(ctx context.Context, e event.Event) {
+	b.mu.RLock()
+	handlers := b.handlers[e.Name()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		// Detach from the request, which is canceled as soon as the response is sent
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), handlerTimeout)
+
+		b.pending.Add(1)
+		go func() {
+			defer b.pending.Done()
+			defer cancel()
+			if err := run(ctx, handler, e); err != nil {
+				logger.ErrorContext(ctx, "Event handler failed", err, zap.String("event", e.Name()))
+			}
+		}()
+	}
+}
+
+// Wait blocks until the events published so far have been handled
+func (b *Bus) Wait() {
+	b.pending.Wait()
+}
+
+// run calls handler, turning a panic into an error
+func run(ctx context.Context, handler event.Handler, e event.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, e)
+}
@@ -0,0 +1,74 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"backend/internal/domain/event"
+	"backend/internal/infrastructure/eventbus"
+	"backend/internal/infrastructure/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_DeliversToSubscribersOfTheEvent(t *testing.T) {
+	bus := eventbus.New()
+	var mu sync.Mutex
+	var received []string
+	record := func(tag string) event.Handler {
+		return func(ctx context.Context, e event.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, tag+":"+e.Name())
+			return nil
+		}
+	}
+	bus.Subscribe(event.MessageSent, record("first"))
+	bus.Subscribe(event.MessageSent, record("second"))
+	bus.Subscribe(event.UserVerified, record("other"))
+
+	bus.Publish(context.Background(), event.MessageSentEvent{MessageID: "msg-1"})
+	bus.Wait()
+
+	assert.ElementsMatch(t, []string{"first:message.sent", "second:message.sent"}, received)
+}
+
+func TestBus_FailingHandlerDoesNotAffectOthers(t *testing.T) {
+	logger.Init("release")
+	bus := eventbus.New()
+	delivered := false
+	bus.Subscribe(event.UserRegistered, func(ctx context.Context, e event.Event) error {
+		return errors.New("integration unavailable")
+	})
+	bus.Subscribe(event.UserRegistered, func(ctx context.Context, e event.Event) error {
+		panic("broken handler")
+	})
+	bus.Subscribe(event.UserRegistered, func(ctx context.Context, e event.Event) error {
+		delivered = true
+		return nil
+	})
+
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), event.UserRegisteredEvent{UserID: "user-1"})
+		bus.Wait()
+	})
+	assert.True(t, delivered)
+}
+
+func TestBus_HandlerOutlivesCanceledRequest(t *testing.T) {
+	bus := eventbus.New()
+	var handlerErr error
+	bus.Subscribe(event.MessageSent, func(ctx context.Context, e event.Event) error {
+		handlerErr = ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bus.Publish(ctx, event.MessageSentEvent{MessageID: "msg-1"})
+	bus.Wait()
+
+	assert.NoError(t, handlerErr)
+}
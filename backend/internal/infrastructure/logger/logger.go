@@ -1,12 +1,16 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 var log *zap.Logger
 
+type requestIDKey struct{}
+
 // Init initializes the logger
 func Init(mode string) {
 	var err error
@@ -25,6 +29,11 @@ func Init(mode string) {
 	}
 }
 
+// Set replaces the logger, e.g. with one that records entries in tests
+func Set(l *zap.Logger) {
+	log = l
+}
+
 // Info logs an info message
 func Info(msg string, fields ...zap.Field) {
 	log.Info(msg, fields...)
@@ -56,3 +65,38 @@ func Warn(msg string, fields ...zap.Field) {
 func Sync() {
 	_ = log.Sync()
 }
+
+// WithRequestID returns a copy of ctx carrying the request ID, which the *Context functions add to their entries
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromContext returns the logger annotated with the request ID carried by ctx
+func FromContext(ctx context.Context) *zap.Logger {
+	if requestID := RequestID(ctx); requestID != "" {
+		return log.With(zap.String("request_id", requestID))
+	}
+	return log
+}
+
+// InfoContext logs an info message with the request ID carried by ctx
+func InfoContext(ctx context.Context, msg string, fields ...zap.Field) {
+	FromContext(ctx).Info(msg, fields...)
+}
+
+// ErrorContext logs an error message with the request ID carried by ctx
+func ErrorContext(ctx context.Context, msg string, err error, fields ...zap.Field) {
+	fields = append(fields, zap.Error(err))
+	FromContext(ctx).Error(msg, fields...)
+}
+
+// WarnContext logs a warning message with the request ID carried by ctx
+func WarnContext(ctx context.Context, msg string, fields ...zap.Field) {
+	FromContext(ctx).Warn(msg, fields...)
+}
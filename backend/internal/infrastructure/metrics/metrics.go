@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"backend/internal/infrastructure/email"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// NewRegistry creates the Prometheus registry for the application, with runtime and process metrics,
+// the database connection pool stats and the email queue state already registered
+func NewRegistry(db *sql.DB, emailQueue *email.EmailQueue) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "postgres"),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "email_queue_pending",
+			Help: "Emails waiting in the queue to be sent.",
+		}, func() float64 {
+			return float64(emailQueue.Pending())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "email_queue_failed_total",
+			Help: "Emails dropped after exhausting their send attempts.",
+		}, func() float64 {
+			return float64(emailQueue.Failed())
+		}),
+	)
+	return registry
+}
@@ -0,0 +1,169 @@
+package retryqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrFull is returned by default when a job is enqueued while every queue slot is taken
+	ErrFull = errors.New("queue is full")
+	// ErrClosed is returned by default when a job is enqueued after the queue was stopped
+	ErrClosed = errors.New("queue is closed")
+)
+
+// Options configures a Queue
+type Options[T any] struct {
+	// Capacity is how many jobs can wait in the queue
+	Capacity int
+	// MaxAttempts is how many times a job is attempted; values below 1 mean a single attempt
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failure, doubled after each further failure
+	InitialBackoff time.Duration
+
+	// Run makes one attempt at the job. Its ctx is not canceled by Stop, so each attempt must
+	// bound itself, e.g. with its own timeout.
+	Run func(ctx context.Context, job T) error
+	// BeforeAttempt, if set, is called before every attempt, e.g. to pace them.
+	// Its ctx is canceled when Stop gives up waiting.
+	BeforeAttempt func(ctx context.Context)
+	// OnRetry, if set, is called when an attempt failed and the job will be retried after backoff
+	OnRetry func(job T, attempt int, backoff time.Duration, err error)
+	// OnFailure, if set, is called when the job is dropped after its last failed attempt
+	OnFailure func(job T, attempts int, err error)
+
+	// ErrFull and ErrClosed replace the errors Enqueue returns, so callers can keep their own
+	ErrFull   error
+	ErrClosed error
+}
+
+// Queue runs jobs one at a time on a background worker, retrying failed jobs with exponential backoff.
+// Jobs are held in a bounded channel: Enqueue never blocks and fails when the queue is full.
+type Queue[T any] struct {
+	opts Options[T]
+
+	mu     sync.RWMutex
+	jobs   chan T
+	closed bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	failed atomic.Int64
+}
+
+// New creates a queue configured by opts; call Start to run its worker
+func New[T any](opts Options[T]) *Queue[T] {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	if opts.ErrFull == nil {
+		opts.ErrFull = ErrFull
+	}
+	if opts.ErrClosed == nil {
+		opts.ErrClosed = ErrClosed
+	}
+	return &Queue[T]{
+		opts: opts,
+		jobs: make(chan T, opts.Capacity),
+		done: make(chan struct{}),
+	}
+}
+
+// Pending returns the number of jobs waiting to be run
+func (q *Queue[T]) Pending() int {
+	return len(q.jobs)
+}
+
+// Failed returns the number of jobs dropped after exhausting their attempts
+func (q *Queue[T]) Failed() int64 {
+	return q.failed.Load()
+}
+
+// Start runs the worker that processes queued jobs until Stop is called
+func (q *Queue[T]) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	q.cancel = cancel
+
+	go func() {
+		defer close(q.done)
+		for job := range q.jobs {
+			q.process(ctx, job)
+		}
+	}()
+}
+
+// Stop stops accepting jobs and waits for the queued ones to be processed.
+// When ctx expires first, pending retries are abandoned and the remaining jobs get one last attempt.
+func (q *Queue[T]) Stop(ctx context.Context) {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	if q.cancel == nil {
+		return
+	}
+	select {
+	case <-q.done:
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+	}
+}
+
+// Enqueue adds the job to the queue without waiting for it to run
+func (q *Queue[T]) Enqueue(job T) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return q.opts.ErrClosed
+	}
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return q.opts.ErrFull
+	}
+}
+
+// process runs the job, retrying with exponential backoff until it succeeds or runs out of attempts.
+// Attempts don't see ctx being canceled: canceling it only skips the remaining backoff, so the last
+// attempts made after Stop gives up still get a chance to succeed.
+func (q *Queue[T]) process(ctx context.Context, job T) {
+	runCtx := context.WithoutCancel(ctx)
+	backoff := q.opts.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		if q.opts.BeforeAttempt != nil {
+			q.opts.BeforeAttempt(ctx)
+		}
+		err := q.opts.Run(runCtx, job)
+		if err == nil {
+			return
+		}
+
+		if attempt >= q.opts.MaxAttempts || ctx.Err() != nil {
+			q.failed.Add(1)
+			if q.opts.OnFailure != nil {
+				q.opts.OnFailure(job, attempt, err)
+			}
+			return
+		}
+
+		if q.opts.OnRetry != nil {
+			q.opts.OnRetry(job, attempt, backoff, err)
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,101 @@
+package retryqueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/infrastructure/retryqueue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyJob fails its first `failures` attempts, then succeeds
+type flakyJob struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (j *flakyJob) run(ctx context.Context, _ string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.attempts++
+	if j.attempts <= j.failures {
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func TestQueue_RetriesWithDoublingBackoff(t *testing.T) {
+	job := &flakyJob{failures: 2}
+	var backoffs []time.Duration
+	queue := retryqueue.New(retryqueue.Options[string]{
+		Capacity:       1,
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Run:            job.run,
+		OnRetry: func(_ string, _ int, backoff time.Duration, _ error) {
+			backoffs = append(backoffs, backoff)
+		},
+	})
+	queue.Start(context.Background())
+
+	assert.NoError(t, queue.Enqueue("job"))
+	queue.Stop(context.Background())
+
+	assert.Equal(t, 3, job.attempts)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, backoffs)
+	assert.Zero(t, queue.Failed())
+}
+
+func TestQueue_GivesUpAfterMaxAttempts(t *testing.T) {
+	job := &flakyJob{failures: 10}
+	failedAfter := 0
+	queue := retryqueue.New(retryqueue.Options[string]{
+		Capacity:       1,
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+		Run:            job.run,
+		OnFailure:      func(_ string, attempts int, _ error) { failedAfter = attempts },
+	})
+	queue.Start(context.Background())
+
+	assert.NoError(t, queue.Enqueue("job"))
+	queue.Stop(context.Background())
+
+	assert.Equal(t, 2, failedAfter)
+	assert.Equal(t, int64(1), queue.Failed())
+}
+
+func TestQueue_StopAbandonsRetriesAtDeadline(t *testing.T) {
+	job := &flakyJob{failures: 10}
+	queue := retryqueue.New(retryqueue.Options[string]{
+		Capacity:       1,
+		MaxAttempts:    5,
+		InitialBackoff: time.Hour,
+		Run:            job.run,
+	})
+	queue.Start(context.Background())
+
+	assert.NoError(t, queue.Enqueue("job"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	queue.Stop(ctx)
+
+	assert.Equal(t, int64(1), queue.Failed())
+}
+
+func TestQueue_EnqueueErrors(t *testing.T) {
+	errFull := errors.New("custom full")
+	// Not started, so nothing drains the queue
+	queue := retryqueue.New(retryqueue.Options[string]{Capacity: 1, ErrFull: errFull})
+
+	assert.NoError(t, queue.Enqueue("first"))
+	assert.ErrorIs(t, queue.Enqueue("second"), errFull)
+
+	queue.Stop(context.Background())
+	assert.ErrorIs(t, queue.Enqueue("third"), retryqueue.ErrClosed)
+}
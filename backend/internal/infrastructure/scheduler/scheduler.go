@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Job is a unit of periodic background work
+type Job func(ctx context.Context) error
+
+type scheduledJob struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs registered jobs on fixed intervals until stopped
+type Scheduler struct {
+	jobs   []scheduledJob
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a job to run once per interval. Jobs must be registered before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, interval: interval, run: job})
+}
+
+// Start runs every registered job in its own goroutine until ctx is cancelled or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job scheduledJob) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job.run(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Scheduled job failed", err, zap.String("job", job.name))
+			}
+		}
+	}
+}
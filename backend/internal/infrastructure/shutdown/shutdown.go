@@ -0,0 +1,80 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// Step stops one subsystem, draining its work until ctx is done
+type Step func(ctx context.Context) error
+
+type namedStep struct {
+	name string
+	stop Step
+}
+
+// Coordinator stops the application's subsystems in order within one overall timeout
+type Coordinator struct {
+	timeout time.Duration
+	steps   []namedStep
+}
+
+// New creates a coordinator that gives all subsystems together timeout to stop
+func New(timeout time.Duration) *Coordinator {
+	return &Coordinator{timeout: timeout}
+}
+
+// Add registers a subsystem. Subsystems are stopped in the order they were added,
+// so add producers of work before the consumers that drain it.
+func (c *Coordinator) Add(name string, stop Step) {
+	c.steps = append(c.steps, namedStep{name: name, stop: stop})
+}
+
+// Shutdown stops every subsystem, logging as each one finishes. A subsystem still stopping when the
+// timeout expires is abandoned, and the rest are then only told to stop without being waited for.
+// The returned error names the subsystems that failed or did not stop in time.
+func (c *Coordinator) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var failed []string
+	for _, step := range c.steps {
+		started := time.Now()
+		done := make(chan error, 1)
+		go func() {
+			done <- step.stop(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Error("Subsystem failed to stop", err, zap.String("subsystem", step.name))
+				failed = append(failed, step.name)
+				continue
+			}
+			logger.Info("Subsystem stopped", zap.String("subsystem", step.name), zap.Duration("took", time.Since(started)))
+		case <-ctx.Done():
+			logger.Warn("Subsystem did not stop before the shutdown timeout", zap.String("subsystem", step.name))
+			failed = append(failed, step.name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("shutdown incomplete: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Wait adapts a blocking wait, such as a sync.WaitGroup's, to a Step
+func Wait(wait func()) Step {
+	return func(ctx context.Context) error {
+		wait()
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package shutdown_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/infrastructure/logger"
+	"backend/internal/infrastructure/shutdown"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinator_StopsSubsystemsInOrder(t *testing.T) {
+	logger.Init("release")
+	coordinator := shutdown.New(time.Second)
+
+	var stopped []string
+	for _, name := range []string{"scheduler", "http_server", "email_queue"} {
+		coordinator.Add(name, func(ctx context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		})
+	}
+
+	require.NoError(t, coordinator.Shutdown())
+	assert.Equal(t, []string{"scheduler", "http_server", "email_queue"}, stopped)
+}
+
+func TestCoordinator_WaitDrainsGoroutines(t *testing.T) {
+	logger.Init("release")
+	coordinator := shutdown.New(time.Second)
+
+	var wg sync.WaitGroup
+	finished := false
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished = true
+	}()
+	coordinator.Add("workers", shutdown.Wait(wg.Wait))
+
+	require.NoError(t, coordinator.Shutdown())
+	assert.True(t, finished)
+}
+
+func TestCoordinator_ReportsFailuresAndTimeouts(t *testing.T) {
+	logger.Init("release")
+	coordinator := shutdown.New(50 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	lateStepErr := make(chan error, 1)
+	coordinator.Add("broken", func(ctx context.Context) error { return errors.New("boom") })
+	coordinator.Add("stuck", shutdown.Wait(func() { <-release }))
+	coordinator.Add("late", func(ctx context.Context) error {
+		// Steps after the timeout are still told to stop, with an expired context
+		lateStepErr <- ctx.Err()
+		return ctx.Err()
+	})
+
+	started := time.Now()
+	err := coordinator.Shutdown()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+	assert.Contains(t, err.Error(), "stuck")
+	assert.Contains(t, err.Error(), "late")
+	assert.Less(t, time.Since(started), time.Second)
+	select {
+	case err := <-lateStepErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("late step was not run")
+	}
+}
@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"backend/internal/domain/event"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/infrastructure/retryqueue"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Headers sent with every delivery
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the body, keyed with the shared secret
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader carries the event name, e.g. user.registered
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader carries the payload ID, the same across retries so receivers can drop duplicates
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+var (
+	// ErrQueueFull is returned when an event arrives while every queue slot is taken
+	ErrQueueFull = errors.New("webhook queue is full")
+	// ErrQueueClosed is returned when an event arrives after the dispatcher was stopped
+	ErrQueueClosed = errors.New("webhook queue is closed")
+)
+
+// Events are the domain events forwarded to webhooks
+var Events = []string{event.UserRegistered, event.UserVerified}
+
+// Payload is the JSON body POSTed to each endpoint
+type Payload struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// UserRegisteredData is the payload data of a user.registered event
+type UserRegisteredData struct {
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	Provider string `json:"provider,omitempty"` // OAuth provider the account signed up with; empty for password sign-ups
+}
+
+// UserVerifiedData is the payload data of a user.verified event
+type UserVerifiedData struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
+
+// delivery is a payload queued for one endpoint
+type delivery struct {
+	id    string
+	event string
+	url   string
+	body  []byte
+}
+
+// WebhookDispatcher POSTs signed event payloads to the configured endpoints in the background,
+// retrying failed deliveries with exponential backoff. Its Handle method is an event.Handler:
+// subscribe it to the Events on the event bus.
+type WebhookDispatcher struct {
+	urls    []string
+	secret  []byte
+	client  *http.Client
+	timeout time.Duration
+	queue   *retryqueue.Queue[delivery]
+}
+
+// NewWebhookDispatcher creates a dispatcher delivering to urls, signing payloads with secret.
+// Up to capacity deliveries are held in memory. Each attempt is bounded by timeout; a delivery is
+// attempted up to maxAttempts times, waiting initialBackoff after the first failure and doubling
+// the wait after each further failure.
+func NewWebhookDispatcher(urls []string, secret string, timeout time.Duration, capacity, maxAttempts int, initialBackoff time.Duration) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		urls:    urls,
+		secret:  []byte(secret),
+		client:  &http.Client{},
+		timeout: timeout,
+	}
+	d.queue = retryqueue.New(retryqueue.Options[delivery]{
+		Capacity:       capacity,
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: initialBackoff,
+		Run:            d.send,
+		OnRetry: func(delivery delivery, attempt int, backoff time.Duration, err error) {
+			logger.Warn("Webhook delivery failed, retrying",
+				zap.String("event", delivery.event),
+				zap.String("host", host(delivery.url)),
+				zap.Int("attempt", attempt),
+				zap.Duration("backoff", backoff),
+				zap.Error(err),
+			)
+		},
+		OnFailure: func(delivery delivery, attempts int, err error) {
+			logger.Error("Webhook failed after retries", err,
+				zap.String("event", delivery.event),
+				zap.String("delivery", delivery.id),
+				zap.String("host", host(delivery.url)),
+				zap.Int("attempts", attempts),
+			)
+		},
+		ErrFull:   ErrQueueFull,
+		ErrClosed: ErrQueueClosed,
+	})
+	return d
+}
+
+// Sign returns the SignatureHeader value of body, for receivers to compare with hmac.Equal
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handle queues a delivery of the event to every endpoint and returns without waiting for them.
+// Events other than Events are ignored.
+func (d *WebhookDispatcher) Handle(ctx context.Context, e event.Event) error {
+	payload := Payload{ID: uuid.New().String(), Event: e.Name()}
+	switch e := e.(type) {
+	case event.UserRegisteredEvent:
+		payload.OccurredAt = e.OccurredAt
+		payload.Data = UserRegisteredData{UserID: e.UserID, Email: e.Email, Provider: e.Provider}
+	case event.UserVerifiedEvent:
+		payload.OccurredAt = e.OccurredAt
+		payload.Data = UserVerifiedData{UserID: e.UserID, Email: e.Email, Name: e.UserName}
+	default:
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	for _, endpoint := range d.urls {
+		if err := d.enqueue(delivery{id: payload.ID, event: payload.Event, url: endpoint, body: body}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of deliveries waiting to be sent
+func (d *WebhookDispatcher) Pending() int {
+	return d.queue.Pending()
+}
+
+// Failed returns the number of deliveries dropped after exhausting their attempts
+func (d *WebhookDispatcher) Failed() int64 {
+	return d.queue.Failed()
+}
+
+// Start runs the worker that sends queued deliveries until Stop is called
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	d.queue.Start(ctx)
+}
+
+// Stop stops accepting events and waits for the queued deliveries to be sent.
+// See retryqueue.Queue.Stop for what happens when ctx expires first.
+func (d *WebhookDispatcher) Stop(ctx context.Context) {
+	d.queue.Stop(ctx)
+}
+
+func (d *WebhookDispatcher) enqueue(delivery delivery) error {
+	return d.queue.Enqueue(delivery)
+}
+
+// send makes one delivery attempt, bounded by d.timeout; any status other than 2xx is a failure
+func (d *WebhookDispatcher) send(ctx context.Context, delivery delivery) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.event)
+	req.Header.Set(DeliveryHeader, delivery.id)
+	req.Header.Set(SignatureHeader, Sign(d.secret, delivery.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// host returns the host of an endpoint for logging, leaving out paths and queries that may hold tokens
+func host(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,217 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/domain/event"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/infrastructure/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "webhook-secret"
+
+// receivedRequest is a delivery as seen by the endpoint
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// recorder is an endpoint answering each delivery with the next status, then 200
+type recorder struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, receivedRequest{header: req.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	r.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+func (r *recorder) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func newDispatcher(t *testing.T, urls []string, maxAttempts int, timeout time.Duration) *webhook.WebhookDispatcher {
+	t.Helper()
+	logger.Init("release")
+	dispatcher := webhook.NewWebhookDispatcher(urls, secret, timeout, 10, maxAttempts, time.Millisecond)
+	dispatcher.Start(context.Background())
+	return dispatcher
+}
+
+func stop(t *testing.T, dispatcher *webhook.WebhookDispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dispatcher.Stop(ctx)
+}
+
+func TestHandle_PostsSignedPayload(t *testing.T) {
+	endpoint := &recorder{}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	dispatcher := newDispatcher(t, []string{server.URL}, 1, time.Second)
+	occurredAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	err := dispatcher.Handle(context.Background(), event.UserRegisteredEvent{
+		UserID: "user-1", Email: "alice@example.com", Provider: "github", OccurredAt: occurredAt,
+	})
+	require.NoError(t, err)
+	stop(t, dispatcher)
+
+	requests := endpoint.received()
+	require.Len(t, requests, 1)
+	req := requests[0]
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(req.body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.header.Get(webhook.SignatureHeader))
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, event.UserRegistered, req.header.Get(webhook.EventHeader))
+
+	var payload struct {
+		ID         string                     `json:"id"`
+		Event      string                     `json:"event"`
+		OccurredAt time.Time                  `json:"occurred_at"`
+		Data       webhook.UserRegisteredData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, req.header.Get(webhook.DeliveryHeader), payload.ID)
+	assert.Equal(t, event.UserRegistered, payload.Event)
+	assert.True(t, occurredAt.Equal(payload.OccurredAt))
+	assert.Equal(t, webhook.UserRegisteredData{UserID: "user-1", Email: "alice@example.com", Provider: "github"}, payload.Data)
+}
+
+func TestHandle_DeliversToEveryEndpoint(t *testing.T) {
+	first, second := &recorder{}, &recorder{}
+	firstServer, secondServer := httptest.NewServer(first), httptest.NewServer(second)
+	t.Cleanup(firstServer.Close)
+	t.Cleanup(secondServer.Close)
+	dispatcher := newDispatcher(t, []string{firstServer.URL, secondServer.URL}, 1, time.Second)
+
+	err := dispatcher.Handle(context.Background(), event.UserVerifiedEvent{UserID: "user-1", Email: "alice@example.com", UserName: "Alice"})
+	require.NoError(t, err)
+	stop(t, dispatcher)
+
+	require.Len(t, first.received(), 1)
+	require.Len(t, second.received(), 1)
+	assert.Equal(t, first.received()[0].body, second.received()[0].body)
+	var payload struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(first.received()[0].body, &payload))
+	assert.JSONEq(t, `{"user_id":"user-1","email":"alice@example.com","name":"Alice"}`, string(payload.Data))
+}
+
+func TestHandle_RetriesFailedDelivery(t *testing.T) {
+	endpoint := &recorder{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	dispatcher := newDispatcher(t, []string{server.URL}, 3, time.Second)
+
+	require.NoError(t, dispatcher.Handle(context.Background(), event.UserVerifiedEvent{UserID: "user-1"}))
+	stop(t, dispatcher)
+
+	requests := endpoint.received()
+	require.Len(t, requests, 3)
+	// Retries resend the same payload, so receivers can drop duplicates by ID
+	assert.Equal(t, requests[0].body, requests[2].body)
+	assert.Equal(t, requests[0].header.Get(webhook.DeliveryHeader), requests[2].header.Get(webhook.DeliveryHeader))
+	assert.Zero(t, dispatcher.Failed())
+}
+
+func TestHandle_GivesUpAfterMaxAttempts(t *testing.T) {
+	endpoint := &recorder{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	dispatcher := newDispatcher(t, []string{server.URL}, 2, time.Second)
+
+	require.NoError(t, dispatcher.Handle(context.Background(), event.UserVerifiedEvent{UserID: "user-1"}))
+	stop(t, dispatcher)
+
+	assert.Len(t, endpoint.received(), 2)
+	assert.Equal(t, int64(1), dispatcher.Failed())
+}
+
+func TestHandle_TimesOutSlowEndpointWithoutBlockingCaller(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	dispatcher := newDispatcher(t, []string{server.URL}, 1, 100*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, dispatcher.Handle(context.Background(), event.UserVerifiedEvent{UserID: "user-1"}))
+	// The delivery is still in flight when Handle returns
+	assert.Zero(t, dispatcher.Failed())
+
+	stop(t, dispatcher)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int64(1), dispatcher.Failed())
+}
+
+func TestHandle_IgnoresOtherEvents(t *testing.T) {
+	endpoint := &recorder{}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	dispatcher := newDispatcher(t, []string{server.URL}, 1, time.Second)
+
+	require.NoError(t, dispatcher.Handle(context.Background(), event.MessageSentEvent{MessageID: "message-1"}))
+	stop(t, dispatcher)
+
+	assert.Empty(t, endpoint.received())
+}
+
+func TestHandle_QueueFull(t *testing.T) {
+	logger.Init("release")
+	// Not started, so nothing drains the single slot
+	dispatcher := webhook.NewWebhookDispatcher([]string{"http://a.example.com", "http://b.example.com"}, secret, time.Second, 1, 1, time.Millisecond)
+
+	err := dispatcher.Handle(context.Background(), event.UserVerifiedEvent{UserID: "user-1"})
+
+	assert.ErrorIs(t, err, webhook.ErrQueueFull)
+	assert.Equal(t, 1, dispatcher.Pending())
+}
+
+func TestHandle_AfterStop(t *testing.T) {
+	dispatcher := newDispatcher(t, []string{"http://hooks.example.com"}, 1, time.Second)
+	stop(t, dispatcher)
+
+	err := dispatcher.Handle(context.Background(), event.UserVerifiedEvent{UserID: "user-1"})
+
+	assert.ErrorIs(t, err, webhook.ErrQueueClosed)
+}
+
+func TestSign(t *testing.T) {
+	signature := webhook.Sign([]byte("key"), []byte("The quick brown fox jumps over the lazy dog"))
+
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", signature)
+}
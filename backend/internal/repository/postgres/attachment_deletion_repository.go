@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PendingAttachmentDeletionModel represents the GORM database model for a Cloudinary attachment awaiting deletion
+type PendingAttachmentDeletionModel struct {
+	PublicID     string    `gorm:"primaryKey;column:public_id"`
+	ResourceType string    `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for PendingAttachmentDeletionModel
+func (PendingAttachmentDeletionModel) TableName() string {
+	return "pending_attachment_deletions"
+}
+
+type attachmentDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentDeletionRepository creates a new Postgres-backed list of attachments awaiting deletion
+func NewAttachmentDeletionRepository(db *gorm.DB) repository.AttachmentDeletionRepository {
+	return &attachmentDeletionRepository{db: db}
+}
+
+func (r *attachmentDeletionRepository) Add(ctx context.Context, attachment *entity.Attachment) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&PendingAttachmentDeletionModel{PublicID: attachment.PublicID, ResourceType: attachment.ResourceType}).Error
+}
+
+// List returns up to limit attachments, oldest first
+func (r *attachmentDeletionRepository) List(ctx context.Context, limit int) ([]*entity.Attachment, error) {
+	var models []PendingAttachmentDeletionModel
+	err := conn(ctx, r.db).
+		Order("created_at").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*entity.Attachment, len(models))
+	for i, model := range models {
+		attachments[i] = &entity.Attachment{PublicID: model.PublicID, ResourceType: model.ResourceType}
+	}
+	return attachments, nil
+}
+
+func (r *attachmentDeletionRepository) Remove(ctx context.Context, publicID string) error {
+	return conn(ctx, r.db).Delete(&PendingAttachmentDeletionModel{}, "public_id = ?", publicID).Error
+}
@@ -0,0 +1,35 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain/entity"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentDeletionRepository_AddListRemove(t *testing.T) {
+	repo := postgres.NewAttachmentDeletionRepository(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Add(ctx, &entity.Attachment{PublicID: "chat/conv_1/a", ResourceType: "image"}))
+	require.NoError(t, repo.Add(ctx, &entity.Attachment{PublicID: "chat/conv_1/b", ResourceType: "raw"}))
+	// Adding the same asset twice keeps a single entry
+	require.NoError(t, repo.Add(ctx, &entity.Attachment{PublicID: "chat/conv_1/a", ResourceType: "image"}))
+
+	attachments, err := repo.List(ctx, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*entity.Attachment{
+		{PublicID: "chat/conv_1/a", ResourceType: "image"},
+		{PublicID: "chat/conv_1/b", ResourceType: "raw"},
+	}, attachments)
+
+	require.NoError(t, repo.Remove(ctx, "chat/conv_1/a"))
+
+	attachments, err = repo.List(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.Attachment{{PublicID: "chat/conv_1/b", ResourceType: "raw"}}, attachments)
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PendingAvatarDeletionModel represents the GORM database model for a Cloudinary avatar awaiting deletion
+type PendingAvatarDeletionModel struct {
+	PublicID  string    `gorm:"primaryKey;column:public_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+// TableName specifies the table name for PendingAvatarDeletionModel
+func (PendingAvatarDeletionModel) TableName() string {
+	return "pending_avatar_deletions"
+}
+
+type avatarDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewAvatarDeletionRepository creates a new Postgres-backed list of avatars awaiting deletion
+func NewAvatarDeletionRepository(db *gorm.DB) repository.AvatarDeletionRepository {
+	return &avatarDeletionRepository{db: db}
+}
+
+func (r *avatarDeletionRepository) Add(ctx context.Context, publicID string) error {
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&PendingAvatarDeletionModel{PublicID: publicID}).Error
+}
+
+// List returns up to limit public IDs, oldest first
+func (r *avatarDeletionRepository) List(ctx context.Context, limit int) ([]string, error) {
+	var publicIDs []string
+	err := conn(ctx, r.db).
+		Model(&PendingAvatarDeletionModel{}).
+		Order("created_at").
+		Limit(limit).
+		Pluck("public_id", &publicIDs).Error
+	return publicIDs, err
+}
+
+func (r *avatarDeletionRepository) Remove(ctx context.Context, publicID string) error {
+	return conn(ctx, r.db).Delete(&PendingAvatarDeletionModel{}, "public_id = ?", publicID).Error
+}
@@ -0,0 +1,43 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvatarDeletionRepository_AddListRemove(t *testing.T) {
+	repo := postgres.NewAvatarDeletionRepository(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Add(ctx, "avatars/user_1"))
+	require.NoError(t, repo.Add(ctx, "avatars/user_2"))
+	// Adding the same asset twice keeps a single entry
+	require.NoError(t, repo.Add(ctx, "avatars/user_1"))
+
+	publicIDs, err := repo.List(ctx, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"avatars/user_1", "avatars/user_2"}, publicIDs)
+
+	require.NoError(t, repo.Remove(ctx, "avatars/user_1"))
+
+	publicIDs, err = repo.List(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"avatars/user_2"}, publicIDs)
+}
+
+func TestAvatarDeletionRepository_ListLimit(t *testing.T) {
+	repo := postgres.NewAvatarDeletionRepository(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Add(ctx, "avatars/user_1"))
+	require.NoError(t, repo.Add(ctx, "avatars/user_2"))
+
+	publicIDs, err := repo.List(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, publicIDs, 1)
+}
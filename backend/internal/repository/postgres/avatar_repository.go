@@ -37,12 +37,12 @@ func NewAvatarRepository(db *gorm.DB) repository.AvatarRepository {
 
 func (r *avatarRepository) Create(ctx context.Context, avatar *entity.Avatar) error {
 	model := r.toModel(avatar)
-	return r.db.WithContext(ctx).Create(model).Error
+	return conn(ctx, r.db).Create(model).Error
 }
 
 func (r *avatarRepository) GetByUserID(ctx context.Context, userID string) (*entity.Avatar, error) {
 	var model AvatarModel
-	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&model).Error
+	err := conn(ctx, r.db).Where("user_id = ?", userID).First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrUserNotFound
 	}
@@ -52,13 +52,29 @@ func (r *avatarRepository) GetByUserID(ctx context.Context, userID string) (*ent
 	return r.toEntity(&model), nil
 }
 
+func (r *avatarRepository) GetByUserIDs(ctx context.Context, userIDs []string) (map[string]*entity.Avatar, error) {
+	avatars := make(map[string]*entity.Avatar, len(userIDs))
+	if len(userIDs) == 0 {
+		return avatars, nil
+	}
+
+	var models []AvatarModel
+	if err := conn(ctx, r.db).Where("user_id IN ?", userIDs).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	for i := range models {
+		avatars[models[i].UserID] = r.toEntity(&models[i])
+	}
+	return avatars, nil
+}
+
 func (r *avatarRepository) Update(ctx context.Context, avatar *entity.Avatar) error {
 	model := r.toModel(avatar)
-	return r.db.WithContext(ctx).Save(model).Error
+	return conn(ctx, r.db).Save(model).Error
 }
 
 func (r *avatarRepository) Delete(ctx context.Context, userID string) error {
-	return r.db.WithContext(ctx).Delete(&AvatarModel{}, "user_id = ?", userID).Error
+	return conn(ctx, r.db).Delete(&AvatarModel{}, "user_id = ?", userID).Error
 }
 
 // toModel converts domain entity to GORM model
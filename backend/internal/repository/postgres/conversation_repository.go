@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// directKeyIndex is the unique index that allows one direct conversation per pair of users
+const directKeyIndex = "idx_conversations_direct_key"
+
+// ConversationModel represents the GORM database model for conversations
+type ConversationModel struct {
+	ID   string `gorm:"primaryKey;type:uuid"`
+	Type string `gorm:"not null"`
+	Name string `gorm:"not null;default:''"`
+	// DirectKey holds the sorted participant pair of a direct conversation and is NULL for groups,
+	// so two users starting a conversation at once can't create two
+	DirectKey *string   `gorm:"uniqueIndex:idx_conversations_direct_key"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index"`
+}
+
+// TableName specifies the table name for ConversationModel
+func (ConversationModel) TableName() string {
+	return "conversations"
+}
+
+// ConversationParticipantModel represents the GORM database model for conversation participants
+type ConversationParticipantModel struct {
+	ConversationID string    `gorm:"primaryKey;type:uuid"`
+	UserID         string    `gorm:"primaryKey;type:uuid;index"`
+	Muted          bool      `gorm:"not null;default:false"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for ConversationParticipantModel
+func (ConversationParticipantModel) TableName() string {
+	return "conversation_participants"
+}
+
+type conversationRepository struct {
+	db *gorm.DB
+}
+
+// NewConversationRepository creates a new conversation repository
+func NewConversationRepository(db *gorm.DB) repository.ConversationRepository {
+	return &conversationRepository{db: db}
+}
+
+// Create stores the conversation together with its participants. It returns ErrConversationExists
+// when the two users of a direct conversation already have one.
+func (r *conversationRepository) Create(ctx context.Context, conversation *entity.Conversation) error {
+	model := &ConversationModel{
+		ID:        conversation.ID,
+		Type:      conversation.Type,
+		Name:      conversation.Name,
+		CreatedAt: conversation.CreatedAt,
+		UpdatedAt: conversation.UpdatedAt,
+	}
+	if conversation.Type == entity.ConversationTypeDirect {
+		model.DirectKey = directKey(conversation.ParticipantIDs)
+	}
+	participants := make([]ConversationParticipantModel, len(conversation.ParticipantIDs))
+	for i, userID := range conversation.ParticipantIDs {
+		participants[i] = ConversationParticipantModel{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+			CreatedAt:      conversation.CreatedAt,
+		}
+	}
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		return tx.Create(&participants).Error
+	})
+	return translateConversationError(err)
+}
+
+func (r *conversationRepository) GetByID(ctx context.Context, id string) (*entity.Conversation, error) {
+	var model ConversationModel
+	err := conn(ctx, r.db).Where("id = ?", id).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, err := r.toEntities(ctx, []ConversationModel{model})
+	if err != nil {
+		return nil, err
+	}
+	return conversations[0], nil
+}
+
+// ListForUser returns the conversations the user takes part in, most recently updated first
+func (r *conversationRepository) ListForUser(ctx context.Context, userID string) ([]*entity.Conversation, error) {
+	var models []ConversationModel
+	err := conn(ctx, r.db).
+		Joins("JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id").
+		Where("conversation_participants.user_id = ?", userID).
+		Order("conversations.updated_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
+func (r *conversationRepository) FindDirectBetween(ctx context.Context, userA, userB string) (*entity.Conversation, error) {
+	var model ConversationModel
+	err := conn(ctx, r.db).
+		Joins("JOIN conversation_participants a ON a.conversation_id = conversations.id AND a.user_id = ?", userA).
+		Joins("JOIN conversation_participants b ON b.conversation_id = conversations.id AND b.user_id = ?", userB).
+		Where("conversations.type = ?", entity.ConversationTypeDirect).
+		Where("(SELECT COUNT(*) FROM conversation_participants p WHERE p.conversation_id = conversations.id) = 2").
+		Order("conversations.created_at").
+		First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conversations, err := r.toEntities(ctx, []ConversationModel{model})
+	if err != nil {
+		return nil, err
+	}
+	return conversations[0], nil
+}
+
+func (r *conversationRepository) SetMuted(ctx context.Context, conversationID, userID string, muted bool) error {
+	return conn(ctx, r.db).
+		Model(&ConversationParticipantModel{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		UpdateColumn("muted", muted).Error
+}
+
+// directKey returns the DirectKey of a conversation between the participants, the same in either order
+func directKey(participantIDs []string) *string {
+	ids := slices.Clone(participantIDs)
+	slices.Sort(ids)
+	key := strings.Join(ids, ":")
+	return &key
+}
+
+// translateConversationError turns a direct key unique violation, e.g. from two users starting
+// the same direct conversation at once, into ErrConversationExists
+func translateConversationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr *pgconn.PgError
+	if stderrors.As(err, &pgErr) {
+		if pgErr.Code == "23505" && pgErr.ConstraintName == directKeyIndex {
+			return errors.ErrConversationExists
+		}
+		return err
+	}
+	// SQLite, used in tests, names the column instead of the index
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: conversations.direct_key") {
+		return errors.ErrConversationExists
+	}
+	return err
+}
+
+// toEntities converts GORM models to domain entities, loading the participants of all conversations in one query
+func (r *conversationRepository) toEntities(ctx context.Context, models []ConversationModel) ([]*entity.Conversation, error) {
+	if len(models) == 0 {
+		return []*entity.Conversation{}, nil
+	}
+
+	ids := make([]string, len(models))
+	for i := range models {
+		ids[i] = models[i].ID
+	}
+
+	var participants []ConversationParticipantModel
+	err := conn(ctx, r.db).
+		Where("conversation_id IN ?", ids).
+		Order("created_at, user_id").
+		Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	participantIDs := make(map[string][]string, len(models))
+	mutedIDs := make(map[string][]string)
+	for _, p := range participants {
+		participantIDs[p.ConversationID] = append(participantIDs[p.ConversationID], p.UserID)
+		if p.Muted {
+			mutedIDs[p.ConversationID] = append(mutedIDs[p.ConversationID], p.UserID)
+		}
+	}
+
+	conversations := make([]*entity.Conversation, len(models))
+	for i := range models {
+		conversations[i] = &entity.Conversation{
+			ID:                  models[i].ID,
+			Type:                models[i].Type,
+			Name:                models[i].Name,
+			ParticipantIDs:      participantIDs[models[i].ID],
+			MutedParticipantIDs: mutedIDs[models[i].ID],
+			CreatedAt:           models[i].CreatedAt,
+			UpdatedAt:           models[i].UpdatedAt,
+		}
+	}
+	return conversations, nil
+}
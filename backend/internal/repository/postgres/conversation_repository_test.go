@@ -0,0 +1,121 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationRepository_CreateAndGetByID(t *testing.T) {
+	repo := postgres.NewConversationRepository(newTestDB(t))
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, repo.Create(ctx, conversation))
+
+	found, err := repo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.ConversationTypeDirect, found.Type)
+	assert.ElementsMatch(t, []string{"user-1", "user-2"}, found.ParticipantIDs)
+}
+
+func TestConversationRepository_GetByIDNotFound(t *testing.T) {
+	repo := postgres.NewConversationRepository(newTestDB(t))
+
+	_, err := repo.GetByID(context.Background(), "missing")
+
+	assert.Equal(t, errors.ErrConversationNotFound, err)
+}
+
+func TestConversationRepository_ListForUser(t *testing.T) {
+	repo := postgres.NewConversationRepository(newTestDB(t))
+	ctx := context.Background()
+
+	older := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	older.CreatedAt = time.Now().Add(-time.Hour)
+	older.UpdatedAt = older.CreatedAt
+	newer := entity.NewConversation(entity.ConversationTypeGroup, "Team", []string{"user-1", "user-2", "user-3"})
+	other := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-2", "user-3"})
+	for _, c := range []*entity.Conversation{older, newer, other} {
+		require.NoError(t, repo.Create(ctx, c))
+	}
+
+	conversations, err := repo.ListForUser(ctx, "user-1")
+	require.NoError(t, err)
+
+	// Only the user's conversations, most recently updated first, with every participant loaded
+	require.Len(t, conversations, 2)
+	assert.Equal(t, newer.ID, conversations[0].ID)
+	assert.Equal(t, "Team", conversations[0].Name)
+	assert.ElementsMatch(t, []string{"user-1", "user-2", "user-3"}, conversations[0].ParticipantIDs)
+	assert.Equal(t, older.ID, conversations[1].ID)
+
+	conversations, err = repo.ListForUser(ctx, "user-4")
+	require.NoError(t, err)
+	assert.Empty(t, conversations)
+}
+
+func TestConversationRepository_SetMutedIsPerParticipant(t *testing.T) {
+	repo := postgres.NewConversationRepository(newTestDB(t))
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeGroup, "Team", []string{"user-1", "user-2", "user-3"})
+	require.NoError(t, repo.Create(ctx, conversation))
+
+	require.NoError(t, repo.SetMuted(ctx, conversation.ID, "user-2", true))
+	found, err := repo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.True(t, found.IsMutedBy("user-2"))
+	assert.False(t, found.IsMutedBy("user-1"))
+	assert.False(t, found.IsMutedBy("user-3"))
+
+	require.NoError(t, repo.SetMuted(ctx, conversation.ID, "user-2", false))
+	found, err = repo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.MutedParticipantIDs)
+	assert.ElementsMatch(t, []string{"user-1", "user-2", "user-3"}, found.ParticipantIDs)
+}
+
+func TestConversationRepository_FindDirectBetween(t *testing.T) {
+	repo := postgres.NewConversationRepository(newTestDB(t))
+	ctx := context.Background()
+
+	direct := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	group := entity.NewConversation(entity.ConversationTypeGroup, "Pair", []string{"user-1", "user-3"})
+	other := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-2", "user-3"})
+	for _, c := range []*entity.Conversation{direct, group, other} {
+		require.NoError(t, repo.Create(ctx, c))
+	}
+
+	// The participants may be given in either order
+	found, err := repo.FindDirectBetween(ctx, "user-2", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, direct.ID, found.ID)
+
+	// A group with the same two members is not a direct conversation
+	_, err = repo.FindDirectBetween(ctx, "user-1", "user-3")
+	assert.Equal(t, errors.ErrConversationNotFound, err)
+}
+
+func TestConversationRepository_CreateRejectsSecondDirectConversation(t *testing.T) {
+	repo := postgres.NewConversationRepository(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})))
+
+	// The same pair in the other order
+	err := repo.Create(ctx, entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-2", "user-1"}))
+	assert.Equal(t, errors.ErrConversationExists, err)
+
+	// Groups with the same members are not limited
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repo.Create(ctx, entity.NewConversation(entity.ConversationTypeGroup, "Pair", []string{"user-1", "user-2"})))
+	}
+}
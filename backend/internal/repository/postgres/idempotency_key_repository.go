@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyModel represents the GORM database model for processed idempotency keys
+type IdempotencyKeyModel struct {
+	UserID    string    `gorm:"primaryKey;type:uuid"`
+	Key       string    `gorm:"primaryKey;column:key"`
+	MessageID string    `gorm:"type:uuid;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for IdempotencyKeyModel
+func (IdempotencyKeyModel) TableName() string {
+	return "idempotency_keys"
+}
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository
+func NewIdempotencyKeyRepository(db *gorm.DB) repository.IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{db: db}
+}
+
+func (r *idempotencyKeyRepository) Claim(ctx context.Context, userID, key, messageID string, expiresAt time.Time) (string, error) {
+	model := &IdempotencyKeyModel{
+		UserID:    userID,
+		Key:       key,
+		MessageID: messageID,
+		ExpiresAt: expiresAt,
+	}
+	// An expired key that the cleanup job hasn't removed yet is taken over rather than replayed
+	err := conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"message_id", "expires_at", "created_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "idempotency_keys.expires_at < ?", Vars: []interface{}{time.Now()}},
+			}},
+		}).
+		Create(model).Error
+	if err != nil {
+		return "", err
+	}
+
+	var stored IdempotencyKeyModel
+	err = conn(ctx, r.db).
+		Where("user_id = ? AND key = ?", userID, key).
+		First(&stored).Error
+	if err != nil {
+		return "", err
+	}
+	return stored.MessageID, nil
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("expires_at < ?", time.Now()).
+		Delete(&IdempotencyKeyModel{})
+	return result.RowsAffected, result.Error
+}
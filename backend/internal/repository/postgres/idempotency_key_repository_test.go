@@ -0,0 +1,72 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/repository/postgres"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeyRepository_ClaimReturnsFirstMessage(t *testing.T) {
+	repo := postgres.NewIdempotencyKeyRepository(newTestDB(t))
+	ctx := context.Background()
+	userID, first, second := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	expiresAt := time.Now().Add(time.Hour)
+
+	bound, err := repo.Claim(ctx, userID, "key-1", first, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, first, bound)
+
+	// Replaying the key keeps the original message
+	bound, err = repo.Claim(ctx, userID, "key-1", second, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, first, bound)
+}
+
+func TestIdempotencyKeyRepository_KeysAreScopedPerUser(t *testing.T) {
+	repo := postgres.NewIdempotencyKeyRepository(newTestDB(t))
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	_, err := repo.Claim(ctx, uuid.NewString(), "key-1", uuid.NewString(), expiresAt)
+	require.NoError(t, err)
+
+	other := uuid.NewString()
+	bound, err := repo.Claim(ctx, uuid.NewString(), "key-1", other, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, other, bound)
+}
+
+func TestIdempotencyKeyRepository_ExpiredKeyIsTakenOver(t *testing.T) {
+	repo := postgres.NewIdempotencyKeyRepository(newTestDB(t))
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	_, err := repo.Claim(ctx, userID, "key-1", uuid.NewString(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	fresh := uuid.NewString()
+	bound, err := repo.Claim(ctx, userID, "key-1", fresh, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, fresh, bound)
+}
+
+func TestIdempotencyKeyRepository_DeleteExpired(t *testing.T) {
+	repo := postgres.NewIdempotencyKeyRepository(newTestDB(t))
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	_, err := repo.Claim(ctx, userID, "old", uuid.NewString(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = repo.Claim(ctx, userID, "fresh", uuid.NewString(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	deleted, err := repo.DeleteExpired(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// LoginEventModel represents the GORM database model for login history
+type LoginEventModel struct {
+	ID        string `gorm:"primaryKey;type:uuid"`
+	UserID    string `gorm:"type:uuid;not null;index:idx_login_events_user_created,priority:1"`
+	Method    string `gorm:"not null"`
+	IPAddress string `gorm:"column:ip_address"`
+	UserAgent string
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_login_events_user_created,priority:2"`
+}
+
+// TableName specifies the table name for LoginEventModel
+func (LoginEventModel) TableName() string {
+	return "login_events"
+}
+
+type loginEventRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginEventRepository creates a new login event repository
+func NewLoginEventRepository(db *gorm.DB) repository.LoginEventRepository {
+	return &loginEventRepository{db: db}
+}
+
+func (r *loginEventRepository) Record(ctx context.Context, event *entity.LoginEvent) error {
+	model := &LoginEventModel{
+		ID:        event.ID,
+		UserID:    event.UserID,
+		Method:    event.Method,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt,
+	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		// Only the one column, so a concurrent profile update isn't overwritten
+		return tx.Model(&UserModel{}).
+			Where("id = ?", event.UserID).
+			UpdateColumn("last_login_at", event.CreatedAt.UnixMilli()).Error
+	})
+}
+
+func (r *loginEventRepository) ListByUserID(ctx context.Context, userID string, limit int) ([]*entity.LoginEvent, error) {
+	var models []LoginEventModel
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*entity.LoginEvent, len(models))
+	for i := range models {
+		events[i] = r.toEntity(&models[i])
+	}
+	return events, nil
+}
+
+// toEntity converts GORM model to domain entity
+func (r *loginEventRepository) toEntity(model *LoginEventModel) *entity.LoginEvent {
+	return &entity.LoginEvent{
+		ID:        model.ID,
+		UserID:    model.UserID,
+		Method:    model.Method,
+		IPAddress: model.IPAddress,
+		UserAgent: model.UserAgent,
+		CreatedAt: model.CreatedAt,
+	}
+}
@@ -0,0 +1,53 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginEventRepository_RecordSetsLastLogin(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, nil)
+	repo := postgres.NewLoginEventRepository(db)
+	ctx := context.Background()
+	user := seedUsers(t, userRepo, "alice@example.com")[0]
+
+	event := entity.NewLoginEvent(user.ID, entity.LoginMethodPassword, "203.0.113.7", "test-agent")
+	require.NoError(t, repo.Record(ctx, event))
+
+	stored, err := userRepo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, event.CreatedAt.UnixMilli(), stored.LastLoginAt.UnixMilli())
+}
+
+func TestLoginEventRepository_ListByUserIDNewestFirst(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, nil)
+	repo := postgres.NewLoginEventRepository(db)
+	ctx := context.Background()
+	users := seedUsers(t, userRepo, "alice@example.com", "bob@example.com")
+
+	start := time.Now().Add(-time.Hour)
+	for i, method := range []string{entity.LoginMethodPassword, "google", "github"} {
+		event := entity.NewLoginEvent(users[0].ID, method, "203.0.113.7", "test-agent")
+		event.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.Record(ctx, event))
+	}
+	require.NoError(t, repo.Record(ctx, entity.NewLoginEvent(users[1].ID, entity.LoginMethodPassword, "", "")))
+
+	events, err := repo.ListByUserID(ctx, users[0].ID, 2)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "github", events[0].Method)
+	assert.Equal(t, "google", events[1].Method)
+	assert.Equal(t, "203.0.113.7", events[0].IPAddress)
+	assert.Equal(t, "test-agent", events[0].UserAgent)
+}
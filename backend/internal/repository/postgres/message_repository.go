@@ -0,0 +1,615 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageModel represents the GORM database model for messages
+type MessageModel struct {
+	ID             string     `gorm:"primaryKey;type:uuid"`
+	ConversationID string     `gorm:"type:uuid;not null;index:idx_messages_conversation_created,priority:1"`
+	SenderID       string     `gorm:"type:uuid;not null;index"`
+	Body           string     `gorm:"type:text;not null"`
+	CreatedAt      time.Time  `gorm:"not null;index:idx_messages_conversation_created,priority:2"`
+	EditedAt       *time.Time `gorm:"default:null"`
+	DeletedAt      *time.Time `gorm:"default:null"`
+}
+
+// TableName specifies the table name for MessageModel
+func (MessageModel) TableName() string {
+	return "messages"
+}
+
+// MessageAttachmentModel represents the GORM database model for message attachments
+type MessageAttachmentModel struct {
+	ID           string    `gorm:"primaryKey;type:uuid"`
+	MessageID    string    `gorm:"type:uuid;not null;index"`
+	PublicID     string    `gorm:"not null"`
+	ResourceType string    `gorm:"not null"`
+	URL          string    `gorm:"type:text;not null"`
+	FileName     string    `gorm:"not null"`
+	ContentType  string    `gorm:"not null"`
+	Size         int64     `gorm:"not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for MessageAttachmentModel
+func (MessageAttachmentModel) TableName() string {
+	return "message_attachments"
+}
+
+// MessageReactionModel represents the GORM database model for message reactions
+type MessageReactionModel struct {
+	MessageID string    `gorm:"primaryKey;type:uuid"`
+	UserID    string    `gorm:"primaryKey;type:uuid;index"`
+	Emoji     string    `gorm:"primaryKey;size:32"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for MessageReactionModel
+func (MessageReactionModel) TableName() string {
+	return "message_reactions"
+}
+
+// MessageMentionModel represents the GORM database model for a user mentioned in a message
+type MessageMentionModel struct {
+	MessageID string `gorm:"primaryKey;type:uuid"`
+	UserID    string `gorm:"primaryKey;type:uuid;index"`
+}
+
+// TableName specifies the table name for MessageMentionModel
+func (MessageMentionModel) TableName() string {
+	return "message_mentions"
+}
+
+// ConversationReadModel represents the GORM database model for a participant's read position
+// A row without a read position only records that the user marked the conversation unread.
+type ConversationReadModel struct {
+	ConversationID    string  `gorm:"primaryKey;type:uuid"`
+	UserID            string  `gorm:"primaryKey;type:uuid;index"`
+	LastReadMessageID *string `gorm:"type:uuid"`
+	LastReadAt        *time.Time
+	MarkedUnread      bool      `gorm:"not null;default:false"`
+	UpdatedAt         time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for ConversationReadModel
+func (ConversationReadModel) TableName() string {
+	return "conversation_reads"
+}
+
+type messageRepository struct {
+	db *gorm.DB
+}
+
+// NewMessageRepository creates a new message repository
+func NewMessageRepository(db *gorm.DB) repository.MessageRepository {
+	return &messageRepository{db: db}
+}
+
+func (r *messageRepository) Create(ctx context.Context, message *entity.Message) error {
+	model := r.toModel(message)
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		if len(message.Attachments) > 0 {
+			attachments := make([]MessageAttachmentModel, len(message.Attachments))
+			for i, attachment := range message.Attachments {
+				attachments[i] = MessageAttachmentModel{
+					ID:           attachment.ID,
+					MessageID:    message.ID,
+					PublicID:     attachment.PublicID,
+					ResourceType: attachment.ResourceType,
+					URL:          attachment.URL,
+					FileName:     attachment.FileName,
+					ContentType:  attachment.ContentType,
+					Size:         attachment.Size,
+					CreatedAt:    attachment.CreatedAt,
+				}
+			}
+			if err := tx.Create(&attachments).Error; err != nil {
+				return err
+			}
+		}
+		if err := createMentions(tx, message); err != nil {
+			return err
+		}
+		// Sending in a conversation clears the sender's unread mark
+		if err := clearMarkedUnread(tx, message.SenderID, message.ConversationID); err != nil {
+			return err
+		}
+		// Keep the conversation list ordered by latest activity
+		return tx.Model(&ConversationModel{}).
+			Where("id = ?", message.ConversationID).
+			UpdateColumn("updated_at", message.CreatedAt).Error
+	})
+}
+
+func (r *messageRepository) GetByID(ctx context.Context, id string) (*entity.Message, error) {
+	var model MessageModel
+	err := conn(ctx, r.db).Where("id = ?", id).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := r.toEntities(ctx, []MessageModel{model})
+	if err != nil {
+		return nil, err
+	}
+	return messages[0], nil
+}
+
+func (r *messageRepository) Update(ctx context.Context, message *entity.Message) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(r.toModel(message)).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", message.ID).Delete(&MessageMentionModel{}).Error; err != nil {
+			return err
+		}
+		if message.IsDeleted() {
+			if err := tx.Where("message_id = ?", message.ID).Delete(&MessageAttachmentModel{}).Error; err != nil {
+				return err
+			}
+		}
+		return createMentions(tx, message)
+	})
+}
+
+func (r *messageRepository) DeleteSentBatch(ctx context.Context, senderID string, limit int) (int, []*entity.Attachment, error) {
+	var ids []string
+	var models []MessageAttachmentModel
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&MessageModel{}).
+			Where("sender_id = ? AND deleted_at IS NULL", senderID).
+			Order("created_at, id").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		err = tx.Where("message_id IN ?", ids).
+			Order("created_at, id").
+			Find(&models).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&MessageModel{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"body": "", "deleted_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("message_id IN ?", ids).Delete(&MessageMentionModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("message_id IN ?", ids).Delete(&MessageAttachmentModel{}).Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	attachments := make([]*entity.Attachment, len(models))
+	for i := range models {
+		attachments[i] = toAttachmentEntity(&models[i])
+	}
+	return len(ids), attachments, nil
+}
+
+func (r *messageRepository) ListAttachmentsBySender(ctx context.Context, senderID string) ([]*entity.Attachment, error) {
+	var models []MessageAttachmentModel
+	err := conn(ctx, r.db).
+		Joins("JOIN messages ON messages.id = message_attachments.message_id").
+		Where("messages.sender_id = ?", senderID).
+		Order("message_attachments.created_at, message_attachments.id").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*entity.Attachment, len(models))
+	for i := range models {
+		attachments[i] = toAttachmentEntity(&models[i])
+	}
+	return attachments, nil
+}
+
+// createMentions stores the users mentioned in the message
+func createMentions(tx *gorm.DB, message *entity.Message) error {
+	if len(message.Mentions) == 0 {
+		return nil
+	}
+	mentions := make([]MessageMentionModel, len(message.Mentions))
+	for i, userID := range message.Mentions {
+		mentions[i] = MessageMentionModel{MessageID: message.ID, UserID: userID}
+	}
+	return tx.Create(&mentions).Error
+}
+
+func (r *messageRepository) ListByConversation(ctx context.Context, conversationID string, before time.Time, beforeID string, limit int) ([]*entity.Message, error) {
+	query := conn(ctx, r.db).Where("conversation_id = ?", conversationID)
+	if !before.IsZero() {
+		// The id breaks ties between messages sent at the same instant
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", before, before, beforeID)
+	}
+
+	var models []MessageModel
+	err := query.
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
+// mediaTypeConditions select the attachments of each media type by content type; the rest are files
+var mediaTypeConditions = map[string]string{
+	entity.MediaTypeImage: "message_attachments.content_type LIKE 'image/%'",
+	entity.MediaTypeVideo: "message_attachments.content_type LIKE 'video/%'",
+	entity.MediaTypeAudio: "(message_attachments.content_type LIKE 'audio/%' OR message_attachments.content_type = 'application/ogg')",
+}
+
+func (r *messageRepository) ListMedia(ctx context.Context, conversationID, mediaType string, before time.Time, beforeID string, limit int) ([]*entity.Attachment, error) {
+	query := conn(ctx, r.db).
+		Joins("JOIN messages ON messages.id = message_attachments.message_id").
+		Where("messages.conversation_id = ? AND messages.deleted_at IS NULL", conversationID)
+
+	switch mediaType {
+	case "":
+	case entity.MediaTypeFile:
+		for _, condition := range mediaTypeConditions {
+			query = query.Where("NOT " + condition)
+		}
+	default:
+		query = query.Where(mediaTypeConditions[mediaType])
+	}
+	if !before.IsZero() {
+		query = query.Where("message_attachments.created_at < ? OR (message_attachments.created_at = ? AND message_attachments.id < ?)",
+			before, before, beforeID)
+	}
+
+	var models []MessageAttachmentModel
+	err := query.
+		Order("message_attachments.created_at DESC, message_attachments.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*entity.Attachment, len(models))
+	for i := range models {
+		attachments[i] = toAttachmentEntity(&models[i])
+	}
+	return attachments, nil
+}
+
+func (r *messageRepository) Search(ctx context.Context, conversationID, query string, limit int) ([]*entity.Message, error) {
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+
+	// LOWER(body) LIKE is served by the trigram index on LOWER(body)
+	var models []MessageModel
+	err := conn(ctx, r.db).
+		Where("conversation_id = ? AND deleted_at IS NULL", conversationID).
+		Where(`LOWER(body) LIKE ? ESCAPE '\'`, pattern).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
+func (r *messageRepository) SearchForUser(ctx context.Context, userID, query string, limit int) ([]*entity.Message, error) {
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+
+	var models []MessageModel
+	err := conn(ctx, r.db).
+		Joins("JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id AND conversation_participants.user_id = ?", userID).
+		Where("messages.deleted_at IS NULL").
+		Where(`LOWER(messages.body) LIKE ? ESCAPE '\'`, pattern).
+		Order("messages.created_at DESC, messages.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
+func (r *messageRepository) ToggleReaction(ctx context.Context, reaction *entity.MessageReaction) (bool, error) {
+	added := false
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("message_id = ? AND user_id = ? AND emoji = ?", reaction.MessageID, reaction.UserID, reaction.Emoji).
+			Delete(&MessageReactionModel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		model := &MessageReactionModel{
+			MessageID: reaction.MessageID,
+			UserID:    reaction.UserID,
+			Emoji:     reaction.Emoji,
+			CreatedAt: reaction.CreatedAt,
+		}
+		// A concurrent toggle may have added the same reaction first; it is added either way
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(model).Error; err != nil {
+			return err
+		}
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+func (r *messageRepository) ListUnseenMentions(ctx context.Context, userID string, before time.Time, beforeID string, limit int) ([]*entity.Message, error) {
+	query := r.unreadMessages(ctx, userID).
+		Joins("JOIN message_mentions ON message_mentions.message_id = messages.id AND message_mentions.user_id = ?", userID).
+		Joins("JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id AND conversation_participants.user_id = ?", userID)
+	if !before.IsZero() {
+		query = query.Where("messages.created_at < ? OR (messages.created_at = ? AND messages.id < ?)", before, before, beforeID)
+	}
+
+	var models []MessageModel
+	err := query.
+		Select("messages.*").
+		Order("messages.created_at DESC, messages.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
+func (r *messageRepository) MarkRead(ctx context.Context, read *entity.ConversationRead) error {
+	model := &ConversationReadModel{
+		ConversationID:    read.ConversationID,
+		UserID:            read.UserID,
+		LastReadMessageID: &read.LastReadMessageID,
+		LastReadAt:        &read.LastReadAt,
+		UpdatedAt:         read.UpdatedAt,
+	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Reading clears the unread mark even when the position does not move
+		if err := clearMarkedUnread(tx, read.UserID, read.ConversationID); err != nil {
+			return err
+		}
+		// Only move the read position forward, so a late request for an older message cannot undo a newer read
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "conversation_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"last_read_message_id", "last_read_at", "updated_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{clause.Expr{
+				SQL: "conversation_reads.last_read_at IS NULL OR excluded.last_read_at > conversation_reads.last_read_at OR " +
+					"(excluded.last_read_at = conversation_reads.last_read_at AND excluded.last_read_message_id > conversation_reads.last_read_message_id)",
+			}}},
+		}).Create(model).Error
+	})
+}
+
+func (r *messageRepository) MarkUnread(ctx context.Context, userID, conversationID string) error {
+	model := &ConversationReadModel{
+		ConversationID: conversationID,
+		UserID:         userID,
+		MarkedUnread:   true,
+		UpdatedAt:      time.Now(),
+	}
+	// The read position is kept, so reading again resumes where the user left off
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "conversation_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"marked_unread"}),
+		}).
+		Create(model).Error
+}
+
+// clearMarkedUnread removes the user's unread mark from the conversation
+func clearMarkedUnread(tx *gorm.DB, userID, conversationID string) error {
+	return tx.Model(&ConversationReadModel{}).
+		Where("conversation_id = ? AND user_id = ? AND marked_unread", conversationID, userID).
+		UpdateColumn("marked_unread", false).Error
+}
+
+func (r *messageRepository) ReadPositions(ctx context.Context, userID string, conversationIDs []string) (map[string]*entity.ConversationRead, error) {
+	reads := make(map[string]*entity.ConversationRead)
+	if len(conversationIDs) == 0 {
+		return reads, nil
+	}
+
+	var models []ConversationReadModel
+	err := conn(ctx, r.db).
+		Where("user_id = ? AND conversation_id IN ?", userID, conversationIDs).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, model := range models {
+		read := &entity.ConversationRead{
+			ConversationID: model.ConversationID,
+			UserID:         model.UserID,
+			MarkedUnread:   model.MarkedUnread,
+			UpdatedAt:      model.UpdatedAt,
+		}
+		if model.LastReadMessageID != nil && model.LastReadAt != nil {
+			read.LastReadMessageID = *model.LastReadMessageID
+			read.LastReadAt = *model.LastReadAt
+		}
+		reads[model.ConversationID] = read
+	}
+	return reads, nil
+}
+
+func (r *messageRepository) UnreadCount(ctx context.Context, userID, conversationID string) (int64, error) {
+	var count int64
+	err := r.unreadMessages(ctx, userID).
+		Where("messages.conversation_id = ?", conversationID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *messageRepository) UnreadCounts(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(conversationIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ConversationID string
+		Count          int64
+	}
+	err := r.unreadMessages(ctx, userID).
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Where("messages.conversation_id IN ?", conversationIDs).
+		Group("messages.conversation_id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+	return counts, nil
+}
+
+// unreadMessages selects the messages from other participants after userID's read position
+func (r *messageRepository) unreadMessages(ctx context.Context, userID string) *gorm.DB {
+	return conn(ctx, r.db).
+		Model(&MessageModel{}).
+		Joins("LEFT JOIN conversation_reads ON conversation_reads.conversation_id = messages.conversation_id AND conversation_reads.user_id = ?", userID).
+		Where("messages.sender_id <> ? AND messages.deleted_at IS NULL", userID).
+		Where("conversation_reads.last_read_at IS NULL OR messages.created_at > conversation_reads.last_read_at OR " +
+			"(messages.created_at = conversation_reads.last_read_at AND messages.id > conversation_reads.last_read_message_id)")
+}
+
+// toModel converts domain entity to GORM model
+func (r *messageRepository) toModel(message *entity.Message) *MessageModel {
+	return &MessageModel{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Body:           message.Body,
+		CreatedAt:      message.CreatedAt,
+		EditedAt:       message.EditedAt,
+		DeletedAt:      message.DeletedAt,
+	}
+}
+
+// toEntities converts GORM models to domain entities, loading the attachments, reaction counts and mentions of all
+// messages with one query each
+func (r *messageRepository) toEntities(ctx context.Context, models []MessageModel) ([]*entity.Message, error) {
+	messages := make([]*entity.Message, len(models))
+	if len(models) == 0 {
+		return messages, nil
+	}
+
+	ids := make([]string, len(models))
+	for i := range models {
+		ids[i] = models[i].ID
+	}
+
+	var attachmentModels []MessageAttachmentModel
+	err := conn(ctx, r.db).
+		Where("message_id IN ?", ids).
+		Order("created_at, id").
+		Find(&attachmentModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make(map[string][]*entity.Attachment, len(attachmentModels))
+	for _, a := range attachmentModels {
+		attachments[a.MessageID] = append(attachments[a.MessageID], toAttachmentEntity(&a))
+	}
+
+	var reactionRows []struct {
+		MessageID string
+		Emoji     string
+		Count     int64
+	}
+	err = conn(ctx, r.db).
+		Model(&MessageReactionModel{}).
+		Select("message_id, emoji, COUNT(*) AS count").
+		Where("message_id IN ?", ids).
+		Group("message_id, emoji").
+		// Emoji keep the position of their first reaction
+		Order("MIN(created_at), emoji").
+		Find(&reactionRows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reactions := make(map[string][]*entity.ReactionCount, len(reactionRows))
+	for _, row := range reactionRows {
+		reactions[row.MessageID] = append(reactions[row.MessageID], &entity.ReactionCount{
+			Emoji: row.Emoji,
+			Count: row.Count,
+		})
+	}
+
+	var mentionModels []MessageMentionModel
+	err = conn(ctx, r.db).
+		Where("message_id IN ?", ids).
+		Order("user_id").
+		Find(&mentionModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := make(map[string][]string, len(mentionModels))
+	for _, m := range mentionModels {
+		mentions[m.MessageID] = append(mentions[m.MessageID], m.UserID)
+	}
+
+	for i := range models {
+		messages[i] = &entity.Message{
+			ID:             models[i].ID,
+			ConversationID: models[i].ConversationID,
+			SenderID:       models[i].SenderID,
+			Body:           models[i].Body,
+			Attachments:    attachments[models[i].ID],
+			Reactions:      reactions[models[i].ID],
+			Mentions:       mentions[models[i].ID],
+			CreatedAt:      models[i].CreatedAt,
+			EditedAt:       models[i].EditedAt,
+			DeletedAt:      models[i].DeletedAt,
+		}
+	}
+	return messages, nil
+}
+
+// toAttachmentEntity converts an attachment GORM model to a domain entity
+func toAttachmentEntity(model *MessageAttachmentModel) *entity.Attachment {
+	return &entity.Attachment{
+		ID:           model.ID,
+		MessageID:    model.MessageID,
+		PublicID:     model.PublicID,
+		ResourceType: model.ResourceType,
+		URL:          model.URL,
+		FileName:     model.FileName,
+		ContentType:  model.ContentType,
+		Size:         model.Size,
+		CreatedAt:    model.CreatedAt,
+	}
+}
@@ -0,0 +1,718 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageRepository_CreateUpdatesConversation(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	conversation.CreatedAt = time.Now().Add(-time.Hour)
+	conversation.UpdatedAt = conversation.CreatedAt
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+
+	message := entity.NewMessage(conversation.ID, "user-1", "hello")
+	require.NoError(t, messageRepo.Create(ctx, message))
+
+	var stored postgres.MessageModel
+	require.NoError(t, db.Where("id = ?", message.ID).First(&stored).Error)
+	assert.Equal(t, "hello", stored.Body)
+	assert.Equal(t, "user-1", stored.SenderID)
+	assert.Nil(t, stored.EditedAt)
+	assert.Nil(t, stored.DeletedAt)
+
+	// The conversation moves to the top of its participants' lists
+	found, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, message.CreatedAt, found.UpdatedAt, time.Millisecond)
+}
+
+func TestMessageRepository_ListByConversationPages(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+	other := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-3"})
+	require.NoError(t, conversationRepo.Create(ctx, other))
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var sent []*entity.Message
+	for i := 0; i < 5; i++ {
+		msg := entity.NewMessage(conversation.ID, "user-1", "hello")
+		msg.CreatedAt = start.Add(time.Duration(i) * time.Second)
+		sent = append(sent, msg)
+	}
+	// Two messages sent at the same instant are told apart by id
+	sent[3].CreatedAt = sent[2].CreatedAt
+	for _, msg := range sent {
+		require.NoError(t, messageRepo.Create(ctx, msg))
+	}
+	require.NoError(t, messageRepo.Create(ctx, entity.NewMessage(other.ID, "user-1", "elsewhere")))
+
+	var seen []string
+	var before time.Time
+	var beforeID string
+	for {
+		page, err := messageRepo.ListByConversation(ctx, conversation.ID, before, beforeID, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		for i, msg := range page {
+			seen = append(seen, msg.ID)
+			if i > 0 {
+				assert.False(t, msg.CreatedAt.After(page[i-1].CreatedAt), "messages must be newest first")
+			}
+		}
+		last := page[len(page)-1]
+		before, beforeID = last.CreatedAt, last.ID
+	}
+
+	// Every message of the conversation appears exactly once
+	expected := make([]string, len(sent))
+	for i, msg := range sent {
+		expected[i] = msg.ID
+	}
+	assert.ElementsMatch(t, expected, seen)
+	assert.Len(t, seen, len(sent))
+}
+
+func TestMessageRepository_MarkReadAndUnreadCount(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	send := func(senderID string, offset int) *entity.Message {
+		msg := entity.NewMessage(conversation.ID, senderID, "hello")
+		msg.CreatedAt = start.Add(time.Duration(offset) * time.Second)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		return msg
+	}
+	first := send("user-2", 0)
+	send("user-1", 1) // the reader's own messages never count as unread
+	third := send("user-2", 2)
+	send("user-2", 3)
+
+	unread, err := messageRepo.UnreadCount(ctx, "user-1", conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), unread)
+
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", third)))
+	unread, err = messageRepo.UnreadCount(ctx, "user-1", conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+
+	// Marking an older message read does not move the position back
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", first)))
+	unread, err = messageRepo.UnreadCount(ctx, "user-1", conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+
+	// Read positions are per participant
+	unread, err = messageRepo.UnreadCount(ctx, "user-2", conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+}
+
+func TestMessageRepository_ReadPositionsFollowMarkRead(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+	other := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-3"})
+	require.NoError(t, conversationRepo.Create(ctx, other))
+
+	first := entity.NewMessage(conversation.ID, "user-2", "hello")
+	first.CreatedAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, messageRepo.Create(ctx, first))
+	second := entity.NewMessage(conversation.ID, "user-2", "again")
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	require.NoError(t, messageRepo.Create(ctx, second))
+
+	reads, err := messageRepo.ReadPositions(ctx, "user-1", []string{conversation.ID, other.ID})
+	require.NoError(t, err)
+	assert.Empty(t, reads)
+
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", first)))
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", second)))
+
+	reads, err = messageRepo.ReadPositions(ctx, "user-1", []string{conversation.ID, other.ID})
+	require.NoError(t, err)
+	require.Len(t, reads, 1)
+	assert.Equal(t, second.ID, reads[conversation.ID].LastReadMessageID)
+	assert.False(t, reads[conversation.ID].UpdatedAt.IsZero())
+
+	// The other participant's position is untouched
+	reads, err = messageRepo.ReadPositions(ctx, "user-2", []string{conversation.ID})
+	require.NoError(t, err)
+	assert.Empty(t, reads)
+}
+
+func TestMessageRepository_SearchForUserOnlyMemberConversations(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	mine := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, mine))
+	group := entity.NewConversation(entity.ConversationTypeGroup, "team", []string{"user-1", "user-3"})
+	require.NoError(t, conversationRepo.Create(ctx, group))
+	theirs := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-2", "user-3"})
+	require.NoError(t, conversationRepo.Create(ctx, theirs))
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	send := func(conversationID, senderID, body string, offset int) *entity.Message {
+		message := entity.NewMessage(conversationID, senderID, body)
+		message.CreatedAt = start.Add(time.Duration(offset) * time.Second)
+		require.NoError(t, messageRepo.Create(ctx, message))
+		return message
+	}
+	older := send(mine.ID, "user-2", "Lunch at noon?", 0)
+	newer := send(group.ID, "user-3", "team lunch friday", 1)
+	send(mine.ID, "user-1", "dinner instead", 2)
+	send(theirs.ID, "user-2", "lunch without user-1", 3)
+	deleted := send(group.ID, "user-1", "lunch deleted", 4)
+	deleted.MarkDeleted()
+	require.NoError(t, messageRepo.Update(ctx, deleted))
+
+	messages, err := messageRepo.SearchForUser(ctx, "user-1", "LUNCH", 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, newer.ID, messages[0].ID)
+	assert.Equal(t, older.ID, messages[1].ID)
+
+	messages, err = messageRepo.SearchForUser(ctx, "user-1", "lunch", 1)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, newer.ID, messages[0].ID)
+}
+
+func TestMessageRepository_ListUnseenMentionsOnlyInMemberConversations(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeGroup, "team", []string{"user-1", "user-2", "user-3"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+	// user-1 is not a member here, so a stale mention of them must not show up
+	elsewhere := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-2", "user-3"})
+	require.NoError(t, conversationRepo.Create(ctx, elsewhere))
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	send := func(conversationID, senderID string, offset int, mentions ...string) *entity.Message {
+		message := entity.NewMessage(conversationID, senderID, "hello")
+		message.Mentions = mentions
+		message.CreatedAt = start.Add(time.Duration(offset) * time.Second)
+		require.NoError(t, messageRepo.Create(ctx, message))
+		return message
+	}
+	seen := send(conversation.ID, "user-2", 0, "user-1")
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", seen)))
+	older := send(conversation.ID, "user-2", 1, "user-1")
+	send(conversation.ID, "user-2", 2, "user-3")
+	send(conversation.ID, "user-1", 3, "user-1")
+	deleted := send(conversation.ID, "user-3", 4, "user-1")
+	deleted.MarkDeleted()
+	require.NoError(t, messageRepo.Update(ctx, deleted))
+	send(elsewhere.ID, "user-2", 5, "user-1")
+	newer := send(conversation.ID, "user-3", 6, "user-1", "user-2")
+
+	mentions, err := messageRepo.ListUnseenMentions(ctx, "user-1", time.Time{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, mentions, 2)
+	assert.Equal(t, newer.ID, mentions[0].ID)
+	assert.Equal(t, older.ID, mentions[1].ID)
+	assert.Equal(t, []string{"user-1", "user-2"}, mentions[0].Mentions)
+
+	mentions, err = messageRepo.ListUnseenMentions(ctx, "user-1", newer.CreatedAt, newer.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, mentions, 1)
+	assert.Equal(t, older.ID, mentions[0].ID)
+
+	// Reading the conversation marks its mentions as seen
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", newer)))
+	mentions, err = messageRepo.ListUnseenMentions(ctx, "user-1", time.Time{}, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, mentions)
+}
+
+func TestMessageRepository_MarkUnreadUntilReadOrSent(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+	received := entity.NewMessage(conversation.ID, "user-2", "hello")
+	received.CreatedAt = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, messageRepo.Create(ctx, received))
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", received)))
+
+	markedUnread := func(userID string) bool {
+		reads, err := messageRepo.ReadPositions(ctx, userID, []string{conversation.ID})
+		require.NoError(t, err)
+		return reads[conversation.ID] != nil && reads[conversation.ID].MarkedUnread
+	}
+
+	require.NoError(t, messageRepo.MarkUnread(ctx, "user-1", conversation.ID))
+	assert.True(t, markedUnread("user-1"))
+	assert.False(t, markedUnread("user-2"))
+	// The read position is kept
+	reads, err := messageRepo.ReadPositions(ctx, "user-1", []string{conversation.ID})
+	require.NoError(t, err)
+	assert.Equal(t, received.ID, reads[conversation.ID].LastReadMessageID)
+
+	// Reading clears it, even without moving the position
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", received)))
+	assert.False(t, markedUnread("user-1"))
+
+	// So does sending
+	require.NoError(t, messageRepo.MarkUnread(ctx, "user-1", conversation.ID))
+	reply := entity.NewMessage(conversation.ID, "user-1", "hi")
+	reply.CreatedAt = received.CreatedAt.Add(time.Second)
+	require.NoError(t, messageRepo.Create(ctx, reply))
+	assert.False(t, markedUnread("user-1"))
+}
+
+func TestMessageRepository_MarkUnreadBeforeReadingAnything(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+	received := entity.NewMessage(conversation.ID, "user-2", "hello")
+	require.NoError(t, messageRepo.Create(ctx, received))
+
+	require.NoError(t, messageRepo.MarkUnread(ctx, "user-1", conversation.ID))
+
+	reads, err := messageRepo.ReadPositions(ctx, "user-1", []string{conversation.ID})
+	require.NoError(t, err)
+	require.Contains(t, reads, conversation.ID)
+	assert.True(t, reads[conversation.ID].MarkedUnread)
+	assert.Empty(t, reads[conversation.ID].LastReadMessageID)
+	unread, err := messageRepo.UnreadCount(ctx, "user-1", conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+
+	// The first read stores the position on the same row
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", received)))
+	reads, err = messageRepo.ReadPositions(ctx, "user-1", []string{conversation.ID})
+	require.NoError(t, err)
+	assert.False(t, reads[conversation.ID].MarkedUnread)
+	assert.Equal(t, received.ID, reads[conversation.ID].LastReadMessageID)
+	unread, err = messageRepo.UnreadCount(ctx, "user-1", conversation.ID)
+	require.NoError(t, err)
+	assert.Zero(t, unread)
+}
+
+func TestMessageRepository_GetByID(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	msg := entity.NewMessage("conv-1", "user-1", "hello")
+	require.NoError(t, messageRepo.Create(ctx, msg))
+
+	found, err := messageRepo.GetByID(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", found.Body)
+
+	_, err = messageRepo.GetByID(ctx, "missing")
+	assert.Equal(t, errors.ErrMessageNotFound, err)
+}
+
+func TestMessageRepository_UnreadCountsMixedStates(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Each conversation is with a different user, since a pair of users has one direct conversation
+	newConversation := func(peer string) *entity.Conversation {
+		conv := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", peer})
+		require.NoError(t, conversationRepo.Create(ctx, conv))
+		return conv
+	}
+	send := func(conv *entity.Conversation, senderID string, offset int) *entity.Message {
+		msg := entity.NewMessage(conv.ID, senderID, "hello")
+		msg.CreatedAt = start.Add(time.Duration(offset) * time.Second)
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		return msg
+	}
+
+	// Never read: every message from the other participant is unread
+	neverRead := newConversation("user-2")
+	send(neverRead, "user-2", 0)
+	send(neverRead, "user-2", 1)
+	send(neverRead, "user-1", 2)
+
+	// Partly read: only the message after the read position counts
+	partlyRead := newConversation("user-3")
+	read := send(partlyRead, "user-3", 0)
+	send(partlyRead, "user-3", 1)
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", read)))
+
+	// Fully read and empty conversations are left out
+	fullyRead := newConversation("user-4")
+	read = send(fullyRead, "user-4", 0)
+	require.NoError(t, messageRepo.MarkRead(ctx, entity.NewConversationRead("user-1", read)))
+	empty := newConversation("user-5")
+
+	queries := countQueries(t, db)
+	counts, err := messageRepo.UnreadCounts(ctx, "user-1", []string{neverRead.ID, partlyRead.ID, fullyRead.ID, empty.ID})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{neverRead.ID: 2, partlyRead.ID: 1}, counts)
+	assert.Equal(t, 1, *queries)
+
+	for id, expected := range counts {
+		single, err := messageRepo.UnreadCount(ctx, "user-1", id)
+		require.NoError(t, err)
+		assert.Equal(t, expected, single)
+	}
+}
+
+func TestMessageRepository_UpdateKeepsDeletedMessagesInHistory(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	edited := entity.NewMessage("conv-1", "user-1", "helo")
+	deleted := entity.NewMessage("conv-1", "user-1", "oops")
+	require.NoError(t, messageRepo.Create(ctx, edited))
+	require.NoError(t, messageRepo.Create(ctx, deleted))
+
+	edited.Edit("hello")
+	require.NoError(t, messageRepo.Update(ctx, edited))
+	deleted.MarkDeleted()
+	require.NoError(t, messageRepo.Update(ctx, deleted))
+
+	found, err := messageRepo.GetByID(ctx, edited.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", found.Body)
+	require.NotNil(t, found.EditedAt)
+
+	history, err := messageRepo.ListByConversation(ctx, "conv-1", time.Time{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	for _, msg := range history {
+		if msg.ID == deleted.ID {
+			assert.True(t, msg.IsDeleted())
+			assert.Empty(t, msg.Body)
+		}
+	}
+}
+
+func TestMessageRepository_DeleteSentBatchOnlyTouchesSender(t *testing.T) {
+	db := newTestDB(t)
+	conversationRepo := postgres.NewConversationRepository(db)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	conversation := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-1", "user-2"})
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var own []*entity.Message
+	for i := range 3 {
+		message := entity.NewMessage(conversation.ID, "user-1", fmt.Sprintf("mine %d", i))
+		message.CreatedAt = start.Add(time.Duration(i) * time.Second)
+		message.Mentions = []string{"user-2"}
+		message.Attachments = []*entity.Attachment{
+			entity.NewAttachment(message.ID, fmt.Sprintf("chat/%s/%d", conversation.ID, i), "image", "https://cdn.example.com/a.png", "a.png", "image/png", 2048),
+		}
+		require.NoError(t, messageRepo.Create(ctx, message))
+		own = append(own, message)
+	}
+	reply := entity.NewMessage(conversation.ID, "user-2", "theirs")
+	reply.CreatedAt = start.Add(time.Minute)
+	reply.Attachments = []*entity.Attachment{
+		entity.NewAttachment(reply.ID, "chat/"+conversation.ID+"/theirs", "image", "https://cdn.example.com/b.png", "b.png", "image/png", 2048),
+	}
+	require.NoError(t, messageRepo.Create(ctx, reply))
+
+	deleted, attachments, err := messageRepo.DeleteSentBatch(ctx, "user-1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+	require.Len(t, attachments, 2)
+	assert.Equal(t, own[0].Attachments[0].PublicID, attachments[0].PublicID)
+	assert.Equal(t, own[1].Attachments[0].PublicID, attachments[1].PublicID)
+
+	deleted, attachments, err = messageRepo.DeleteSentBatch(ctx, "user-1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Len(t, attachments, 1)
+
+	deleted, attachments, err = messageRepo.DeleteSentBatch(ctx, "user-1", 2)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	assert.Empty(t, attachments)
+
+	// The sender's messages stay in the history as deleted messages
+	for _, message := range own {
+		found, err := messageRepo.GetByID(ctx, message.ID)
+		require.NoError(t, err)
+		assert.True(t, found.IsDeleted())
+		assert.Empty(t, found.Body)
+		assert.Empty(t, found.Attachments)
+		assert.Empty(t, found.Mentions)
+	}
+
+	// The other participant's message is untouched
+	found, err := messageRepo.GetByID(ctx, reply.ID)
+	require.NoError(t, err)
+	assert.False(t, found.IsDeleted())
+	assert.Equal(t, "theirs", found.Body)
+	assert.Len(t, found.Attachments, 1)
+}
+
+func TestMessageRepository_StoresAttachments(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	withAttachment := entity.NewMessage("conv-1", "user-1", "")
+	withAttachment.Attachments = []*entity.Attachment{
+		entity.NewAttachment(withAttachment.ID, "chat/conv-1/abc", "image", "https://cdn.example.com/abc.png", "photo.png", "image/png", 2048),
+	}
+	plain := entity.NewMessage("conv-1", "user-1", "hello")
+	require.NoError(t, messageRepo.Create(ctx, withAttachment))
+	require.NoError(t, messageRepo.Create(ctx, plain))
+
+	found, err := messageRepo.GetByID(ctx, withAttachment.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Attachments, 1)
+	assert.Equal(t, "chat/conv-1/abc", found.Attachments[0].PublicID)
+	assert.Equal(t, "https://cdn.example.com/abc.png", found.Attachments[0].URL)
+	assert.Equal(t, "photo.png", found.Attachments[0].FileName)
+	assert.Equal(t, int64(2048), found.Attachments[0].Size)
+
+	// History loads the attachments, reactions and mentions of a whole page with one extra query each
+	queries := countQueries(t, db)
+	history, err := messageRepo.ListByConversation(ctx, "conv-1", time.Time{}, "", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 4, *queries)
+	for _, msg := range history {
+		if msg.ID == plain.ID {
+			assert.Empty(t, msg.Attachments)
+		} else {
+			assert.Len(t, msg.Attachments, 1)
+		}
+	}
+}
+
+func TestMessageRepository_DeletedMessageDropsAttachments(t *testing.T) {
+	messageRepo := postgres.NewMessageRepository(newTestDB(t))
+	ctx := context.Background()
+
+	kept := entity.NewMessage("conv-1", "user-1", "")
+	kept.Attachments = []*entity.Attachment{
+		entity.NewAttachment(kept.ID, "chat/conv-1/kept", "image", "https://cdn.example.com/kept.png", "kept.png", "image/png", 10),
+	}
+	deleted := entity.NewMessage("conv-1", "user-1", "")
+	deleted.Attachments = []*entity.Attachment{
+		entity.NewAttachment(deleted.ID, "chat/conv-1/deleted", "raw", "https://cdn.example.com/deleted.zip", "deleted.zip", "application/zip", 10),
+	}
+	other := entity.NewMessage("conv-1", "user-2", "")
+	other.Attachments = []*entity.Attachment{
+		entity.NewAttachment(other.ID, "chat/conv-1/other", "image", "https://cdn.example.com/other.png", "other.png", "image/png", 10),
+	}
+	for _, msg := range []*entity.Message{kept, deleted, other} {
+		require.NoError(t, messageRepo.Create(ctx, msg))
+	}
+
+	sent, err := messageRepo.ListAttachmentsBySender(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, sent, 2)
+	assert.ElementsMatch(t, []string{"chat/conv-1/kept", "chat/conv-1/deleted"}, []string{sent[0].PublicID, sent[1].PublicID})
+
+	deleted.MarkDeleted()
+	require.NoError(t, messageRepo.Update(ctx, deleted))
+
+	found, err := messageRepo.GetByID(ctx, deleted.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Attachments)
+
+	sent, err = messageRepo.ListAttachmentsBySender(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "chat/conv-1/kept", sent[0].PublicID)
+	assert.Equal(t, "image", sent[0].ResourceType)
+}
+
+func TestMessageRepository_ListMediaFiltersByType(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	send := func(conversationID, contentType string, offset int, deleted bool) *entity.Attachment {
+		msg := entity.NewMessage(conversationID, "user-1", "")
+		msg.CreatedAt = start.Add(time.Duration(offset) * time.Second)
+		if deleted {
+			deletedAt := msg.CreatedAt
+			msg.DeletedAt = &deletedAt
+		}
+		attachment := entity.NewAttachment(msg.ID, "chat/"+msg.ID, "image", "https://example.com/"+msg.ID, "file", contentType, 10)
+		attachment.CreatedAt = msg.CreatedAt
+		msg.Attachments = []*entity.Attachment{attachment}
+		require.NoError(t, messageRepo.Create(ctx, msg))
+		return attachment
+	}
+	olderImage := send("conv-1", "image/png", 0, false)
+	video := send("conv-1", "video/mp4", 1, false)
+	audio := send("conv-1", "application/ogg", 2, false)
+	document := send("conv-1", "application/pdf", 3, false)
+	newerImage := send("conv-1", "image/jpeg", 4, false)
+	send("conv-1", "image/png", 5, true) // attachments of deleted messages are left out
+	send("conv-2", "image/png", 6, false)
+
+	ids := func(attachments []*entity.Attachment) []string {
+		result := make([]string, len(attachments))
+		for i, attachment := range attachments {
+			result[i] = attachment.ID
+		}
+		return result
+	}
+	tests := map[string][]string{
+		"":                    {newerImage.ID, document.ID, audio.ID, video.ID, olderImage.ID},
+		entity.MediaTypeImage: {newerImage.ID, olderImage.ID},
+		entity.MediaTypeVideo: {video.ID},
+		entity.MediaTypeAudio: {audio.ID},
+		entity.MediaTypeFile:  {document.ID},
+	}
+	for mediaType, expected := range tests {
+		media, err := messageRepo.ListMedia(ctx, "conv-1", mediaType, time.Time{}, "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, expected, ids(media), "type %q", mediaType)
+	}
+
+	// Pages continue after the given position
+	media, err := messageRepo.ListMedia(ctx, "conv-1", entity.MediaTypeImage, newerImage.CreatedAt, newerImage.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{olderImage.ID}, ids(media))
+}
+
+func TestMessageRepository_StoresMentions(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	message := entity.NewMessage("conv-1", "user-1", "@bob @carol")
+	message.Mentions = []string{"user-3", "user-2"}
+	require.NoError(t, messageRepo.Create(ctx, message))
+
+	found, err := messageRepo.GetByID(ctx, message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-2", "user-3"}, found.Mentions)
+
+	// Editing replaces the mentions
+	found.Edit("@carol")
+	found.Mentions = []string{"user-3"}
+	require.NoError(t, messageRepo.Update(ctx, found))
+	found, err = messageRepo.GetByID(ctx, message.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-3"}, found.Mentions)
+
+	// Deleting the message drops them
+	found.MarkDeleted()
+	require.NoError(t, messageRepo.Update(ctx, found))
+	found, err = messageRepo.GetByID(ctx, message.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Mentions)
+}
+
+func TestMessageRepository_ToggleReactionCountsPerEmoji(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	msg := entity.NewMessage("conv-1", "user-1", "hello")
+	require.NoError(t, messageRepo.Create(ctx, msg))
+
+	toggle := func(userID, emoji string) bool {
+		added, err := messageRepo.ToggleReaction(ctx, entity.NewMessageReaction(msg.ID, userID, emoji))
+		require.NoError(t, err)
+		return added
+	}
+	assert.True(t, toggle("user-1", "👍"))
+	assert.True(t, toggle("user-2", "👍"))
+	assert.True(t, toggle("user-2", "🎉"))
+
+	found, err := messageRepo.GetByID(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.ReactionCount{{Emoji: "👍", Count: 2}, {Emoji: "🎉", Count: 1}}, found.Reactions)
+
+	// Reacting again with the same emoji takes the reaction back
+	assert.False(t, toggle("user-2", "👍"))
+	assert.False(t, toggle("user-2", "🎉"))
+
+	found, err = messageRepo.GetByID(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.ReactionCount{{Emoji: "👍", Count: 1}}, found.Reactions)
+}
+
+func TestMessageRepository_SearchMatchesLiterally(t *testing.T) {
+	db := newTestDB(t)
+	messageRepo := postgres.NewMessageRepository(db)
+	ctx := context.Background()
+
+	older := entity.NewMessage("conv-1", "user-1", "Lunch at noon?")
+	newer := entity.NewMessage("conv-1", "user-2", "lunch sounds good, 100% in")
+	newer.CreatedAt = older.CreatedAt.Add(time.Second)
+	deleted := entity.NewMessage("conv-1", "user-1", "lunch is cancelled")
+	other := entity.NewMessage("conv-2", "user-1", "lunch elsewhere")
+	for _, msg := range []*entity.Message{older, newer, deleted, other} {
+		require.NoError(t, messageRepo.Create(ctx, msg))
+	}
+	deleted.MarkDeleted()
+	require.NoError(t, messageRepo.Update(ctx, deleted))
+
+	found, err := messageRepo.Search(ctx, "conv-1", "LUNCH", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, newer.ID, found[0].ID)
+	assert.Equal(t, older.ID, found[1].ID)
+
+	// Wildcards in the query are matched as plain characters
+	found, err = messageRepo.Search(ctx, "conv-1", "0%", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, newer.ID, found[0].ID)
+
+	found, err = messageRepo.Search(ctx, "conv-1", "_", 10)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// OAuthStateModel represents the GORM database model for pending OAuth authorization requests
+type OAuthStateModel struct {
+	State        string    `gorm:"primaryKey"`
+	Provider     string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for OAuthStateModel
+func (OAuthStateModel) TableName() string {
+	return "oauth_states"
+}
+
+type oauthStateStore struct {
+	db *gorm.DB
+}
+
+// NewOAuthStateStore creates a new Postgres-backed OAuth state store
+func NewOAuthStateStore(db *gorm.DB) repository.OAuthStateStore {
+	return &oauthStateStore{db: db}
+}
+
+func (s *oauthStateStore) Save(ctx context.Context, state, provider, codeVerifier string, expiresAt time.Time) error {
+	model := &OAuthStateModel{
+		State:        state,
+		Provider:     provider,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    expiresAt,
+	}
+	return conn(ctx, s.db).Create(model).Error
+}
+
+func (s *oauthStateStore) Consume(ctx context.Context, state string) (string, string, error) {
+	var model OAuthStateModel
+	err := conn(ctx, s.db).Where("state = ?", state).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", "", errors.ErrInvalidOAuthState
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	// Only the request whose delete removes the row may use the state
+	result := conn(ctx, s.db).Where("state = ?", state).Delete(&OAuthStateModel{})
+	if result.Error != nil {
+		return "", "", result.Error
+	}
+	if result.RowsAffected == 0 || time.Now().After(model.ExpiresAt) {
+		return "", "", errors.ErrInvalidOAuthState
+	}
+
+	return model.Provider, model.CodeVerifier, nil
+}
+
+func (s *oauthStateStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result := conn(ctx, s.db).
+		Where("expires_at < ?", time.Now()).
+		Delete(&OAuthStateModel{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,63 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthStateStore_ConsumeOnce(t *testing.T) {
+	store := postgres.NewOAuthStateStore(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, "state-1", "google", "verifier-1", time.Now().Add(10*time.Minute)))
+
+	provider, verifier, err := store.Consume(ctx, "state-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "google", provider)
+	assert.Equal(t, "verifier-1", verifier)
+
+	// Replaying the same state fails
+	_, _, err = store.Consume(ctx, "state-1")
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+}
+
+func TestOAuthStateStore_UnknownState(t *testing.T) {
+	store := postgres.NewOAuthStateStore(newTestDB(t))
+
+	_, _, err := store.Consume(context.Background(), "never-saved")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+}
+
+func TestOAuthStateStore_DeleteExpired(t *testing.T) {
+	store := postgres.NewOAuthStateStore(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, "old", "google", "verifier", time.Now().Add(-time.Minute)))
+	require.NoError(t, store.Save(ctx, "fresh", "google", "verifier", time.Now().Add(time.Minute)))
+
+	deleted, err := store.DeleteExpired(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, _, err = store.Consume(ctx, "old")
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+}
+
+func TestOAuthStateStore_ExpiredStateNotConsumable(t *testing.T) {
+	store := postgres.NewOAuthStateStore(newTestDB(t))
+	ctx := context.Background()
+
+	require.NoError(t, store.Save(ctx, "old", "google", "verifier", time.Now().Add(-time.Minute)))
+
+	_, _, err := store.Consume(ctx, "old")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+}
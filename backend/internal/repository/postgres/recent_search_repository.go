@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecentSearchModel represents the GORM database model for the queries users searched for recently
+type RecentSearchModel struct {
+	UserID     string    `gorm:"primaryKey;type:uuid;index:idx_recent_searches_user_searched,priority:1"`
+	QueryKey   string    `gorm:"primaryKey"` // lowercase Query, so repeated searches differing only in case are kept once
+	Query      string    `gorm:"not null"`
+	SearchedAt time.Time `gorm:"not null;index:idx_recent_searches_user_searched,priority:2"`
+}
+
+// TableName specifies the table name for RecentSearchModel
+func (RecentSearchModel) TableName() string {
+	return "recent_searches"
+}
+
+type recentSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewRecentSearchRepository creates a new recent search repository
+func NewRecentSearchRepository(db *gorm.DB) repository.RecentSearchRepository {
+	return &recentSearchRepository{db: db}
+}
+
+func (r *recentSearchRepository) Record(ctx context.Context, search *entity.RecentSearch, keep int) error {
+	model := &RecentSearchModel{
+		UserID:     search.UserID,
+		QueryKey:   strings.ToLower(search.Query),
+		Query:      search.Query,
+		SearchedAt: search.SearchedAt,
+	}
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "query_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"query", "searched_at"}),
+		}).Create(model).Error
+		if err != nil {
+			return err
+		}
+
+		var stale []string
+		err = tx.Model(&RecentSearchModel{}).
+			Where("user_id = ?", search.UserID).
+			Order("searched_at DESC, query_key").
+			Offset(keep).
+			Pluck("query_key", &stale).Error
+		if err != nil || len(stale) == 0 {
+			return err
+		}
+		return tx.Where("user_id = ? AND query_key IN ?", search.UserID, stale).Delete(&RecentSearchModel{}).Error
+	})
+}
+
+func (r *recentSearchRepository) ListByUserID(ctx context.Context, userID string, limit int) ([]*entity.RecentSearch, error) {
+	var models []RecentSearchModel
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("searched_at DESC, query_key").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	searches := make([]*entity.RecentSearch, len(models))
+	for i := range models {
+		searches[i] = r.toEntity(&models[i])
+	}
+	return searches, nil
+}
+
+func (r *recentSearchRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return conn(ctx, r.db).Where("user_id = ?", userID).Delete(&RecentSearchModel{}).Error
+}
+
+// toEntity converts GORM model to domain entity
+func (r *recentSearchRepository) toEntity(model *RecentSearchModel) *entity.RecentSearch {
+	return &entity.RecentSearch{
+		UserID:     model.UserID,
+		Query:      model.Query,
+		SearchedAt: model.SearchedAt,
+	}
+}
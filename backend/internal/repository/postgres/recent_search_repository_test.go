@@ -0,0 +1,85 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/repository"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSearches records the queries for the user a minute apart, oldest first
+func recordSearches(t *testing.T, repo repository.RecentSearchRepository, userID string, keep int, queries ...string) {
+	start := time.Now().Add(-time.Hour)
+	for i, query := range queries {
+		search := entity.NewRecentSearch(userID, query)
+		search.SearchedAt = start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.Record(context.Background(), search, keep))
+	}
+}
+
+func TestRecentSearchRepository_DedupesRepeatedQueries(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, nil)
+	repo := postgres.NewRecentSearchRepository(db)
+	user := seedUsers(t, userRepo, "alice@example.com")[0]
+
+	recordSearches(t, repo, user.ID, 10, "lunch", "budget", "Lunch")
+
+	searches, err := repo.ListByUserID(context.Background(), user.ID, 10)
+
+	require.NoError(t, err)
+	require.Len(t, searches, 2)
+	// The repeated query moves to the top with its latest spelling
+	assert.Equal(t, "Lunch", searches[0].Query)
+	assert.Equal(t, "budget", searches[1].Query)
+}
+
+func TestRecentSearchRepository_KeepsOnlyTheNewest(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, nil)
+	repo := postgres.NewRecentSearchRepository(db)
+	users := seedUsers(t, userRepo, "alice@example.com", "bob@example.com")
+
+	recordSearches(t, repo, users[1].ID, 3, "bob's search")
+	recordSearches(t, repo, users[0].ID, 3, "one", "two", "three", "four", "five")
+
+	searches, err := repo.ListByUserID(context.Background(), users[0].ID, 10)
+	require.NoError(t, err)
+	queries := make([]string, len(searches))
+	for i, search := range searches {
+		queries[i] = search.Query
+	}
+	assert.Equal(t, []string{"five", "four", "three"}, queries)
+
+	// Other users' searches are neither trimmed nor listed
+	searches, err = repo.ListByUserID(context.Background(), users[1].ID, 10)
+	require.NoError(t, err)
+	require.Len(t, searches, 1)
+	assert.Equal(t, "bob's search", searches[0].Query)
+}
+
+func TestRecentSearchRepository_DeleteByUserID(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, nil)
+	repo := postgres.NewRecentSearchRepository(db)
+	ctx := context.Background()
+	users := seedUsers(t, userRepo, "alice@example.com", "bob@example.com")
+
+	recordSearches(t, repo, users[0].ID, 10, "lunch", "budget")
+	recordSearches(t, repo, users[1].ID, 10, "lunch")
+
+	require.NoError(t, repo.DeleteByUserID(ctx, users[0].ID))
+
+	searches, err := repo.ListByUserID(ctx, users[0].ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, searches)
+	searches, err = repo.ListByUserID(ctx, users[1].ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, searches, 1)
+}
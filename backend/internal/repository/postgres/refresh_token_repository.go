@@ -13,12 +13,16 @@ import (
 
 // RefreshTokenModel represents the GORM database model for refresh tokens
 type RefreshTokenModel struct {
-	ID        string     `gorm:"primaryKey;type:uuid"`
-	UserID    string     `gorm:"type:uuid;not null;index"`
-	Token     string     `gorm:"uniqueIndex;not null"`
-	ExpiresAt time.Time  `gorm:"not null;index"`
-	CreatedAt time.Time  `gorm:"autoCreateTime"`
-	RevokedAt *time.Time `gorm:"default:null"`
+	ID         string     `gorm:"primaryKey;type:uuid"`
+	UserID     string     `gorm:"type:uuid;not null;index"`
+	FamilyID   string     `gorm:"type:uuid;not null;index"`
+	Token      string     `gorm:"uniqueIndex;not null"`
+	ExpiresAt  time.Time  `gorm:"not null;index"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	LastUsedAt time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time `gorm:"default:null"`
+	UserAgent  string
+	IPAddress  string `gorm:"column:ip_address"`
 }
 
 // TableName specifies the table name for RefreshTokenModel
@@ -37,12 +41,12 @@ func NewRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
 
 func (r *refreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
 	model := r.toModel(token)
-	return r.db.WithContext(ctx).Create(model).Error
+	return conn(ctx, r.db).Create(model).Error
 }
 
 func (r *refreshTokenRepository) GetByToken(ctx context.Context, token string) (*entity.RefreshToken, error) {
 	var model RefreshTokenModel
-	err := r.db.WithContext(ctx).Where("token = ?", token).First(&model).Error
+	err := conn(ctx, r.db).Where("token = ?", token).First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrRefreshTokenNotFound
 	}
@@ -54,7 +58,7 @@ func (r *refreshTokenRepository) GetByToken(ctx context.Context, token string) (
 
 func (r *refreshTokenRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.RefreshToken, error) {
 	var models []RefreshTokenModel
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
 		Find(&models).Error
 	if err != nil {
@@ -70,57 +74,94 @@ func (r *refreshTokenRepository) GetByUserID(ctx context.Context, userID string)
 
 func (r *refreshTokenRepository) Revoke(ctx context.Context, token string) error {
 	now := time.Now()
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&RefreshTokenModel{}).
 		Where("token = ?", token).
 		Update("revoked_at", now).Error
 }
 
+// RevokeActive checks revoked_at in the UPDATE itself, so of two concurrent rotations of the
+// same token only one sees it revoked by its own call
+func (r *refreshTokenRepository) RevokeActive(ctx context.Context, token string) (bool, error) {
+	result := conn(ctx, r.db).
+		Model(&RefreshTokenModel{}).
+		Where("token = ? AND revoked_at IS NULL", token).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *refreshTokenRepository) UpdateLastUsed(ctx context.Context, token string, usedAt time.Time) error {
+	return conn(ctx, r.db).
+		Model(&RefreshTokenModel{}).
+		Where("token = ?", token).
+		Update("last_used_at", usedAt).Error
+}
+
 func (r *refreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID string) error {
 	now := time.Now()
-	return r.db.WithContext(ctx).
+	return conn(ctx, r.db).
 		Model(&RefreshTokenModel{}).
 		Where("user_id = ? AND revoked_at IS NULL", userID).
 		Update("revoked_at", now).Error
 }
 
-func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) error {
-	return r.db.WithContext(ctx).
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	return conn(ctx, r.db).
+		Model(&RefreshTokenModel{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result := conn(ctx, r.db).
 		Where("expires_at < ?", time.Now()).
-		Delete(&RefreshTokenModel{}).Error
+		Delete(&RefreshTokenModel{})
+	return result.RowsAffected, result.Error
 }
 
 // toModel converts domain entity to GORM model
 func (r *refreshTokenRepository) toModel(token *entity.RefreshToken) *RefreshTokenModel {
 	model := &RefreshTokenModel{
-		ID:        token.ID,
-		UserID:    token.UserID,
-		Token:     token.Token,
-		ExpiresAt: token.ExpiresAt,
+		ID:         token.ID,
+		UserID:     token.UserID,
+		FamilyID:   token.FamilyID,
+		Token:      token.Token,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		UserAgent:  token.UserAgent,
+		IPAddress:  token.IPAddress,
 	}
-	
+
 	if token.RevokedAt != nil {
 		revokedAt := *token.RevokedAt
 		model.RevokedAt = &revokedAt
 	}
-	
+
 	return model
 }
 
 // toEntity converts GORM model to domain entity
 func (r *refreshTokenRepository) toEntity(model *RefreshTokenModel) *entity.RefreshToken {
 	token := &entity.RefreshToken{
-		ID:        model.ID,
-		UserID:    model.UserID,
-		Token:     model.Token,
-		ExpiresAt: model.ExpiresAt,
-		CreatedAt: model.CreatedAt,
+		ID:         model.ID,
+		UserID:     model.UserID,
+		FamilyID:   model.FamilyID,
+		Token:      model.Token,
+		ExpiresAt:  model.ExpiresAt,
+		CreatedAt:  model.CreatedAt,
+		LastUsedAt: model.LastUsedAt,
+		UserAgent:  model.UserAgent,
+		IPAddress:  model.IPAddress,
 	}
-	
+
 	if model.RevokedAt != nil {
 		revokedAt := *model.RevokedAt
 		token.RevokedAt = &revokedAt
 	}
-	
+
 	return token
 }
@@ -0,0 +1,34 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenRepository_RevokeActiveOnlyOnce(t *testing.T) {
+	repo := postgres.NewRefreshTokenRepository(newTestDB(t))
+	ctx := context.Background()
+
+	token := entity.NewRefreshToken("00000000-0000-0000-0000-000000000001", "token-1", time.Now().Add(time.Hour), "", "")
+	require.NoError(t, repo.Create(ctx, token))
+
+	revoked, err := repo.RevokeActive(ctx, "token-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// A second rotation of the same token finds it already revoked
+	revoked, err = repo.RevokeActive(ctx, "token-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = repo.RevokeActive(ctx, "unknown")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
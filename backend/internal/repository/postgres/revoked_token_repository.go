@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenModel represents the GORM database model for revoked access tokens
+type RevokedTokenModel struct {
+	TokenID   string    `gorm:"primaryKey;column:token_id"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for RevokedTokenModel
+func (RevokedTokenModel) TableName() string {
+	return "revoked_tokens"
+}
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository creates a new Postgres-backed access token blacklist
+func NewRevokedTokenRepository(db *gorm.DB) repository.RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+func (r *revokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	model := &RevokedTokenModel{
+		TokenID:   tokenID,
+		ExpiresAt: expiresAt,
+	}
+	return conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(model).Error
+}
+
+func (r *revokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).
+		Model(&RevokedTokenModel{}).
+		Where("token_id = ? AND expires_at > ?", tokenID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("expires_at < ?", time.Now()).
+		Delete(&RevokedTokenModel{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type txManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a transaction manager over db
+func NewTxManager(db *gorm.DB) repository.TxManager {
+	return &txManager{db: db}
+}
+
+func (m *txManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction started by TxManager.WithTx when ctx carries one, and db otherwise
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
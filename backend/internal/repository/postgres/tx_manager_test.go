@@ -0,0 +1,78 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxManager_RollsBackOnError(t *testing.T) {
+	db := newTestDB(t)
+	repo := postgres.NewRefreshTokenRepository(db)
+	txManager := postgres.NewTxManager(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	old := entity.NewRefreshToken("00000000-0000-0000-0000-000000000001", "old", expiresAt, "", "")
+	require.NoError(t, repo.Create(ctx, old))
+
+	// Rotation fails halfway: the revoke succeeds, then the insert hits the unique token index
+	err := txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := repo.Revoke(ctx, "old"); err != nil {
+			return err
+		}
+		return repo.Create(ctx, entity.NewRefreshToken(old.UserID, "old", expiresAt, "", ""))
+	})
+	require.Error(t, err)
+
+	stored, err := repo.GetByToken(ctx, "old")
+	require.NoError(t, err)
+	assert.Nil(t, stored.RevokedAt, "the revoke must be rolled back with the failed insert")
+}
+
+func TestTxManager_CommitsOnSuccess(t *testing.T) {
+	db := newTestDB(t)
+	repo := postgres.NewRefreshTokenRepository(db)
+	txManager := postgres.NewTxManager(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := txManager.WithTx(ctx, func(ctx context.Context) error {
+		// Nested calls join the outer transaction
+		return txManager.WithTx(ctx, func(ctx context.Context) error {
+			return repo.Create(ctx, entity.NewRefreshToken("00000000-0000-0000-0000-000000000001", "new", expiresAt, "", ""))
+		})
+	})
+	require.NoError(t, err)
+
+	_, err = repo.GetByToken(ctx, "new")
+	assert.NoError(t, err)
+}
+
+func TestTxManager_NestedErrorRollsBackOuter(t *testing.T) {
+	db := newTestDB(t)
+	repo := postgres.NewRefreshTokenRepository(db)
+	txManager := postgres.NewTxManager(db)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	err := txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, entity.NewRefreshToken("00000000-0000-0000-0000-000000000001", "new", expiresAt, "", "")); err != nil {
+			return err
+		}
+		return txManager.WithTx(ctx, func(ctx context.Context) error {
+			return assert.AnError
+		})
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	_, err = repo.GetByToken(ctx, "new")
+	assert.ErrorIs(t, err, errors.ErrRefreshTokenNotFound)
+}
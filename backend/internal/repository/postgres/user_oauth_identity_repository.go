@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// UserOAuthIdentityModel represents the GORM database model for linked OAuth identities
+type UserOAuthIdentityModel struct {
+	ID             string    `gorm:"primaryKey;type:uuid"`
+	UserID         string    `gorm:"type:uuid;not null;index;uniqueIndex:idx_user_oauth_identities_user_provider"`
+	Provider       string    `gorm:"not null;uniqueIndex:idx_user_oauth_identities_provider_user;uniqueIndex:idx_user_oauth_identities_user_provider"`
+	ProviderUserID string    `gorm:"not null;uniqueIndex:idx_user_oauth_identities_provider_user"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for UserOAuthIdentityModel
+func (UserOAuthIdentityModel) TableName() string {
+	return "user_oauth_identities"
+}
+
+type userOAuthIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserOAuthIdentityRepository creates a new OAuth identity repository
+func NewUserOAuthIdentityRepository(db *gorm.DB) repository.UserOAuthIdentityRepository {
+	return &userOAuthIdentityRepository{db: db}
+}
+
+func (r *userOAuthIdentityRepository) Create(ctx context.Context, identity *entity.UserOAuthIdentity) error {
+	model := &UserOAuthIdentityModel{
+		ID:             identity.ID,
+		UserID:         identity.UserID,
+		Provider:       identity.Provider,
+		ProviderUserID: identity.ProviderUserID,
+		CreatedAt:      identity.CreatedAt,
+	}
+	return conn(ctx, r.db).Create(model).Error
+}
+
+func (r *userOAuthIdentityRepository) GetByProvider(ctx context.Context, provider, providerUserID string) (*entity.UserOAuthIdentity, error) {
+	var model UserOAuthIdentityModel
+	err := conn(ctx, r.db).
+		Where("provider = ? AND provider_user_id = ?", provider, providerUserID).
+		First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrOAuthProviderNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(&model), nil
+}
+
+func (r *userOAuthIdentityRepository) ListByUserID(ctx context.Context, userID string) ([]*entity.UserOAuthIdentity, error) {
+	var models []UserOAuthIdentityModel
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]*entity.UserOAuthIdentity, len(models))
+	for i := range models {
+		identities[i] = r.toEntity(&models[i])
+	}
+	return identities, nil
+}
+
+func (r *userOAuthIdentityRepository) Delete(ctx context.Context, userID, provider string) error {
+	result := conn(ctx, r.db).
+		Where("user_id = ? AND provider = ?", userID, provider).
+		Delete(&UserOAuthIdentityModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrOAuthProviderNotLinked
+	}
+	return nil
+}
+
+// toEntity converts GORM model to domain entity
+func (r *userOAuthIdentityRepository) toEntity(model *UserOAuthIdentityModel) *entity.UserOAuthIdentity {
+	return &entity.UserOAuthIdentity{
+		ID:             model.ID,
+		UserID:         model.UserID,
+		Provider:       model.Provider,
+		ProviderUserID: model.ProviderUserID,
+		CreatedAt:      model.CreatedAt,
+	}
+}
@@ -0,0 +1,77 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOAuthIdentityRepository_LinkTwoProviders(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, postgres.NewAvatarRepository(db))
+	identityRepo := postgres.NewUserOAuthIdentityRepository(db)
+	ctx := context.Background()
+
+	user := seedUsers(t, userRepo, "alice@example.com")[0]
+	require.NoError(t, identityRepo.Create(ctx, entity.NewUserOAuthIdentity(user.ID, "google", "google-123")))
+	require.NoError(t, identityRepo.Create(ctx, entity.NewUserOAuthIdentity(user.ID, "github", "4242")))
+
+	// Both provider accounts resolve to the same user
+	viaGoogle, err := userRepo.GetByOAuthID(ctx, "google", "google-123")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, viaGoogle.ID)
+	assert.Equal(t, "alice@example.com", viaGoogle.Email)
+
+	viaGitHub, err := userRepo.GetByOAuthID(ctx, "github", "4242")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, viaGitHub.ID)
+
+	identities, err := identityRepo.ListByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, identities, 2)
+}
+
+func TestUserOAuthIdentityRepository_ProviderAccountLinkedOnce(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, postgres.NewAvatarRepository(db))
+	identityRepo := postgres.NewUserOAuthIdentityRepository(db)
+	ctx := context.Background()
+
+	users := seedUsers(t, userRepo, "alice@example.com", "bob@example.com")
+	require.NoError(t, identityRepo.Create(ctx, entity.NewUserOAuthIdentity(users[0].ID, "google", "google-123")))
+
+	// The same Google account cannot belong to another user
+	err := identityRepo.Create(ctx, entity.NewUserOAuthIdentity(users[1].ID, "google", "google-123"))
+	assert.Error(t, err)
+
+	// Nor can a user link two accounts of the same provider
+	err = identityRepo.Create(ctx, entity.NewUserOAuthIdentity(users[0].ID, "google", "google-456"))
+	assert.Error(t, err)
+}
+
+func TestUserOAuthIdentityRepository_Delete(t *testing.T) {
+	db := newTestDB(t)
+	userRepo := postgres.NewUserRepository(db, postgres.NewAvatarRepository(db))
+	identityRepo := postgres.NewUserOAuthIdentityRepository(db)
+	ctx := context.Background()
+
+	user := seedUsers(t, userRepo, "alice@example.com")[0]
+	require.NoError(t, identityRepo.Create(ctx, entity.NewUserOAuthIdentity(user.ID, "google", "google-123")))
+	require.NoError(t, identityRepo.Create(ctx, entity.NewUserOAuthIdentity(user.ID, "github", "4242")))
+
+	require.NoError(t, identityRepo.Delete(ctx, user.ID, "google"))
+
+	_, err := userRepo.GetByOAuthID(ctx, "google", "google-123")
+	assert.ErrorIs(t, err, errors.ErrUserNotFound)
+	_, err = userRepo.GetByOAuthID(ctx, "github", "4242")
+	assert.NoError(t, err)
+
+	err = identityRepo.Delete(ctx, user.ID, "google")
+	assert.ErrorIs(t, err, errors.ErrOAuthProviderNotLinked)
+}
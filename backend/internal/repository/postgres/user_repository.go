@@ -2,31 +2,44 @@ package postgres
 
 import (
 	"context"
+	stderrors "errors"
+	"strings"
 	"time"
 
 	"backend/internal/domain/entity"
 	"backend/internal/domain/errors"
 	"backend/internal/domain/repository"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
 // UserModel represents the GORM database model for users
 type UserModel struct {
-	ID                           string `gorm:"primaryKey;type:uuid"`
-	Email                        string `gorm:"uniqueIndex;not null"`
-	Password                     string
-	Name                         string `gorm:"not null"`
-	Phone                        string
-	OAuthProvider                string `gorm:"column:oauth_provider"`
-	OAuthID                      string `gorm:"column:oauth_id"`
-	EmailVerified                bool   `gorm:"default:false"`
-	VerificationToken            string `gorm:"column:verification_token"`
-	VerificationTokenExpiresAt   int64  `gorm:"column:verification_token_expires_at"`
-	ResetPasswordToken           string `gorm:"column:reset_password_token"`
-	ResetPasswordTokenExpiresAt  int64  `gorm:"column:reset_password_token_expires_at"`
-	CreatedAt                    int64  `gorm:"autoCreateTime:milli"`
-	UpdatedAt                    int64  `gorm:"autoUpdateTime:milli"`
+	ID                          string `gorm:"primaryKey;type:uuid"`
+	Email                       string `gorm:"uniqueIndex;not null"`
+	Password                    string
+	Name                        string  `gorm:"not null"`
+	Username                    *string `gorm:"uniqueIndex:idx_users_username"` // NULL until chosen, so users without one don't collide
+	Phone                       string
+	Role                        string `gorm:"not null;default:user"`
+	OAuthProvider               string `gorm:"column:oauth_provider"`
+	OAuthID                     string `gorm:"column:oauth_id"`
+	EmailVerified               bool   `gorm:"default:false"`
+	VerificationToken           string `gorm:"column:verification_token"`
+	VerificationTokenExpiresAt  int64  `gorm:"column:verification_token_expires_at"`
+	ResetPasswordToken          string `gorm:"column:reset_password_token"`
+	ResetPasswordTokenExpiresAt int64  `gorm:"column:reset_password_token_expires_at"`
+	PendingEmail                string `gorm:"column:pending_email"`
+	EmailChangeToken            string `gorm:"column:email_change_token"`
+	EmailChangeTokenExpiresAt   int64  `gorm:"column:email_change_token_expires_at"`
+	DeletionRequestedAt         int64  `gorm:"column:deletion_requested_at"`
+	DeactivatedAt               int64  `gorm:"column:deactivated_at"`
+	NameChangedAt               int64  `gorm:"column:name_changed_at"`
+	AvatarChangedAt             int64  `gorm:"column:avatar_changed_at"`
+	LastLoginAt                 int64  `gorm:"column:last_login_at"`
+	CreatedAt                   int64  `gorm:"autoCreateTime:milli"`
+	UpdatedAt                   int64  `gorm:"autoUpdateTime:milli"`
 }
 
 // TableName specifies the table name for UserModel
@@ -34,6 +47,12 @@ func (UserModel) TableName() string {
 	return "users"
 }
 
+// usernameIndex is the unique index that enforces usernames are not shared
+const usernameIndex = "idx_users_username"
+
+// likeEscaper escapes LIKE wildcards so search input is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type userRepository struct {
 	db         *gorm.DB
 	avatarRepo repository.AvatarRepository
@@ -49,12 +68,12 @@ func NewUserRepository(db *gorm.DB, avatarRepo repository.AvatarRepository) repo
 
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
 	model := r.toModel(user)
-	return r.db.WithContext(ctx).Create(model).Error
+	return translateUserError(conn(ctx, r.db).Create(model).Error)
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
 	var model UserModel
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error
+	err := conn(ctx, r.db).Where("id = ?", id).First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrUserNotFound
 	}
@@ -66,7 +85,19 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*entity.User,
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var model UserModel
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&model).Error
+	err := conn(ctx, r.db).Where("email = ?", email).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(ctx, &model), nil
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var model UserModel
+	err := conn(ctx, r.db).Where("username = ?", username).First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrUserNotFound
 	}
@@ -76,9 +107,25 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entity.
 	return r.toEntity(ctx, &model), nil
 }
 
+func (r *userRepository) ListByUsernames(ctx context.Context, usernames []string) ([]*entity.User, error) {
+	if len(usernames) == 0 {
+		return []*entity.User{}, nil
+	}
+
+	var models []UserModel
+	if err := conn(ctx, r.db).Where("username IN ?", usernames).Find(&models).Error; err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
 func (r *userRepository) GetByOAuthID(ctx context.Context, provider, oauthID string) (*entity.User, error) {
 	var model UserModel
-	err := r.db.WithContext(ctx).Where("oauth_provider = ? AND oauth_id = ?", provider, oauthID).First(&model).Error
+	err := conn(ctx, r.db).
+		Select("users.*").
+		Joins("JOIN user_oauth_identities ON user_oauth_identities.user_id = users.id").
+		Where("user_oauth_identities.provider = ? AND user_oauth_identities.provider_user_id = ?", provider, oauthID).
+		First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrUserNotFound
 	}
@@ -90,7 +137,7 @@ func (r *userRepository) GetByOAuthID(ctx context.Context, provider, oauthID str
 
 func (r *userRepository) GetByVerificationToken(ctx context.Context, token string) (*entity.User, error) {
 	var model UserModel
-	err := r.db.WithContext(ctx).Where("verification_token = ?", token).First(&model).Error
+	err := conn(ctx, r.db).Where("verification_token = ?", token).First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrUserNotFound
 	}
@@ -102,7 +149,19 @@ func (r *userRepository) GetByVerificationToken(ctx context.Context, token strin
 
 func (r *userRepository) GetByResetPasswordToken(ctx context.Context, token string) (*entity.User, error) {
 	var model UserModel
-	err := r.db.WithContext(ctx).Where("reset_password_token = ?", token).First(&model).Error
+	err := conn(ctx, r.db).Where("reset_password_token = ?", token).First(&model).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntity(ctx, &model), nil
+}
+
+func (r *userRepository) GetByEmailChangeToken(ctx context.Context, token string) (*entity.User, error) {
+	var model UserModel
+	err := conn(ctx, r.db).Where("email_change_token = ?", token).First(&model).Error
 	if err == gorm.ErrRecordNotFound {
 		return nil, errors.ErrUserNotFound
 	}
@@ -114,50 +173,172 @@ func (r *userRepository) GetByResetPasswordToken(ctx context.Context, token stri
 
 func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
 	model := r.toModel(user)
-	return r.db.WithContext(ctx).Save(model).Error
+	return translateUserError(conn(ctx, r.db).Save(model).Error)
 }
 
 func (r *userRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&UserModel{}, "id = ?", id).Error
+	return conn(ctx, r.db).Delete(&UserModel{}, "id = ?", id).Error
 }
 
 func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*entity.User, error) {
 	var models []UserModel
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&models).Error
+	err := conn(ctx, r.db).Limit(limit).Offset(offset).Find(&models).Error
 	if err != nil {
 		return nil, err
 	}
 
-	users := make([]*entity.User, len(models))
-	for i, model := range models {
-		users[i] = r.toEntity(ctx, &model)
+	return r.toEntities(ctx, models)
+}
+
+func (r *userRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := conn(ctx, r.db).Model(&UserModel{}).Count(&count).Error; err != nil {
+		return 0, err
 	}
-	return users, nil
+	return count, nil
+}
+
+func (r *userRepository) Search(ctx context.Context, query string, limit, offset int) ([]*entity.User, int64, error) {
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	scope := conn(ctx, r.db).Model(&UserModel{}).
+		Where("name ILIKE ? OR email ILIKE ? OR username ILIKE ?", pattern, pattern, pattern)
+
+	var total int64
+	if err := scope.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var models []UserModel
+	if err := scope.Order("name").Limit(limit).Offset(offset).Find(&models).Error; err != nil {
+		return nil, 0, err
+	}
+
+	users, err := r.toEntities(ctx, models)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) SearchContactable(ctx context.Context, query, excludeID string, limit int) ([]*entity.User, error) {
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+
+	var models []UserModel
+	err := conn(ctx, r.db).
+		Where("id <> ? AND deactivated_at = 0 AND deletion_requested_at = 0", excludeID).
+		Where(`LOWER(name) LIKE ? ESCAPE '\' OR LOWER(username) LIKE ? ESCAPE '\'`, pattern, pattern).
+		Order("name, id").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.toEntities(ctx, models)
+}
+
+// pendingDeletionBefore matches users whose deletion was requested before the bound Unix millisecond time
+const pendingDeletionBefore = "deletion_requested_at > 0 AND deletion_requested_at < ?"
+
+func (r *userRepository) ListPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]*entity.User, error) {
+	var models []UserModel
+	err := conn(ctx, r.db).
+		Where(pendingDeletionBefore, requestedBefore.UnixMilli()).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toEntities(ctx, models)
+}
+
+// DeletePendingDeletion re-checks the deletion request in the DELETE itself, so a user who cancels
+// after ListPendingDeletion ran is kept
+func (r *userRepository) DeletePendingDeletion(ctx context.Context, id string, requestedBefore time.Time) (bool, error) {
+	result := conn(ctx, r.db).
+		Where("id = ?", id).
+		Where(pendingDeletionBefore, requestedBefore.UnixMilli()).
+		Delete(&UserModel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// translateUserError turns a username unique violation, e.g. from two users claiming the
+// same name at once, into ErrUsernameTaken
+func translateUserError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr *pgconn.PgError
+	if stderrors.As(err, &pgErr) {
+		if pgErr.Code == "23505" && pgErr.ConstraintName == usernameIndex {
+			return errors.ErrUsernameTaken
+		}
+		return err
+	}
+	// SQLite, used in tests, names the column instead of the index
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: users.username") {
+		return errors.ErrUsernameTaken
+	}
+	return err
 }
 
 // toModel converts domain entity to GORM model
 func (r *userRepository) toModel(user *entity.User) *UserModel {
-	var verificationTokenExpiresAt, resetPasswordTokenExpiresAt int64
+	var verificationTokenExpiresAt, resetPasswordTokenExpiresAt, emailChangeTokenExpiresAt, deletionRequestedAt, deactivatedAt, nameChangedAt, avatarChangedAt, lastLoginAt int64
 	if !user.VerificationTokenExpiresAt.IsZero() {
 		verificationTokenExpiresAt = user.VerificationTokenExpiresAt.UnixMilli()
 	}
 	if !user.ResetPasswordTokenExpiresAt.IsZero() {
 		resetPasswordTokenExpiresAt = user.ResetPasswordTokenExpiresAt.UnixMilli()
 	}
+	if !user.EmailChangeTokenExpiresAt.IsZero() {
+		emailChangeTokenExpiresAt = user.EmailChangeTokenExpiresAt.UnixMilli()
+	}
+	if !user.DeletionRequestedAt.IsZero() {
+		deletionRequestedAt = user.DeletionRequestedAt.UnixMilli()
+	}
+	if !user.DeactivatedAt.IsZero() {
+		deactivatedAt = user.DeactivatedAt.UnixMilli()
+	}
+	if !user.NameChangedAt.IsZero() {
+		nameChangedAt = user.NameChangedAt.UnixMilli()
+	}
+	if !user.AvatarChangedAt.IsZero() {
+		avatarChangedAt = user.AvatarChangedAt.UnixMilli()
+	}
+	if !user.LastLoginAt.IsZero() {
+		lastLoginAt = user.LastLoginAt.UnixMilli()
+	}
+	var username *string
+	if user.Username != "" {
+		username = &user.Username
+	}
 
 	return &UserModel{
-		ID:                           user.ID,
-		Email:                        user.Email,
-		Password:                     user.Password,
-		Name:                         user.Name,
-		Phone:                        user.Phone,
-		OAuthProvider:                user.OAuthProvider,
-		OAuthID:                      user.OAuthID,
-		EmailVerified:                user.EmailVerified,
-		VerificationToken:            user.VerificationToken,
-		VerificationTokenExpiresAt:   verificationTokenExpiresAt,
-		ResetPasswordToken:           user.ResetPasswordToken,
-		ResetPasswordTokenExpiresAt:  resetPasswordTokenExpiresAt,
+		ID:                          user.ID,
+		Email:                       user.Email,
+		Password:                    user.Password,
+		Name:                        user.Name,
+		Username:                    username,
+		Phone:                       user.Phone,
+		Role:                        user.Role,
+		OAuthProvider:               user.OAuthProvider,
+		OAuthID:                     user.OAuthID,
+		EmailVerified:               user.EmailVerified,
+		VerificationToken:           user.VerificationToken,
+		VerificationTokenExpiresAt:  verificationTokenExpiresAt,
+		ResetPasswordToken:          user.ResetPasswordToken,
+		ResetPasswordTokenExpiresAt: resetPasswordTokenExpiresAt,
+		PendingEmail:                user.PendingEmail,
+		EmailChangeToken:            user.EmailChangeToken,
+		EmailChangeTokenExpiresAt:   emailChangeTokenExpiresAt,
+		DeletionRequestedAt:         deletionRequestedAt,
+		DeactivatedAt:               deactivatedAt,
+		NameChangedAt:               nameChangedAt,
+		AvatarChangedAt:             avatarChangedAt,
+		LastLoginAt:                 lastLoginAt,
 	}
 }
 
@@ -170,29 +351,90 @@ func (r *userRepository) toEntity(ctx context.Context, model *UserModel) *entity
 		// Ignore error if avatar not found, it's optional
 	}
 
-	var verificationTokenExpiresAt, resetPasswordTokenExpiresAt time.Time
+	return r.toEntityWithAvatar(model, avatar)
+}
+
+// toEntities converts a page of GORM models to domain entities,
+// loading all of their avatars in a single query
+func (r *userRepository) toEntities(ctx context.Context, models []UserModel) ([]*entity.User, error) {
+	var avatars map[string]*entity.Avatar
+	if r.avatarRepo != nil && len(models) > 0 {
+		ids := make([]string, len(models))
+		for i := range models {
+			ids[i] = models[i].ID
+		}
+
+		var err error
+		avatars, err = r.avatarRepo.GetByUserIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	users := make([]*entity.User, len(models))
+	for i := range models {
+		users[i] = r.toEntityWithAvatar(&models[i], avatars[models[i].ID])
+	}
+	return users, nil
+}
+
+// toEntityWithAvatar converts GORM model to domain entity using an already loaded avatar
+func (r *userRepository) toEntityWithAvatar(model *UserModel, avatar *entity.Avatar) *entity.User {
+	var verificationTokenExpiresAt, resetPasswordTokenExpiresAt, emailChangeTokenExpiresAt, deletionRequestedAt, deactivatedAt, nameChangedAt, avatarChangedAt, lastLoginAt time.Time
 	if model.VerificationTokenExpiresAt > 0 {
 		verificationTokenExpiresAt = time.UnixMilli(model.VerificationTokenExpiresAt)
 	}
 	if model.ResetPasswordTokenExpiresAt > 0 {
 		resetPasswordTokenExpiresAt = time.UnixMilli(model.ResetPasswordTokenExpiresAt)
 	}
+	if model.EmailChangeTokenExpiresAt > 0 {
+		emailChangeTokenExpiresAt = time.UnixMilli(model.EmailChangeTokenExpiresAt)
+	}
+	if model.DeletionRequestedAt > 0 {
+		deletionRequestedAt = time.UnixMilli(model.DeletionRequestedAt)
+	}
+	if model.DeactivatedAt > 0 {
+		deactivatedAt = time.UnixMilli(model.DeactivatedAt)
+	}
+	if model.NameChangedAt > 0 {
+		nameChangedAt = time.UnixMilli(model.NameChangedAt)
+	}
+	if model.AvatarChangedAt > 0 {
+		avatarChangedAt = time.UnixMilli(model.AvatarChangedAt)
+	}
+	if model.LastLoginAt > 0 {
+		lastLoginAt = time.UnixMilli(model.LastLoginAt)
+	}
+	var username string
+	if model.Username != nil {
+		username = *model.Username
+	}
 
 	return &entity.User{
-		ID:                           model.ID,
-		Email:                        model.Email,
-		Password:                     model.Password,
-		Name:                         model.Name,
-		Avatar:                       avatar,
-		Phone:                        model.Phone,
-		OAuthProvider:                model.OAuthProvider,
-		OAuthID:                      model.OAuthID,
-		EmailVerified:                model.EmailVerified,
-		VerificationToken:            model.VerificationToken,
-		VerificationTokenExpiresAt:   verificationTokenExpiresAt,
-		ResetPasswordToken:           model.ResetPasswordToken,
-		ResetPasswordTokenExpiresAt:  resetPasswordTokenExpiresAt,
-		CreatedAt:                    time.UnixMilli(model.CreatedAt),
-		UpdatedAt:                    time.UnixMilli(model.UpdatedAt),
+		ID:                          model.ID,
+		Email:                       model.Email,
+		Password:                    model.Password,
+		Name:                        model.Name,
+		Username:                    username,
+		Avatar:                      avatar,
+		Phone:                       model.Phone,
+		Role:                        model.Role,
+		OAuthProvider:               model.OAuthProvider,
+		OAuthID:                     model.OAuthID,
+		EmailVerified:               model.EmailVerified,
+		VerificationToken:           model.VerificationToken,
+		VerificationTokenExpiresAt:  verificationTokenExpiresAt,
+		ResetPasswordToken:          model.ResetPasswordToken,
+		ResetPasswordTokenExpiresAt: resetPasswordTokenExpiresAt,
+		PendingEmail:                model.PendingEmail,
+		EmailChangeToken:            model.EmailChangeToken,
+		EmailChangeTokenExpiresAt:   emailChangeTokenExpiresAt,
+		DeletionRequestedAt:         deletionRequestedAt,
+		DeactivatedAt:               deactivatedAt,
+		NameChangedAt:               nameChangedAt,
+		AvatarChangedAt:             avatarChangedAt,
+		LastLoginAt:                 lastLoginAt,
+		CreatedAt:                   time.UnixMilli(model.CreatedAt),
+		UpdatedAt:                   time.UnixMilli(model.UpdatedAt),
 	}
 }
@@ -0,0 +1,232 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+	"backend/internal/infrastructure/database"
+	"backend/internal/repository/postgres"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database with the schema migrated
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	require.NoError(t, database.AutoMigrate(db))
+	return db
+}
+
+func newTestUserRepository(t *testing.T) repository.UserRepository {
+	db := newTestDB(t)
+	return postgres.NewUserRepository(db, postgres.NewAvatarRepository(db))
+}
+
+// countQueries registers a callback that counts SELECT queries issued through db
+func countQueries(t *testing.T, db *gorm.DB) *int {
+	t.Helper()
+	count := new(int)
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		*count++
+	}))
+	return count
+}
+
+func seedUsers(t *testing.T, repo repository.UserRepository, emails ...string) []*entity.User {
+	t.Helper()
+	users := make([]*entity.User, len(emails))
+	for i, email := range emails {
+		users[i] = entity.NewUser(email, "hashed", "Test User", "", "")
+		require.NoError(t, repo.Create(context.Background(), users[i]))
+	}
+	return users
+}
+
+func TestUserRepository_Count_EmptyTable(t *testing.T) {
+	repo := newTestUserRepository(t)
+
+	count, err := repo.Count(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUserRepository_Count_IgnoresPagination(t *testing.T) {
+	repo := newTestUserRepository(t)
+	ctx := context.Background()
+	seedUsers(t, repo, "a@example.com", "b@example.com", "c@example.com")
+
+	page, err := repo.List(ctx, 2, 0)
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+
+	beyond, err := repo.List(ctx, 2, 10)
+	require.NoError(t, err)
+	assert.Empty(t, beyond)
+
+	count, err := repo.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestUserRepository_List_LoadsAvatarsInConstantQueries(t *testing.T) {
+	db := newTestDB(t)
+	avatarRepo := postgres.NewAvatarRepository(db)
+	repo := postgres.NewUserRepository(db, avatarRepo)
+	ctx := context.Background()
+
+	users := seedUsers(t, repo, "a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com")
+	for _, user := range users[:3] {
+		avatar := entity.NewExternalAvatar(user.ID, "https://example.com/"+user.ID+".png")
+		require.NoError(t, avatarRepo.Create(ctx, avatar))
+	}
+
+	queries := countQueries(t, db)
+
+	small, err := repo.List(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, small, 1)
+	smallQueries := *queries
+
+	*queries = 0
+	page, err := repo.List(ctx, 5, 0)
+	require.NoError(t, err)
+	require.Len(t, page, 5)
+
+	assert.Equal(t, smallQueries, *queries)
+	assert.Equal(t, 2, *queries)
+
+	withAvatar := 0
+	for _, user := range page {
+		if user.Avatar != nil {
+			assert.Equal(t, user.ID, user.Avatar.UserID)
+			withAvatar++
+		}
+	}
+	assert.Equal(t, 3, withAvatar)
+}
+
+func TestUserRepository_GetByUsername(t *testing.T) {
+	repo := newTestUserRepository(t)
+	ctx := context.Background()
+	user := entity.NewUser("a@example.com", "hashed", "Test User", "alice", "")
+	require.NoError(t, repo.Create(ctx, user))
+
+	found, err := repo.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, "alice", found.Username)
+
+	_, err = repo.GetByUsername(ctx, "bob")
+	assert.Equal(t, errors.ErrUserNotFound, err)
+}
+
+func TestUserRepository_UsernameUnique(t *testing.T) {
+	repo := newTestUserRepository(t)
+	ctx := context.Background()
+
+	// Users without a username don't conflict with each other
+	users := seedUsers(t, repo, "a@example.com", "b@example.com")
+
+	require.NoError(t, repo.Create(ctx, entity.NewUser("c@example.com", "hashed", "Test User", "alice", "")))
+
+	err := repo.Create(ctx, entity.NewUser("d@example.com", "hashed", "Test User", "alice", ""))
+	assert.Equal(t, errors.ErrUsernameTaken, err)
+
+	users[0].Username = "alice"
+	err = repo.Update(ctx, users[0])
+	assert.Equal(t, errors.ErrUsernameTaken, err)
+}
+
+func TestUserRepository_ListByUsernames(t *testing.T) {
+	repo := newTestUserRepository(t)
+	ctx := context.Background()
+	alice := entity.NewUser("a@example.com", "hashed", "Alice", "alice", "")
+	bob := entity.NewUser("b@example.com", "hashed", "Bob", "bob", "")
+	require.NoError(t, repo.Create(ctx, alice))
+	require.NoError(t, repo.Create(ctx, bob))
+	seedUsers(t, repo, "c@example.com")
+
+	users, err := repo.ListByUsernames(ctx, []string{"alice", "bob", "nobody"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.ElementsMatch(t, []string{alice.ID, bob.ID}, []string{users[0].ID, users[1].ID})
+
+	users, err = repo.ListByUsernames(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestUserRepository_SearchContactableSkipsUnreachableUsers(t *testing.T) {
+	repo := newTestUserRepository(t)
+	ctx := context.Background()
+	requester := entity.NewUser("alex@example.com", "hashed", "Alex", "alex", "")
+	alice := entity.NewUser("a@example.com", "hashed", "Alice", "", "")
+	bob := entity.NewUser("b@example.com", "hashed", "Bob", "al_b", "")
+	away := entity.NewUser("c@example.com", "hashed", "Alan", "", "")
+	leaving := entity.NewUser("d@example.com", "hashed", "Albert", "", "")
+	byEmail := entity.NewUser("alfie@example.com", "hashed", "Frank", "", "")
+	for _, user := range []*entity.User{requester, alice, bob, away, leaving, byEmail} {
+		require.NoError(t, repo.Create(ctx, user))
+	}
+	away.Deactivate()
+	leaving.RequestDeletion()
+	require.NoError(t, repo.Update(ctx, away))
+	require.NoError(t, repo.Update(ctx, leaving))
+
+	users, err := repo.SearchContactable(ctx, "AL", requester.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	// Matched by name and by username; the requester, unreachable users and emails are left out
+	assert.Equal(t, alice.ID, users[0].ID)
+	assert.Equal(t, bob.ID, users[1].ID)
+
+	users, err = repo.SearchContactable(ctx, "al", requester.ID, 1)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+}
+
+func TestUserRepository_DeletePendingDeletion(t *testing.T) {
+	repo := newTestUserRepository(t)
+	ctx := context.Background()
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+
+	users := seedUsers(t, repo, "expired@example.com", "recent@example.com", "active@example.com")
+	users[0].DeletionRequestedAt = cutoff.Add(-time.Hour)
+	users[1].DeletionRequestedAt = cutoff.Add(time.Hour)
+	for _, user := range users[:2] {
+		require.NoError(t, repo.Update(ctx, user))
+	}
+
+	for i, want := range []bool{true, false, false} {
+		deleted, err := repo.DeletePendingDeletion(ctx, users[i].ID, cutoff)
+		require.NoError(t, err)
+		assert.Equal(t, want, deleted, users[i].Email)
+	}
+
+	_, err := repo.GetByID(ctx, users[0].ID)
+	assert.ErrorIs(t, err, errors.ErrUserNotFound)
+	for _, user := range users[1:] {
+		_, err := repo.GetByID(ctx, user.ID)
+		assert.NoError(t, err, user.Email)
+	}
+}
@@ -9,51 +9,110 @@ import (
 
 	"backend/internal/domain/entity"
 	"backend/internal/domain/errors"
+	"backend/internal/domain/event"
 	"backend/internal/domain/repository"
 	"backend/internal/infrastructure/email"
+	"backend/internal/infrastructure/logger"
+	"backend/pkg/utils"
 
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// verificationTokenTTL is how long a verification link stays valid
+	verificationTokenTTL = 24 * time.Hour
+	// verificationResendCooldown is the minimum delay between two verification emails
+	verificationResendCooldown = time.Minute
+)
+
 // AuthUseCase defines the interface for authentication use cases
 type AuthUseCase interface {
-	Register(ctx context.Context, email, password, name, phone string) (*entity.User, error)
+	Register(ctx context.Context, email, password, name, username, phone string) (*entity.User, error)
 	Login(ctx context.Context, email, password string) (*entity.User, error)
 	VerifyEmail(ctx context.Context, token string) error
 	ResendVerificationEmail(ctx context.Context, email string) error
+	ResendVerificationEmailForUser(ctx context.Context, userID string) error
 	ForgotPassword(ctx context.Context, email string) error
 	ResetPassword(ctx context.Context, token, newPassword string) error
 }
 
 type authUseCase struct {
-	userRepo     repository.UserRepository
-	emailService email.EmailService
+	userRepo            repository.UserRepository
+	emailService        email.EmailService
+	emailQueue          email.EmailService
+	emailLimiter        EmailRateLimiter
+	eventBus            event.EventBus
+	deletionGracePeriod time.Duration
+	passwordPolicy      entity.PasswordPolicy
+	bcryptCost          int
 }
 
-// NewAuthUseCase creates a new authentication use case
-func NewAuthUseCase(userRepo repository.UserRepository, emailService email.EmailService) AuthUseCase {
+// NewAuthUseCase creates a new authentication use case.
+// Registration and verification hand their emails to emailQueue so a failing send is retried in the background;
+// the other flows send through emailService and report failures to the caller.
+// emailLimiter caps verification resends and password reset emails per address.
+// Registrations and verifications are published to eventBus; a nil bus discards them.
+// Logging in within deletionGracePeriod of an account deletion request reactivates the account.
+// passwordPolicy is enforced on registration and password reset, and passwords are hashed with bcryptCost.
+func NewAuthUseCase(
+	userRepo repository.UserRepository,
+	emailService email.EmailService,
+	emailQueue email.EmailService,
+	emailLimiter EmailRateLimiter,
+	eventBus event.EventBus,
+	deletionGracePeriod time.Duration,
+	passwordPolicy entity.PasswordPolicy,
+	bcryptCost int,
+) AuthUseCase {
+	if eventBus == nil {
+		eventBus = event.NopBus{}
+	}
 	return &authUseCase{
-		userRepo:     userRepo,
-		emailService: emailService,
+		userRepo:            userRepo,
+		emailService:        emailService,
+		emailQueue:          emailQueue,
+		emailLimiter:        emailLimiter,
+		eventBus:            eventBus,
+		deletionGracePeriod: deletionGracePeriod,
+		passwordPolicy:      passwordPolicy,
+		bcryptCost:          bcryptCost,
 	}
 }
 
 // Register creates a new user account
-func (uc *authUseCase) Register(ctx context.Context, email, password, name, phone string) (*entity.User, error) {
+func (uc *authUseCase) Register(ctx context.Context, email, password, name, username, phone string) (*entity.User, error) {
 	// Check if user already exists
-	existingUser, err := uc.userRepo.GetByEmail(ctx, email)
+	existingUser, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 	if err == nil && existingUser != nil {
 		return nil, errors.ErrUserAlreadyExists
 	}
 
+	if err := entity.ValidatePasswordStrength(password, uc.passwordPolicy); err != nil {
+		return nil, err
+	}
+
+	username, err = utils.NormalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.EnsureUsernameAvailable(ctx, uc.userRepo, username, ""); err != nil {
+		return nil, err
+	}
+
+	phone, err = utils.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), uc.bcryptCost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	// Create user entity
-	user := entity.NewUser(email, string(hashedPassword), name, phone)
+	user := entity.NewUser(email, string(hashedPassword), name, username, phone)
 
 	// Generate verification token
 	token, err := generateToken()
@@ -62,33 +121,39 @@ func (uc *authUseCase) Register(ctx context.Context, email, password, name, phon
 	}
 
 	user.VerificationToken = token
-	user.VerificationTokenExpiresAt = time.Now().Add(24 * time.Hour) // 24 hours
+	user.VerificationTokenExpiresAt = time.Now().Add(verificationTokenTTL)
 
 	// Save user to database
 	if err := uc.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Send verification email
-	if err := uc.emailService.SendVerificationEmail(user.Email, user.Name, token); err != nil {
-		// Log error but don't fail registration
-		fmt.Printf("Failed to send verification email: %v\n", err)
+	// Queue verification email
+	if err := uc.emailQueue.SendVerificationEmail(ctx, user.Email, user.Name, token); err != nil {
+		// Log error but don't fail registration, the user can request a new email
+		logger.ErrorContext(ctx, "Failed to queue verification email", err, zap.String("user_id", user.ID))
 	}
 
+	uc.eventBus.Publish(ctx, event.UserRegisteredEvent{
+		UserID:     user.ID,
+		Email:      user.Email,
+		OccurredAt: user.CreatedAt,
+	})
+
 	return user, nil
 }
 
 // Login authenticates a user
 func (uc *authUseCase) Login(ctx context.Context, email, password string) (*entity.User, error) {
 	// Get user by email
-	user, err := uc.userRepo.GetByEmail(ctx, email)
+	user, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 	if err != nil {
 		return nil, errors.ErrInvalidCredentials
 	}
 
 	// Check if user is OAuth user (no password)
 	if user.IsOAuthUser() {
-		return nil, fmt.Errorf("this account uses OAuth login, please use Google login")
+		return nil, errors.ErrPasswordLoginUnavailable
 	}
 
 	// Check if email is verified
@@ -101,6 +166,22 @@ func (uc *authUseCase) Login(ctx context.Context, email, password string) (*enti
 		return nil, errors.ErrInvalidCredentials
 	}
 
+	// Checked after the password so the error doesn't reveal deactivated accounts
+	if user.IsDeactivated() {
+		return nil, errors.ErrAccountDeactivated
+	}
+
+	// Logging back in within the grace period cancels a pending deletion
+	if user.IsPendingDeletion() {
+		if user.DeletionDue(uc.deletionGracePeriod) {
+			return nil, errors.ErrInvalidCredentials
+		}
+		user.CancelDeletion()
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to reactivate user: %w", err)
+		}
+	}
+
 	return user, nil
 }
 
@@ -132,20 +213,55 @@ func (uc *authUseCase) VerifyEmail(ctx context.Context, token string) error {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
+	// Subscribers send the welcome email
+	uc.eventBus.Publish(ctx, event.UserVerifiedEvent{
+		UserID:     user.ID,
+		Email:      user.Email,
+		UserName:   user.Name,
+		OccurredAt: time.Now(),
+	})
+
 	return nil
 }
 
 // ResendVerificationEmail resends the verification email
 func (uc *authUseCase) ResendVerificationEmail(ctx context.Context, email string) error {
 	// Get user by email
-	user, err := uc.userRepo.GetByEmail(ctx, email)
+	user, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 	if err != nil {
 		return errors.ErrUserNotFound
 	}
 
+	return uc.resendVerification(ctx, user)
+}
+
+// ResendVerificationEmailForUser resends the verification email to the given user's address
+func (uc *authUseCase) ResendVerificationEmailForUser(ctx context.Context, userID string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	return uc.resendVerification(ctx, user)
+}
+
+// resendVerification issues a fresh verification token and emails it, subject to the resend cooldown
+func (uc *authUseCase) resendVerification(ctx context.Context, user *entity.User) error {
 	// Check if already verified
 	if user.EmailVerified {
-		return fmt.Errorf("email already verified")
+		return errors.ErrEmailAlreadyVerified
+	}
+
+	// The previous token was issued verificationTokenTTL before it expires
+	if !user.VerificationTokenExpiresAt.IsZero() {
+		lastSentAt := user.VerificationTokenExpiresAt.Add(-verificationTokenTTL)
+		if time.Since(lastSentAt) < verificationResendCooldown {
+			return errors.ErrVerificationResendTooSoon
+		}
+	}
+
+	if !uc.emailLimiter.Allow(user.Email) {
+		return errors.ErrTooManyRequests
 	}
 
 	// Generate new verification token
@@ -155,7 +271,7 @@ func (uc *authUseCase) ResendVerificationEmail(ctx context.Context, email string
 	}
 
 	user.VerificationToken = token
-	user.VerificationTokenExpiresAt = time.Now().Add(24 * time.Hour)
+	user.VerificationTokenExpiresAt = time.Now().Add(verificationTokenTTL)
 
 	// Update user
 	if err := uc.userRepo.Update(ctx, user); err != nil {
@@ -163,7 +279,7 @@ func (uc *authUseCase) ResendVerificationEmail(ctx context.Context, email string
 	}
 
 	// Send verification email
-	if err := uc.emailService.SendVerificationEmail(user.Email, user.Name, token); err != nil {
+	if err := uc.emailService.SendVerificationEmail(ctx, user.Email, user.Name, token); err != nil {
 		return fmt.Errorf("failed to send verification email: %w", err)
 	}
 
@@ -173,7 +289,7 @@ func (uc *authUseCase) ResendVerificationEmail(ctx context.Context, email string
 // ForgotPassword initiates the password reset process
 func (uc *authUseCase) ForgotPassword(ctx context.Context, email string) error {
 	// Get user by email
-	user, err := uc.userRepo.GetByEmail(ctx, email)
+	user, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 	if err != nil {
 		// Don't reveal if user exists or not for security
 		return nil
@@ -185,6 +301,11 @@ func (uc *authUseCase) ForgotPassword(ctx context.Context, email string) error {
 		return nil
 	}
 
+	// Throttled requests look successful too, so they don't reveal the account either
+	if !uc.emailLimiter.Allow(user.Email) {
+		return nil
+	}
+
 	// Generate reset token
 	token, err := generateToken()
 	if err != nil {
@@ -200,7 +321,7 @@ func (uc *authUseCase) ForgotPassword(ctx context.Context, email string) error {
 	}
 
 	// Send password reset email
-	if err := uc.emailService.SendPasswordResetEmail(user.Email, user.Name, token); err != nil {
+	if err := uc.emailService.SendPasswordResetEmail(ctx, user.Email, user.Name, token); err != nil {
 		return fmt.Errorf("failed to send password reset email: %w", err)
 	}
 
@@ -209,6 +330,10 @@ func (uc *authUseCase) ForgotPassword(ctx context.Context, email string) error {
 
 // ResetPassword resets a user's password
 func (uc *authUseCase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := entity.ValidatePasswordStrength(newPassword, uc.passwordPolicy); err != nil {
+		return err
+	}
+
 	// Find user by reset token
 	user, err := uc.userRepo.GetByResetPasswordToken(ctx, token)
 	if err != nil {
@@ -221,7 +346,7 @@ func (uc *authUseCase) ResetPassword(ctx context.Context, token, newPassword str
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), uc.bcryptCost)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -0,0 +1,535 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/event"
+	"backend/internal/infrastructure/email"
+	"backend/internal/infrastructure/eventbus"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/usecase/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockUserRepository is a mock implementation of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *entity.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByUsernames(ctx context.Context, usernames []string) ([]*entity.User, error) {
+	args := m.Called(ctx, usernames)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByOAuthID(ctx context.Context, provider, oauthID string) (*entity.User, error) {
+	args := m.Called(ctx, provider, oauthID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByVerificationToken(ctx context.Context, token string) (*entity.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByResetPasswordToken(ctx context.Context, token string) (*entity.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmailChangeToken(ctx context.Context, token string) (*entity.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *entity.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*entity.User, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) Search(ctx context.Context, query string, limit, offset int) ([]*entity.User, int64, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*entity.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) SearchContactable(ctx context.Context, query, excludeID string, limit int) ([]*entity.User, error) {
+	args := m.Called(ctx, query, excludeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]*entity.User, error) {
+	args := m.Called(ctx, requestedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) DeletePendingDeletion(ctx context.Context, id string, requestedBefore time.Time) (bool, error) {
+	args := m.Called(ctx, id, requestedBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockEmailService is a mock implementation of EmailService
+type MockEmailService struct {
+	mock.Mock
+}
+
+func (m *MockEmailService) SendVerificationEmail(ctx context.Context, to, name, token string) error {
+	args := m.Called(ctx, to, name, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendPasswordResetEmail(ctx context.Context, to, name, token string) error {
+	args := m.Called(ctx, to, name, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendWelcomeEmail(ctx context.Context, to, name string) error {
+	args := m.Called(ctx, to, name)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error {
+	args := m.Called(ctx, to, name, token)
+	return args.Error(0)
+}
+
+// recordingBus collects published events
+type recordingBus struct {
+	events []event.Event
+}
+
+func (b *recordingBus) Publish(ctx context.Context, e event.Event) {
+	b.events = append(b.events, e)
+}
+
+const gracePeriod = 30 * 24 * time.Hour
+
+func TestLogin_ReactivatesWithinGracePeriod(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), new(MockEmailService), auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{
+		ID:                  "user-1",
+		Email:               "test@example.com",
+		Password:            string(hashed),
+		EmailVerified:       true,
+		DeletionRequestedAt: time.Now().Add(-24 * time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+	mockRepo.On("Update", mock.Anything, user).Return(nil)
+
+	result, err := uc.Login(context.Background(), "test@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsPendingDeletion())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLogin_PendingDeletionWrongPasswordNotReactivated(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), new(MockEmailService), auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{
+		ID:                  "user-1",
+		Email:               "test@example.com",
+		Password:            string(hashed),
+		EmailVerified:       true,
+		DeletionRequestedAt: time.Now().Add(-24 * time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+	_, err = uc.Login(context.Background(), "test@example.com", "wrong-password")
+
+	assert.Equal(t, errors.ErrInvalidCredentials, err)
+	assert.True(t, user.IsPendingDeletion())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestLogin_OAuthAccountRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), new(MockEmailService), auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{ID: "user-1", Email: "test@example.com", EmailVerified: true, OAuthProvider: "github", OAuthID: "gh-1"}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+	result, err := uc.Login(context.Background(), "test@example.com", "password123")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrPasswordLoginUnavailable, err)
+}
+
+func TestLogin_DeactivatedRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), new(MockEmailService), auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &entity.User{
+		ID:            "user-1",
+		Email:         "test@example.com",
+		Password:      string(hashed),
+		EmailVerified: true,
+		DeactivatedAt: time.Now().Add(-24 * time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+	result, err := uc.Login(context.Background(), "test@example.com", "password123")
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrAccountDeactivated, err)
+
+	// A wrong password doesn't reveal that the account is deactivated
+	_, err = uc.Login(context.Background(), "test@example.com", "wrong-password")
+	assert.Equal(t, errors.ErrInvalidCredentials, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestResendVerificationEmailForUser_Success(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockEmail, auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{
+		ID:                         "user-1",
+		Email:                      "test@example.com",
+		Name:                       "Test User",
+		VerificationToken:          "old-token",
+		VerificationTokenExpiresAt: time.Now().Add(23 * time.Hour), // sent an hour ago
+	}
+	mockRepo.On("GetByID", mock.Anything, "user-1").Return(user, nil)
+	mockRepo.On("Update", mock.Anything, user).Return(nil)
+	mockEmail.On("SendVerificationEmail", mock.Anything, "test@example.com", "Test User", mock.AnythingOfType("string")).Return(nil)
+
+	err := uc.ResendVerificationEmailForUser(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "old-token", user.VerificationToken)
+	mockRepo.AssertExpectations(t)
+	mockEmail.AssertExpectations(t)
+}
+
+func TestResendVerificationEmailForUser_AlreadyVerified(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockEmail, auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{ID: "user-1", Email: "test@example.com", EmailVerified: true}
+	mockRepo.On("GetByID", mock.Anything, "user-1").Return(user, nil)
+
+	err := uc.ResendVerificationEmailForUser(context.Background(), "user-1")
+
+	assert.Equal(t, errors.ErrEmailAlreadyVerified, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockEmail.AssertNotCalled(t, "SendVerificationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResendVerificationEmailForUser_Cooldown(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockEmail, auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{
+		ID:                         "user-1",
+		Email:                      "test@example.com",
+		VerificationToken:          "recent-token",
+		VerificationTokenExpiresAt: time.Now().Add(24*time.Hour - 10*time.Second), // sent 10s ago
+	}
+	mockRepo.On("GetByID", mock.Anything, "user-1").Return(user, nil)
+
+	err := uc.ResendVerificationEmailForUser(context.Background(), "user-1")
+
+	assert.Equal(t, errors.ErrVerificationResendTooSoon, err)
+	assert.Equal(t, "recent-token", user.VerificationToken)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockEmail.AssertNotCalled(t, "SendVerificationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResendVerificationEmailForUser_UserNotFound(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), new(MockEmailService), auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	mockRepo.On("GetByID", mock.Anything, "missing").Return(nil, errors.ErrUserNotFound)
+
+	err := uc.ResendVerificationEmailForUser(context.Background(), "missing")
+
+	assert.Equal(t, errors.ErrUserNotFound, err)
+}
+
+// welcomeEmailBus delivers user.verified events to the welcome email handler
+func welcomeEmailBus(emailQueue email.EmailService) *eventbus.Bus {
+	bus := eventbus.New()
+	bus.Subscribe(event.UserVerified, auth.WelcomeEmailHandler(emailQueue))
+	return bus
+}
+
+func TestVerifyEmail_SendsWelcomeEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockQueue := new(MockEmailService)
+	bus := welcomeEmailBus(mockQueue)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), mockQueue, auth.NewEmailRateLimiter(0, 0), bus, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{
+		ID:                         "user-1",
+		Email:                      "test@example.com",
+		Name:                       "Test User",
+		VerificationToken:          "token",
+		VerificationTokenExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetByVerificationToken", mock.Anything, "token").Return(user, nil)
+	mockRepo.On("Update", mock.Anything, user).Return(nil)
+	mockQueue.On("SendWelcomeEmail", mock.Anything, "test@example.com", "Test User").Return(nil)
+
+	err := uc.VerifyEmail(context.Background(), "token")
+	bus.Wait()
+
+	assert.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+	mockQueue.AssertExpectations(t)
+}
+
+func TestVerifyEmail_WelcomeEmailFailureIgnored(t *testing.T) {
+	logger.Init("release")
+	mockRepo := new(MockUserRepository)
+	mockQueue := new(MockEmailService)
+	bus := welcomeEmailBus(mockQueue)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), mockQueue, auth.NewEmailRateLimiter(0, 0), bus, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{
+		ID:                         "user-1",
+		Email:                      "test@example.com",
+		Name:                       "Test User",
+		VerificationToken:          "token",
+		VerificationTokenExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetByVerificationToken", mock.Anything, "token").Return(user, nil)
+	mockRepo.On("Update", mock.Anything, user).Return(nil)
+	mockQueue.On("SendWelcomeEmail", mock.Anything, "test@example.com", "Test User").Return(email.ErrQueueFull)
+
+	err := uc.VerifyEmail(context.Background(), "token")
+	bus.Wait()
+
+	assert.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+}
+
+func TestVerifyEmail_AlreadyVerifiedSendsNoWelcome(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockQueue := new(MockEmailService)
+	bus := welcomeEmailBus(mockQueue)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), mockQueue, auth.NewEmailRateLimiter(0, 0), bus, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{
+		ID:                         "user-1",
+		Email:                      "test@example.com",
+		EmailVerified:              true,
+		VerificationToken:          "token",
+		VerificationTokenExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetByVerificationToken", mock.Anything, "token").Return(user, nil)
+
+	err := uc.VerifyEmail(context.Background(), "token")
+	bus.Wait()
+
+	assert.NoError(t, err)
+	mockQueue.AssertNotCalled(t, "SendWelcomeEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResendVerificationEmail_RateLimited(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockEmail, auth.NewEmailRateLimiter(1, 15*time.Minute), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{ID: "user-1", Email: "test@example.com", Name: "Test User"}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+	mockRepo.On("Update", mock.Anything, user).Return(nil)
+	mockEmail.On("SendVerificationEmail", mock.Anything, "test@example.com", "Test User", mock.AnythingOfType("string")).Return(nil)
+
+	assert.NoError(t, uc.ResendVerificationEmail(context.Background(), "test@example.com"))
+
+	// Skip the per-token cooldown to reach the per-address limit
+	user.VerificationTokenExpiresAt = time.Time{}
+	err := uc.ResendVerificationEmail(context.Background(), "test@example.com")
+
+	assert.Equal(t, errors.ErrTooManyRequests, err)
+	mockEmail.AssertNumberOfCalls(t, "SendVerificationEmail", 1)
+}
+
+func TestForgotPassword_RateLimitedLooksSuccessful(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockEmail, auth.NewEmailRateLimiter(2, 15*time.Minute), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	user := &entity.User{ID: "user-1", Email: "test@example.com", Name: "Test User", Password: "hashed"}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+	mockRepo.On("Update", mock.Anything, user).Return(nil)
+	mockEmail.On("SendPasswordResetEmail", mock.Anything, "test@example.com", "Test User", mock.AnythingOfType("string")).Return(nil)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, uc.ForgotPassword(context.Background(), "test@example.com"))
+	}
+
+	mockEmail.AssertNumberOfCalls(t, "SendPasswordResetEmail", 2)
+}
+
+func TestRegister_QueuesVerificationEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	mockQueue := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockQueue, auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "foo@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "foo").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockQueue.On("SendVerificationEmail", mock.Anything, "foo@example.com", "Foo", mock.AnythingOfType("string")).Return(nil)
+
+	registered, err := uc.Register(context.Background(), "foo@example.com", "password123", "Foo", "foo", "")
+
+	assert.NoError(t, err)
+	mockQueue.AssertCalled(t, "SendVerificationEmail", mock.Anything, "foo@example.com", "Foo", registered.VerificationToken)
+	mockEmail.AssertNotCalled(t, "SendVerificationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRegister_PublishesUserRegistered(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockQueue := new(MockEmailService)
+	bus := &recordingBus{}
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), mockQueue, auth.NewEmailRateLimiter(0, 0), bus, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "foo@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "foo").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockQueue.On("SendVerificationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	registered, err := uc.Register(context.Background(), "foo@example.com", "password123", "Foo", "foo", "")
+
+	require.NoError(t, err)
+	require.Len(t, bus.events, 1)
+	published, ok := bus.events[0].(event.UserRegisteredEvent)
+	require.True(t, ok)
+	assert.Equal(t, registered.ID, published.UserID)
+	assert.Equal(t, "foo@example.com", published.Email)
+	assert.Empty(t, published.Provider)
+}
+
+func TestRegister_QueueFullDoesNotFailRegistration(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockQueue := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, new(MockEmailService), mockQueue, auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "foo@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "foo").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockQueue.On("SendVerificationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(email.ErrQueueFull)
+
+	registered, err := uc.Register(context.Background(), "foo@example.com", "password123", "Foo", "foo", "")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, registered)
+}
+
+func TestRegisterAndLogin_EmailCaseInsensitive(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := auth.NewAuthUseCase(mockRepo, mockEmail, mockEmail, auth.NewEmailRateLimiter(0, 0), nil, gracePeriod, entity.PasswordPolicy{MinLength: 8}, bcrypt.MinCost)
+	ctx := context.Background()
+
+	var stored *entity.User
+	mockRepo.On("GetByEmail", mock.Anything, "foo@example.com").Return(nil, errors.ErrUserNotFound).Once()
+	mockRepo.On("GetByUsername", mock.Anything, "foo").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*entity.User) }).
+		Return(nil)
+	mockEmail.On("SendVerificationEmail", mock.Anything, "foo@example.com", "Foo", mock.Anything).Return(nil)
+
+	registered, err := uc.Register(ctx, "  Foo@Example.com ", "password123", "Foo", "foo", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo@example.com", registered.Email)
+
+	stored.EmailVerified = true
+	mockRepo.On("GetByEmail", mock.Anything, "foo@example.com").Return(stored, nil)
+
+	loggedIn, err := uc.Login(ctx, "FOO@example.COM", "password123")
+	assert.NoError(t, err)
+	assert.Equal(t, stored.ID, loggedIn.ID)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// EmailRateLimiter limits how many emails are sent to the same address within a window
+type EmailRateLimiter interface {
+	// Allow records a send to email and reports whether it is within the limit
+	Allow(email string) bool
+}
+
+type emailRateLimiter struct {
+	maxSends int
+	window   time.Duration
+
+	mu    sync.Mutex
+	sends map[string][]time.Time
+}
+
+// NewEmailRateLimiter creates an in-memory limiter allowing maxSends emails per address within window.
+// A zero or negative maxSends disables the limit.
+func NewEmailRateLimiter(maxSends int, window time.Duration) EmailRateLimiter {
+	return &emailRateLimiter{
+		maxSends: maxSends,
+		window:   window,
+		sends:    make(map[string][]time.Time),
+	}
+}
+
+func (l *emailRateLimiter) Allow(email string) bool {
+	if l.maxSends <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	if len(l.sends[email]) >= l.maxSends {
+		return false
+	}
+	l.sends[email] = append(l.sends[email], now)
+	return true
+}
+
+// prune drops sends that left the window, so addresses that stopped sending are forgotten
+func (l *emailRateLimiter) prune(now time.Time) {
+	cutoff := now.Add(-l.window)
+	for email, times := range l.sends {
+		i := 0
+		for i < len(times) && !times[i].After(cutoff) {
+			i++
+		}
+		if i == len(times) {
+			delete(l.sends, email)
+		} else if i > 0 {
+			l.sends[email] = times[i:]
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/usecase/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailRateLimiter_LimitsPerAddress(t *testing.T) {
+	limiter := auth.NewEmailRateLimiter(3, 15*time.Minute)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("alice@example.com"), "send %d", i+1)
+	}
+	assert.False(t, limiter.Allow("alice@example.com"))
+
+	// Other addresses have their own budget
+	assert.True(t, limiter.Allow("bob@example.com"))
+}
+
+func TestEmailRateLimiter_WindowExpires(t *testing.T) {
+	limiter := auth.NewEmailRateLimiter(1, 20*time.Millisecond)
+
+	assert.True(t, limiter.Allow("alice@example.com"))
+	assert.False(t, limiter.Allow("alice@example.com"))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, limiter.Allow("alice@example.com"))
+}
+
+func TestEmailRateLimiter_ZeroDisablesLimit(t *testing.T) {
+	limiter := auth.NewEmailRateLimiter(0, time.Minute)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.Allow("alice@example.com"))
+	}
+}
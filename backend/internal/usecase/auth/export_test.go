@@ -0,0 +1,8 @@
+package auth
+
+import "time"
+
+// NewGoogleOAuthServiceWithUserInfoURL creates a Google OAuth service that fetches profiles from userInfoURL instead of Google
+func NewGoogleOAuthServiceWithUserInfoURL(userInfoURL string, timeout time.Duration) OAuthService {
+	return newGoogleOAuthService("client-id", "client-secret", "https://app.example.com/callback", userInfoURL, timeout)
+}
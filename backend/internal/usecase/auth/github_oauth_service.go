@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/domain/errors"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+// githubUser represents the user information from the GitHub API
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// githubEmail represents an entry of the GitHub user emails API
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+type githubOAuthService struct {
+	config  *oauth2.Config
+	timeout time.Duration
+}
+
+// NewGitHubOAuthService creates a new GitHub OAuth service.
+// Each call to GitHub must complete within timeout; timeouts are reported as errors.ErrUpstreamTimeout.
+func NewGitHubOAuthService(clientID, clientSecret, redirectURL string, timeout time.Duration) OAuthService {
+	return &githubOAuthService{
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"read:user", "user:email"},
+			Endpoint:     github.Endpoint,
+		},
+		timeout: timeout,
+	}
+}
+
+// GetAuthURL returns the GitHub OAuth authorization URL with the PKCE code challenge
+func (s *githubOAuthService) GetAuthURL(state, codeChallenge string) string {
+	return s.config.AuthCodeURL(state, pkceChallengeOptions(codeChallenge)...)
+}
+
+// ExchangeCode exchanges the authorization code for an access token, proving possession of the PKCE code verifier
+func (s *githubOAuthService) ExchangeCode(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	token, err := s.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", errors.WrapTimeout(err))
+	}
+	return token, nil
+}
+
+// GetUserInfo retrieves user information from GitHub using the access token.
+// Only the primary verified email is used, since accounts are linked by email.
+func (s *githubOAuthService) GetUserInfo(ctx context.Context, token *oauth2.Token) (*OAuthUserInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	client := s.config.Client(ctx, token)
+
+	var user githubUser
+	if err := s.getJSON(ctx, client, "/user", &user); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", errors.WrapTimeout(err))
+	}
+
+	var emails []githubEmail
+	if err := s.getJSON(ctx, client, "/user/emails", &emails); err != nil {
+		return nil, fmt.Errorf("failed to get user emails: %w", errors.WrapTimeout(err))
+	}
+
+	email := ""
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			email = e.Email
+			break
+		}
+	}
+	if email == "" {
+		return nil, fmt.Errorf("github account has no verified primary email")
+	}
+
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+
+	return &OAuthUserInfo{
+		ID:      strconv.FormatInt(user.ID, 10),
+		Email:   email,
+		Name:    name,
+		Picture: user.AvatarURL,
+	}, nil
+}
+
+// getJSON fetches a GitHub API path and decodes the JSON response into v
+func (s *githubOAuthService) getJSON(ctx context.Context, client *http.Client, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
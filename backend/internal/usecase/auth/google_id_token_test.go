@@ -0,0 +1,145 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/usecase/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const googleClientID = "client-id.apps.googleusercontent.com"
+
+// newGoogleCertsServer serves key as a JWK set under kid, like Google's certs endpoint
+func newGoogleCertsServer(t *testing.T, kid string, key *rsa.PublicKey) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": kid,
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func googleIDTokenClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            googleClientID,
+		"sub":            "google-123",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"name":           "Alice",
+		"picture":        "https://example.com/alice.png",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+func signGoogleIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestGoogleIDTokenVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := newGoogleCertsServer(t, "key-1", &key.PublicKey)
+	verifier := auth.NewGoogleIDTokenVerifier(googleClientID, server.URL, time.Second)
+	now := time.Now()
+
+	t.Run("valid token", func(t *testing.T) {
+		info, err := verifier.VerifyIDToken(context.Background(), signGoogleIDToken(t, key, "key-1", googleIDTokenClaims(now)))
+
+		require.NoError(t, err)
+		assert.Equal(t, "google-123", info.ID)
+		assert.Equal(t, "alice@example.com", info.Email)
+		assert.Equal(t, "Alice", info.Name)
+		assert.Equal(t, "https://example.com/alice.png", info.Picture)
+	})
+
+	rejected := map[string]func() string{
+		"expired": func() string {
+			claims := googleIDTokenClaims(now)
+			claims["exp"] = now.Add(-time.Minute).Unix()
+			return signGoogleIDToken(t, key, "key-1", claims)
+		},
+		"wrong audience": func() string {
+			claims := googleIDTokenClaims(now)
+			claims["aud"] = "someone-else.apps.googleusercontent.com"
+			return signGoogleIDToken(t, key, "key-1", claims)
+		},
+		"wrong issuer": func() string {
+			claims := googleIDTokenClaims(now)
+			claims["iss"] = "https://evil.example.com"
+			return signGoogleIDToken(t, key, "key-1", claims)
+		},
+		"unverified email": func() string {
+			claims := googleIDTokenClaims(now)
+			claims["email_verified"] = false
+			return signGoogleIDToken(t, key, "key-1", claims)
+		},
+		"signed by unknown key": func() string {
+			return signGoogleIDToken(t, otherKey, "key-1", googleIDTokenClaims(now))
+		},
+		"unknown key id": func() string {
+			return signGoogleIDToken(t, key, "key-2", googleIDTokenClaims(now))
+		},
+		"HMAC signed": func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, googleIDTokenClaims(now))
+			token.Header["kid"] = "key-1"
+			signed, err := token.SignedString([]byte("secret"))
+			require.NoError(t, err)
+			return signed
+		},
+		"malformed": func() string {
+			return "not-a-jwt"
+		},
+	}
+
+	for name, idToken := range rejected {
+		t.Run(name, func(t *testing.T) {
+			info, err := verifier.VerifyIDToken(context.Background(), idToken())
+
+			assert.ErrorIs(t, err, errors.ErrInvalidToken)
+			assert.Nil(t, info)
+		})
+	}
+}
+
+func TestGoogleIDTokenVerifier_CertsTimeout(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	server := newSlowServer(t)
+	verifier := auth.NewGoogleIDTokenVerifier(googleClientID, server.URL, 100*time.Millisecond)
+
+	info, err := verifier.VerifyIDToken(context.Background(), signGoogleIDToken(t, key, "key-1", googleIDTokenClaims(time.Now())))
+
+	assert.Nil(t, info)
+	assert.Equal(t, errors.ErrUpstreamTimeout, err)
+}
@@ -1,11 +1,16 @@
 package auth
 
 import (
+	"context"
+	"crypto/rsa"
+	"fmt"
 	"time"
 
 	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // TokenType represents the type of JWT token
@@ -16,69 +21,203 @@ const (
 	RefreshToken TokenType = "refresh"
 )
 
+// Supported JWT signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// SigningKey is an RSA key pair identified by its kid header.
+// Verify-only keys are kept around after rotation so tokens they signed
+// stay valid until they expire; they are never used to sign new tokens.
+type SigningKey struct {
+	ID         string
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+	VerifyOnly bool
+}
+
 // JWTClaims represents the JWT claims
 type JWTClaims struct {
 	UserID    string    `json:"user_id"`
+	Roles     []string  `json:"roles,omitempty"`
 	TokenType TokenType `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
 // JWTService defines the interface for JWT operations
 type JWTService interface {
-	GenerateAccessToken(userID string) (string, error)
-	GenerateRefreshToken(userID string) (string, error)
-	ValidateToken(tokenString string, expectedType TokenType) (*JWTClaims, error)
+	GenerateAccessToken(userID string, roles []string) (string, error)
+	GenerateRefreshToken(userID string, ttl time.Duration) (string, time.Time, error)
+	ValidateToken(ctx context.Context, tokenString string, expectedType TokenType) (*JWTClaims, error)
+	RevokeToken(ctx context.Context, claims *JWTClaims) error
 	GetAccessTokenExpiration() time.Duration
 	GetRefreshTokenExpiration() time.Duration
+	GetRememberMeRefreshTokenExpiration() time.Duration
 }
 
 type jwtService struct {
+	algorithm                string
 	secretKey                string
+	signingKey               *SigningKey
+	verifyKeys               map[string]*rsa.PublicKey
+	issuer                   string
+	audience                 string
+	allowLegacyTokens        bool
 	accessTokenExpireMinutes int
 	refreshTokenExpireDays   int
+	rememberMeExpireDays     int
+	revokedTokenRepo         repository.RevokedTokenRepository
 }
 
-// NewJWTService creates a new JWT service
-func NewJWTService(secretKey string, accessTokenExpireMinutes, refreshTokenExpireDays int) JWTService {
-	return &jwtService{
+// NewJWTService creates a new JWT service.
+// With HS256 (or an empty algorithm) tokens are signed with secretKey and keys is ignored.
+// With RS256 exactly one key in keys must be usable for signing; the rest must be
+// verify-only. When secretKey is set, HS256 tokens issued before switching to RS256
+// keep validating. Tokens are issued with issuer/audience and rejected when they don't
+// match; allowLegacyTokens accepts tokens that carry neither claim (issued before they
+// were introduced). rememberMeExpireDays is the longer refresh token lifetime offered
+// to "remember me" logins. revokedTokenRepo backs the token blacklist consulted by ValidateToken.
+func NewJWTService(
+	algorithm string,
+	secretKey string,
+	keys []SigningKey,
+	issuer, audience string,
+	allowLegacyTokens bool,
+	accessTokenExpireMinutes, refreshTokenExpireDays, rememberMeExpireDays int,
+	revokedTokenRepo repository.RevokedTokenRepository,
+) (JWTService, error) {
+	s := &jwtService{
+		algorithm:                algorithm,
 		secretKey:                secretKey,
+		verifyKeys:               make(map[string]*rsa.PublicKey),
+		issuer:                   issuer,
+		audience:                 audience,
+		allowLegacyTokens:        allowLegacyTokens,
 		accessTokenExpireMinutes: accessTokenExpireMinutes,
 		refreshTokenExpireDays:   refreshTokenExpireDays,
+		rememberMeExpireDays:     rememberMeExpireDays,
+		revokedTokenRepo:         revokedTokenRepo,
 	}
+
+	switch algorithm {
+	case "", AlgorithmHS256:
+		if secretKey == "" {
+			return nil, fmt.Errorf("jwt: HS256 requires a secret")
+		}
+		s.algorithm = AlgorithmHS256
+	case AlgorithmRS256:
+		for i := range keys {
+			key := keys[i]
+			if key.ID == "" {
+				return nil, fmt.Errorf("jwt: RS256 keys require a kid")
+			}
+			if _, exists := s.verifyKeys[key.ID]; exists {
+				return nil, fmt.Errorf("jwt: duplicate kid %q", key.ID)
+			}
+
+			publicKey := key.PublicKey
+			if publicKey == nil && key.PrivateKey != nil {
+				publicKey = &key.PrivateKey.PublicKey
+			}
+			if publicKey == nil {
+				return nil, fmt.Errorf("jwt: key %q has no public key", key.ID)
+			}
+			s.verifyKeys[key.ID] = publicKey
+
+			if key.VerifyOnly {
+				continue
+			}
+			if key.PrivateKey == nil {
+				return nil, fmt.Errorf("jwt: signing key %q has no private key", key.ID)
+			}
+			if s.signingKey != nil {
+				return nil, fmt.Errorf("jwt: only one signing key is allowed, mark older keys verify-only")
+			}
+			s.signingKey = &key
+		}
+		if s.signingKey == nil {
+			return nil, fmt.Errorf("jwt: RS256 requires one key that is not verify-only")
+		}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported signing algorithm %q", algorithm)
+	}
+
+	return s, nil
 }
 
-func (s *jwtService) GenerateAccessToken(userID string) (string, error) {
-	return s.generateToken(userID, AccessToken, time.Minute*time.Duration(s.accessTokenExpireMinutes))
+func (s *jwtService) GenerateAccessToken(userID string, roles []string) (string, error) {
+	token, _, err := s.generateToken(userID, roles, AccessToken, s.GetAccessTokenExpiration())
+	return token, err
 }
 
-func (s *jwtService) GenerateRefreshToken(userID string) (string, error) {
-	return s.generateToken(userID, RefreshToken, time.Hour*24*time.Duration(s.refreshTokenExpireDays))
+// GenerateRefreshToken issues a refresh token valid for ttl, or for the configured
+// refresh expiry when ttl is zero. It returns the token's expiry so callers can store it as-is.
+func (s *jwtService) GenerateRefreshToken(userID string, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 {
+		ttl = s.GetRefreshTokenExpiration()
+	}
+	return s.generateToken(userID, nil, RefreshToken, ttl)
 }
 
-func (s *jwtService) generateToken(userID string, tokenType TokenType, duration time.Duration) (string, error) {
-	now := time.Now()
+func (s *jwtService) generateToken(userID string, roles []string, tokenType TokenType, duration time.Duration) (string, time.Time, error) {
+	// JWT timestamps have second precision; truncate so the returned expiry matches the exp claim
+	now := time.Now().Truncate(time.Second)
+	expiresAt := now.Add(duration)
 	claims := &JWTClaims{
 		UserID:    userID,
+		Roles:     roles,
 		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+			ID:        uuid.New().String(),
+			Issuer:    s.issuer,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secretKey))
+	var signed string
+	var err error
+	if s.algorithm == AlgorithmRS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = s.signingKey.ID
+		signed, err = token.SignedString(s.signingKey.PrivateKey)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signed, err = token.SignedString([]byte(s.secretKey))
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
-func (s *jwtService) ValidateToken(tokenString string, expectedType TokenType) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// verificationKey selects the key used to verify a token based on its header
+func (s *jwtService) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if s.secretKey == "" {
 			return nil, errors.ErrInvalidToken
 		}
 		return []byte(s.secretKey), nil
-	})
+	case *jwt.SigningMethodRSA:
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.verifyKeys[kid]
+		if !ok {
+			return nil, errors.ErrInvalidToken
+		}
+		return key, nil
+	default:
+		return nil, errors.ErrInvalidToken
+	}
+}
+
+func (s *jwtService) ValidateToken(ctx context.Context, tokenString string, expectedType TokenType) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.verificationKey)
 
 	if err != nil {
 		return nil, errors.ErrInvalidToken
@@ -94,14 +233,69 @@ func (s *jwtService) ValidateToken(tokenString string, expectedType TokenType) (
 		return nil, errors.ErrInvalidToken
 	}
 
+	// Verify the token was minted by us, for us
+	if !s.validIssuerAndAudience(claims) {
+		return nil, errors.ErrInvalidToken
+	}
+
 	// Check expiration
 	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
 		return nil, errors.ErrTokenExpired
 	}
 
+	// Check blacklist
+	if s.revokedTokenRepo != nil && claims.ID != "" {
+		revoked, err := s.revokedTokenRepo.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, errors.ErrTokenRevoked
+		}
+	}
+
 	return claims, nil
 }
 
+// validIssuerAndAudience checks the iss and aud claims against the configured values
+func (s *jwtService) validIssuerAndAudience(claims *JWTClaims) bool {
+	if claims.Issuer == "" && len(claims.Audience) == 0 && s.allowLegacyTokens {
+		return true
+	}
+
+	if s.issuer != "" && claims.Issuer != s.issuer {
+		return false
+	}
+
+	if s.audience != "" {
+		for _, aud := range claims.Audience {
+			if aud == s.audience {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// RevokeToken blacklists the token identified by claims until it would have expired
+func (s *jwtService) RevokeToken(ctx context.Context, claims *JWTClaims) error {
+	if s.revokedTokenRepo == nil || claims == nil || claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(s.GetAccessTokenExpiration())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil // Already expired, nothing to blacklist
+	}
+
+	return s.revokedTokenRepo.Revoke(ctx, claims.ID, expiresAt)
+}
+
 func (s *jwtService) GetAccessTokenExpiration() time.Duration {
 	return time.Minute * time.Duration(s.accessTokenExpireMinutes)
 }
@@ -109,3 +303,7 @@ func (s *jwtService) GetAccessTokenExpiration() time.Duration {
 func (s *jwtService) GetRefreshTokenExpiration() time.Duration {
 	return time.Hour * 24 * time.Duration(s.refreshTokenExpireDays)
 }
+
+func (s *jwtService) GetRememberMeRefreshTokenExpiration() time.Duration {
+	return time.Hour * 24 * time.Duration(s.rememberMeExpireDays)
+}
@@ -0,0 +1,309 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+	"backend/internal/usecase/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRevokedTokenRepository is an in-memory RevokedTokenRepository for tests
+type memoryRevokedTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+}
+
+func newMemoryRevokedTokenRepository() *memoryRevokedTokenRepository {
+	return &memoryRevokedTokenRepository{tokens: make(map[string]time.Time)}
+}
+
+func (r *memoryRevokedTokenRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (r *memoryRevokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expiresAt, ok := r.tokens[tokenID]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (r *memoryRevokedTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, expiresAt := range r.tokens {
+		if time.Now().After(expiresAt) {
+			delete(r.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func newHS256Service(t *testing.T, repo repository.RevokedTokenRepository) auth.JWTService {
+	t.Helper()
+	svc, err := auth.NewJWTService(auth.AlgorithmHS256, "test-secret", nil, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, repo)
+	require.NoError(t, err)
+	return svc
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func TestGenerateAccessToken_SetsTokenID(t *testing.T) {
+	svc := newHS256Service(t, newMemoryRevokedTokenRepository())
+
+	token, err := svc.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(context.Background(), token, auth.AccessToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, "user-1", claims.UserID)
+}
+
+func TestValidateToken_RevokedAccessTokenRejected(t *testing.T) {
+	repo := newMemoryRevokedTokenRepository()
+	svc := newHS256Service(t, repo)
+	ctx := context.Background()
+
+	token, err := svc.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(ctx, token, auth.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RevokeToken(ctx, claims))
+
+	result, err := svc.ValidateToken(ctx, token, auth.AccessToken)
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrTokenRevoked, err)
+
+	// The blacklist entry lives for the remaining lifetime of the token
+	expiresAt := repo.tokens[claims.ID]
+	assert.WithinDuration(t, claims.ExpiresAt.Time, expiresAt, time.Second)
+}
+
+func TestValidateToken_OtherTokensUnaffectedByRevocation(t *testing.T) {
+	svc := newHS256Service(t, newMemoryRevokedTokenRepository())
+	ctx := context.Background()
+
+	revoked, err := svc.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+	other, err := svc.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(ctx, revoked, auth.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, svc.RevokeToken(ctx, claims))
+
+	_, err = svc.ValidateToken(ctx, other, auth.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestRS256_SetsKidAndValidates(t *testing.T) {
+	key := newRSAKey(t)
+	svc, err := auth.NewJWTService(auth.AlgorithmRS256, "", []auth.SigningKey{
+		{ID: "k1", PrivateKey: key},
+	}, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	require.NoError(t, err)
+
+	token, err := svc.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.JWTClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", parsed.Method.Alg())
+	assert.Equal(t, "k1", parsed.Header["kid"])
+
+	claims, err := svc.ValidateToken(context.Background(), token, auth.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+}
+
+func TestRS256_RotationKeepsOldTokensValid(t *testing.T) {
+	oldKey := newRSAKey(t)
+	newKey := newRSAKey(t)
+	ctx := context.Background()
+
+	before, err := auth.NewJWTService(auth.AlgorithmRS256, "", []auth.SigningKey{
+		{ID: "old", PrivateKey: oldKey},
+	}, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	require.NoError(t, err)
+	oldToken, err := before.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	// Rotate: new key signs, old key only verifies
+	after, err := auth.NewJWTService(auth.AlgorithmRS256, "", []auth.SigningKey{
+		{ID: "new", PrivateKey: newKey},
+		{ID: "old", PublicKey: &oldKey.PublicKey, VerifyOnly: true},
+	}, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	require.NoError(t, err)
+
+	_, err = after.ValidateToken(ctx, oldToken, auth.AccessToken)
+	assert.NoError(t, err)
+
+	newToken, err := after.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &auth.JWTClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+
+	// Once the old key is dropped its tokens no longer validate
+	dropped, err := auth.NewJWTService(auth.AlgorithmRS256, "", []auth.SigningKey{
+		{ID: "new", PrivateKey: newKey},
+	}, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	require.NoError(t, err)
+	_, err = dropped.ValidateToken(ctx, oldToken, auth.AccessToken)
+	assert.Equal(t, errors.ErrInvalidToken, err)
+}
+
+func TestRS256_HS256TokensStillValidWithSecret(t *testing.T) {
+	hs := newHS256Service(t, nil)
+	legacyToken, err := hs.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	rs, err := auth.NewJWTService(auth.AlgorithmRS256, "test-secret", []auth.SigningKey{
+		{ID: "k1", PrivateKey: newRSAKey(t)},
+	}, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	require.NoError(t, err)
+
+	_, err = rs.ValidateToken(context.Background(), legacyToken, auth.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestNewJWTService_RejectsInvalidKeySets(t *testing.T) {
+	key := newRSAKey(t)
+
+	_, err := auth.NewJWTService(auth.AlgorithmRS256, "", nil, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	assert.Error(t, err, "no signing key")
+
+	_, err = auth.NewJWTService(auth.AlgorithmRS256, "", []auth.SigningKey{
+		{ID: "a", PrivateKey: key},
+		{ID: "b", PrivateKey: newRSAKey(t)},
+	}, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	assert.Error(t, err, "two signing keys")
+
+	_, err = auth.NewJWTService(auth.AlgorithmHS256, "", nil, "tkhanchat", "tkhanchat-api", false, 15, 7, 30, nil)
+	assert.Error(t, err, "HS256 without secret")
+}
+
+func TestGenerateAccessToken_IncludesRoles(t *testing.T) {
+	svc := newHS256Service(t, nil)
+
+	token, err := svc.GenerateAccessToken("user-1", []string{"admin"})
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(context.Background(), token, auth.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+}
+
+// signRaw signs arbitrary claims with the test secret to simulate foreign or legacy tokens
+func signRaw(t *testing.T, claims *auth.JWTClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func TestGenerateAccessToken_SetsIssuerAndAudience(t *testing.T) {
+	svc := newHS256Service(t, nil)
+
+	token, err := svc.GenerateAccessToken("user-1", nil)
+	require.NoError(t, err)
+
+	claims, err := svc.ValidateToken(context.Background(), token, auth.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tkhanchat", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"tkhanchat-api"}, claims.Audience)
+}
+
+func TestValidateToken_RejectsWrongIssuerOrAudience(t *testing.T) {
+	svc := newHS256Service(t, nil)
+	ctx := context.Background()
+	expiresAt := jwt.NewNumericDate(time.Now().Add(time.Minute))
+
+	wrongIssuer := signRaw(t, &auth.JWTClaims{
+		UserID:    "user-1",
+		TokenType: auth.AccessToken,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "someone-else",
+			Audience:  jwt.ClaimStrings{"tkhanchat-api"},
+			ExpiresAt: expiresAt,
+		},
+	})
+	_, err := svc.ValidateToken(ctx, wrongIssuer, auth.AccessToken)
+	assert.Equal(t, errors.ErrInvalidToken, err)
+
+	wrongAudience := signRaw(t, &auth.JWTClaims{
+		UserID:    "user-1",
+		TokenType: auth.AccessToken,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "tkhanchat",
+			Audience:  jwt.ClaimStrings{"other-service"},
+			ExpiresAt: expiresAt,
+		},
+	})
+	_, err = svc.ValidateToken(ctx, wrongAudience, auth.AccessToken)
+	assert.Equal(t, errors.ErrInvalidToken, err)
+}
+
+func TestValidateToken_LegacyTokensWithoutIssuerAudience(t *testing.T) {
+	ctx := context.Background()
+	legacy := signRaw(t, &auth.JWTClaims{
+		UserID:    "user-1",
+		TokenType: auth.AccessToken,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+
+	strict := newHS256Service(t, nil)
+	_, err := strict.ValidateToken(ctx, legacy, auth.AccessToken)
+	assert.Equal(t, errors.ErrInvalidToken, err)
+
+	lenient, err := auth.NewJWTService(auth.AlgorithmHS256, "test-secret", nil, "tkhanchat", "tkhanchat-api", true, 15, 7, 30, nil)
+	require.NoError(t, err)
+	claims, err := lenient.ValidateToken(ctx, legacy, auth.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID)
+}
+
+func TestGenerateRefreshToken_ExpiryMatchesClaims(t *testing.T) {
+	svc := newHS256Service(t, nil)
+	ctx := context.Background()
+
+	// Zero TTL keeps the configured refresh expiry
+	token, expiresAt, err := svc.GenerateRefreshToken("user-1", 0)
+	require.NoError(t, err)
+	claims, err := svc.ValidateToken(ctx, token, auth.RefreshToken)
+	require.NoError(t, err)
+	assert.True(t, claims.ExpiresAt.Time.Equal(expiresAt))
+	assert.Equal(t, svc.GetRefreshTokenExpiration(), claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+
+	// Remember me uses the longer expiry
+	token, expiresAt, err = svc.GenerateRefreshToken("user-1", svc.GetRememberMeRefreshTokenExpiration())
+	require.NoError(t, err)
+	claims, err = svc.ValidateToken(ctx, token, auth.RefreshToken)
+	require.NoError(t, err)
+	assert.True(t, claims.ExpiresAt.Time.Equal(expiresAt))
+	assert.Equal(t, 30*24*time.Hour, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+}
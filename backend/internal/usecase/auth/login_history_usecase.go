@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/repository"
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultLoginHistoryLimit is the number of logins returned when no limit is requested
+	DefaultLoginHistoryLimit = 20
+	// MaxLoginHistoryLimit caps the number of logins returned
+	MaxLoginHistoryLimit = 100
+	// loginRecordTimeout bounds writing a login event after the response has been sent
+	loginRecordTimeout = 5 * time.Second
+)
+
+// LoginHistoryUseCase defines the interface for recording and listing successful logins
+type LoginHistoryUseCase interface {
+	// RecordLogin stores the login in the background, so it doesn't slow down the login response.
+	// Failures are logged.
+	RecordLogin(ctx context.Context, userID, method, ipAddress, userAgent string)
+	ListLogins(ctx context.Context, userID string, limit int) ([]*entity.LoginEvent, error)
+	// Wait blocks until the logins being recorded are written
+	Wait()
+}
+
+type loginHistoryUseCase struct {
+	loginEventRepo repository.LoginEventRepository
+	pending        sync.WaitGroup
+}
+
+// NewLoginHistoryUseCase creates a new login history use case
+func NewLoginHistoryUseCase(loginEventRepo repository.LoginEventRepository) LoginHistoryUseCase {
+	return &loginHistoryUseCase{loginEventRepo: loginEventRepo}
+}
+
+func (uc *loginHistoryUseCase) RecordLogin(ctx context.Context, userID, method, ipAddress, userAgent string) {
+	event := entity.NewLoginEvent(userID, method, ipAddress, userAgent)
+	// Detach from the request, which is canceled as soon as the response is sent
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loginRecordTimeout)
+
+	uc.pending.Add(1)
+	go func() {
+		defer uc.pending.Done()
+		defer cancel()
+		if err := uc.loginEventRepo.Record(ctx, event); err != nil {
+			logger.ErrorContext(ctx, "Failed to record login", err, zap.String("user_id", userID))
+		}
+	}()
+}
+
+// ListLogins returns the user's most recent logins, newest first; limit is clamped to MaxLoginHistoryLimit
+func (uc *loginHistoryUseCase) ListLogins(ctx context.Context, userID string, limit int) ([]*entity.LoginEvent, error) {
+	if limit <= 0 {
+		limit = DefaultLoginHistoryLimit
+	}
+	if limit > MaxLoginHistoryLimit {
+		limit = MaxLoginHistoryLimit
+	}
+	return uc.loginEventRepo.ListByUserID(ctx, userID, limit)
+}
+
+func (uc *loginHistoryUseCase) Wait() {
+	uc.pending.Wait()
+}
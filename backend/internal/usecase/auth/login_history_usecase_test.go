@@ -0,0 +1,79 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain/entity"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/usecase/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLoginEventRepository is a mock implementation of LoginEventRepository
+type MockLoginEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginEventRepository) Record(ctx context.Context, event *entity.LoginEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockLoginEventRepository) ListByUserID(ctx context.Context, userID string, limit int) ([]*entity.LoginEvent, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.LoginEvent), args.Error(1)
+}
+
+func TestRecordLogin_WritesAfterRequestEnds(t *testing.T) {
+	repo := new(MockLoginEventRepository)
+	uc := auth.NewLoginHistoryUseCase(repo)
+
+	repo.On("Record", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil
+	}), mock.MatchedBy(func(event *entity.LoginEvent) bool {
+		return event.UserID == "user-1" && event.Method == "google" &&
+			event.IPAddress == "203.0.113.7" && event.UserAgent == "test-agent"
+	})).Return(nil)
+
+	// The request context is canceled once the response is sent
+	ctx, cancel := context.WithCancel(context.Background())
+	uc.RecordLogin(ctx, "user-1", "google", "203.0.113.7", "test-agent")
+	cancel()
+	uc.Wait()
+
+	repo.AssertExpectations(t)
+}
+
+func TestRecordLogin_FailureIsLogged(t *testing.T) {
+	logger.Init("release")
+	repo := new(MockLoginEventRepository)
+	uc := auth.NewLoginHistoryUseCase(repo)
+
+	repo.On("Record", mock.Anything, mock.Anything).Return(assert.AnError)
+
+	uc.RecordLogin(context.Background(), "user-1", entity.LoginMethodPassword, "", "")
+	uc.Wait()
+
+	repo.AssertExpectations(t)
+}
+
+func TestListLogins_ClampsLimit(t *testing.T) {
+	repo := new(MockLoginEventRepository)
+	uc := auth.NewLoginHistoryUseCase(repo)
+
+	repo.On("ListByUserID", mock.Anything, "user-1", auth.DefaultLoginHistoryLimit).Return([]*entity.LoginEvent{}, nil).Once()
+	repo.On("ListByUserID", mock.Anything, "user-1", auth.MaxLoginHistoryLimit).Return([]*entity.LoginEvent{}, nil).Once()
+
+	_, err := uc.ListLogins(context.Background(), "user-1", 0)
+	assert.NoError(t, err)
+	_, err = uc.ListLogins(context.Background(), "user-1", 1000)
+	assert.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
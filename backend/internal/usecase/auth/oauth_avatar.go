@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"backend/internal/domain/entity"
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// importAvatar copies the provider's profile picture to Cloudinary, since provider URLs can expire.
+// Avatars are optional, so nil is returned when the picture can't be downloaded or uploaded.
+func (uc *oauthUseCase) importAvatar(ctx context.Context, userID, pictureURL string) *entity.Avatar {
+	if uc.cloudinaryServ == nil {
+		return nil
+	}
+
+	picture, err := uc.downloadPicture(ctx, pictureURL)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to download OAuth profile picture", zap.String("user_id", userID), zap.Error(err))
+		return nil
+	}
+
+	uploadResult, err := uc.cloudinaryServ.UploadAvatar(ctx, picture, userID)
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to upload OAuth profile picture", zap.String("user_id", userID), zap.Error(err))
+		return nil
+	}
+
+	return entity.NewAvatar(userID, uploadResult.PublicID, uploadResult.PublicURL, uploadResult.SecureURL)
+}
+
+// downloadPicture fetches an image of at most entity.MaxAvatarSize bytes
+func (uc *oauthUseCase) downloadPicture(ctx context.Context, pictureURL string) (*memoryFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pictureURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := uc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, entity.MaxAvatarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > entity.MaxAvatarSize {
+		return nil, fmt.Errorf("picture exceeds %d bytes", entity.MaxAvatarSize)
+	}
+
+	return &memoryFile{Reader: bytes.NewReader(data)}, nil
+}
+
+// memoryFile serves downloaded bytes as a multipart.File
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
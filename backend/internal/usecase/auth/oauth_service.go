@@ -5,11 +5,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"time"
+
+	"backend/internal/domain/errors"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
+// Supported OAuth providers, stored as the user's OAuthProvider
+const (
+	ProviderGoogle = "google"
+	ProviderGitHub = "github"
+)
+
+// googleUserInfoURL is the Google endpoint returning the profile of the access token's user
+const googleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+
+// OAuthUserInfo represents the provider-independent profile of an OAuth user
+type OAuthUserInfo struct {
+	ID      string
+	Email   string
+	Name    string
+	Picture string
+}
+
 // GoogleUserInfo represents the user information from Google
 type GoogleUserInfo struct {
 	ID            string `json:"id"`
@@ -24,17 +45,25 @@ type GoogleUserInfo struct {
 
 // OAuthService defines the interface for OAuth operations
 type OAuthService interface {
-	GetAuthURL(state string) string
-	ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error)
-	GetUserInfo(ctx context.Context, token *oauth2.Token) (*GoogleUserInfo, error)
+	GetAuthURL(state, codeChallenge string) string
+	ExchangeCode(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error)
+	GetUserInfo(ctx context.Context, token *oauth2.Token) (*OAuthUserInfo, error)
 }
 
 type googleOAuthService struct {
-	config *oauth2.Config
+	config      *oauth2.Config
+	idTokens    IDTokenVerifier
+	userInfoURL string
+	timeout     time.Duration
+}
+
+// NewGoogleOAuthService creates a new Google OAuth service.
+// Each call to Google must complete within timeout; timeouts are reported as errors.ErrUpstreamTimeout.
+func NewGoogleOAuthService(clientID, clientSecret, redirectURL string, timeout time.Duration) OAuthService {
+	return newGoogleOAuthService(clientID, clientSecret, redirectURL, googleUserInfoURL, timeout)
 }
 
-// NewGoogleOAuthService creates a new Google OAuth service
-func NewGoogleOAuthService(clientID, clientSecret, redirectURL string) OAuthService {
+func newGoogleOAuthService(clientID, clientSecret, redirectURL, userInfoURL string, timeout time.Duration) *googleOAuthService {
 	return &googleOAuthService{
 		config: &oauth2.Config{
 			ClientID:     clientID,
@@ -46,31 +75,45 @@ func NewGoogleOAuthService(clientID, clientSecret, redirectURL string) OAuthServ
 			},
 			Endpoint: google.Endpoint,
 		},
+		idTokens:    NewGoogleIDTokenVerifier(clientID, GoogleCertsURL, timeout),
+		userInfoURL: userInfoURL,
+		timeout:     timeout,
 	}
 }
 
-// GetAuthURL returns the Google OAuth authorization URL
-func (s *googleOAuthService) GetAuthURL(state string) string {
-	return s.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+// GetAuthURL returns the Google OAuth authorization URL with the PKCE code challenge
+func (s *googleOAuthService) GetAuthURL(state, codeChallenge string) string {
+	opts := append(pkceChallengeOptions(codeChallenge), oauth2.AccessTypeOffline)
+	return s.config.AuthCodeURL(state, opts...)
 }
 
-// ExchangeCode exchanges the authorization code for an access token
-func (s *googleOAuthService) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	token, err := s.config.Exchange(ctx, code)
+// ExchangeCode exchanges the authorization code for an access token, proving possession of the PKCE code verifier
+func (s *googleOAuthService) ExchangeCode(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	token, err := s.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
 	if err != nil {
-		return nil, fmt.Errorf("failed to exchange code: %w", err)
+		return nil, fmt.Errorf("failed to exchange code: %w", errors.WrapTimeout(err))
 	}
 	return token, nil
 }
 
-// GetUserInfo retrieves user information from Google using the access token
-func (s *googleOAuthService) GetUserInfo(ctx context.Context, token *oauth2.Token) (*GoogleUserInfo, error) {
-	client := s.config.Client(ctx, token)
-	
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+// GetUserInfo retrieves user information from Google using the access token.
+// Profiles without a verified email are rejected.
+func (s *googleOAuthService) GetUserInfo(ctx context.Context, token *oauth2.Token) (*OAuthUserInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.userInfoURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
+
+	resp, err := s.config.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", errors.WrapTimeout(err))
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
@@ -79,13 +122,35 @@ func (s *googleOAuthService) GetUserInfo(ctx context.Context, token *oauth2.Toke
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", errors.WrapTimeout(err))
 	}
 
 	var userInfo GoogleUserInfo
 	if err := json.Unmarshal(data, &userInfo); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal user info: %w", err)
 	}
+	// Accounts are linked by email, so an unverified one could take over another user's account
+	if userInfo.Email == "" || !userInfo.VerifiedEmail {
+		return nil, fmt.Errorf("google account has no verified email")
+	}
 
-	return &userInfo, nil
+	return &OAuthUserInfo{
+		ID:      userInfo.ID,
+		Email:   userInfo.Email,
+		Name:    userInfo.Name,
+		Picture: userInfo.Picture,
+	}, nil
+}
+
+// VerifyIDToken validates a Google ID token obtained by the client and returns its user information
+func (s *googleOAuthService) VerifyIDToken(ctx context.Context, idToken string) (*OAuthUserInfo, error) {
+	return s.idTokens.VerifyIDToken(ctx, idToken)
+}
+
+// pkceChallengeOptions adds an S256 PKCE code challenge to the authorization URL
+func pkceChallengeOptions(codeChallenge string) []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
 }
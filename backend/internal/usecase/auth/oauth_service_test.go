@@ -0,0 +1,82 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/domain/errors"
+	"backend/internal/usecase/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// newSlowServer returns a server whose requests hang until the test ends, well past any client timeout
+func newSlowServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server
+}
+
+func TestGoogleOAuthService_GetUserInfo(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":             "google-123",
+			"email":          "alice@example.com",
+			"verified_email": true,
+			"name":           "Alice",
+			"picture":        "https://example.com/alice.png",
+		})
+	}))
+	t.Cleanup(server.Close)
+	service := auth.NewGoogleOAuthServiceWithUserInfoURL(server.URL, time.Second)
+
+	info, err := service.GetUserInfo(context.Background(), &oauth2.Token{AccessToken: "access-token"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer access-token", authorization)
+	assert.Equal(t, "google-123", info.ID)
+	assert.Equal(t, "alice@example.com", info.Email)
+	assert.Equal(t, "https://example.com/alice.png", info.Picture)
+}
+
+func TestGoogleOAuthService_GetUserInfoRejectsUnverifiedEmail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":             "google-123",
+			"email":          "alice@example.com",
+			"verified_email": false,
+		})
+	}))
+	t.Cleanup(server.Close)
+	service := auth.NewGoogleOAuthServiceWithUserInfoURL(server.URL, time.Second)
+
+	info, err := service.GetUserInfo(context.Background(), &oauth2.Token{AccessToken: "access-token"})
+
+	assert.Nil(t, info)
+	assert.Error(t, err)
+}
+
+func TestGoogleOAuthService_GetUserInfoTimesOut(t *testing.T) {
+	server := newSlowServer(t)
+	service := auth.NewGoogleOAuthServiceWithUserInfoURL(server.URL, 100*time.Millisecond)
+
+	start := time.Now()
+	info, err := service.GetUserInfo(context.Background(), &oauth2.Token{AccessToken: "access-token"})
+
+	assert.Nil(t, info)
+	assert.ErrorIs(t, err, errors.ErrUpstreamTimeout)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
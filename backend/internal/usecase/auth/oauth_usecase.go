@@ -5,33 +5,81 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"net/http"
+	"time"
 
 	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/event"
 	"backend/internal/domain/repository"
+	"backend/internal/infrastructure/cloudinary"
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
 )
 
 // OAuthUseCase defines the interface for OAuth use cases
 type OAuthUseCase interface {
-	GenerateStateToken() (string, error)
-	GetGoogleAuthURL(state string) string
-	HandleGoogleCallback(ctx context.Context, code string) (*entity.User, error)
+	GetAuthURL(ctx context.Context, provider string) (authURL, state string, err error)
+	HandleCallback(ctx context.Context, provider, state, code string) (*entity.User, error)
+	LoginWithIDToken(ctx context.Context, provider, idToken string) (*entity.User, error)
+	UnlinkProvider(ctx context.Context, userID, provider string) error
+	LinkedProviders(ctx context.Context, userID string) ([]string, error)
 }
 
+// oauthStateTTL bounds how long an authorization request may stay pending
+const oauthStateTTL = 10 * time.Minute
+
 type oauthUseCase struct {
-	userRepo     repository.UserRepository
-	oauthService OAuthService
+	userRepo            repository.UserRepository
+	avatarRepo          repository.AvatarRepository
+	identityRepo        repository.UserOAuthIdentityRepository
+	stateStore          repository.OAuthStateStore
+	providers           map[string]OAuthService
+	cloudinaryServ      cloudinary.Service
+	httpClient          *http.Client
+	eventBus            event.EventBus
+	deletionGracePeriod time.Duration
 }
 
-// NewOAuthUseCase creates a new OAuth use case
-func NewOAuthUseCase(userRepo repository.UserRepository, oauthService OAuthService) OAuthUseCase {
+// NewOAuthUseCase creates a new OAuth use case for the given providers, keyed by provider name.
+// Profile pictures are downloaded with httpClient and uploaded to cloudinaryServ; a nil client uses a default
+// one and a nil Cloudinary service leaves OAuth users without an avatar.
+// Accounts created on a first login are published to eventBus; a nil bus discards them.
+// Logging in within deletionGracePeriod of an account deletion request reactivates the account.
+func NewOAuthUseCase(
+	userRepo repository.UserRepository,
+	avatarRepo repository.AvatarRepository,
+	identityRepo repository.UserOAuthIdentityRepository,
+	stateStore repository.OAuthStateStore,
+	providers map[string]OAuthService,
+	cloudinaryServ cloudinary.Service,
+	httpClient *http.Client,
+	eventBus event.EventBus,
+	deletionGracePeriod time.Duration,
+) OAuthUseCase {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if eventBus == nil {
+		eventBus = event.NopBus{}
+	}
 	return &oauthUseCase{
-		userRepo:     userRepo,
-		oauthService: oauthService,
+		userRepo:            userRepo,
+		avatarRepo:          avatarRepo,
+		identityRepo:        identityRepo,
+		stateStore:          stateStore,
+		providers:           providers,
+		cloudinaryServ:      cloudinaryServ,
+		httpClient:          httpClient,
+		eventBus:            eventBus,
+		deletionGracePeriod: deletionGracePeriod,
 	}
 }
 
-// GenerateStateToken generates a random state token for CSRF protection
-func (uc *oauthUseCase) GenerateStateToken() (string, error) {
+// generateStateToken generates a random state token for CSRF protection
+func generateStateToken() (string, error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
 		return "", fmt.Errorf("failed to generate state token: %w", err)
@@ -39,60 +87,240 @@ func (uc *oauthUseCase) GenerateStateToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// GetGoogleAuthURL returns the Google OAuth authorization URL
-func (uc *oauthUseCase) GetGoogleAuthURL(state string) string {
-	return uc.oauthService.GetAuthURL(state)
+// GetAuthURL starts an authorization request and returns the provider's OAuth authorization URL and its state.
+// The state and PKCE code verifier are stored server-side; only the verifier's challenge leaves the server.
+func (uc *oauthUseCase) GetAuthURL(ctx context.Context, provider string) (string, string, error) {
+	oauthService, ok := uc.providers[provider]
+	if !ok {
+		return "", "", errors.ErrOAuthProviderNotSupported
+	}
+
+	state, err := generateStateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	if err := uc.stateStore.Save(ctx, state, provider, verifier, time.Now().Add(oauthStateTTL)); err != nil {
+		return "", "", fmt.Errorf("failed to store oauth state: %w", err)
+	}
+
+	return oauthService.GetAuthURL(state, oauth2.S256ChallengeFromVerifier(verifier)), state, nil
 }
 
-// HandleGoogleCallback handles the Google OAuth callback
-func (uc *oauthUseCase) HandleGoogleCallback(ctx context.Context, code string) (*entity.User, error) {
+// HandleCallback handles the OAuth callback of the given provider
+func (uc *oauthUseCase) HandleCallback(ctx context.Context, provider, state, code string) (*entity.User, error) {
+	oauthService, ok := uc.providers[provider]
+	if !ok {
+		return nil, errors.ErrOAuthProviderNotSupported
+	}
+
+	// Each state can complete exactly one login
+	stateProvider, verifier, err := uc.stateStore.Consume(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if stateProvider != provider {
+		return nil, errors.ErrInvalidOAuthState
+	}
+
 	// Exchange code for token
-	token, err := uc.oauthService.ExchangeCode(ctx, code)
+	token, err := oauthService.ExchangeCode(ctx, code, verifier)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	// Get user info from Google
-	userInfo, err := uc.oauthService.GetUserInfo(ctx, token)
+	// Get user info from the provider
+	userInfo, err := oauthService.GetUserInfo(ctx, token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
+	return uc.login(ctx, provider, userInfo)
+}
+
+// LoginWithIDToken logs in with an ID token the client obtained from the provider directly
+func (uc *oauthUseCase) LoginWithIDToken(ctx context.Context, provider, idToken string) (*entity.User, error) {
+	verifier, ok := uc.providers[provider].(IDTokenVerifier)
+	if !ok {
+		return nil, errors.ErrOAuthProviderNotSupported
+	}
+
+	userInfo, err := verifier.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.login(ctx, provider, userInfo)
+}
+
+// login finds the user for the provider's profile, linking an existing account by email or creating a new one
+func (uc *oauthUseCase) login(ctx context.Context, provider string, userInfo *OAuthUserInfo) (*entity.User, error) {
 	// Check if user already exists by OAuth ID
-	existingUser, err := uc.userRepo.GetByOAuthID(ctx, "google", userInfo.ID)
+	existingUser, err := uc.userRepo.GetByOAuthID(ctx, provider, userInfo.ID)
 	if err == nil {
-		// User exists, return it
+		// User exists, reactivate it if it was pending deletion or deactivated
+		if err := uc.reactivate(ctx, existingUser); err != nil {
+			return nil, err
+		}
+		uc.linkAvatar(ctx, existingUser, userInfo.Picture)
 		return existingUser, nil
 	}
 
 	// Check if user exists by email (linking existing account)
-	existingUser, err = uc.userRepo.GetByEmail(ctx, userInfo.Email)
+	existingUser, err = uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(userInfo.Email))
 	if err == nil {
+		if err := uc.reactivate(ctx, existingUser); err != nil {
+			return nil, err
+		}
 		// User exists with this email, link OAuth account
-		existingUser.OAuthProvider = "google"
-		existingUser.OAuthID = userInfo.ID
-		// Note: We don't update avatar here to preserve user's uploaded avatar
-		// If you want to update avatar from OAuth, you would need to:
-		// 1. Create/update avatar entity
-		// 2. Save it via avatar repository
-		if err := uc.userRepo.Update(ctx, existingUser); err != nil {
-			return nil, fmt.Errorf("failed to link OAuth account: %w", err)
+		if err := uc.linkIdentity(ctx, existingUser, provider, userInfo.ID); err != nil {
+			return nil, err
 		}
+		// Only fill in a missing avatar to preserve the user's uploaded one
+		uc.linkAvatar(ctx, existingUser, userInfo.Picture)
 		return existingUser, nil
 	}
 
-	// Create new user
+	// Create new user; the avatar is imported once the user exists
 	newUser := entity.NewOAuthUser(
 		userInfo.Email,
 		userInfo.Name,
-		userInfo.Picture,
-		"google",
+		"",
+		provider,
 		userInfo.ID,
 	)
 
 	if err := uc.userRepo.Create(ctx, newUser); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	if err := uc.linkIdentity(ctx, newUser, provider, userInfo.ID); err != nil {
+		return nil, err
+	}
+	uc.linkAvatar(ctx, newUser, userInfo.Picture)
+
+	uc.eventBus.Publish(ctx, event.UserRegisteredEvent{
+		UserID:     newUser.ID,
+		Email:      newUser.Email,
+		Provider:   provider,
+		OccurredAt: newUser.CreatedAt,
+	})
 
 	return newUser, nil
 }
+
+// UnlinkProvider disconnects the OAuth provider from the user's account.
+// The last provider of an account without a password is kept, since it is the only way to sign in.
+func (uc *oauthUseCase) UnlinkProvider(ctx context.Context, userID, provider string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	identities, err := uc.identityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list OAuth accounts: %w", err)
+	}
+
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		return errors.ErrOAuthProviderNotLinked
+	}
+	if user.Password == "" && len(identities) == 1 {
+		return errors.ErrCannotUnlinkLastLogin
+	}
+
+	if err := uc.identityRepo.Delete(ctx, userID, provider); err != nil {
+		return fmt.Errorf("failed to unlink OAuth account: %w", err)
+	}
+
+	// Release the sign-up provider so the account can be linked again, possibly to another user
+	if user.OAuthProvider == provider {
+		user.OAuthProvider = ""
+		user.OAuthID = ""
+		user.UpdatedAt = time.Now()
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to unlink OAuth account: %w", err)
+		}
+	}
+	return nil
+}
+
+// LinkedProviders returns the OAuth providers linked to the user's account, oldest link first
+func (uc *oauthUseCase) LinkedProviders(ctx context.Context, userID string) ([]string, error) {
+	identities, err := uc.identityRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OAuth accounts: %w", err)
+	}
+
+	providers := make([]string, len(identities))
+	for i, identity := range identities {
+		providers[i] = identity.Provider
+	}
+	return providers, nil
+}
+
+// linkIdentity records that the provider account belongs to the user
+func (uc *oauthUseCase) linkIdentity(ctx context.Context, user *entity.User, provider, providerUserID string) error {
+	identity := entity.NewUserOAuthIdentity(user.ID, provider, providerUserID)
+	if err := uc.identityRepo.Create(ctx, identity); err != nil {
+		return fmt.Errorf("failed to link OAuth account: %w", err)
+	}
+	return nil
+}
+
+// linkAvatar uses the provider's profile picture for users who have no avatar yet
+func (uc *oauthUseCase) linkAvatar(ctx context.Context, user *entity.User, pictureURL string) {
+	if user.Avatar != nil || pictureURL == "" {
+		return
+	}
+	uc.saveAvatar(ctx, user, uc.importAvatar(ctx, user.ID, pictureURL))
+}
+
+// saveAvatar persists an OAuth avatar for the user.
+// Avatars are optional, so a failed save leaves the user without one instead of failing the login.
+func (uc *oauthUseCase) saveAvatar(ctx context.Context, user *entity.User, avatar *entity.Avatar) {
+	if avatar == nil {
+		return
+	}
+	if err := uc.avatarRepo.Create(ctx, avatar); err != nil {
+		// Nothing references the upload without its row, unless an avatar saved meanwhile uses the same public ID
+		if existing, _ := uc.avatarRepo.GetByUserID(ctx, user.ID); existing == nil || existing.PublicID != avatar.PublicID {
+			if err := uc.cloudinaryServ.DeleteAvatar(ctx, avatar.PublicID); err != nil {
+				logger.WarnContext(ctx, "Failed to delete OAuth profile picture", zap.String("public_id", avatar.PublicID), zap.Error(err))
+			}
+		}
+		user.Avatar = nil
+		return
+	}
+	user.Avatar = avatar
+}
+
+// reactivate cancels a pending deletion when the user logs in within the grace period,
+// and reactivates a deactivated account: signing in with the provider proves the user's identity
+// as the password does for the reactivate endpoint, which accounts without a password can't use
+func (uc *oauthUseCase) reactivate(ctx context.Context, user *entity.User) error {
+	if !user.IsPendingDeletion() && !user.IsDeactivated() {
+		return nil
+	}
+	if user.DeletionDue(uc.deletionGracePeriod) {
+		return errors.ErrInvalidCredentials
+	}
+
+	if user.IsPendingDeletion() {
+		user.CancelDeletion()
+	}
+	if user.IsDeactivated() {
+		user.Reactivate()
+	}
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+	return nil
+}
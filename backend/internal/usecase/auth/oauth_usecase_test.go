@@ -0,0 +1,794 @@
+package auth_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/infrastructure/cloudinary"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/usecase/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/oauth2"
+)
+
+// MockOAuthService is a mock implementation of OAuthService
+type MockOAuthService struct {
+	mock.Mock
+}
+
+func (m *MockOAuthService) GetAuthURL(state, codeChallenge string) string {
+	args := m.Called(state, codeChallenge)
+	return args.String(0)
+}
+
+func (m *MockOAuthService) ExchangeCode(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	args := m.Called(ctx, code, codeVerifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oauth2.Token), args.Error(1)
+}
+
+func (m *MockOAuthService) GetUserInfo(ctx context.Context, token *oauth2.Token) (*auth.OAuthUserInfo, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.OAuthUserInfo), args.Error(1)
+}
+
+// MockAvatarRepository is a mock implementation of AvatarRepository
+type MockAvatarRepository struct {
+	mock.Mock
+}
+
+func (m *MockAvatarRepository) Create(ctx context.Context, avatar *entity.Avatar) error {
+	args := m.Called(ctx, avatar)
+	return args.Error(0)
+}
+
+func (m *MockAvatarRepository) GetByUserID(ctx context.Context, userID string) (*entity.Avatar, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Avatar), args.Error(1)
+}
+
+func (m *MockAvatarRepository) GetByUserIDs(ctx context.Context, userIDs []string) (map[string]*entity.Avatar, error) {
+	args := m.Called(ctx, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*entity.Avatar), args.Error(1)
+}
+
+func (m *MockAvatarRepository) Update(ctx context.Context, avatar *entity.Avatar) error {
+	args := m.Called(ctx, avatar)
+	return args.Error(0)
+}
+
+func (m *MockAvatarRepository) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockCloudinaryService is a mock implementation of cloudinary.Service
+type MockCloudinaryService struct {
+	mock.Mock
+}
+
+func (m *MockCloudinaryService) UploadAvatar(ctx context.Context, file multipart.File, userID string) (*cloudinary.UploadResult, error) {
+	args := m.Called(ctx, file, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudinary.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinaryService) DeleteAvatar(ctx context.Context, publicID string) error {
+	args := m.Called(ctx, publicID)
+	return args.Error(0)
+}
+
+func (m *MockCloudinaryService) ThumbnailURLs(publicID string) map[int]string {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(map[int]string)
+}
+
+func (m *MockCloudinaryService) UploadAttachment(ctx context.Context, file io.Reader, conversationID string) (*cloudinary.UploadResult, error) {
+	args := m.Called(ctx, file, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudinary.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinaryService) DeleteAttachment(ctx context.Context, publicID, resourceType string) error {
+	args := m.Called(ctx, publicID, resourceType)
+	return args.Error(0)
+}
+
+// MockUserOAuthIdentityRepository is a mock implementation of UserOAuthIdentityRepository
+type MockUserOAuthIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserOAuthIdentityRepository) Create(ctx context.Context, identity *entity.UserOAuthIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserOAuthIdentityRepository) GetByProvider(ctx context.Context, provider, providerUserID string) (*entity.UserOAuthIdentity, error) {
+	args := m.Called(ctx, provider, providerUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.UserOAuthIdentity), args.Error(1)
+}
+
+func (m *MockUserOAuthIdentityRepository) ListByUserID(ctx context.Context, userID string) ([]*entity.UserOAuthIdentity, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.UserOAuthIdentity), args.Error(1)
+}
+
+func (m *MockUserOAuthIdentityRepository) Delete(ctx context.Context, userID, provider string) error {
+	args := m.Called(ctx, userID, provider)
+	return args.Error(0)
+}
+
+// identityFor matches an identity linking the provider account to the user
+func identityFor(userID, provider, providerUserID string) interface{} {
+	return mock.MatchedBy(func(identity *entity.UserOAuthIdentity) bool {
+		return identity.UserID == userID && identity.Provider == provider && identity.ProviderUserID == providerUserID
+	})
+}
+
+const (
+	oauthPicture      = "https://example.com/photo.jpg"
+	oauthPictureBytes = "\xff\xd8\xff\xe0jpeg"
+	importedPublicID  = "avatars/user_1"
+	importedAvatarURL = "https://res.cloudinary.com/demo/image/upload/avatars/user_1.jpg"
+)
+
+// roundTripFunc stubs the transport of an http.Client
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// pictureClient answers every request with the given status, content type and body
+func pictureClient(status int, contentType, body string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": []string{contentType}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})}
+}
+
+// newOAuthLogin returns a use case whose provider reports a user with the given provider ID.
+// The provider's picture downloads and uploads successfully.
+func newOAuthLogin(t *testing.T, provider, providerUserID string) (*MockUserRepository, *MockAvatarRepository, *MockUserOAuthIdentityRepository, auth.OAuthUseCase) {
+	t.Helper()
+
+	mockCloudinary := new(MockCloudinaryService)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, mock.Anything).
+		Return(&cloudinary.UploadResult{PublicID: importedPublicID, SecureURL: importedAvatarURL}, nil).Maybe()
+	return newOAuthLoginWith(t, provider, providerUserID, mockCloudinary, pictureClient(http.StatusOK, "image/jpeg", oauthPictureBytes))
+}
+
+// newOAuthLoginWith is newOAuthLogin with the given Cloudinary service and HTTP client for the provider's picture
+func newOAuthLoginWith(t *testing.T, provider, providerUserID string, cloudinaryServ cloudinary.Service, httpClient *http.Client) (*MockUserRepository, *MockAvatarRepository, *MockUserOAuthIdentityRepository, auth.OAuthUseCase) {
+	t.Helper()
+
+	mockUserRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockIdentityRepo := new(MockUserOAuthIdentityRepository)
+	mockOAuth := new(MockOAuthService)
+
+	token := &oauth2.Token{AccessToken: provider + "-token"}
+	mockOAuth.On("GetAuthURL", mock.Anything, mock.Anything).Return("https://" + provider + ".example.com/authorize")
+	mockOAuth.On("ExchangeCode", mock.Anything, "code", mock.Anything).Return(token, nil)
+	mockOAuth.On("GetUserInfo", mock.Anything, token).Return(&auth.OAuthUserInfo{
+		ID:      providerUserID,
+		Email:   "Alice@Example.com",
+		Name:    "Alice",
+		Picture: oauthPicture,
+	}, nil)
+
+	providers := map[string]auth.OAuthService{provider: mockOAuth}
+	uc := auth.NewOAuthUseCase(mockUserRepo, mockAvatarRepo, mockIdentityRepo, newMemoryOAuthStateStore(), providers, cloudinaryServ, httpClient, nil, gracePeriod)
+	return mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc
+}
+
+func newGoogleLogin(t *testing.T) (*MockUserRepository, *MockAvatarRepository, *MockUserOAuthIdentityRepository, auth.OAuthUseCase) {
+	return newOAuthLogin(t, auth.ProviderGoogle, "google-123")
+}
+
+// completeLogin starts an authorization request and completes it with the provider's code
+func completeLogin(t *testing.T, uc auth.OAuthUseCase, provider string) (*entity.User, error) {
+	t.Helper()
+	_, state, err := uc.GetAuthURL(context.Background(), provider)
+	if err != nil {
+		return nil, err
+	}
+	return uc.HandleCallback(context.Background(), provider, state, "code")
+}
+
+func TestHandleCallback_GoogleFirstLoginUploadsPicture(t *testing.T) {
+	var requestedURL string
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requestedURL = req.URL.String()
+		return pictureClient(http.StatusOK, "image/jpeg", oauthPictureBytes).Transport.RoundTrip(req)
+	})}
+	var uploaded string
+	mockCloudinary := new(MockCloudinaryService)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) {
+			data, _ := io.ReadAll(args.Get(1).(multipart.File))
+			uploaded = string(data)
+		}).
+		Return(&cloudinary.UploadResult{PublicID: importedPublicID, SecureURL: importedAvatarURL}, nil)
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLoginWith(t, auth.ProviderGoogle, "google-123", mockCloudinary, client)
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.UserOAuthIdentity")).Return(nil)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	assert.Equal(t, oauthPicture, requestedURL)
+	assert.Equal(t, oauthPictureBytes, uploaded)
+	mockCloudinary.AssertCalled(t, "UploadAvatar", mock.Anything, mock.Anything, result.ID)
+	if assert.NotNil(t, result.Avatar) {
+		assert.Equal(t, result.ID, result.Avatar.UserID)
+		assert.Equal(t, importedPublicID, result.Avatar.PublicID)
+		assert.Equal(t, importedAvatarURL, result.Avatar.SecureURL)
+	}
+	mockUserRepo.AssertExpectations(t)
+	mockAvatarRepo.AssertExpectations(t)
+}
+
+func TestHandleCallback_PictureDownloadFailureLeavesNoAvatar(t *testing.T) {
+	logger.Init("release")
+
+	failingTransport := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("connection refused")
+	})}
+	tests := []struct {
+		name   string
+		client *http.Client
+	}{
+		{"not found", pictureClient(http.StatusNotFound, "image/jpeg", "")},
+		{"not an image", pictureClient(http.StatusOK, "text/html", "<html></html>")},
+		{"too large", pictureClient(http.StatusOK, "image/jpeg", strings.Repeat("a", entity.MaxAvatarSize+1))},
+		{"transport error", failingTransport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCloudinary := new(MockCloudinaryService)
+			mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLoginWith(t, auth.ProviderGoogle, "google-123", mockCloudinary, tt.client)
+
+			mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+			mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, errors.ErrUserNotFound)
+			mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+			mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.UserOAuthIdentity")).Return(nil)
+
+			result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+			assert.NoError(t, err)
+			assert.Nil(t, result.Avatar)
+			mockCloudinary.AssertNotCalled(t, "UploadAvatar", mock.Anything, mock.Anything, mock.Anything)
+			mockAvatarRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandleCallback_PictureUploadFailureLeavesNoAvatar(t *testing.T) {
+	logger.Init("release")
+
+	mockCloudinary := new(MockCloudinaryService)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("cloudinary unavailable"))
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLoginWith(t, auth.ProviderGoogle, "google-123", mockCloudinary, pictureClient(http.StatusOK, "image/png", oauthPictureBytes))
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.UserOAuthIdentity")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result.Avatar)
+	mockAvatarRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestHandleCallback_AvatarSaveFailureDeletesUpload(t *testing.T) {
+	mockCloudinary := new(MockCloudinaryService)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, mock.Anything).
+		Return(&cloudinary.UploadResult{PublicID: importedPublicID, SecureURL: importedAvatarURL}, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, importedPublicID).Return(nil)
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLoginWith(t, auth.ProviderGoogle, "google-123", mockCloudinary, pictureClient(http.StatusOK, "image/jpeg", oauthPictureBytes))
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.UserOAuthIdentity")).Return(nil)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(fmt.Errorf("db down"))
+	mockAvatarRepo.On("GetByUserID", mock.Anything, mock.Anything).Return(nil, errors.ErrUserNotFound)
+
+	result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result.Avatar)
+	mockCloudinary.AssertExpectations(t)
+}
+
+func TestHandleCallback_AvatarSaveFailureKeepsUploadReferencedByExistingAvatar(t *testing.T) {
+	mockCloudinary := new(MockCloudinaryService)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, mock.Anything).
+		Return(&cloudinary.UploadResult{PublicID: importedPublicID, SecureURL: importedAvatarURL}, nil)
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLoginWith(t, auth.ProviderGoogle, "google-123", mockCloudinary, pictureClient(http.StatusOK, "image/jpeg", oauthPictureBytes))
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.UserOAuthIdentity")).Return(nil)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(fmt.Errorf("duplicate avatar"))
+	// An avatar saved meanwhile points at the same public ID, overwritten by this upload
+	mockAvatarRepo.On("GetByUserID", mock.Anything, mock.Anything).
+		Return(&entity.Avatar{ID: "a1", PublicID: importedPublicID}, nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result.Avatar)
+	mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, mock.Anything)
+}
+
+func TestHandleCallback_GoogleLinkKeepsUploadedAvatar(t *testing.T) {
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newGoogleLogin(t)
+
+	uploaded := entity.NewAvatar("user-1", "avatars/user-1", "http://cdn/avatar.png", "https://cdn/avatar.png")
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com", Avatar: uploaded}
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(existingUser, nil)
+	mockIdentityRepo.On("Create", mock.Anything, identityFor("user-1", "google", "google-123")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	assert.Same(t, uploaded, result.Avatar)
+	mockIdentityRepo.AssertExpectations(t)
+	mockAvatarRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestHandleCallback_GoogleLinkFillsMissingAvatar(t *testing.T) {
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newGoogleLogin(t)
+
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com"}
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(existingUser, nil)
+	mockIdentityRepo.On("Create", mock.Anything, identityFor("user-1", "google", "google-123")).Return(nil)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, result.Avatar) {
+		assert.Equal(t, "user-1", result.Avatar.UserID)
+		assert.Equal(t, importedAvatarURL, result.Avatar.SecureURL)
+	}
+	mockAvatarRepo.AssertExpectations(t)
+}
+
+func TestHandleCallback_GitHubFirstLoginStoresProvider(t *testing.T) {
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLogin(t, auth.ProviderGitHub, "4242")
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "github", "4242").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+	mockIdentityRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.UserOAuthIdentity")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGitHub)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "github", result.OAuthProvider)
+	assert.Equal(t, "4242", result.OAuthID)
+	mockIdentityRepo.AssertCalled(t, "Create", mock.Anything, identityFor(result.ID, "github", "4242"))
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestHandleCallback_GitHubLinksExistingAccountByEmail(t *testing.T) {
+	mockUserRepo, mockAvatarRepo, mockIdentityRepo, uc := newOAuthLogin(t, auth.ProviderGitHub, "4242")
+
+	uploaded := entity.NewAvatar("user-1", "avatars/user-1", "http://cdn/avatar.png", "https://cdn/avatar.png")
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com", Avatar: uploaded}
+
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "github", "4242").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(existingUser, nil)
+	mockIdentityRepo.On("Create", mock.Anything, identityFor("user-1", "github", "4242")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGitHub)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", result.ID)
+	mockUserRepo.AssertExpectations(t)
+	mockIdentityRepo.AssertExpectations(t)
+	mockAvatarRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestHandleCallback_SecondProviderKeepsFirstLinked(t *testing.T) {
+	mockUserRepo, _, mockIdentityRepo, uc := newOAuthLogin(t, auth.ProviderGitHub, "4242")
+
+	// Signed up with Google, now logs in with GitHub using the same email
+	existingUser := &entity.User{
+		ID:            "user-1",
+		Email:         "alice@example.com",
+		OAuthProvider: "google",
+		OAuthID:       "google-123",
+		Avatar:        entity.NewAvatar("user-1", "p", "u", "s"),
+	}
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "github", "4242").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(existingUser, nil)
+	mockIdentityRepo.On("Create", mock.Anything, identityFor("user-1", "github", "4242")).Return(nil)
+
+	result, err := completeLogin(t, uc, auth.ProviderGitHub)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "google", result.OAuthProvider)
+	assert.Equal(t, "google-123", result.OAuthID)
+	mockIdentityRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestHandleCallback_UnsupportedProvider(t *testing.T) {
+	_, _, _, uc := newGoogleLogin(t)
+
+	result, err := uc.HandleCallback(context.Background(), "gitlab", "state", "code")
+	assert.ErrorIs(t, err, errors.ErrOAuthProviderNotSupported)
+	assert.Nil(t, result)
+
+	url, state, err := uc.GetAuthURL(context.Background(), "gitlab")
+	assert.ErrorIs(t, err, errors.ErrOAuthProviderNotSupported)
+	assert.Empty(t, url)
+	assert.Empty(t, state)
+}
+
+// memoryOAuthStateStore is an in-memory OAuthStateStore
+type memoryOAuthStateStore struct {
+	states map[string]memoryOAuthState
+}
+
+type memoryOAuthState struct {
+	provider     string
+	codeVerifier string
+	expiresAt    time.Time
+}
+
+func newMemoryOAuthStateStore() *memoryOAuthStateStore {
+	return &memoryOAuthStateStore{states: make(map[string]memoryOAuthState)}
+}
+
+func (s *memoryOAuthStateStore) Save(ctx context.Context, state, provider, codeVerifier string, expiresAt time.Time) error {
+	s.states[state] = memoryOAuthState{provider: provider, codeVerifier: codeVerifier, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *memoryOAuthStateStore) Consume(ctx context.Context, state string) (string, string, error) {
+	entry, ok := s.states[state]
+	delete(s.states, state)
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", "", errors.ErrInvalidOAuthState
+	}
+	return entry.provider, entry.codeVerifier, nil
+}
+
+func (s *memoryOAuthStateStore) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// pkceProvider is a fake authorization server that, like a real one, only
+// exchanges a code for the verifier matching the challenge it was issued with
+type pkceProvider struct {
+	challenges map[string]string // code -> code challenge
+}
+
+func (p *pkceProvider) GetAuthURL(state, codeChallenge string) string {
+	p.challenges["code-"+state] = codeChallenge
+	return "https://provider.example.com/authorize?state=" + state
+}
+
+func (p *pkceProvider) ExchangeCode(ctx context.Context, code, codeVerifier string) (*oauth2.Token, error) {
+	challenge, ok := p.challenges[code]
+	if !ok || oauth2.S256ChallengeFromVerifier(codeVerifier) != challenge {
+		return nil, fmt.Errorf("invalid_grant: code verifier does not match")
+	}
+	delete(p.challenges, code)
+	return &oauth2.Token{AccessToken: "token"}, nil
+}
+
+func (p *pkceProvider) GetUserInfo(ctx context.Context, token *oauth2.Token) (*auth.OAuthUserInfo, error) {
+	return &auth.OAuthUserInfo{ID: "google-123", Email: "alice@example.com", Name: "Alice"}, nil
+}
+
+func newPKCELogin() (*MockUserRepository, auth.OAuthUseCase) {
+	mockUserRepo := new(MockUserRepository)
+	provider := &pkceProvider{challenges: make(map[string]string)}
+	providers := map[string]auth.OAuthService{auth.ProviderGoogle: provider}
+	return mockUserRepo, auth.NewOAuthUseCase(mockUserRepo, new(MockAvatarRepository), new(MockUserOAuthIdentityRepository), newMemoryOAuthStateStore(), providers, nil, nil, nil, gracePeriod)
+}
+
+func TestHandleCallback_PKCEVerifierMatchesChallenge(t *testing.T) {
+	mockUserRepo, uc := newPKCELogin()
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com", OAuthProvider: "google", OAuthID: "google-123"}
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(existingUser, nil)
+
+	authURL, state, err := uc.GetAuthURL(context.Background(), auth.ProviderGoogle)
+	assert.NoError(t, err)
+	assert.Contains(t, authURL, "state="+state)
+
+	result, err := uc.HandleCallback(context.Background(), auth.ProviderGoogle, state, "code-"+state)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", result.ID)
+}
+
+func TestHandleCallback_MismatchedVerifierFailsExchange(t *testing.T) {
+	mockUserRepo, uc := newPKCELogin()
+
+	_, stateA, err := uc.GetAuthURL(context.Background(), auth.ProviderGoogle)
+	assert.NoError(t, err)
+	_, stateB, err := uc.GetAuthURL(context.Background(), auth.ProviderGoogle)
+	assert.NoError(t, err)
+
+	// The code was issued for state A's challenge but is redeemed with state B's verifier
+	result, err := uc.HandleCallback(context.Background(), auth.ProviderGoogle, stateB, "code-"+stateA)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	mockUserRepo.AssertNotCalled(t, "GetByOAuthID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleCallback_UnknownStateRejected(t *testing.T) {
+	_, uc := newPKCELogin()
+
+	result, err := uc.HandleCallback(context.Background(), auth.ProviderGoogle, "never-issued", "code-never-issued")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+	assert.Nil(t, result)
+}
+
+func TestHandleCallback_ReplayedStateRejected(t *testing.T) {
+	mockUserRepo, uc := newPKCELogin()
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com", OAuthProvider: "google", OAuthID: "google-123"}
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(existingUser, nil)
+
+	_, state, err := uc.GetAuthURL(context.Background(), auth.ProviderGoogle)
+	assert.NoError(t, err)
+
+	_, err = uc.HandleCallback(context.Background(), auth.ProviderGoogle, state, "code-"+state)
+	assert.NoError(t, err)
+
+	result, err := uc.HandleCallback(context.Background(), auth.ProviderGoogle, state, "code-"+state)
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+	assert.Nil(t, result)
+}
+
+func TestHandleCallback_StateFromOtherProviderRejected(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	providers := map[string]auth.OAuthService{
+		auth.ProviderGoogle: &pkceProvider{challenges: make(map[string]string)},
+		auth.ProviderGitHub: &pkceProvider{challenges: make(map[string]string)},
+	}
+	uc := auth.NewOAuthUseCase(mockUserRepo, new(MockAvatarRepository), new(MockUserOAuthIdentityRepository), newMemoryOAuthStateStore(), providers, nil, nil, nil, gracePeriod)
+
+	_, state, err := uc.GetAuthURL(context.Background(), auth.ProviderGoogle)
+	assert.NoError(t, err)
+
+	result, err := uc.HandleCallback(context.Background(), auth.ProviderGitHub, state, "code-"+state)
+	assert.ErrorIs(t, err, errors.ErrInvalidOAuthState)
+	assert.Nil(t, result)
+}
+
+func TestUnlinkProvider_ClearsLinkWhenPasswordSet(t *testing.T) {
+	mockUserRepo, _, mockIdentityRepo, uc := newGoogleLogin(t)
+	existingUser := &entity.User{ID: "user-1", Password: "hashed", OAuthProvider: "google", OAuthID: "google-123"}
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(existingUser, nil)
+	mockUserRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockIdentityRepo.On("ListByUserID", mock.Anything, "user-1").Return([]*entity.UserOAuthIdentity{
+		entity.NewUserOAuthIdentity("user-1", "google", "google-123"),
+	}, nil)
+	mockIdentityRepo.On("Delete", mock.Anything, "user-1", "google").Return(nil)
+
+	err := uc.UnlinkProvider(context.Background(), "user-1", auth.ProviderGoogle)
+
+	assert.NoError(t, err)
+	assert.Empty(t, existingUser.OAuthProvider)
+	assert.Empty(t, existingUser.OAuthID)
+	mockUserRepo.AssertExpectations(t)
+	mockIdentityRepo.AssertExpectations(t)
+}
+
+func TestUnlinkProvider_KeepsOtherProviderWithoutPassword(t *testing.T) {
+	mockUserRepo, _, mockIdentityRepo, uc := newGoogleLogin(t)
+	existingUser := &entity.User{ID: "user-1", OAuthProvider: "google", OAuthID: "google-123"}
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(existingUser, nil)
+	mockIdentityRepo.On("ListByUserID", mock.Anything, "user-1").Return([]*entity.UserOAuthIdentity{
+		entity.NewUserOAuthIdentity("user-1", "google", "google-123"),
+		entity.NewUserOAuthIdentity("user-1", "github", "4242"),
+	}, nil)
+	mockIdentityRepo.On("Delete", mock.Anything, "user-1", "github").Return(nil)
+
+	err := uc.UnlinkProvider(context.Background(), "user-1", auth.ProviderGitHub)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "google", existingUser.OAuthProvider)
+	mockIdentityRepo.AssertExpectations(t)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUnlinkProvider_RejectsLastLoginMethod(t *testing.T) {
+	mockUserRepo, _, mockIdentityRepo, uc := newGoogleLogin(t)
+	existingUser := &entity.User{ID: "user-1", OAuthProvider: "google", OAuthID: "google-123"}
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(existingUser, nil)
+	mockIdentityRepo.On("ListByUserID", mock.Anything, "user-1").Return([]*entity.UserOAuthIdentity{
+		entity.NewUserOAuthIdentity("user-1", "google", "google-123"),
+	}, nil)
+
+	err := uc.UnlinkProvider(context.Background(), "user-1", auth.ProviderGoogle)
+
+	assert.ErrorIs(t, err, errors.ErrCannotUnlinkLastLogin)
+	assert.Equal(t, "google", existingUser.OAuthProvider)
+	mockIdentityRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUnlinkProvider_ProviderNotLinked(t *testing.T) {
+	mockUserRepo, _, mockIdentityRepo, uc := newGoogleLogin(t)
+	existingUser := &entity.User{ID: "user-1", Password: "hashed", OAuthProvider: "google", OAuthID: "google-123"}
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(existingUser, nil)
+	mockIdentityRepo.On("ListByUserID", mock.Anything, "user-1").Return([]*entity.UserOAuthIdentity{
+		entity.NewUserOAuthIdentity("user-1", "google", "google-123"),
+	}, nil)
+
+	err := uc.UnlinkProvider(context.Background(), "user-1", auth.ProviderGitHub)
+
+	assert.ErrorIs(t, err, errors.ErrOAuthProviderNotLinked)
+	mockIdentityRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestLinkedProviders_ListsLinkedProviders(t *testing.T) {
+	_, _, mockIdentityRepo, uc := newGoogleLogin(t)
+	mockIdentityRepo.On("ListByUserID", mock.Anything, "user-1").Return([]*entity.UserOAuthIdentity{
+		entity.NewUserOAuthIdentity("user-1", "google", "google-123"),
+		entity.NewUserOAuthIdentity("user-1", "github", "4242"),
+	}, nil)
+
+	providers, err := uc.LinkedProviders(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"google", "github"}, providers)
+}
+
+func TestLinkedProviders_NoneLinked(t *testing.T) {
+	_, _, mockIdentityRepo, uc := newGoogleLogin(t)
+	mockIdentityRepo.On("ListByUserID", mock.Anything, "user-1").Return([]*entity.UserOAuthIdentity{}, nil)
+
+	providers, err := uc.LinkedProviders(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	assert.Empty(t, providers)
+}
+
+// idTokenProvider is an OAuth provider that also verifies client-side ID tokens
+type idTokenProvider struct {
+	*MockOAuthService
+}
+
+func (p idTokenProvider) VerifyIDToken(ctx context.Context, idToken string) (*auth.OAuthUserInfo, error) {
+	args := p.Called(ctx, idToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.OAuthUserInfo), args.Error(1)
+}
+
+func newIDTokenLogin() (*MockUserRepository, *MockUserOAuthIdentityRepository, *MockOAuthService, auth.OAuthUseCase) {
+	mockUserRepo := new(MockUserRepository)
+	mockIdentityRepo := new(MockUserOAuthIdentityRepository)
+	mockOAuth := new(MockOAuthService)
+	providers := map[string]auth.OAuthService{
+		auth.ProviderGoogle: idTokenProvider{mockOAuth},
+		auth.ProviderGitHub: new(MockOAuthService),
+	}
+	uc := auth.NewOAuthUseCase(mockUserRepo, new(MockAvatarRepository), mockIdentityRepo, newMemoryOAuthStateStore(), providers, nil, nil, nil, gracePeriod)
+	return mockUserRepo, mockIdentityRepo, mockOAuth, uc
+}
+
+func TestLoginWithIDToken_LinksExistingAccount(t *testing.T) {
+	mockUserRepo, mockIdentityRepo, mockOAuth, uc := newIDTokenLogin()
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com", Avatar: entity.NewAvatar("user-1", "p", "u", "s")}
+
+	mockOAuth.On("VerifyIDToken", mock.Anything, "id-token").Return(&auth.OAuthUserInfo{
+		ID:    "google-123",
+		Email: "alice@example.com",
+		Name:  "Alice",
+	}, nil)
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(nil, errors.ErrUserNotFound)
+	mockUserRepo.On("GetByEmail", mock.Anything, "alice@example.com").Return(existingUser, nil)
+	mockIdentityRepo.On("Create", mock.Anything, identityFor("user-1", "google", "google-123")).Return(nil)
+
+	result, err := uc.LoginWithIDToken(context.Background(), auth.ProviderGoogle, "id-token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", result.ID)
+	mockUserRepo.AssertExpectations(t)
+	mockIdentityRepo.AssertExpectations(t)
+}
+
+func TestLoginWithIDToken_ReactivatesDeactivatedAccount(t *testing.T) {
+	mockUserRepo, _, mockOAuth, uc := newIDTokenLogin()
+	existingUser := &entity.User{ID: "user-1", Email: "alice@example.com", DeactivatedAt: time.Now().Add(-time.Hour)}
+
+	mockOAuth.On("VerifyIDToken", mock.Anything, "id-token").Return(&auth.OAuthUserInfo{
+		ID:    "google-123",
+		Email: "alice@example.com",
+	}, nil)
+	mockUserRepo.On("GetByOAuthID", mock.Anything, "google", "google-123").Return(existingUser, nil)
+	mockUserRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	result, err := uc.LoginWithIDToken(context.Background(), auth.ProviderGoogle, "id-token")
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsDeactivated())
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestLoginWithIDToken_InvalidTokenRejected(t *testing.T) {
+	mockUserRepo, _, mockOAuth, uc := newIDTokenLogin()
+	mockOAuth.On("VerifyIDToken", mock.Anything, "expired").Return(nil, errors.ErrInvalidToken)
+
+	result, err := uc.LoginWithIDToken(context.Background(), auth.ProviderGoogle, "expired")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidToken)
+	assert.Nil(t, result)
+	mockUserRepo.AssertNotCalled(t, "GetByOAuthID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginWithIDToken_ProviderWithoutIDTokens(t *testing.T) {
+	_, _, _, uc := newIDTokenLogin()
+
+	result, err := uc.LoginWithIDToken(context.Background(), auth.ProviderGitHub, "id-token")
+
+	assert.ErrorIs(t, err, errors.ErrOAuthProviderNotSupported)
+	assert.Nil(t, result)
+}
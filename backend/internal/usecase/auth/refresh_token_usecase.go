@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	stderrors "errors"
 	"time"
 
 	"backend/internal/domain/entity"
@@ -11,25 +12,32 @@ import (
 
 // RefreshTokenUseCase defines the interface for refresh token operations
 type RefreshTokenUseCase interface {
-	CreateRefreshToken(ctx context.Context, userID string, token string, expiresAt time.Time) error
+	CreateRefreshToken(ctx context.Context, userID string, token string, expiresAt time.Time, userAgent, ipAddress string) error
 	ValidateRefreshToken(ctx context.Context, token string) (*entity.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, old *entity.RefreshToken, token string, expiresAt time.Time, userAgent, ipAddress string) error
 	RevokeRefreshToken(ctx context.Context, token string) error
 	RevokeAllUserTokens(ctx context.Context, userID string) error
+	ListActiveSessions(ctx context.Context, userID string) ([]*entity.RefreshToken, error)
 }
 
 type refreshTokenUseCase struct {
 	refreshTokenRepo repository.RefreshTokenRepository
+	txManager        repository.TxManager
+	idleTimeout      time.Duration
 }
 
-// NewRefreshTokenUseCase creates a new refresh token use case
-func NewRefreshTokenUseCase(refreshTokenRepo repository.RefreshTokenRepository) RefreshTokenUseCase {
+// NewRefreshTokenUseCase creates a new refresh token use case.
+// A zero idleTimeout disables the idle-session check.
+func NewRefreshTokenUseCase(refreshTokenRepo repository.RefreshTokenRepository, txManager repository.TxManager, idleTimeout time.Duration) RefreshTokenUseCase {
 	return &refreshTokenUseCase{
 		refreshTokenRepo: refreshTokenRepo,
+		txManager:        txManager,
+		idleTimeout:      idleTimeout,
 	}
 }
 
-func (uc *refreshTokenUseCase) CreateRefreshToken(ctx context.Context, userID string, token string, expiresAt time.Time) error {
-	refreshToken := entity.NewRefreshToken(userID, token, expiresAt)
+func (uc *refreshTokenUseCase) CreateRefreshToken(ctx context.Context, userID string, token string, expiresAt time.Time, userAgent, ipAddress string) error {
+	refreshToken := entity.NewRefreshToken(userID, token, expiresAt, userAgent, ipAddress)
 	return uc.refreshTokenRepo.Create(ctx, refreshToken)
 }
 
@@ -41,14 +49,61 @@ func (uc *refreshTokenUseCase) ValidateRefreshToken(ctx context.Context, token s
 
 	if !refreshToken.IsValid() {
 		if refreshToken.RevokedAt != nil {
+			// A rotated-out token being replayed means it was likely stolen:
+			// revoke every token descended from the same login
+			if refreshToken.FamilyID != "" {
+				if err := uc.refreshTokenRepo.RevokeFamily(ctx, refreshToken.FamilyID); err != nil {
+					return nil, err
+				}
+			}
 			return nil, errors.ErrTokenRevoked
 		}
 		return nil, errors.ErrTokenExpired
 	}
 
+	// Treat tokens left unused past the idle window as expired
+	if refreshToken.IsIdle(uc.idleTimeout) {
+		return nil, errors.ErrTokenExpired
+	}
+
+	now := time.Now()
+	if err := uc.refreshTokenRepo.UpdateLastUsed(ctx, token, now); err != nil {
+		return nil, err
+	}
+	refreshToken.LastUsedAt = now
+
 	return refreshToken, nil
 }
 
+// RotateRefreshToken revokes old and stores token as its replacement in the same family.
+// Both happen in one transaction, so a failure never leaves the session without a valid token.
+// When old was already revoked, e.g. by a concurrent refresh with the same token, the rotation
+// is treated as reuse: the family is revoked and ErrTokenRevoked returned.
+func (uc *refreshTokenUseCase) RotateRefreshToken(ctx context.Context, old *entity.RefreshToken, token string, expiresAt time.Time, userAgent, ipAddress string) error {
+	refreshToken := entity.NewRefreshToken(old.UserID, token, expiresAt, userAgent, ipAddress)
+	if old.FamilyID != "" {
+		refreshToken.FamilyID = old.FamilyID
+	}
+
+	err := uc.txManager.WithTx(ctx, func(ctx context.Context) error {
+		revoked, err := uc.refreshTokenRepo.RevokeActive(ctx, old.Token)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return errors.ErrTokenRevoked
+		}
+		return uc.refreshTokenRepo.Create(ctx, refreshToken)
+	})
+	// Revoke the family after the transaction, the rollback would undo it otherwise
+	if stderrors.Is(err, errors.ErrTokenRevoked) && old.FamilyID != "" {
+		if revokeErr := uc.refreshTokenRepo.RevokeFamily(ctx, old.FamilyID); revokeErr != nil {
+			return revokeErr
+		}
+	}
+	return err
+}
+
 func (uc *refreshTokenUseCase) RevokeRefreshToken(ctx context.Context, token string) error {
 	return uc.refreshTokenRepo.Revoke(ctx, token)
 }
@@ -56,3 +111,19 @@ func (uc *refreshTokenUseCase) RevokeRefreshToken(ctx context.Context, token str
 func (uc *refreshTokenUseCase) RevokeAllUserTokens(ctx context.Context, userID string) error {
 	return uc.refreshTokenRepo.RevokeAllByUserID(ctx, userID)
 }
+
+// ListActiveSessions returns the user's refresh tokens that can still be used
+func (uc *refreshTokenUseCase) ListActiveSessions(ctx context.Context, userID string) ([]*entity.RefreshToken, error) {
+	tokens, err := uc.refreshTokenRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*entity.RefreshToken, 0, len(tokens))
+	for _, token := range tokens {
+		if token.IsValid() && !token.IsIdle(uc.idleTimeout) {
+			sessions = append(sessions, token)
+		}
+	}
+	return sessions, nil
+}
@@ -0,0 +1,355 @@
+package auth_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/usecase/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// passthroughTxManager runs fn directly; transactional behavior is covered by the repository tests
+type passthroughTxManager struct{}
+
+func (passthroughTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByToken(ctx context.Context, token string) (*entity.RefreshToken, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) Revoke(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeActive(ctx context.Context, token string) (bool, error) {
+	args := m.Called(ctx, token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) UpdateLastUsed(ctx context.Context, token string, usedAt time.Time) error {
+	args := m.Called(ctx, token, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestValidateRefreshToken_IdleTimeoutExceeded(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	uc := auth.NewRefreshTokenUseCase(mockRepo, passthroughTxManager{}, 30*time.Minute)
+
+	stored := &entity.RefreshToken{
+		ID:         "1",
+		UserID:     "user-1",
+		Token:      "token",
+		ExpiresAt:  time.Now().Add(7 * 24 * time.Hour),
+		CreatedAt:  time.Now().Add(-2 * time.Hour),
+		LastUsedAt: time.Now().Add(-time.Hour),
+	}
+	mockRepo.On("GetByToken", mock.Anything, "token").Return(stored, nil)
+
+	result, err := uc.ValidateRefreshToken(context.Background(), "token")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrTokenExpired, err)
+	mockRepo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestValidateRefreshToken_WithinIdleWindowUpdatesLastUsed(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	uc := auth.NewRefreshTokenUseCase(mockRepo, passthroughTxManager{}, 30*time.Minute)
+
+	lastUsedAt := time.Now().Add(-10 * time.Minute)
+	stored := &entity.RefreshToken{
+		ID:         "1",
+		UserID:     "user-1",
+		Token:      "token",
+		ExpiresAt:  time.Now().Add(7 * 24 * time.Hour),
+		CreatedAt:  time.Now().Add(-time.Hour),
+		LastUsedAt: lastUsedAt,
+	}
+	mockRepo.On("GetByToken", mock.Anything, "token").Return(stored, nil)
+	mockRepo.On("UpdateLastUsed", mock.Anything, "token", mock.AnythingOfType("time.Time")).Return(nil)
+
+	result, err := uc.ValidateRefreshToken(context.Background(), "token")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.True(t, result.LastUsedAt.After(lastUsedAt))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidateRefreshToken_IdleTimeoutDisabled(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	uc := auth.NewRefreshTokenUseCase(mockRepo, passthroughTxManager{}, 0)
+
+	stored := &entity.RefreshToken{
+		ID:         "1",
+		UserID:     "user-1",
+		Token:      "token",
+		ExpiresAt:  time.Now().Add(24 * time.Hour),
+		CreatedAt:  time.Now().Add(-6 * 24 * time.Hour),
+		LastUsedAt: time.Now().Add(-6 * 24 * time.Hour),
+	}
+	mockRepo.On("GetByToken", mock.Anything, "token").Return(stored, nil)
+	mockRepo.On("UpdateLastUsed", mock.Anything, "token", mock.AnythingOfType("time.Time")).Return(nil)
+
+	result, err := uc.ValidateRefreshToken(context.Background(), "token")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListActiveSessions_SkipsIdleSessions(t *testing.T) {
+	mockRepo := new(MockRefreshTokenRepository)
+	uc := auth.NewRefreshTokenUseCase(mockRepo, passthroughTxManager{}, 30*time.Minute)
+
+	active := entity.NewRefreshToken("user-1", "active", time.Now().Add(time.Hour), "Mozilla/5.0", "203.0.113.7")
+	idle := entity.NewRefreshToken("user-1", "idle", time.Now().Add(time.Hour), "okhttp/4.9", "198.51.100.2")
+	idle.LastUsedAt = time.Now().Add(-time.Hour)
+	mockRepo.On("GetByUserID", mock.Anything, "user-1").Return([]*entity.RefreshToken{active, idle}, nil)
+
+	sessions, err := uc.ListActiveSessions(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.RefreshToken{active}, sessions)
+	assert.Equal(t, "Mozilla/5.0", sessions[0].UserAgent)
+	assert.Equal(t, "203.0.113.7", sessions[0].IPAddress)
+}
+
+// memoryRefreshTokenRepository is an in-memory RefreshTokenRepository for multi-step scenarios
+type memoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*entity.RefreshToken
+}
+
+func newMemoryRefreshTokenRepository() *memoryRefreshTokenRepository {
+	return &memoryRefreshTokenRepository{tokens: make(map[string]*entity.RefreshToken)}
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *token
+	r.tokens[token.Token] = &stored
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) GetByToken(ctx context.Context, token string) (*entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.tokens[token]
+	if !ok {
+		return nil, errors.ErrRefreshTokenNotFound
+	}
+	result := *stored
+	return &result, nil
+}
+
+func (r *memoryRefreshTokenRepository) GetByUserID(ctx context.Context, userID string) ([]*entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*entity.RefreshToken
+	for _, stored := range r.tokens {
+		if stored.UserID == userID && stored.IsValid() {
+			token := *stored
+			result = append(result, &token)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryRefreshTokenRepository) Revoke(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.tokens[token]; ok {
+		stored.Revoke()
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeActive(ctx context.Context, token string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.tokens[token]
+	if !ok || stored.RevokedAt != nil {
+		return false, nil
+	}
+	stored.Revoke()
+	return true, nil
+}
+
+func (r *memoryRefreshTokenRepository) UpdateLastUsed(ctx context.Context, token string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.tokens[token]; ok {
+		stored.LastUsedAt = usedAt
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeAllByUserID(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.tokens {
+		if stored.UserID == userID && stored.RevokedAt == nil {
+			stored.Revoke()
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.tokens {
+		if stored.FamilyID == familyID && stored.RevokedAt == nil {
+			stored.Revoke()
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for key, stored := range r.tokens {
+		if time.Now().After(stored.ExpiresAt) {
+			delete(r.tokens, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func TestRotateRefreshToken_KeepsFamily(t *testing.T) {
+	repo := newMemoryRefreshTokenRepository()
+	uc := auth.NewRefreshTokenUseCase(repo, passthroughTxManager{}, 0)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	assert.NoError(t, uc.CreateRefreshToken(ctx, "user-1", "first", expiresAt, "", ""))
+	first, err := uc.ValidateRefreshToken(ctx, "first")
+	assert.NoError(t, err)
+
+	assert.NoError(t, uc.RotateRefreshToken(ctx, first, "second", expiresAt, "", ""))
+
+	second, err := uc.ValidateRefreshToken(ctx, "second")
+	assert.NoError(t, err)
+	assert.Equal(t, first.FamilyID, second.FamilyID)
+	assert.NotEqual(t, first.ID, second.ID)
+}
+
+func TestValidateRefreshToken_ReuseAfterRotationRevokesFamily(t *testing.T) {
+	repo := newMemoryRefreshTokenRepository()
+	uc := auth.NewRefreshTokenUseCase(repo, passthroughTxManager{}, 0)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	// A second, unrelated login must survive the reuse
+	assert.NoError(t, uc.CreateRefreshToken(ctx, "user-1", "other-device", expiresAt, "", ""))
+
+	assert.NoError(t, uc.CreateRefreshToken(ctx, "user-1", "first", expiresAt, "", ""))
+	first, err := uc.ValidateRefreshToken(ctx, "first")
+	assert.NoError(t, err)
+	assert.NoError(t, uc.RotateRefreshToken(ctx, first, "second", expiresAt, "", ""))
+
+	// Replaying the rotated-out token is rejected...
+	result, err := uc.ValidateRefreshToken(ctx, "first")
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrTokenRevoked, err)
+
+	// ...and takes the legitimate successor down with it
+	_, err = uc.ValidateRefreshToken(ctx, "second")
+	assert.Equal(t, errors.ErrTokenRevoked, err)
+
+	_, err = uc.ValidateRefreshToken(ctx, "other-device")
+	assert.NoError(t, err)
+}
+
+func TestRevokeRefreshToken_LeavesOtherSessionsActive(t *testing.T) {
+	repo := newMemoryRefreshTokenRepository()
+	uc := auth.NewRefreshTokenUseCase(repo, passthroughTxManager{}, 0)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	assert.NoError(t, uc.CreateRefreshToken(ctx, "user-1", "laptop", expiresAt, "", ""))
+	assert.NoError(t, uc.CreateRefreshToken(ctx, "user-1", "phone", expiresAt, "", ""))
+
+	assert.NoError(t, uc.RevokeRefreshToken(ctx, "laptop"))
+
+	_, err := uc.ValidateRefreshToken(ctx, "laptop")
+	assert.ErrorIs(t, err, errors.ErrTokenRevoked)
+	_, err = uc.ValidateRefreshToken(ctx, "phone")
+	assert.NoError(t, err)
+}
+
+func TestRotateRefreshToken_ConcurrentRotationRevokesFamily(t *testing.T) {
+	repo := newMemoryRefreshTokenRepository()
+	uc := auth.NewRefreshTokenUseCase(repo, passthroughTxManager{}, 0)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	assert.NoError(t, uc.CreateRefreshToken(ctx, "user-1", "first", expiresAt, "", ""))
+
+	// Both refreshes validate the token before either rotates it
+	first, err := uc.ValidateRefreshToken(ctx, "first")
+	assert.NoError(t, err)
+	replayed, err := uc.ValidateRefreshToken(ctx, "first")
+	assert.NoError(t, err)
+
+	assert.NoError(t, uc.RotateRefreshToken(ctx, first, "second", expiresAt, "", ""))
+	err = uc.RotateRefreshToken(ctx, replayed, "third", expiresAt, "", "")
+	assert.ErrorIs(t, err, errors.ErrTokenRevoked)
+
+	// Neither successor survives
+	_, err = uc.ValidateRefreshToken(ctx, "second")
+	assert.ErrorIs(t, err, errors.ErrTokenRevoked)
+	_, err = uc.ValidateRefreshToken(ctx, "third")
+	assert.ErrorIs(t, err, errors.ErrRefreshTokenNotFound)
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"backend/internal/domain/event"
+	"backend/internal/infrastructure/email"
+)
+
+// WelcomeEmailHandler queues the welcome email for a newly verified user.
+// Subscribe it to event.UserVerified.
+func WelcomeEmailHandler(emailQueue email.EmailService) event.Handler {
+	return func(ctx context.Context, e event.Event) error {
+		verified, ok := e.(event.UserVerifiedEvent)
+		if !ok {
+			return fmt.Errorf("welcome email: unexpected event %s", e.Name())
+		}
+		if err := emailQueue.SendWelcomeEmail(ctx, verified.Email, verified.UserName); err != nil {
+			return fmt.Errorf("failed to queue welcome email: %w", err)
+		}
+		return nil
+	}
+}
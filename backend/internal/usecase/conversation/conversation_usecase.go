@@ -0,0 +1,191 @@
+package conversation
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+)
+
+// ConversationUseCase defines the interface for conversation business logic.
+// Every method acts on behalf of userID and only exposes conversations that user takes part in.
+type ConversationUseCase interface {
+	Create(ctx context.Context, userID, conversationType, name string, memberIDs []string) (*entity.Conversation, error)
+	GetByID(ctx context.Context, userID, conversationID string) (*Summary, error)
+	ListForUser(ctx context.Context, userID string) ([]*Summary, error)
+	SetMuted(ctx context.Context, userID, conversationID string, muted bool) (*entity.Conversation, error)
+	MarkUnread(ctx context.Context, userID, conversationID string) (*Summary, error)
+}
+
+// Summary is a conversation as seen by one of its participants
+type Summary struct {
+	Conversation *entity.Conversation
+	UnreadCount  int64 // messages from other participants the user has not read
+	// Read is the user's own read position, nil until they read a message
+	Read *entity.ConversationRead
+}
+
+type conversationUseCase struct {
+	conversationRepo repository.ConversationRepository
+	messageRepo      repository.MessageRepository
+	userRepo         repository.UserRepository
+}
+
+// NewConversationUseCase creates a new conversation use case
+func NewConversationUseCase(conversationRepo repository.ConversationRepository, messageRepo repository.MessageRepository, userRepo repository.UserRepository) ConversationUseCase {
+	return &conversationUseCase{
+		conversationRepo: conversationRepo,
+		messageRepo:      messageRepo,
+		userRepo:         userRepo,
+	}
+}
+
+// Create starts a conversation between userID and the other members.
+// A direct conversation takes exactly one other member; the name only applies to groups.
+// If the two users already have a direct conversation, that one is returned instead of a duplicate.
+func (uc *conversationUseCase) Create(ctx context.Context, userID, conversationType, name string, memberIDs []string) (*entity.Conversation, error) {
+	participantIDs := []string{userID}
+	seen := map[string]bool{userID: true}
+	for _, memberID := range memberIDs {
+		if seen[memberID] {
+			return nil, errors.ErrInvalidParticipants
+		}
+		seen[memberID] = true
+		participantIDs = append(participantIDs, memberID)
+	}
+
+	switch conversationType {
+	case entity.ConversationTypeDirect:
+		if len(memberIDs) != 1 {
+			return nil, errors.ErrInvalidParticipants
+		}
+		name = ""
+	case entity.ConversationTypeGroup:
+		if len(memberIDs) == 0 {
+			return nil, errors.ErrInvalidParticipants
+		}
+	default:
+		return nil, errors.ErrInvalidParticipants
+	}
+
+	for _, memberID := range memberIDs {
+		member, err := uc.userRepo.GetByID(ctx, memberID)
+		if err != nil {
+			return nil, err
+		}
+		if member.IsPendingDeletion() || member.IsDeactivated() {
+			return nil, errors.ErrUserNotFound
+		}
+	}
+
+	if conversationType == entity.ConversationTypeDirect {
+		existing, err := uc.conversationRepo.FindDirectBetween(ctx, userID, memberIDs[0])
+		if err == nil {
+			return existing, nil
+		}
+		if err != errors.ErrConversationNotFound {
+			return nil, err
+		}
+	}
+
+	conversation := entity.NewConversation(conversationType, name, participantIDs)
+	err := uc.conversationRepo.Create(ctx, conversation)
+	if err == errors.ErrConversationExists {
+		// The other user started the same conversation since FindDirectBetween ran
+		return uc.conversationRepo.FindDirectBetween(ctx, userID, memberIDs[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// GetByID returns the conversation with userID's read position if userID is one of its participants
+func (uc *conversationUseCase) GetByID(ctx context.Context, userID, conversationID string) (*Summary, error) {
+	conversation, err := uc.participantConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.summary(ctx, userID, conversation)
+}
+
+// summary returns the conversation with userID's read position
+func (uc *conversationUseCase) summary(ctx context.Context, userID string, conversation *entity.Conversation) (*Summary, error) {
+	reads, err := uc.messageRepo.ReadPositions(ctx, userID, []string{conversation.ID})
+	if err != nil {
+		return nil, err
+	}
+	return &Summary{Conversation: conversation, Read: reads[conversation.ID]}, nil
+}
+
+// participantConversation loads the conversation, ensuring userID takes part in it
+func (uc *conversationUseCase) participantConversation(ctx context.Context, userID, conversationID string) (*entity.Conversation, error) {
+	conversation, err := uc.conversationRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.HasParticipant(userID) {
+		return nil, errors.ErrNotConversationMember
+	}
+	return conversation, nil
+}
+
+// ListForUser returns the conversations userID takes part in with their unread counts and the user's read positions
+func (uc *conversationUseCase) ListForUser(ctx context.Context, userID string) ([]*Summary, error) {
+	conversations, err := uc.conversationRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+	unread, err := uc.messageRepo.UnreadCounts(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	reads, err := uc.messageRepo.ReadPositions(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*Summary, len(conversations))
+	for i, conversation := range conversations {
+		summaries[i] = &Summary{
+			Conversation: conversation,
+			UnreadCount:  unread[conversation.ID],
+			Read:         reads[conversation.ID],
+		}
+	}
+	return summaries, nil
+}
+
+// SetMuted mutes or unmutes the conversation's notifications for userID only
+func (uc *conversationUseCase) SetMuted(ctx context.Context, userID, conversationID string, muted bool) (*entity.Conversation, error) {
+	conversation, err := uc.participantConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.conversationRepo.SetMuted(ctx, conversationID, userID, muted); err != nil {
+		return nil, err
+	}
+	conversation.SetMuted(userID, muted)
+	return conversation, nil
+}
+
+// MarkUnread flags the conversation as unread for userID only, until they next read or send a message in it.
+// Their read position is kept.
+func (uc *conversationUseCase) MarkUnread(ctx context.Context, userID, conversationID string) (*Summary, error) {
+	conversation, err := uc.participantConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.messageRepo.MarkUnread(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+	return uc.summary(ctx, userID, conversation)
+}
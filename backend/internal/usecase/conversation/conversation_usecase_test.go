@@ -0,0 +1,402 @@
+package conversation_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+	"backend/internal/usecase/conversation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockConversationRepository is a mock implementation of ConversationRepository
+type MockConversationRepository struct {
+	mock.Mock
+}
+
+func (m *MockConversationRepository) Create(ctx context.Context, c *entity.Conversation) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) GetByID(ctx context.Context, id string) (*entity.Conversation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) ListForUser(ctx context.Context, userID string) ([]*entity.Conversation, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) FindDirectBetween(ctx context.Context, userA, userB string) (*entity.Conversation, error) {
+	args := m.Called(ctx, userA, userB)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) SetMuted(ctx context.Context, conversationID, userID string, muted bool) error {
+	args := m.Called(ctx, conversationID, userID, muted)
+	return args.Error(0)
+}
+
+// MockMessageRepository mocks the unread counts used by the conversation list;
+// other MessageRepository methods are not used
+type MockMessageRepository struct {
+	mock.Mock
+	repository.MessageRepository
+}
+
+func (m *MockMessageRepository) UnreadCounts(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error) {
+	args := m.Called(ctx, userID, conversationIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockMessageRepository) MarkUnread(ctx context.Context, userID, conversationID string) error {
+	args := m.Called(ctx, userID, conversationID)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) ReadPositions(ctx context.Context, userID string, conversationIDs []string) (map[string]*entity.ConversationRead, error) {
+	args := m.Called(ctx, userID, conversationIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*entity.ConversationRead), args.Error(1)
+}
+
+func (m *MockMessageRepository) Search(ctx context.Context, conversationID, query string, limit int) ([]*entity.Message, error) {
+	args := m.Called(ctx, conversationID, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ToggleReaction(ctx context.Context, reaction *entity.MessageReaction) (bool, error) {
+	args := m.Called(ctx, reaction)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockUserRepository mocks the user lookups made by the conversation use case;
+// other UserRepository methods are not used
+type MockUserRepository struct {
+	mock.Mock
+	repository.UserRepository
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func TestCreate_DirectConversation(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	mockUserRepo.On("GetByID", mock.Anything, "user-2").Return(&entity.User{ID: "user-2"}, nil)
+	mockConvRepo.On("FindDirectBetween", mock.Anything, "user-1", "user-2").Return(nil, errors.ErrConversationNotFound)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Conversation")).Return(nil)
+
+	result, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeDirect, "ignored", []string{"user-2"})
+
+	require.NoError(t, err)
+	assert.Equal(t, entity.ConversationTypeDirect, result.Type)
+	assert.Empty(t, result.Name)
+	// The creator is always a participant
+	assert.Equal(t, []string{"user-1", "user-2"}, result.ParticipantIDs)
+	mockConvRepo.AssertExpectations(t)
+}
+
+func TestCreate_DirectConversationTwiceReturnsExisting(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	var created *entity.Conversation
+	mockUserRepo.On("GetByID", mock.Anything, mock.Anything).Return(&entity.User{}, nil)
+	mockConvRepo.On("FindDirectBetween", mock.Anything, "user-1", "user-2").Return(nil, errors.ErrConversationNotFound).Once()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Conversation")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*entity.Conversation) }).
+		Return(nil).Once()
+
+	first, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeDirect, "", []string{"user-2"})
+	require.NoError(t, err)
+
+	// The other user starts the same conversation
+	mockConvRepo.On("FindDirectBetween", mock.Anything, "user-2", "user-1").Return(created, nil).Once()
+	second, err := uc.Create(context.Background(), "user-2", entity.ConversationTypeDirect, "", []string{"user-1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ID, second.ID)
+	mockConvRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestCreate_DirectConversationCreatedConcurrentlyReturnsExisting(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	existing := entity.NewConversation(entity.ConversationTypeDirect, "", []string{"user-2", "user-1"})
+	mockUserRepo.On("GetByID", mock.Anything, "user-2").Return(&entity.User{ID: "user-2"}, nil)
+	// The other user's conversation is stored between the lookup and the insert
+	mockConvRepo.On("FindDirectBetween", mock.Anything, "user-1", "user-2").Return(nil, errors.ErrConversationNotFound).Once()
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Conversation")).Return(errors.ErrConversationExists)
+	mockConvRepo.On("FindDirectBetween", mock.Anything, "user-1", "user-2").Return(existing, nil).Once()
+
+	result, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeDirect, "", []string{"user-2"})
+
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, result.ID)
+	mockConvRepo.AssertExpectations(t)
+}
+
+func TestCreate_GroupConversation(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	mockUserRepo.On("GetByID", mock.Anything, mock.Anything).Return(&entity.User{}, nil)
+	mockConvRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Conversation")).Return(nil)
+
+	result, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeGroup, "Team", []string{"user-2", "user-3"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Team", result.Name)
+	assert.Equal(t, []string{"user-1", "user-2", "user-3"}, result.ParticipantIDs)
+}
+
+func TestCreate_InvalidParticipants(t *testing.T) {
+	tests := map[string]struct {
+		conversationType string
+		memberIDs        []string
+	}{
+		"direct with self":          {entity.ConversationTypeDirect, []string{"user-1"}},
+		"direct with two members":   {entity.ConversationTypeDirect, []string{"user-2", "user-3"}},
+		"group with duplicates":     {entity.ConversationTypeGroup, []string{"user-2", "user-2"}},
+		"group without members":     {entity.ConversationTypeGroup, nil},
+		"unknown conversation type": {"channel", []string{"user-2"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockConvRepo := new(MockConversationRepository)
+			uc := conversation.NewConversationUseCase(mockConvRepo, nil, new(MockUserRepository))
+
+			_, err := uc.Create(context.Background(), "user-1", tt.conversationType, "", tt.memberIDs)
+
+			assert.Equal(t, errors.ErrInvalidParticipants, err)
+			mockConvRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCreate_MemberNotFound(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	mockUserRepo.On("GetByID", mock.Anything, "missing").Return(nil, errors.ErrUserNotFound)
+
+	_, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeDirect, "", []string{"missing"})
+
+	assert.Equal(t, errors.ErrUserNotFound, err)
+	mockConvRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreate_MemberPendingDeletion(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	mockUserRepo.On("GetByID", mock.Anything, "user-2").Return(&entity.User{ID: "user-2", DeletionRequestedAt: time.Now()}, nil)
+
+	_, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeDirect, "", []string{"user-2"})
+
+	assert.Equal(t, errors.ErrUserNotFound, err)
+}
+
+func TestCreate_MemberDeactivated(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, mockUserRepo)
+
+	mockUserRepo.On("GetByID", mock.Anything, "user-2").Return(&entity.User{ID: "user-2", DeactivatedAt: time.Now()}, nil)
+
+	_, err := uc.Create(context.Background(), "user-1", entity.ConversationTypeDirect, "", []string{"user-2"})
+
+	assert.Equal(t, errors.ErrUserNotFound, err)
+}
+
+func TestGetByID_Participant(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockMsgRepo := new(MockMessageRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, mockMsgRepo, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+	mockMsgRepo.On("ReadPositions", mock.Anything, "user-2", []string{"conv-1"}).
+		Return(map[string]*entity.ConversationRead{}, nil).Once()
+
+	result, err := uc.GetByID(context.Background(), "user-2", "conv-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, stored, result.Conversation)
+	assert.Nil(t, result.Read)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestGetByID_IncludesRequestersReadPosition(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockMsgRepo := new(MockMessageRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, mockMsgRepo, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+	read := &entity.ConversationRead{ConversationID: "conv-1", UserID: "user-1", LastReadMessageID: "msg-1"}
+	mockMsgRepo.On("ReadPositions", mock.Anything, "user-1", []string{"conv-1"}).
+		Return(map[string]*entity.ConversationRead{"conv-1": read}, nil)
+	mockMsgRepo.On("ReadPositions", mock.Anything, "user-2", []string{"conv-1"}).
+		Return(map[string]*entity.ConversationRead{}, nil)
+
+	forReader, err := uc.GetByID(context.Background(), "user-1", "conv-1")
+	require.NoError(t, err)
+	assert.Equal(t, read, forReader.Read)
+
+	// The other participant has not read anything yet
+	forOther, err := uc.GetByID(context.Background(), "user-2", "conv-1")
+	require.NoError(t, err)
+	assert.Nil(t, forOther.Read)
+}
+
+func TestGetByID_NotParticipant(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+
+	result, err := uc.GetByID(context.Background(), "user-3", "conv-1")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+}
+
+func TestSetMuted_TogglesForUserOnly(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+	mockConvRepo.On("SetMuted", mock.Anything, "conv-1", "user-2", true).Return(nil).Once()
+	mockConvRepo.On("SetMuted", mock.Anything, "conv-1", "user-2", false).Return(nil).Once()
+
+	result, err := uc.SetMuted(context.Background(), "user-2", "conv-1", true)
+	require.NoError(t, err)
+	assert.True(t, result.IsMutedBy("user-2"))
+	assert.False(t, result.IsMutedBy("user-1"))
+
+	result, err = uc.SetMuted(context.Background(), "user-2", "conv-1", false)
+	require.NoError(t, err)
+	assert.False(t, result.IsMutedBy("user-2"))
+	mockConvRepo.AssertExpectations(t)
+}
+
+func TestSetMuted_NotParticipant(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, nil, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+
+	_, err := uc.SetMuted(context.Background(), "user-3", "conv-1", true)
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockConvRepo.AssertNotCalled(t, "SetMuted", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMarkUnread_ForUserOnly(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockMsgRepo := new(MockMessageRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, mockMsgRepo, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+	mockMsgRepo.On("MarkUnread", mock.Anything, "user-2", "conv-1").Return(nil).Once()
+	read := &entity.ConversationRead{ConversationID: "conv-1", UserID: "user-2", LastReadMessageID: "msg-1", MarkedUnread: true}
+	mockMsgRepo.On("ReadPositions", mock.Anything, "user-2", []string{"conv-1"}).
+		Return(map[string]*entity.ConversationRead{"conv-1": read}, nil)
+
+	result, err := uc.MarkUnread(context.Background(), "user-2", "conv-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, stored, result.Conversation)
+	assert.True(t, result.Read.MarkedUnread)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestMarkUnread_NotParticipant(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockMsgRepo := new(MockMessageRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, mockMsgRepo, nil)
+
+	stored := &entity.Conversation{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(stored, nil)
+
+	_, err := uc.MarkUnread(context.Background(), "user-3", "conv-1")
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "MarkUnread", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListForUser_IncludesUnreadCounts(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockMsgRepo := new(MockMessageRepository)
+	uc := conversation.NewConversationUseCase(mockConvRepo, mockMsgRepo, nil)
+
+	stored := []*entity.Conversation{
+		{ID: "conv-1", ParticipantIDs: []string{"user-1", "user-2"}},
+		{ID: "conv-2", ParticipantIDs: []string{"user-1", "user-3"}},
+	}
+	mockConvRepo.On("ListForUser", mock.Anything, "user-1").Return(stored, nil)
+	// Counts for every conversation come from a single call
+	mockMsgRepo.On("UnreadCounts", mock.Anything, "user-1", []string{"conv-1", "conv-2"}).
+		Return(map[string]int64{"conv-2": 3}, nil).Once()
+	// and so do read positions
+	read := &entity.ConversationRead{ConversationID: "conv-1", UserID: "user-1", LastReadMessageID: "msg-1"}
+	mockMsgRepo.On("ReadPositions", mock.Anything, "user-1", []string{"conv-1", "conv-2"}).
+		Return(map[string]*entity.ConversationRead{"conv-1": read}, nil).Once()
+
+	result, err := uc.ListForUser(context.Background(), "user-1")
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, stored[0], result[0].Conversation)
+	assert.Equal(t, int64(0), result[0].UnreadCount)
+	assert.Equal(t, read, result[0].Read)
+	assert.Equal(t, stored[1], result[1].Conversation)
+	assert.Equal(t, int64(3), result[1].UnreadCount)
+	assert.Nil(t, result[1].Read)
+	mockMsgRepo.AssertExpectations(t)
+}
@@ -0,0 +1,4 @@
+package message
+
+// ClearHistoryBatchSize is how many messages ClearHistory deletes per transaction
+const ClearHistoryBatchSize = clearHistoryBatchSize
@@ -0,0 +1,42 @@
+package message
+
+import (
+	"backend/internal/domain/entity"
+)
+
+// ParseMentions returns the usernames mentioned in body, lowercased, without duplicates and in order of first mention.
+// A mention is "@" followed by a valid username. It is not a mention when the "@" is escaped as "\@", doubled as "@@",
+// or follows a letter or digit as in an email address.
+func ParseMentions(body string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(body); i++ {
+		if body[i] != '@' {
+			continue
+		}
+		if i > 0 && (body[i-1] == '@' || body[i-1] == '\\' || isUsernameChar(body[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(body) && isUsernameChar(body[end]) {
+			end++
+		}
+		if end == i+1 {
+			continue
+		}
+
+		username := entity.NormalizeUsername(body[i+1 : end])
+		if entity.IsValidUsername(username) && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+		i = end - 1
+	}
+	return usernames
+}
+
+// isUsernameChar reports whether c may appear in a username, before lowercasing
+func isUsernameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
@@ -0,0 +1,40 @@
+package message_test
+
+import (
+	"strings"
+	"testing"
+
+	"backend/internal/usecase/message"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"none", "hello there", nil},
+		{"single", "@alice hi", []string{"alice"}},
+		{"multiple in order", "hey @carol, @bob and @alice!", []string{"carol", "bob", "alice"}},
+		{"lowercased and deduplicated", "@Alice @alice @ALICE", []string{"alice"}},
+		{"punctuation ends the username", "thanks @bob. cc (@carol)", []string{"bob", "carol"}},
+		{"newline before mention", "first line\n@bob", []string{"bob"}},
+		{"escaped", `not a mention: \@alice`, nil},
+		{"doubled at sign", "@@alice", nil},
+		{"doubled then valid", "@@alice @bob", []string{"bob"}},
+		{"email address", "mail bob@example.com", nil},
+		{"lone at sign", "meet @ noon", nil},
+		{"too short", "@ab", nil},
+		{"too long", "@" + strings.Repeat("a", 31), nil},
+		{"longest allowed", "@" + strings.Repeat("a", 30), []string{strings.Repeat("a", 30)}},
+		{"mention followed by at sign", "@alice@bob", []string{"alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, message.ParseMentions(tt.body))
+		})
+	}
+}
@@ -0,0 +1,784 @@
+package message
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/event"
+	"backend/internal/domain/repository"
+	"backend/internal/infrastructure/cloudinary"
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultHistoryLimit is the page size used when no limit is requested
+	DefaultHistoryLimit = 50
+	// MaxHistoryLimit caps the page size of the message history
+	MaxHistoryLimit = 100
+	// DefaultMediaLimit is the page size of the media gallery used when no limit is requested
+	DefaultMediaLimit = 30
+	// MaxMediaLimit caps the page size of the media gallery
+	MaxMediaLimit = 100
+	// DefaultMentionLimit is the page size of the mentions inbox used when no limit is requested
+	DefaultMentionLimit = 20
+	// MaxMentionLimit caps the page size of the mentions inbox
+	MaxMentionLimit = 100
+	// DefaultSearchLimit is the number of search results returned when no limit is requested
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the number of search results
+	MaxSearchLimit = 50
+	// MaxSearchQueryLength is the longest search query accepted, in characters
+	MaxSearchQueryLength = 100
+	// DefaultMaxAttachments is the number of attachments a message can carry when no limit is configured
+	DefaultMaxAttachments = 10
+	// MaxIdempotencyKeyLength is the longest idempotency key accepted, in bytes
+	MaxIdempotencyKeyLength = 255
+	// IdempotencyKeyTTL is how long a processed idempotency key replays its message
+	IdempotencyKeyTTL = 24 * time.Hour
+
+	// attachmentDeleteTimeout bounds each attempt to delete an attachment from Cloudinary
+	attachmentDeleteTimeout = 10 * time.Second
+	// attachmentDeletionBatchSize is how many failed attachment deletions are retried per run
+	attachmentDeletionBatchSize = 100
+	// clearHistoryBatchSize is how many messages ClearHistory deletes per transaction
+	clearHistoryBatchSize = 500
+)
+
+// MessageUseCase defines the interface for message business logic
+type MessageUseCase interface {
+	Send(ctx context.Context, senderID, conversationID, body, idempotencyKey string) (*entity.Message, error)
+	SendAttachments(ctx context.Context, senderID, conversationID string, uploads []AttachmentUpload, body string) (*entity.Message, error)
+	History(ctx context.Context, userID, conversationID, cursor string, limit int) (*HistoryPage, error)
+	Media(ctx context.Context, userID, conversationID, mediaType, cursor string, limit int) (*MediaPage, error)
+	Search(ctx context.Context, userID, conversationID, query string, limit int) ([]*entity.Message, error)
+	Mentions(ctx context.Context, userID, cursor string, limit int) (*MentionsPage, error)
+	MarkRead(ctx context.Context, userID, conversationID, messageID string) error
+	UnreadCount(ctx context.Context, userID, conversationID string) (int64, error)
+	Edit(ctx context.Context, userID, messageID, body string) (*entity.Message, error)
+	Delete(ctx context.Context, userID, messageID string) (*entity.Message, error)
+	React(ctx context.Context, userID, messageID, emoji string) (*entity.Message, error)
+	ClearHistory(ctx context.Context, userID, password string) (int, error)
+	SentAttachments(ctx context.Context, userID string) ([]*entity.Attachment, error)
+	DeleteAttachmentAssets(ctx context.Context, attachments []*entity.Attachment)
+	RetryAttachmentDeletions(ctx context.Context) (int, error)
+}
+
+// AttachmentUpload is a file to upload and send as a message attachment
+type AttachmentUpload struct {
+	File        io.Reader
+	FileName    string
+	ContentType string // validated by the caller
+}
+
+// HistoryPage is one page of a conversation's messages, newest first
+type HistoryPage struct {
+	Messages []*entity.Message
+	// NextCursor fetches the next, older page; empty when there are no older messages
+	NextCursor string
+}
+
+// MediaPage is one page of the attachments shared in a conversation, newest first
+type MediaPage struct {
+	Attachments []*entity.Attachment
+	// NextCursor fetches the next, older page; empty when there are no older attachments
+	NextCursor string
+}
+
+// Mention is a message mentioning the user, with the conversation it was sent in
+type Mention struct {
+	Message      *entity.Message
+	Conversation *entity.Conversation
+}
+
+// MentionsPage is one page of the user's unseen mentions, newest first
+type MentionsPage struct {
+	Mentions []*Mention
+	// NextCursor fetches the next, older page; empty when there are no older mentions
+	NextCursor string
+}
+
+// mediaTypes are the media types the gallery can be filtered by
+var mediaTypes = map[string]bool{
+	entity.MediaTypeImage: true,
+	entity.MediaTypeVideo: true,
+	entity.MediaTypeAudio: true,
+	entity.MediaTypeFile:  true,
+}
+
+type messageUseCase struct {
+	messageRepo            repository.MessageRepository
+	conversationRepo       repository.ConversationRepository
+	userRepo               repository.UserRepository
+	idempotencyKeyRepo     repository.IdempotencyKeyRepository
+	txManager              repository.TxManager
+	cloudinaryService      cloudinary.Service
+	attachmentDeletionRepo repository.AttachmentDeletionRepository
+	eventBus               event.EventBus
+	maxAttachments         int
+}
+
+// NewMessageUseCase creates a new message use case.
+// Attachments of deleted messages that cannot be deleted from Cloudinary are recorded in attachmentDeletionRepo
+// and retried by RetryAttachmentDeletions.
+// Sent messages and mentions are published to eventBus; a nil bus discards them.
+// A message carries at most maxAttachments attachments, DefaultMaxAttachments when it is not positive.
+func NewMessageUseCase(messageRepo repository.MessageRepository, conversationRepo repository.ConversationRepository, userRepo repository.UserRepository, idempotencyKeyRepo repository.IdempotencyKeyRepository, txManager repository.TxManager, cloudinaryService cloudinary.Service, attachmentDeletionRepo repository.AttachmentDeletionRepository, eventBus event.EventBus, maxAttachments int) MessageUseCase {
+	if eventBus == nil {
+		eventBus = event.NopBus{}
+	}
+	if maxAttachments <= 0 {
+		maxAttachments = DefaultMaxAttachments
+	}
+	return &messageUseCase{
+		messageRepo:            messageRepo,
+		conversationRepo:       conversationRepo,
+		userRepo:               userRepo,
+		idempotencyKeyRepo:     idempotencyKeyRepo,
+		txManager:              txManager,
+		cloudinaryService:      cloudinaryService,
+		attachmentDeletionRepo: attachmentDeletionRepo,
+		eventBus:               eventBus,
+		maxAttachments:         maxAttachments,
+	}
+}
+
+// Send posts a message to the conversation; only its participants may send.
+// Participants mentioned with @username are recorded on the message and notified.
+// A non-empty idempotencyKey makes retries safe: for IdempotencyKeyTTL, sending again with the
+// same key returns the message created by the first request instead of posting a duplicate.
+func (uc *messageUseCase) Send(ctx context.Context, senderID, conversationID, body, idempotencyKey string) (*entity.Message, error) {
+	if err := validateBody(body); err != nil {
+		return nil, err
+	}
+	if err := validateIdempotencyKey(idempotencyKey); err != nil {
+		return nil, err
+	}
+
+	conversation, err := uc.participantConversation(ctx, senderID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := entity.NewMessage(conversationID, senderID, body)
+	if message.Mentions, err = uc.resolveMentions(ctx, conversation, body); err != nil {
+		return nil, err
+	}
+	if idempotencyKey == "" {
+		if err := uc.messageRepo.Create(ctx, message); err != nil {
+			return nil, err
+		}
+		uc.publishSent(ctx, message)
+		return message, nil
+	}
+
+	// Claiming the key and creating the message commit together, so a failed send can be retried with the same key
+	var result *entity.Message
+	err = uc.txManager.WithTx(ctx, func(ctx context.Context) error {
+		boundID, err := uc.idempotencyKeyRepo.Claim(ctx, senderID, idempotencyKey, message.ID, time.Now().Add(IdempotencyKeyTTL))
+		if err != nil {
+			return err
+		}
+		if boundID == message.ID {
+			result = message
+			return uc.messageRepo.Create(ctx, message)
+		}
+
+		original, err := uc.messageRepo.GetByID(ctx, boundID)
+		if err != nil {
+			return err
+		}
+		if original.ConversationID != conversationID {
+			return errors.ErrIdempotencyKeyReused
+		}
+		result = original
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// A replay returns the original message, which was published when it was sent
+	if result == message {
+		uc.publishSent(ctx, message)
+	}
+	return result, nil
+}
+
+// SendAttachments uploads the files to the conversation's Cloudinary folder and posts a message carrying them.
+// The body is an optional caption. The number of files is checked before anything is uploaded, and when one
+// upload fails the files already uploaded are deleted, so a message is never sent with part of its files.
+func (uc *messageUseCase) SendAttachments(ctx context.Context, senderID, conversationID string, uploads []AttachmentUpload, body string) (*entity.Message, error) {
+	if len(uploads) == 0 || len(uploads) > uc.maxAttachments {
+		return nil, errors.ErrInvalidAttachmentCount.WithMessage(
+			fmt.Sprintf("a message must carry between 1 and %d attachments", uc.maxAttachments))
+	}
+	if strings.TrimSpace(body) == "" {
+		body = ""
+	} else if err := validateBody(body); err != nil {
+		return nil, err
+	}
+
+	conversation, err := uc.participantConversation(ctx, senderID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := uc.resolveMentions(ctx, conversation, body)
+	if err != nil {
+		return nil, err
+	}
+
+	message := entity.NewMessage(conversationID, senderID, body)
+	message.Mentions = mentions
+	for _, upload := range uploads {
+		result, err := uc.cloudinaryService.UploadAttachment(ctx, upload.File, conversationID)
+		if err != nil {
+			uc.DeleteAttachmentAssets(context.WithoutCancel(ctx), message.Attachments)
+			return nil, err
+		}
+		message.Attachments = append(message.Attachments,
+			entity.NewAttachment(message.ID, result.PublicID, result.ResourceType, result.SecureURL, upload.FileName, upload.ContentType, result.Bytes))
+	}
+	if err := uc.messageRepo.Create(ctx, message); err != nil {
+		// Don't leave an asset behind that no message references
+		uc.DeleteAttachmentAssets(context.WithoutCancel(ctx), message.Attachments)
+		return nil, err
+	}
+	uc.publishSent(ctx, message)
+	return message, nil
+}
+
+// publishSent announces a newly created message and its mentions
+func (uc *messageUseCase) publishSent(ctx context.Context, message *entity.Message) {
+	uc.eventBus.Publish(ctx, event.MessageSentEvent{
+		MessageID:      message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		OccurredAt:     message.CreatedAt,
+	})
+	uc.publishMentions(ctx, message, message.Mentions)
+}
+
+// publishMentions announces each of userIDs being mentioned in the message, except the sender
+func (uc *messageUseCase) publishMentions(ctx context.Context, message *entity.Message, userIDs []string) {
+	occurredAt := message.CreatedAt
+	if message.EditedAt != nil {
+		occurredAt = *message.EditedAt
+	}
+	for _, userID := range userIDs {
+		if userID == message.SenderID {
+			continue
+		}
+		uc.eventBus.Publish(ctx, event.UserMentionedEvent{
+			MessageID:       message.ID,
+			ConversationID:  message.ConversationID,
+			SenderID:        message.SenderID,
+			MentionedUserID: userID,
+			OccurredAt:      occurredAt,
+		})
+	}
+}
+
+// resolveMentions returns the IDs of the conversation's participants mentioned in body.
+// Unknown usernames and users outside the conversation are ignored.
+func (uc *messageUseCase) resolveMentions(ctx context.Context, conversation *entity.Conversation, body string) ([]string, error) {
+	usernames := ParseMentions(body)
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	users, err := uc.userRepo.ListByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	byUsername := make(map[string]string, len(users))
+	for _, user := range users {
+		byUsername[user.Username] = user.ID
+	}
+
+	var mentions []string
+	for _, username := range usernames {
+		if id, ok := byUsername[username]; ok && conversation.HasParticipant(id) {
+			mentions = append(mentions, id)
+		}
+	}
+	return mentions, nil
+}
+
+// History returns the conversation's messages older than cursor, newest first.
+// An empty cursor starts from the latest message; limit is clamped to MaxHistoryLimit.
+func (uc *messageUseCase) History(ctx context.Context, userID, conversationID, cursor string, limit int) (*HistoryPage, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+
+	var before time.Time
+	var beforeID string
+	if cursor != "" {
+		var err error
+		before, beforeID, err = decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.checkParticipant(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra message to learn whether an older page exists
+	messages, err := uc.messageRepo.ListByConversation(ctx, conversationID, before, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &HistoryPage{Messages: messages}
+	if len(messages) > limit {
+		page.Messages = messages[:limit]
+		last := page.Messages[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// Media returns the attachments shared in the conversation older than cursor, newest first, leaving out those
+// of deleted messages. An empty mediaType returns every type; limit is clamped to MaxMediaLimit.
+func (uc *messageUseCase) Media(ctx context.Context, userID, conversationID, mediaType, cursor string, limit int) (*MediaPage, error) {
+	if mediaType != "" && !mediaTypes[mediaType] {
+		return nil, errors.ErrInvalidMediaType
+	}
+	if limit <= 0 {
+		limit = DefaultMediaLimit
+	}
+	if limit > MaxMediaLimit {
+		limit = MaxMediaLimit
+	}
+
+	var before time.Time
+	var beforeID string
+	if cursor != "" {
+		var err error
+		before, beforeID, err = decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.checkParticipant(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra attachment to learn whether an older page exists
+	attachments, err := uc.messageRepo.ListMedia(ctx, conversationID, mediaType, before, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &MediaPage{Attachments: attachments}
+	if len(attachments) > limit {
+		page.Attachments = attachments[:limit]
+		last := page.Attachments[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// Search returns the conversation's messages containing query, newest first; limit is clamped to MaxSearchLimit.
+// Each result carries its created_at, which a client can use to load the history around it.
+func (uc *messageUseCase) Search(ctx context.Context, userID, conversationID, query string, limit int) ([]*entity.Message, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > MaxSearchQueryLength {
+		return nil, errors.ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	if err := uc.checkParticipant(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	return uc.messageRepo.Search(ctx, conversationID, query, limit)
+}
+
+// Mentions returns the messages mentioning userID that they have not seen yet, across their conversations,
+// newest first. A mention is seen once the user's read position in its conversation reaches it.
+// An empty cursor starts from the latest mention; limit is clamped to MaxMentionLimit.
+func (uc *messageUseCase) Mentions(ctx context.Context, userID, cursor string, limit int) (*MentionsPage, error) {
+	if limit <= 0 {
+		limit = DefaultMentionLimit
+	}
+	if limit > MaxMentionLimit {
+		limit = MaxMentionLimit
+	}
+
+	var before time.Time
+	var beforeID string
+	if cursor != "" {
+		var err error
+		before, beforeID, err = decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra mention to learn whether an older page exists
+	messages, err := uc.messageRepo.ListUnseenMentions(ctx, userID, before, beforeID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &MentionsPage{Mentions: make([]*Mention, 0, min(len(messages), limit))}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	if len(messages) == 0 {
+		return page, nil
+	}
+
+	// The repository only returns mentions in the user's conversations, so one lookup covers them all
+	conversations, err := uc.conversationRepo.ListForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*entity.Conversation, len(conversations))
+	for _, conversation := range conversations {
+		byID[conversation.ID] = conversation
+	}
+	for _, message := range messages {
+		// Skip a mention whose conversation the user left since the first query
+		if conversation := byID[message.ConversationID]; conversation != nil {
+			page.Mentions = append(page.Mentions, &Mention{Message: message, Conversation: conversation})
+		}
+	}
+	return page, nil
+}
+
+// MarkRead records messageID as the last message userID has read in the conversation.
+// Reading an older message than the one already recorded leaves the read position unchanged.
+func (uc *messageUseCase) MarkRead(ctx context.Context, userID, conversationID, messageID string) error {
+	if err := uc.checkParticipant(ctx, userID, conversationID); err != nil {
+		return err
+	}
+
+	message, err := uc.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if message.ConversationID != conversationID {
+		return errors.ErrMessageNotFound
+	}
+
+	return uc.messageRepo.MarkRead(ctx, entity.NewConversationRead(userID, message))
+}
+
+// UnreadCount counts the messages from other participants that userID has not read yet
+func (uc *messageUseCase) UnreadCount(ctx context.Context, userID, conversationID string) (int64, error) {
+	if err := uc.checkParticipant(ctx, userID, conversationID); err != nil {
+		return 0, err
+	}
+	return uc.messageRepo.UnreadCount(ctx, userID, conversationID)
+}
+
+// Edit replaces the body of a message; only its sender may edit it.
+// Mentions are resolved again, and only participants who were not already mentioned are notified.
+func (uc *messageUseCase) Edit(ctx context.Context, userID, messageID, body string) (*entity.Message, error) {
+	if err := validateBody(body); err != nil {
+		return nil, err
+	}
+
+	message, err := uc.senderMessage(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsDeleted() {
+		return nil, errors.ErrMessageNotFound
+	}
+
+	var mentions []string
+	if len(ParseMentions(body)) > 0 {
+		conversation, err := uc.conversationRepo.GetByID(ctx, message.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		if mentions, err = uc.resolveMentions(ctx, conversation, body); err != nil {
+			return nil, err
+		}
+	}
+	added := newMentions(message.Mentions, mentions)
+
+	message.Edit(body)
+	message.Mentions = mentions
+	if err := uc.messageRepo.Update(ctx, message); err != nil {
+		return nil, err
+	}
+	uc.publishMentions(ctx, message, added)
+	return message, nil
+}
+
+// Delete soft-deletes a message, leaving a tombstone in the history; only its sender may delete it
+func (uc *messageUseCase) Delete(ctx context.Context, userID, messageID string) (*entity.Message, error) {
+	message, err := uc.senderMessage(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsDeleted() {
+		return message, nil
+	}
+
+	attachments := message.Attachments
+	message.MarkDeleted()
+	if err := uc.messageRepo.Update(ctx, message); err != nil {
+		return nil, err
+	}
+	// The attachments are no longer referenced, their files must not stay reachable
+	uc.DeleteAttachmentAssets(ctx, attachments)
+	return message, nil
+}
+
+// ClearHistory deletes every message userID sent, leaving them in their conversations as deleted messages
+// like Delete does, and returns how many were deleted. Accounts with a password must confirm it with password.
+// Messages are deleted in batches, so a long history doesn't hold one large transaction; the attachments
+// of each batch are scheduled for deletion from Cloudinary by RetryAttachmentDeletions.
+func (uc *messageUseCase) ClearHistory(ctx context.Context, userID, password string) (int, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return 0, errors.ErrInvalidCredentials
+		}
+	}
+
+	cleared := 0
+	for {
+		var deleted int
+		err := uc.txManager.WithTx(ctx, func(ctx context.Context) error {
+			var attachments []*entity.Attachment
+			var err error
+			deleted, attachments, err = uc.messageRepo.DeleteSentBatch(ctx, userID, clearHistoryBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, attachment := range attachments {
+				if err := uc.attachmentDeletionRepo.Add(ctx, attachment); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return cleared, err
+		}
+		cleared += deleted
+		if deleted < clearHistoryBatchSize {
+			return cleared, nil
+		}
+	}
+}
+
+// SentAttachments returns the attachments of every message userID sent
+func (uc *messageUseCase) SentAttachments(ctx context.Context, userID string) ([]*entity.Attachment, error) {
+	return uc.messageRepo.ListAttachmentsBySender(ctx, userID)
+}
+
+// DeleteAttachmentAssets deletes attachments that no message references anymore from Cloudinary.
+// A failed deletion doesn't fail the caller; it is recorded so RetryAttachmentDeletions can remove the asset later.
+func (uc *messageUseCase) DeleteAttachmentAssets(ctx context.Context, attachments []*entity.Attachment) {
+	for _, attachment := range attachments {
+		err := uc.tryDeleteAttachment(ctx, attachment)
+		if err == nil {
+			continue
+		}
+
+		logger.ErrorContext(ctx, "Failed to delete attachment from Cloudinary", err, zap.String("public_id", attachment.PublicID))
+		// Record it even if the request was canceled meanwhile
+		if err := uc.attachmentDeletionRepo.Add(context.WithoutCancel(ctx), attachment); err != nil {
+			logger.ErrorContext(ctx, "Failed to record attachment for deletion", err, zap.String("public_id", attachment.PublicID))
+		}
+	}
+}
+
+// tryDeleteAttachment makes a single deletion attempt bounded by attachmentDeleteTimeout
+func (uc *messageUseCase) tryDeleteAttachment(ctx context.Context, attachment *entity.Attachment) error {
+	ctx, cancel := context.WithTimeout(ctx, attachmentDeleteTimeout)
+	defer cancel()
+	return uc.cloudinaryService.DeleteAttachment(ctx, attachment.PublicID, attachment.ResourceType)
+}
+
+// RetryAttachmentDeletions retries deleting the attachments whose Cloudinary deletion failed earlier
+// and returns how many were deleted. Attachments that still fail stay recorded for the next run.
+func (uc *messageUseCase) RetryAttachmentDeletions(ctx context.Context) (int, error) {
+	attachments, err := uc.attachmentDeletionRepo.List(ctx, attachmentDeletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, attachment := range attachments {
+		if err := uc.tryDeleteAttachment(ctx, attachment); err != nil {
+			logger.Warn("Attachment deletion retry failed", zap.String("public_id", attachment.PublicID), zap.Error(err))
+			continue
+		}
+		if err := uc.attachmentDeletionRepo.Remove(ctx, attachment.PublicID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// React toggles the user's emoji reaction to a message and returns the message with its updated reaction counts.
+// Only participants of the message's conversation may react.
+func (uc *messageUseCase) React(ctx context.Context, userID, messageID, emoji string) (*entity.Message, error) {
+	if err := validateReaction(emoji); err != nil {
+		return nil, err
+	}
+
+	message, err := uc.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkParticipant(ctx, userID, message.ConversationID); err != nil {
+		return nil, err
+	}
+	if message.IsDeleted() {
+		return nil, errors.ErrMessageNotFound
+	}
+
+	if _, err := uc.messageRepo.ToggleReaction(ctx, entity.NewMessageReaction(messageID, userID, emoji)); err != nil {
+		return nil, err
+	}
+	return uc.messageRepo.GetByID(ctx, messageID)
+}
+
+// senderMessage loads a message that userID sent
+func (uc *messageUseCase) senderMessage(ctx context.Context, userID, messageID string) (*entity.Message, error) {
+	message, err := uc.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != userID {
+		return nil, errors.ErrNotMessageSender
+	}
+	return message, nil
+}
+
+// validateBody rejects blank and overlong message bodies
+func validateBody(body string) error {
+	if strings.TrimSpace(body) == "" || utf8.RuneCountInString(body) > entity.MaxMessageLength {
+		return errors.ErrInvalidMessageBody
+	}
+	return nil
+}
+
+// validateIdempotencyKey accepts an empty key, meaning none was sent, or up to MaxIdempotencyKeyLength printable ASCII characters
+func validateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLength {
+		return errors.ErrInvalidIdempotencyKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return errors.ErrInvalidIdempotencyKey
+		}
+	}
+	return nil
+}
+
+// validateReaction accepts a single emoji, which may span several code points (skin tones, ZWJ sequences, keycaps)
+func validateReaction(emoji string) error {
+	if emoji == "" || len(emoji) > entity.MaxReactionLength {
+		return errors.ErrInvalidReaction
+	}
+
+	hasSymbol := false
+	for _, r := range emoji {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) || unicode.IsControl(r) || r == utf8.RuneError {
+			return errors.ErrInvalidReaction
+		}
+		// Keycap emoji are a digit or # followed by the combining keycap mark
+		if r > unicode.MaxASCII && (unicode.IsSymbol(r) || r == '\u20e3') {
+			hasSymbol = true
+		}
+	}
+	if !hasSymbol {
+		return errors.ErrInvalidReaction
+	}
+	return nil
+}
+
+// checkParticipant ensures the conversation exists and userID takes part in it
+func (uc *messageUseCase) checkParticipant(ctx context.Context, userID, conversationID string) error {
+	_, err := uc.participantConversation(ctx, userID, conversationID)
+	return err
+}
+
+// participantConversation loads the conversation, ensuring userID takes part in it
+func (uc *messageUseCase) participantConversation(ctx context.Context, userID, conversationID string) (*entity.Conversation, error) {
+	conversation, err := uc.conversationRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.HasParticipant(userID) {
+		return nil, errors.ErrNotConversationMember
+	}
+	return conversation, nil
+}
+
+// newMentions returns the IDs in current that are not in previous
+func newMentions(previous, current []string) []string {
+	mentioned := make(map[string]bool, len(previous))
+	for _, id := range previous {
+		mentioned[id] = true
+	}
+
+	var added []string
+	for _, id := range current {
+		if !mentioned[id] {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// encodeCursor builds an opaque cursor pointing just past the item created at createdAt with the given id
+func encodeCursor(createdAt time.Time, id string) string {
+	raw := strconv.FormatInt(createdAt.UnixNano(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor extracts the position encoded by encodeCursor
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errors.ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", errors.ErrInvalidCursor
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", errors.ErrInvalidCursor
+	}
+	return time.Unix(0, unixNano).UTC(), id, nil
+}
@@ -0,0 +1,1265 @@
+package message_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/event"
+	"backend/internal/domain/repository"
+	"backend/internal/infrastructure/cloudinary"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/usecase/message"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MockMessageRepository is a mock implementation of MessageRepository
+type MockMessageRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageRepository) Create(ctx context.Context, msg *entity.Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) ListByConversation(ctx context.Context, conversationID string, before time.Time, beforeID string, limit int) ([]*entity.Message, error) {
+	args := m.Called(ctx, conversationID, before, beforeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListMedia(ctx context.Context, conversationID, mediaType string, before time.Time, beforeID string, limit int) ([]*entity.Attachment, error) {
+	args := m.Called(ctx, conversationID, mediaType, before, beforeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Attachment), args.Error(1)
+}
+
+func (m *MockMessageRepository) DeleteSentBatch(ctx context.Context, senderID string, limit int) (int, []*entity.Attachment, error) {
+	args := m.Called(ctx, senderID, limit)
+	if args.Get(1) == nil {
+		return args.Int(0), nil, args.Error(2)
+	}
+	return args.Int(0), args.Get(1).([]*entity.Attachment), args.Error(2)
+}
+
+func (m *MockMessageRepository) SearchForUser(ctx context.Context, userID, query string, limit int) ([]*entity.Message, error) {
+	args := m.Called(ctx, userID, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListUnseenMentions(ctx context.Context, userID string, before time.Time, beforeID string, limit int) ([]*entity.Message, error) {
+	args := m.Called(ctx, userID, before, beforeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetByID(ctx context.Context, id string) (*entity.Message, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) Update(ctx context.Context, msg *entity.Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) ListAttachmentsBySender(ctx context.Context, senderID string) ([]*entity.Attachment, error) {
+	args := m.Called(ctx, senderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Attachment), args.Error(1)
+}
+
+func (m *MockMessageRepository) MarkRead(ctx context.Context, read *entity.ConversationRead) error {
+	args := m.Called(ctx, read)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) UnreadCount(ctx context.Context, userID, conversationID string) (int64, error) {
+	args := m.Called(ctx, userID, conversationID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageRepository) UnreadCounts(ctx context.Context, userID string, conversationIDs []string) (map[string]int64, error) {
+	args := m.Called(ctx, userID, conversationIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockMessageRepository) MarkUnread(ctx context.Context, userID, conversationID string) error {
+	args := m.Called(ctx, userID, conversationID)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) ReadPositions(ctx context.Context, userID string, conversationIDs []string) (map[string]*entity.ConversationRead, error) {
+	args := m.Called(ctx, userID, conversationIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*entity.ConversationRead), args.Error(1)
+}
+
+func (m *MockMessageRepository) Search(ctx context.Context, conversationID, query string, limit int) ([]*entity.Message, error) {
+	args := m.Called(ctx, conversationID, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ToggleReaction(ctx context.Context, reaction *entity.MessageReaction) (bool, error) {
+	args := m.Called(ctx, reaction)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockConversationRepository is a mock implementation of ConversationRepository
+type MockConversationRepository struct {
+	mock.Mock
+}
+
+func (m *MockConversationRepository) Create(ctx context.Context, c *entity.Conversation) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) GetByID(ctx context.Context, id string) (*entity.Conversation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) ListForUser(ctx context.Context, userID string) ([]*entity.Conversation, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) FindDirectBetween(ctx context.Context, userA, userB string) (*entity.Conversation, error) {
+	args := m.Called(ctx, userA, userB)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) SetMuted(ctx context.Context, conversationID, userID string, muted bool) error {
+	args := m.Called(ctx, conversationID, userID, muted)
+	return args.Error(0)
+}
+
+// MockCloudinaryService is a mock implementation of cloudinary.Service
+// MockUserRepository mocks the username lookups made to resolve mentions;
+// other UserRepository methods are not used
+type MockUserRepository struct {
+	mock.Mock
+	repository.UserRepository
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByUsernames(ctx context.Context, usernames []string) ([]*entity.User, error) {
+	args := m.Called(ctx, usernames)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+type MockCloudinaryService struct {
+	mock.Mock
+}
+
+func (m *MockCloudinaryService) UploadAvatar(ctx context.Context, file multipart.File, userID string) (*cloudinary.UploadResult, error) {
+	args := m.Called(ctx, file, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudinary.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinaryService) DeleteAvatar(ctx context.Context, publicID string) error {
+	args := m.Called(ctx, publicID)
+	return args.Error(0)
+}
+
+func (m *MockCloudinaryService) ThumbnailURLs(publicID string) map[int]string {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(map[int]string)
+}
+
+func (m *MockCloudinaryService) UploadAttachment(ctx context.Context, file io.Reader, conversationID string) (*cloudinary.UploadResult, error) {
+	args := m.Called(ctx, file, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudinary.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinaryService) DeleteAttachment(ctx context.Context, publicID, resourceType string) error {
+	args := m.Called(ctx, publicID, resourceType)
+	return args.Error(0)
+}
+
+// fakeIdempotencyKeyRepository keeps claimed keys in memory
+type fakeIdempotencyKeyRepository struct {
+	keys map[string]string
+}
+
+func (r *fakeIdempotencyKeyRepository) Claim(ctx context.Context, userID, key, messageID string, expiresAt time.Time) (string, error) {
+	if r.keys == nil {
+		r.keys = make(map[string]string)
+	}
+	if bound, ok := r.keys[userID+"/"+key]; ok {
+		return bound, nil
+	}
+	r.keys[userID+"/"+key] = messageID
+	return messageID, nil
+}
+
+func (r *fakeIdempotencyKeyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// fakeAttachmentDeletionRepository keeps the attachments awaiting deletion in memory
+type fakeAttachmentDeletionRepository struct {
+	pending []*entity.Attachment
+}
+
+func (r *fakeAttachmentDeletionRepository) Add(ctx context.Context, attachment *entity.Attachment) error {
+	r.pending = append(r.pending, attachment)
+	return nil
+}
+
+func (r *fakeAttachmentDeletionRepository) List(ctx context.Context, limit int) ([]*entity.Attachment, error) {
+	return r.pending[:min(limit, len(r.pending))], nil
+}
+
+func (r *fakeAttachmentDeletionRepository) Remove(ctx context.Context, publicID string) error {
+	r.pending = slices.DeleteFunc(r.pending, func(a *entity.Attachment) bool { return a.PublicID == publicID })
+	return nil
+}
+
+// recordingBus collects published events
+type recordingBus struct {
+	events []event.Event
+}
+
+func (b *recordingBus) Publish(ctx context.Context, e event.Event) {
+	b.events = append(b.events, e)
+}
+
+// passthroughTxManager runs fn directly; transactional behavior is covered by the repository tests
+type passthroughTxManager struct{}
+
+func (passthroughTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+var directConversation = &entity.Conversation{
+	ID:             "conv-1",
+	Type:           entity.ConversationTypeDirect,
+	ParticipantIDs: []string{"user-1", "user-2"},
+}
+
+func TestSend_Success(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	bus := &recordingBus{}
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, bus, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	result, err := uc.Send(context.Background(), "user-2", "conv-1", "hello", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "conv-1", result.ConversationID)
+	assert.Equal(t, "user-2", result.SenderID)
+	assert.Equal(t, "hello", result.Body)
+	mockMsgRepo.AssertExpectations(t)
+	assert.Equal(t, []event.Event{event.MessageSentEvent{
+		MessageID:      result.ID,
+		ConversationID: "conv-1",
+		SenderID:       "user-2",
+		OccurredAt:     result.CreatedAt,
+	}}, bus.events)
+}
+
+var groupConversation = &entity.Conversation{
+	ID:             "conv-2",
+	Type:           entity.ConversationTypeGroup,
+	ParticipantIDs: []string{"user-1", "user-2", "user-3"},
+}
+
+func TestSend_Mentions(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	bus := &recordingBus{}
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, mockUserRepo, nil, nil, nil, nil, bus, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-2").Return(groupConversation, nil)
+	mockUserRepo.On("ListByUsernames", mock.Anything, []string{"carol", "bob", "mallory", "nobody_here", "alice"}).Return([]*entity.User{
+		{ID: "user-1", Username: "alice"},
+		{ID: "user-2", Username: "bob"},
+		{ID: "user-3", Username: "carol"},
+		{ID: "user-4", Username: "mallory"}, // not a participant
+	}, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	result, err := uc.Send(context.Background(), "user-1", "conv-2", "@carol @Bob @mallory @nobody_here and me @alice", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-3", "user-2", "user-1"}, result.Mentions)
+	// The sender mentioning themselves is stored but not announced
+	require.Len(t, bus.events, 3)
+	assert.Equal(t, event.MessageSentEvent{
+		MessageID:      result.ID,
+		ConversationID: "conv-2",
+		SenderID:       "user-1",
+		OccurredAt:     result.CreatedAt,
+	}, bus.events[0])
+	for i, mentioned := range []string{"user-3", "user-2"} {
+		assert.Equal(t, event.UserMentionedEvent{
+			MessageID:       result.ID,
+			ConversationID:  "conv-2",
+			SenderID:        "user-1",
+			MentionedUserID: mentioned,
+			OccurredAt:      result.CreatedAt,
+		}, bus.events[i+1])
+	}
+}
+
+func TestSend_NoMentionsSkipsUserLookup(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, mockUserRepo, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-2").Return(groupConversation, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	result, err := uc.Send(context.Background(), "user-1", "conv-2", "mail me at bob@example.com", "")
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Mentions)
+	mockUserRepo.AssertNotCalled(t, "ListByUsernames", mock.Anything, mock.Anything)
+}
+
+func TestSend_NotParticipant(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.Send(context.Background(), "user-3", "conv-1", "hello", "")
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSend_ConversationNotFound(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(new(MockMessageRepository), mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "missing").Return(nil, errors.ErrConversationNotFound)
+
+	_, err := uc.Send(context.Background(), "user-1", "missing", "hello", "")
+
+	assert.Equal(t, errors.ErrConversationNotFound, err)
+}
+
+func TestSend_InvalidBody(t *testing.T) {
+	bodies := map[string]string{
+		"empty":      "",
+		"whitespace": " \n\t ",
+		"too long":   strings.Repeat("a", entity.MaxMessageLength+1),
+	}
+
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			mockConvRepo := new(MockConversationRepository)
+			uc := message.NewMessageUseCase(new(MockMessageRepository), mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+			_, err := uc.Send(context.Background(), "user-1", "conv-1", body, "")
+
+			assert.Equal(t, errors.ErrInvalidMessageBody, err)
+			mockConvRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSend_MaxLengthCountsCharacters(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	// Multi-byte characters count once each
+	_, err := uc.Send(context.Background(), "user-1", "conv-1", strings.Repeat("é", entity.MaxMessageLength), "")
+
+	assert.NoError(t, err)
+}
+
+func TestSend_ReplaysIdempotencyKey(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	bus := &recordingBus{}
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, &fakeIdempotencyKeyRepository{}, passthroughTxManager{}, nil, nil, bus, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil).Once()
+
+	first, err := uc.Send(context.Background(), "user-1", "conv-1", "hello", "retry-1")
+	require.NoError(t, err)
+
+	mockMsgRepo.On("GetByID", mock.Anything, first.ID).Return(first, nil)
+	replayed, err := uc.Send(context.Background(), "user-1", "conv-1", "hello", "retry-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, replayed.ID)
+	mockMsgRepo.AssertNumberOfCalls(t, "Create", 1)
+	// Only the original send is announced
+	assert.Len(t, bus.events, 1)
+}
+
+func TestSend_IdempotencyKeysAreScopedPerUser(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, &fakeIdempotencyKeyRepository{}, passthroughTxManager{}, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	first, err := uc.Send(context.Background(), "user-1", "conv-1", "hello", "retry-1")
+	require.NoError(t, err)
+	second, err := uc.Send(context.Background(), "user-2", "conv-1", "hello", "retry-1")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.ID, second.ID)
+	mockMsgRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestSend_IdempotencyKeyReusedForOtherConversation(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	idempotencyKeys := &fakeIdempotencyKeyRepository{keys: map[string]string{"user-1/retry-1": "msg-1"}}
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, idempotencyKeys, passthroughTxManager{}, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("GetByID", mock.Anything, "msg-1").Return(&entity.Message{ID: "msg-1", ConversationID: "conv-2"}, nil)
+
+	_, err := uc.Send(context.Background(), "user-1", "conv-1", "hello", "retry-1")
+
+	assert.Equal(t, errors.ErrIdempotencyKeyReused, err)
+	mockMsgRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSend_InvalidIdempotencyKey(t *testing.T) {
+	keys := map[string]string{
+		"too long":     strings.Repeat("k", message.MaxIdempotencyKeyLength+1),
+		"control char": "retry\n1",
+		"non-ascii":    "retry-é",
+	}
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			uc := message.NewMessageUseCase(new(MockMessageRepository), new(MockConversationRepository), nil, &fakeIdempotencyKeyRepository{}, passthroughTxManager{}, nil, nil, nil, 0)
+
+			_, err := uc.Send(context.Background(), "user-1", "conv-1", "hello", key)
+
+			assert.Equal(t, errors.ErrInvalidIdempotencyKey, err)
+		})
+	}
+}
+
+// historyMessages builds n messages in conv-1, newest first, one second apart
+func historyMessages(n int) []*entity.Message {
+	newest := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	messages := make([]*entity.Message, n)
+	for i := range messages {
+		messages[i] = &entity.Message{
+			ID:             fmt.Sprintf("msg-%d", n-i),
+			ConversationID: "conv-1",
+			SenderID:       "user-1",
+			Body:           "hello",
+			CreatedAt:      newest.Add(-time.Duration(i) * time.Second),
+		}
+	}
+	return messages
+}
+
+func TestHistory_ReturnsCursorForOlderPage(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	messages := historyMessages(3)
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("ListByConversation", mock.Anything, "conv-1", time.Time{}, "", 3).Return(messages, nil).Once()
+
+	page, err := uc.History(context.Background(), "user-1", "conv-1", "", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, messages[:2], page.Messages)
+	require.NotEmpty(t, page.NextCursor)
+
+	// The cursor resumes right after the last message returned
+	last := messages[1]
+	mockMsgRepo.On("ListByConversation", mock.Anything, "conv-1", last.CreatedAt, last.ID, 3).Return(messages[2:], nil).Once()
+
+	page, err = uc.History(context.Background(), "user-1", "conv-1", page.NextCursor, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, messages[2:], page.Messages)
+	assert.Empty(t, page.NextCursor)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestHistory_ClampsLimit(t *testing.T) {
+	tests := map[string]struct {
+		requested int
+		queried   int
+	}{
+		"default":   {0, message.DefaultHistoryLimit + 1},
+		"negative":  {-5, message.DefaultHistoryLimit + 1},
+		"above max": {1000, message.MaxHistoryLimit + 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockMsgRepo := new(MockMessageRepository)
+			mockConvRepo := new(MockConversationRepository)
+			uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+			mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+			mockMsgRepo.On("ListByConversation", mock.Anything, "conv-1", time.Time{}, "", tt.queried).Return([]*entity.Message{}, nil)
+
+			_, err := uc.History(context.Background(), "user-1", "conv-1", "", tt.requested)
+
+			require.NoError(t, err)
+			mockMsgRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHistory_NotParticipant(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.History(context.Background(), "user-3", "conv-1", "", 10)
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "ListByConversation", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHistory_InvalidCursor(t *testing.T) {
+	cursors := []string{"not base64!", "bm8tc2VwYXJhdG9y", "YWJjOm1zZy0x"}
+
+	for _, cursor := range cursors {
+		t.Run(cursor, func(t *testing.T) {
+			uc := message.NewMessageUseCase(new(MockMessageRepository), new(MockConversationRepository), nil, nil, nil, nil, nil, nil, 0)
+
+			_, err := uc.History(context.Background(), "user-1", "conv-1", cursor, 10)
+
+			assert.Equal(t, errors.ErrInvalidCursor, err)
+		})
+	}
+}
+
+func TestMedia_FiltersByTypeAndPages(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	newest := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	attachments := []*entity.Attachment{
+		{ID: "att-3", MessageID: "msg-3", ContentType: "image/png", CreatedAt: newest},
+		{ID: "att-2", MessageID: "msg-2", ContentType: "image/png", CreatedAt: newest.Add(-time.Second)},
+		{ID: "att-1", MessageID: "msg-1", ContentType: "image/png", CreatedAt: newest.Add(-2 * time.Second)},
+	}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("ListMedia", mock.Anything, "conv-1", entity.MediaTypeImage, time.Time{}, "", 3).Return(attachments, nil).Once()
+
+	page, err := uc.Media(context.Background(), "user-1", "conv-1", entity.MediaTypeImage, "", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, attachments[:2], page.Attachments)
+	require.NotEmpty(t, page.NextCursor)
+
+	// The cursor resumes right after the last attachment returned
+	last := attachments[1]
+	mockMsgRepo.On("ListMedia", mock.Anything, "conv-1", entity.MediaTypeImage, last.CreatedAt, last.ID, 3).Return(attachments[2:], nil).Once()
+
+	page, err = uc.Media(context.Background(), "user-1", "conv-1", entity.MediaTypeImage, page.NextCursor, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, attachments[2:], page.Attachments)
+	assert.Empty(t, page.NextCursor)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestMentions_PagesWithConversationContext(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	newest := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	messages := []*entity.Message{
+		{ID: "msg-3", ConversationID: "conv-1", SenderID: "user-2", Body: "@one look", Mentions: []string{"user-1"}, CreatedAt: newest},
+		{ID: "msg-2", ConversationID: "conv-1", SenderID: "user-2", Body: "@one hi", Mentions: []string{"user-1"}, CreatedAt: newest.Add(-time.Second)},
+		{ID: "msg-1", ConversationID: "conv-1", SenderID: "user-2", Body: "@one hey", Mentions: []string{"user-1"}, CreatedAt: newest.Add(-2 * time.Second)},
+	}
+	mockConvRepo.On("ListForUser", mock.Anything, "user-1").Return([]*entity.Conversation{directConversation}, nil)
+	mockMsgRepo.On("ListUnseenMentions", mock.Anything, "user-1", time.Time{}, "", 3).Return(messages, nil).Once()
+
+	page, err := uc.Mentions(context.Background(), "user-1", "", 2)
+
+	require.NoError(t, err)
+	require.Len(t, page.Mentions, 2)
+	assert.Equal(t, messages[0], page.Mentions[0].Message)
+	assert.Equal(t, directConversation, page.Mentions[0].Conversation)
+	require.NotEmpty(t, page.NextCursor)
+
+	// The cursor resumes right after the last mention returned
+	last := messages[1]
+	mockMsgRepo.On("ListUnseenMentions", mock.Anything, "user-1", last.CreatedAt, last.ID, 3).Return(messages[2:], nil).Once()
+
+	page, err = uc.Mentions(context.Background(), "user-1", page.NextCursor, 2)
+
+	require.NoError(t, err)
+	require.Len(t, page.Mentions, 1)
+	assert.Equal(t, messages[2], page.Mentions[0].Message)
+	assert.Empty(t, page.NextCursor)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestMentions_EmptySkipsConversationLookup(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockMsgRepo.On("ListUnseenMentions", mock.Anything, "user-1", time.Time{}, "", message.DefaultMentionLimit+1).Return([]*entity.Message{}, nil)
+
+	page, err := uc.Mentions(context.Background(), "user-1", "", 0)
+
+	require.NoError(t, err)
+	assert.Empty(t, page.Mentions)
+	assert.Empty(t, page.NextCursor)
+	mockConvRepo.AssertNotCalled(t, "ListForUser", mock.Anything, mock.Anything)
+}
+
+func TestMedia_NotParticipant(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.Media(context.Background(), "user-3", "conv-1", "", "", 10)
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "ListMedia", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMedia_InvalidType(t *testing.T) {
+	uc := message.NewMessageUseCase(new(MockMessageRepository), new(MockConversationRepository), nil, nil, nil, nil, nil, nil, 0)
+
+	_, err := uc.Media(context.Background(), "user-1", "conv-1", "images", "", 10)
+
+	assert.Equal(t, errors.ErrInvalidMediaType, err)
+}
+
+func TestSearch_TrimsQueryAndClampsLimit(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	found := []*entity.Message{entity.NewMessage("conv-1", "user-2", "lunch?")}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("Search", mock.Anything, "conv-1", "lunch", message.MaxSearchLimit).Return(found, nil)
+
+	result, err := uc.Search(context.Background(), "user-1", "conv-1", "  lunch ", 1000)
+
+	require.NoError(t, err)
+	assert.Equal(t, found, result)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestSearch_NotParticipant(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.Search(context.Background(), "user-3", "conv-1", "lunch", 0)
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearch_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"", "   ", strings.Repeat("a", message.MaxSearchQueryLength+1)} {
+		uc := message.NewMessageUseCase(new(MockMessageRepository), new(MockConversationRepository), nil, nil, nil, nil, nil, nil, 0)
+
+		_, err := uc.Search(context.Background(), "user-1", "conv-1", query, 0)
+
+		assert.Equal(t, errors.ErrInvalidSearchQuery, err)
+	}
+}
+
+func TestMarkRead_RecordsReadPosition(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	msg := historyMessages(1)[0]
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("GetByID", mock.Anything, msg.ID).Return(msg, nil)
+	mockMsgRepo.On("MarkRead", mock.Anything, mock.MatchedBy(func(read *entity.ConversationRead) bool {
+		return read.UserID == "user-2" && read.ConversationID == "conv-1" &&
+			read.LastReadMessageID == msg.ID && read.LastReadAt.Equal(msg.CreatedAt)
+	})).Return(nil)
+
+	err := uc.MarkRead(context.Background(), "user-2", "conv-1", msg.ID)
+
+	require.NoError(t, err)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestMarkRead_MessageFromOtherConversation(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	msg := &entity.Message{ID: "msg-1", ConversationID: "conv-2"}
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("GetByID", mock.Anything, "msg-1").Return(msg, nil)
+
+	err := uc.MarkRead(context.Background(), "user-1", "conv-1", "msg-1")
+
+	assert.Equal(t, errors.ErrMessageNotFound, err)
+	mockMsgRepo.AssertNotCalled(t, "MarkRead", mock.Anything, mock.Anything)
+}
+
+func TestMarkRead_NotParticipant(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	err := uc.MarkRead(context.Background(), "user-3", "conv-1", "msg-1")
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "MarkRead", mock.Anything, mock.Anything)
+}
+
+func TestUnreadCount(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("UnreadCount", mock.Anything, "user-1", "conv-1").Return(int64(4), nil)
+
+	count, err := uc.UnreadCount(context.Background(), "user-1", "conv-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestEdit_BySender(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "helo")
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockMsgRepo.On("Update", mock.Anything, stored).Return(nil)
+
+	result, err := uc.Edit(context.Background(), "user-1", stored.ID, "hello")
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello", result.Body)
+	assert.NotNil(t, result.EditedAt)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestEdit_AnnouncesOnlyNewMentions(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockUserRepo := new(MockUserRepository)
+	bus := &recordingBus{}
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, mockUserRepo, nil, nil, nil, nil, bus, 0)
+
+	stored := entity.NewMessage("conv-2", "user-1", "hi @bob")
+	stored.Mentions = []string{"user-2"}
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockMsgRepo.On("Update", mock.Anything, stored).Return(nil)
+	mockConvRepo.On("GetByID", mock.Anything, "conv-2").Return(groupConversation, nil)
+	mockUserRepo.On("ListByUsernames", mock.Anything, []string{"bob", "carol"}).Return([]*entity.User{
+		{ID: "user-2", Username: "bob"},
+		{ID: "user-3", Username: "carol"},
+	}, nil)
+
+	result, err := uc.Edit(context.Background(), "user-1", stored.ID, "hi @bob and @carol")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-2", "user-3"}, result.Mentions)
+	assert.Equal(t, []event.Event{event.UserMentionedEvent{
+		MessageID:       stored.ID,
+		ConversationID:  "conv-2",
+		SenderID:        "user-1",
+		MentionedUserID: "user-3",
+		OccurredAt:      *result.EditedAt,
+	}}, bus.events)
+
+	// Removing a mention drops it without an event
+	bus.events = nil
+	result, err = uc.Edit(context.Background(), "user-1", stored.ID, "hi all")
+	require.NoError(t, err)
+	assert.Empty(t, result.Mentions)
+	assert.Empty(t, bus.events)
+}
+
+func TestEdit_NotSender(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+
+	_, err := uc.Edit(context.Background(), "user-2", stored.ID, "changed")
+
+	assert.Equal(t, errors.ErrNotMessageSender, err)
+	assert.Equal(t, "hello", stored.Body)
+	mockMsgRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestEdit_DeletedMessage(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	stored.MarkDeleted()
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+
+	_, err := uc.Edit(context.Background(), "user-1", stored.ID, "changed")
+
+	assert.Equal(t, errors.ErrMessageNotFound, err)
+}
+
+func TestEdit_InvalidBody(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	_, err := uc.Edit(context.Background(), "user-1", "msg-1", "   ")
+
+	assert.Equal(t, errors.ErrInvalidMessageBody, err)
+	mockMsgRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestDelete_BySender(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockMsgRepo.On("Update", mock.Anything, stored).Return(nil).Once()
+
+	result, err := uc.Delete(context.Background(), "user-1", stored.ID)
+
+	require.NoError(t, err)
+	assert.True(t, result.IsDeleted())
+	assert.Empty(t, result.Body)
+
+	// Deleting again is a no-op
+	_, err = uc.Delete(context.Background(), "user-1", stored.ID)
+
+	require.NoError(t, err)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestDelete_NotSender(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+
+	_, err := uc.Delete(context.Background(), "user-2", stored.ID)
+
+	assert.Equal(t, errors.ErrNotMessageSender, err)
+	assert.False(t, stored.IsDeleted())
+}
+
+func TestDelete_DeletesAttachments(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	deletions := &fakeAttachmentDeletionRepository{}
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, mockCloudinary, deletions, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "")
+	stored.Attachments = []*entity.Attachment{
+		entity.NewAttachment(stored.ID, "chat/conv-1/a", "image", "https://res.cloudinary.com/a.png", "a.png", "image/png", 10),
+	}
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockMsgRepo.On("Update", mock.Anything, stored).Return(nil)
+	mockCloudinary.On("DeleteAttachment", mock.Anything, "chat/conv-1/a", "image").Return(nil).Once()
+
+	result, err := uc.Delete(context.Background(), "user-1", stored.ID)
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Attachments)
+	assert.Empty(t, deletions.pending)
+	mockCloudinary.AssertExpectations(t)
+}
+
+func TestDelete_RecordsFailedAttachmentDeletionForRetry(t *testing.T) {
+	logger.Init("release")
+	mockMsgRepo := new(MockMessageRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	deletions := &fakeAttachmentDeletionRepository{}
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, nil, nil, nil, mockCloudinary, deletions, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "")
+	attachment := entity.NewAttachment(stored.ID, "chat/conv-1/a", "raw", "https://res.cloudinary.com/a.zip", "a.zip", "application/zip", 10)
+	stored.Attachments = []*entity.Attachment{attachment}
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockMsgRepo.On("Update", mock.Anything, stored).Return(nil)
+	mockCloudinary.On("DeleteAttachment", mock.Anything, "chat/conv-1/a", "raw").Return(assert.AnError).Once()
+
+	_, err := uc.Delete(context.Background(), "user-1", stored.ID)
+
+	// The message is deleted anyway, the asset is left for the retry
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.Attachment{attachment}, deletions.pending)
+
+	mockCloudinary.On("DeleteAttachment", mock.Anything, "chat/conv-1/a", "raw").Return(nil).Once()
+
+	deleted, err := uc.RetryAttachmentDeletions(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Empty(t, deletions.pending)
+	mockCloudinary.AssertExpectations(t)
+}
+
+// userWithPassword returns a user whose password is "password123"
+func userWithPassword(t *testing.T, id string) *entity.User {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := entity.NewUser(id+"@example.com", string(hashed), "User", "", "")
+	user.ID = id
+	return user
+}
+
+func TestClearHistory_DeletesInBatchesAndSchedulesAttachments(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	deletions := &fakeAttachmentDeletionRepository{}
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, mockUserRepo, nil, passthroughTxManager{}, mockCloudinary, deletions, nil, 0)
+
+	first := entity.NewAttachment("msg-1", "chat/conv-1/a", "image", "https://res.cloudinary.com/a.png", "a.png", "image/png", 10)
+	second := entity.NewAttachment("msg-9", "chat/conv-2/b", "raw", "https://res.cloudinary.com/b.zip", "b.zip", "application/zip", 10)
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(userWithPassword(t, "user-1"), nil)
+	mockMsgRepo.On("DeleteSentBatch", mock.Anything, "user-1", message.ClearHistoryBatchSize).
+		Return(message.ClearHistoryBatchSize, []*entity.Attachment{first}, nil).Once()
+	mockMsgRepo.On("DeleteSentBatch", mock.Anything, "user-1", message.ClearHistoryBatchSize).
+		Return(3, []*entity.Attachment{second}, nil).Once()
+
+	deleted, err := uc.ClearHistory(context.Background(), "user-1", "password123")
+
+	require.NoError(t, err)
+	assert.Equal(t, message.ClearHistoryBatchSize+3, deleted)
+	// The assets are left to the cleanup job instead of being deleted during the request
+	assert.Equal(t, []*entity.Attachment{first, second}, deletions.pending)
+	mockCloudinary.AssertNotCalled(t, "DeleteAttachment", mock.Anything, mock.Anything, mock.Anything)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestClearHistory_WrongPassword(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, nil, mockUserRepo, nil, passthroughTxManager{}, nil, nil, nil, 0)
+
+	mockUserRepo.On("GetByID", mock.Anything, "user-1").Return(userWithPassword(t, "user-1"), nil)
+
+	_, err := uc.ClearHistory(context.Background(), "user-1", "wrong-password")
+
+	assert.Equal(t, errors.ErrInvalidCredentials, err)
+	mockMsgRepo.AssertNotCalled(t, "DeleteSentBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+var uploadedAttachment = &cloudinary.UploadResult{
+	PublicID:     "chat/conv-1/x7k2",
+	SecureURL:    "https://res.cloudinary.com/demo/image/upload/chat/conv-1/x7k2.png",
+	ResourceType: "image",
+	Bytes:        2048,
+}
+
+func TestReact_TogglesReaction(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	reacted := *stored
+	reacted.Reactions = []*entity.ReactionCount{{Emoji: "👍🏽", Count: 1}}
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil).Once()
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(&reacted, nil).Once()
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockMsgRepo.On("ToggleReaction", mock.Anything, mock.MatchedBy(func(r *entity.MessageReaction) bool {
+		return r.MessageID == stored.ID && r.UserID == "user-2" && r.Emoji == "👍🏽"
+	})).Return(true, nil)
+
+	result, err := uc.React(context.Background(), "user-2", stored.ID, "👍🏽")
+
+	require.NoError(t, err)
+	assert.Equal(t, reacted.Reactions, result.Reactions)
+	mockMsgRepo.AssertExpectations(t)
+}
+
+func TestReact_NotParticipant(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.React(context.Background(), "user-3", stored.ID, "👍")
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockMsgRepo.AssertNotCalled(t, "ToggleReaction", mock.Anything, mock.Anything)
+}
+
+func TestReact_DeletedMessage(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+	stored := entity.NewMessage("conv-1", "user-1", "hello")
+	stored.MarkDeleted()
+	mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.React(context.Background(), "user-2", stored.ID, "👍")
+
+	assert.Equal(t, errors.ErrMessageNotFound, err)
+	mockMsgRepo.AssertNotCalled(t, "ToggleReaction", mock.Anything, mock.Anything)
+}
+
+func TestReact_ValidatesEmoji(t *testing.T) {
+	reactions := map[string]bool{
+		"👍":                    true,
+		"❤️":                   true,
+		"1️⃣":                  true,
+		"👨‍👩‍👧‍👦":              true,
+		"":                     false,
+		"ok":                   false,
+		":)":                   false,
+		"👍 👍":                  false,
+		"Ж":                    false,
+		"\u200b":               false,
+		strings.Repeat("👍", 9): false,
+	}
+
+	for emoji, valid := range reactions {
+		t.Run(emoji, func(t *testing.T) {
+			mockMsgRepo := new(MockMessageRepository)
+			mockConvRepo := new(MockConversationRepository)
+			uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, nil, nil, nil, 0)
+
+			stored := entity.NewMessage("conv-1", "user-1", "hello")
+			mockMsgRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+			mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+			mockMsgRepo.On("ToggleReaction", mock.Anything, mock.Anything).Return(true, nil)
+
+			_, err := uc.React(context.Background(), "user-1", stored.ID, emoji)
+
+			if valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, errors.ErrInvalidReaction, err)
+			}
+		})
+	}
+}
+
+// photoUploads returns n PNG uploads
+func photoUploads(n int) []message.AttachmentUpload {
+	uploads := make([]message.AttachmentUpload, n)
+	for i := range uploads {
+		uploads[i] = message.AttachmentUpload{File: strings.NewReader("content"), FileName: fmt.Sprintf("photo-%d.png", i), ContentType: "image/png"}
+	}
+	return uploads
+}
+
+func TestSendAttachments_CreatesMessageWithAttachment(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, mockCloudinary, nil, nil, 0)
+
+	file := strings.NewReader("content")
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockCloudinary.On("UploadAttachment", mock.Anything, file, "conv-1").Return(uploadedAttachment, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(nil)
+
+	result, err := uc.SendAttachments(context.Background(), "user-1", "conv-1", []message.AttachmentUpload{{File: file, FileName: "photo.png", ContentType: "image/png"}}, "  ")
+
+	require.NoError(t, err)
+	// A blank caption is dropped rather than rejected
+	assert.Empty(t, result.Body)
+	require.Len(t, result.Attachments, 1)
+	attachment := result.Attachments[0]
+	assert.Equal(t, result.ID, attachment.MessageID)
+	assert.Equal(t, uploadedAttachment.SecureURL, attachment.URL)
+	assert.Equal(t, "photo.png", attachment.FileName)
+	assert.Equal(t, "image/png", attachment.ContentType)
+	assert.Equal(t, int64(2048), attachment.Size)
+}
+
+func TestSendAttachments_NotParticipant(t *testing.T) {
+	mockConvRepo := new(MockConversationRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(new(MockMessageRepository), mockConvRepo, nil, nil, nil, mockCloudinary, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.SendAttachments(context.Background(), "user-3", "conv-1", photoUploads(1), "")
+
+	assert.Equal(t, errors.ErrNotConversationMember, err)
+	mockCloudinary.AssertNotCalled(t, "UploadAttachment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSendAttachments_DeletesUploadWhenMessageFails(t *testing.T) {
+	logger.Init("release")
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, mockCloudinary, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockCloudinary.On("UploadAttachment", mock.Anything, mock.Anything, "conv-1").Return(uploadedAttachment, nil)
+	mockMsgRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Message")).Return(assert.AnError)
+	mockCloudinary.On("DeleteAttachment", mock.Anything, uploadedAttachment.PublicID, "image").Return(nil)
+
+	_, err := uc.SendAttachments(context.Background(), "user-1", "conv-1", photoUploads(1), "")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	mockCloudinary.AssertExpectations(t)
+}
+
+func TestSendAttachments_UploadTooLarge(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, mockCloudinary, nil, nil, 0)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockCloudinary.On("UploadAttachment", mock.Anything, mock.Anything, "conv-1").Return(nil, errors.ErrAttachmentTooLarge)
+
+	_, err := uc.SendAttachments(context.Background(), "user-1", "conv-1", []message.AttachmentUpload{{File: strings.NewReader("content"), FileName: "big.zip", ContentType: "application/zip"}}, "")
+
+	assert.Equal(t, errors.ErrAttachmentTooLarge, err)
+	mockMsgRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSendAttachments_TooManyRejectedBeforeUpload(t *testing.T) {
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, mockCloudinary, nil, nil, 2)
+
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+
+	_, err := uc.SendAttachments(context.Background(), "user-1", "conv-1", photoUploads(3), "")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidAttachmentCount)
+	assert.Contains(t, err.Error(), "between 1 and 2 attachments")
+	mockCloudinary.AssertNotCalled(t, "UploadAttachment", mock.Anything, mock.Anything, mock.Anything)
+	mockMsgRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSendAttachments_DefaultLimit(t *testing.T) {
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(new(MockMessageRepository), new(MockConversationRepository), nil, nil, nil, mockCloudinary, nil, nil, 0)
+
+	_, err := uc.SendAttachments(context.Background(), "user-1", "conv-1", photoUploads(message.DefaultMaxAttachments+1), "")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidAttachmentCount)
+	mockCloudinary.AssertNotCalled(t, "UploadAttachment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSendAttachments_DeletesEarlierUploadsWhenOneFails(t *testing.T) {
+	logger.Init("release")
+	mockMsgRepo := new(MockMessageRepository)
+	mockConvRepo := new(MockConversationRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := message.NewMessageUseCase(mockMsgRepo, mockConvRepo, nil, nil, nil, mockCloudinary, nil, nil, 0)
+
+	uploads := photoUploads(2)
+	// Distinct contents tell the uploads apart
+	uploads[1].File = strings.NewReader("too large")
+	mockConvRepo.On("GetByID", mock.Anything, "conv-1").Return(directConversation, nil)
+	mockCloudinary.On("UploadAttachment", mock.Anything, uploads[0].File, "conv-1").Return(uploadedAttachment, nil)
+	mockCloudinary.On("UploadAttachment", mock.Anything, uploads[1].File, "conv-1").Return(nil, errors.ErrAttachmentTooLarge)
+	mockCloudinary.On("DeleteAttachment", mock.Anything, uploadedAttachment.PublicID, "image").Return(nil)
+
+	_, err := uc.SendAttachments(context.Background(), "user-1", "conv-1", uploads, "")
+
+	assert.Equal(t, errors.ErrAttachmentTooLarge, err)
+	mockCloudinary.AssertExpectations(t)
+	mockMsgRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
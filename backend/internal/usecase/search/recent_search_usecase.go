@@ -0,0 +1,55 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/repository"
+	"backend/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// RecentSearchUseCase defines the interface for remembering the queries a user searched for, to suggest them again
+type RecentSearchUseCase interface {
+	// Record remembers the query as the user's latest search. Failures are logged, so a search never fails because of it.
+	Record(ctx context.Context, userID, query string)
+	// List returns the user's recent queries, newest first, each query once
+	List(ctx context.Context, userID string) ([]*entity.RecentSearch, error)
+	// Clear forgets every query the user searched for
+	Clear(ctx context.Context, userID string) error
+}
+
+type recentSearchUseCase struct {
+	recentSearchRepo repository.RecentSearchRepository
+	limit            int
+}
+
+// NewRecentSearchUseCase creates a new recent search use case keeping the last limit queries of each user.
+// A limit of 0 turns recent searches off: nothing is recorded and List returns no queries.
+func NewRecentSearchUseCase(recentSearchRepo repository.RecentSearchRepository, limit int) RecentSearchUseCase {
+	return &recentSearchUseCase{recentSearchRepo: recentSearchRepo, limit: limit}
+}
+
+func (uc *recentSearchUseCase) Record(ctx context.Context, userID, query string) {
+	query = strings.TrimSpace(query)
+	if uc.limit <= 0 || query == "" {
+		return
+	}
+	if err := uc.recentSearchRepo.Record(ctx, entity.NewRecentSearch(userID, query), uc.limit); err != nil {
+		logger.ErrorContext(ctx, "Failed to record recent search", err, zap.String("user_id", userID))
+	}
+}
+
+func (uc *recentSearchUseCase) List(ctx context.Context, userID string) ([]*entity.RecentSearch, error) {
+	if uc.limit <= 0 {
+		return []*entity.RecentSearch{}, nil
+	}
+	return uc.recentSearchRepo.ListByUserID(ctx, userID, uc.limit)
+}
+
+// Clear works even while recent searches are off, so queries recorded before can still be forgotten
+func (uc *recentSearchUseCase) Clear(ctx context.Context, userID string) error {
+	return uc.recentSearchRepo.DeleteByUserID(ctx, userID)
+}
@@ -0,0 +1,96 @@
+package search_test
+
+import (
+	"context"
+	"testing"
+
+	"backend/internal/domain/entity"
+	"backend/internal/infrastructure/logger"
+	"backend/internal/usecase/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRecentSearchRepository is a mock implementation of RecentSearchRepository
+type MockRecentSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecentSearchRepository) Record(ctx context.Context, recent *entity.RecentSearch, keep int) error {
+	args := m.Called(ctx, recent, keep)
+	return args.Error(0)
+}
+
+func (m *MockRecentSearchRepository) ListByUserID(ctx context.Context, userID string, limit int) ([]*entity.RecentSearch, error) {
+	args := m.Called(ctx, userID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.RecentSearch), args.Error(1)
+}
+
+func (m *MockRecentSearchRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestRecord_KeepsConfiguredNumberOfQueries(t *testing.T) {
+	repo := new(MockRecentSearchRepository)
+	uc := search.NewRecentSearchUseCase(repo, 5)
+
+	repo.On("Record", mock.Anything, mock.MatchedBy(func(recent *entity.RecentSearch) bool {
+		return recent.UserID == "user-1" && recent.Query == "lunch"
+	}), 5).Return(nil)
+
+	uc.Record(context.Background(), "user-1", "  lunch ")
+	uc.Record(context.Background(), "user-1", "   ")
+
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "Record", 1)
+}
+
+func TestRecord_FailureIsLogged(t *testing.T) {
+	logger.Init("release")
+	repo := new(MockRecentSearchRepository)
+	uc := search.NewRecentSearchUseCase(repo, 5)
+
+	repo.On("Record", mock.Anything, mock.Anything, 5).Return(assert.AnError)
+
+	uc.Record(context.Background(), "user-1", "lunch")
+
+	repo.AssertExpectations(t)
+}
+
+func TestList_CappedToConfiguredNumber(t *testing.T) {
+	repo := new(MockRecentSearchRepository)
+	uc := search.NewRecentSearchUseCase(repo, 5)
+
+	searches := []*entity.RecentSearch{entity.NewRecentSearch("user-1", "lunch")}
+	repo.On("ListByUserID", mock.Anything, "user-1", 5).Return(searches, nil)
+
+	listed, err := uc.List(context.Background(), "user-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, searches, listed)
+}
+
+func TestRecentSearches_Disabled(t *testing.T) {
+	repo := new(MockRecentSearchRepository)
+	uc := search.NewRecentSearchUseCase(repo, 0)
+
+	repo.On("DeleteByUserID", mock.Anything, "user-1").Return(nil)
+
+	uc.Record(context.Background(), "user-1", "lunch")
+	listed, err := uc.List(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	// Queries recorded while the feature was on can still be cleared
+	require.NoError(t, uc.Clear(context.Background(), "user-1"))
+
+	repo.AssertNotCalled(t, "Record", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "ListByUserID", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultSectionLimit is the number of results per section used when no limit is requested
+	DefaultSectionLimit = 5
+	// MaxSectionLimit caps the number of results per section
+	MaxSectionLimit = 20
+	// MaxQueryLength is the longest query accepted, in characters, as for message search
+	MaxQueryLength = 100
+)
+
+// SearchUseCase defines the interface for searching users and messages from one search box
+type SearchUseCase interface {
+	Search(ctx context.Context, userID, query string, limit int) (*Results, error)
+}
+
+// Results are the matches of a search, grouped by type
+type Results struct {
+	// Users are the users the requester can start a conversation with, ordered by name
+	Users []*entity.User
+	// Messages are the messages of the requester's conversations, newest first
+	Messages []*entity.Message
+}
+
+type searchUseCase struct {
+	userRepo    repository.UserRepository
+	messageRepo repository.MessageRepository
+}
+
+// NewSearchUseCase creates a new search use case
+func NewSearchUseCase(userRepo repository.UserRepository, messageRepo repository.MessageRepository) SearchUseCase {
+	return &searchUseCase{userRepo: userRepo, messageRepo: messageRepo}
+}
+
+// Search returns up to limit users and up to limit messages matching query for userID; limit is clamped
+// to MaxSectionLimit. Each section is only searched within what userID may see: users they can contact
+// and messages of conversations they take part in. The sections are queried in parallel.
+func (uc *searchUseCase) Search(ctx context.Context, userID, query string, limit int) (*Results, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > MaxQueryLength {
+		return nil, errors.ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = DefaultSectionLimit
+	}
+	if limit > MaxSectionLimit {
+		limit = MaxSectionLimit
+	}
+
+	results := &Results{}
+	group, ctx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		users, err := uc.userRepo.SearchContactable(ctx, query, userID, limit)
+		results.Users = users
+		return err
+	})
+	group.Go(func() error {
+		messages, err := uc.messageRepo.SearchForUser(ctx, userID, query, limit)
+		results.Messages = messages
+		return err
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,109 @@
+package search_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"backend/internal/domain/entity"
+	"backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+	"backend/internal/usecase/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserRepository is a mock of the user search of UserRepository
+type MockUserRepository struct {
+	mock.Mock
+	repository.UserRepository
+}
+
+func (m *MockUserRepository) SearchContactable(ctx context.Context, query, excludeID string, limit int) ([]*entity.User, error) {
+	args := m.Called(ctx, query, excludeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+// MockMessageRepository is a mock of the message search of MessageRepository
+type MockMessageRepository struct {
+	mock.Mock
+	repository.MessageRepository
+}
+
+func (m *MockMessageRepository) SearchForUser(ctx context.Context, userID, query string, limit int) ([]*entity.Message, error) {
+	args := m.Called(ctx, userID, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.Message), args.Error(1)
+}
+
+func TestSearch_GroupsSectionsForRequester(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	messageRepo := new(MockMessageRepository)
+	uc := search.NewSearchUseCase(userRepo, messageRepo)
+
+	users := []*entity.User{entity.NewUser("alice@example.com", "", "Alice", "alice", "")}
+	messages := []*entity.Message{entity.NewMessage("conv-1", "user-2", "ask alice")}
+	// Both sections are scoped to the requester: contactable users, and messages of their conversations
+	userRepo.On("SearchContactable", mock.Anything, "alice", "user-1", 3).Return(users, nil)
+	messageRepo.On("SearchForUser", mock.Anything, "user-1", "alice", 3).Return(messages, nil)
+
+	results, err := uc.Search(context.Background(), "user-1", "  alice ", 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, users, results.Users)
+	assert.Equal(t, messages, results.Messages)
+	userRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestSearch_LimitClampedPerSection(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	messageRepo := new(MockMessageRepository)
+	uc := search.NewSearchUseCase(userRepo, messageRepo)
+
+	userRepo.On("SearchContactable", mock.Anything, "alice", "user-1", search.DefaultSectionLimit).Return([]*entity.User{}, nil).Once()
+	messageRepo.On("SearchForUser", mock.Anything, "user-1", "alice", search.DefaultSectionLimit).Return([]*entity.Message{}, nil).Once()
+	_, err := uc.Search(context.Background(), "user-1", "alice", 0)
+	require.NoError(t, err)
+
+	userRepo.On("SearchContactable", mock.Anything, "alice", "user-1", search.MaxSectionLimit).Return([]*entity.User{}, nil).Once()
+	messageRepo.On("SearchForUser", mock.Anything, "user-1", "alice", search.MaxSectionLimit).Return([]*entity.Message{}, nil).Once()
+	_, err = uc.Search(context.Background(), "user-1", "alice", 1000)
+	require.NoError(t, err)
+
+	userRepo.AssertExpectations(t)
+	messageRepo.AssertExpectations(t)
+}
+
+func TestSearch_SectionFailureFailsSearch(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	messageRepo := new(MockMessageRepository)
+	uc := search.NewSearchUseCase(userRepo, messageRepo)
+
+	userRepo.On("SearchContactable", mock.Anything, "alice", "user-1", search.DefaultSectionLimit).Return([]*entity.User{}, nil)
+	messageRepo.On("SearchForUser", mock.Anything, "user-1", "alice", search.DefaultSectionLimit).Return(nil, assert.AnError)
+
+	_, err := uc.Search(context.Background(), "user-1", "alice", 0)
+
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestSearch_InvalidQuery(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	messageRepo := new(MockMessageRepository)
+	uc := search.NewSearchUseCase(userRepo, messageRepo)
+
+	for _, query := range []string{"", "   ", strings.Repeat("a", search.MaxQueryLength+1)} {
+		_, err := uc.Search(context.Background(), "user-1", query, 0)
+		assert.Equal(t, errors.ErrInvalidSearchQuery, err)
+	}
+	userRepo.AssertNotCalled(t, "SearchContactable", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	messageRepo.AssertNotCalled(t, "SearchForUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
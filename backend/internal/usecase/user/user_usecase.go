@@ -2,57 +2,197 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
 	"mime/multipart"
+	"strings"
 	"time"
 
 	"backend/internal/domain/entity"
 	"backend/internal/domain/errors"
+	"backend/internal/domain/event"
 	"backend/internal/domain/repository"
 	"backend/internal/infrastructure/cloudinary"
+	"backend/internal/infrastructure/email"
+	"backend/internal/infrastructure/logger"
+	"backend/pkg/utils"
+
+	"go.uber.org/zap"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// emailChangeTokenTTL is how long an email change confirmation link stays valid
+	emailChangeTokenTTL = 24 * time.Hour
+	// avatarDeleteTimeout bounds each attempt to delete an avatar from Cloudinary
+	avatarDeleteTimeout = 10 * time.Second
+	// avatarDeletionBatchSize is how many failed avatar deletions are retried per run
+	avatarDeletionBatchSize = 100
+)
+
 // UserUseCase defines the interface for user business logic
 type UserUseCase interface {
-	Register(ctx context.Context, email, password, name, phone string) (*entity.User, error)
+	Register(ctx context.Context, email, password, name, username, phone string) (*entity.User, error)
+	CreateAdmin(ctx context.Context, email, password, name, username string) (*entity.User, error)
 	GetByID(ctx context.Context, id string) (*entity.User, error)
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	Authenticate(ctx context.Context, email, password string) (*entity.User, error)
-	Update(ctx context.Context, id, name, phone string) (*entity.User, error)
+	Update(ctx context.Context, id, name, username, phone string) (*entity.User, error)
 	UpdateAvatar(ctx context.Context, userID string, file multipart.File) (*entity.User, error)
+	DeleteAvatar(ctx context.Context, userID string) (*entity.User, error)
+	RequestEmailChange(ctx context.Context, userID, newEmail, currentPassword string) error
+	ConfirmEmailChange(ctx context.Context, token string) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]*entity.User, error)
+	RequestDeletion(ctx context.Context, id string) error
+	Deactivate(ctx context.Context, id string) error
+	Reactivate(ctx context.Context, email, password string) (*entity.User, error)
+	PurgeDeletedAccounts(ctx context.Context) (int, error)
+	RetryAvatarDeletions(ctx context.Context) (int, error)
+	List(ctx context.Context, limit, offset int) ([]*entity.User, int64, error)
+	Search(ctx context.Context, query string, limit, offset int) ([]*entity.User, int64, error)
+}
+
+// EmailRateLimiter limits how many emails are sent to the same address within a window.
+// auth.NewEmailRateLimiter creates one; share it with the auth use case so every email counts.
+type EmailRateLimiter interface {
+	// Allow records a send to email and reports whether it is within the limit
+	Allow(email string) bool
+}
+
+// AttachmentCleaner finds and deletes the chat attachments a user sent; it is implemented by the message use case
+type AttachmentCleaner interface {
+	// SentAttachments returns the attachments of every message userID sent
+	SentAttachments(ctx context.Context, userID string) ([]*entity.Attachment, error)
+	// DeleteAttachmentAssets deletes the attachments from Cloudinary, recording failures for a retry
+	DeleteAttachmentAssets(ctx context.Context, attachments []*entity.Attachment)
 }
 
 type userUseCase struct {
-	userRepo       repository.UserRepository
-	avatarRepo     repository.AvatarRepository
-	cloudinaryServ cloudinary.Service
+	userRepo             repository.UserRepository
+	avatarRepo           repository.AvatarRepository
+	avatarDeletionRepo   repository.AvatarDeletionRepository
+	txManager            repository.TxManager
+	cloudinaryServ       cloudinary.Service
+	attachments          AttachmentCleaner
+	emailService         email.EmailService
+	emailLimiter         EmailRateLimiter
+	eventBus             event.EventBus
+	deletionGracePeriod  time.Duration
+	nameChangeCooldown   time.Duration
+	avatarChangeCooldown time.Duration
+	passwordPolicy       entity.PasswordPolicy
+	bcryptCost           int
 }
 
-// NewUserUseCase creates a new user use case
+// NewUserUseCase creates a new user use case.
+// Replaced and orphaned avatars that cannot be deleted from Cloudinary are recorded in avatarDeletionRepo
+// and retried by RetryAvatarDeletions. txManager keeps the avatar and user rows consistent.
+// The chat attachments of deleted accounts are removed from Cloudinary through attachments; nil keeps them.
+// emailService sends the confirmation link when a user changes their email address, limited per
+// address by emailLimiter; a nil limiter allows every send.
+// Registrations are published to eventBus; a nil bus discards them.
+// Accounts pending deletion can be reactivated by logging in until deletionGracePeriod has passed.
+// nameChangeCooldown and avatarChangeCooldown set the minimum time between changes for
+// non-admin users; zero disables the check. passwordPolicy is enforced on registration,
+// and passwords are hashed with bcryptCost.
 func NewUserUseCase(
 	userRepo repository.UserRepository,
 	avatarRepo repository.AvatarRepository,
+	avatarDeletionRepo repository.AvatarDeletionRepository,
+	txManager repository.TxManager,
 	cloudinaryServ cloudinary.Service,
+	attachments AttachmentCleaner,
+	emailService email.EmailService,
+	emailLimiter EmailRateLimiter,
+	eventBus event.EventBus,
+	deletionGracePeriod time.Duration,
+	nameChangeCooldown, avatarChangeCooldown time.Duration,
+	passwordPolicy entity.PasswordPolicy,
+	bcryptCost int,
 ) UserUseCase {
+	if eventBus == nil {
+		eventBus = event.NopBus{}
+	}
 	return &userUseCase{
-		userRepo:       userRepo,
-		avatarRepo:     avatarRepo,
-		cloudinaryServ: cloudinaryServ,
+		userRepo:             userRepo,
+		avatarRepo:           avatarRepo,
+		avatarDeletionRepo:   avatarDeletionRepo,
+		txManager:            txManager,
+		cloudinaryServ:       cloudinaryServ,
+		attachments:          attachments,
+		emailService:         emailService,
+		emailLimiter:         emailLimiter,
+		eventBus:             eventBus,
+		deletionGracePeriod:  deletionGracePeriod,
+		nameChangeCooldown:   nameChangeCooldown,
+		avatarChangeCooldown: avatarChangeCooldown,
+		passwordPolicy:       passwordPolicy,
+		bcryptCost:           bcryptCost,
+	}
+}
+
+func (uc *userUseCase) Register(ctx context.Context, email, password, name, username, phone string) (*entity.User, error) {
+	user, err := uc.newLocalUser(ctx, email, password, name, username, phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.createUser(ctx, user); err != nil {
+		return nil, err
 	}
+
+	return user, nil
+}
+
+// CreateAdmin creates an already verified user with the admin role. It is
+// meant for bootstrapping the first administrator from the command line and
+// applies the same duplicate, password policy and username checks as Register.
+func (uc *userUseCase) CreateAdmin(ctx context.Context, email, password, name, username string) (*entity.User, error) {
+	user, err := uc.newLocalUser(ctx, email, password, name, username, "")
+	if err != nil {
+		return nil, err
+	}
+	user.Role = entity.RoleAdmin
+	user.EmailVerified = true
+
+	if err := uc.createUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
-func (uc *userUseCase) Register(ctx context.Context, email, password, name, phone string) (*entity.User, error) {
+// newLocalUser validates the registration input and builds a password based
+// user with the configured bcrypt cost. The user is not persisted.
+func (uc *userUseCase) newLocalUser(ctx context.Context, email, password, name, username, phone string) (*entity.User, error) {
 	// Check if user already exists
-	existingUser, err := uc.userRepo.GetByEmail(ctx, email)
+	existingUser, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 	if err == nil && existingUser != nil {
 		return nil, errors.ErrUserExists
 	}
 
+	if err := entity.ValidatePasswordStrength(password, uc.passwordPolicy); err != nil {
+		return nil, err
+	}
+
+	username, err = utils.NormalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.EnsureUsernameAvailable(ctx, uc.userRepo, username, ""); err != nil {
+		return nil, err
+	}
+
+	phone, err = utils.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), uc.bcryptCost)
 	if err != nil {
 		return nil, &errors.DomainError{
 			Code:    "PASSWORD_HASH_FAILED",
@@ -62,14 +202,23 @@ func (uc *userUseCase) Register(ctx context.Context, email, password, name, phon
 	}
 
 	// Create user entity
-	user := entity.NewUser(email, string(hashedPassword), name, phone)
+	return entity.NewUser(email, string(hashedPassword), name, username, phone), nil
+}
 
+// createUser persists a new user and announces the registration
+func (uc *userUseCase) createUser(ctx context.Context, user *entity.User) error {
 	// Save to repository
 	if err := uc.userRepo.Create(ctx, user); err != nil {
-		return nil, err
+		return err
 	}
 
-	return user, nil
+	uc.eventBus.Publish(ctx, event.UserRegisteredEvent{
+		UserID:     user.ID,
+		Email:      user.Email,
+		OccurredAt: user.CreatedAt,
+	})
+
+	return nil
 }
 
 func (uc *userUseCase) GetByID(ctx context.Context, id string) (*entity.User, error) {
@@ -77,11 +226,11 @@ func (uc *userUseCase) GetByID(ctx context.Context, id string) (*entity.User, er
 }
 
 func (uc *userUseCase) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
-	return uc.userRepo.GetByEmail(ctx, email)
+	return uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 }
 
 func (uc *userUseCase) Authenticate(ctx context.Context, email, password string) (*entity.User, error) {
-	user, err := uc.userRepo.GetByEmail(ctx, email)
+	user, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
 	if err != nil {
 		return nil, errors.ErrInvalidCredentials
 	}
@@ -91,16 +240,56 @@ func (uc *userUseCase) Authenticate(ctx context.Context, email, password string)
 		return nil, errors.ErrInvalidCredentials
 	}
 
+	if user.IsDeactivated() {
+		return nil, errors.ErrAccountDeactivated
+	}
+
+	// Logging back in within the grace period cancels a pending deletion
+	if user.IsPendingDeletion() {
+		if user.DeletionDue(uc.deletionGracePeriod) {
+			return nil, errors.ErrInvalidCredentials
+		}
+		user.CancelDeletion()
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
 	return user, nil
 }
 
-func (uc *userUseCase) Update(ctx context.Context, id, name, phone string) (*entity.User, error) {
+func (uc *userUseCase) Update(ctx context.Context, id, name, username, phone string) (*entity.User, error) {
+	phone, err := utils.NormalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		if username, err = utils.NormalizeUsername(username); err != nil {
+			return nil, err
+		}
+	}
+
 	user, err := uc.userRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	user.Name = name
+	// An empty username keeps the current one
+	if username != "" && username != user.Username {
+		if err := utils.EnsureUsernameAvailable(ctx, uc.userRepo, username, user.ID); err != nil {
+			return nil, err
+		}
+		user.Username = username
+	}
+
+	if name != user.Name {
+		if err := uc.checkCooldown(user, "name", user.NameChangedAt, uc.nameChangeCooldown); err != nil {
+			return nil, err
+		}
+		user.Name = name
+		user.NameChangedAt = time.Now()
+	}
+
 	user.Phone = phone
 	user.UpdatedAt = time.Now()
 
@@ -119,11 +308,23 @@ func (uc *userUseCase) UpdateAvatar(ctx context.Context, userID string, file mul
 		return nil, err
 	}
 
+	if err := uc.checkCooldown(user, "avatar", user.AvatarChangedAt, uc.avatarChangeCooldown); err != nil {
+		return nil, err
+	}
+
 	// Get existing avatar (if any)
 	existingAvatar, _ := uc.avatarRepo.GetByUserID(ctx, userID)
+	// Avatars uploaded before public IDs were unique were overwritten in place, so the old row
+	// may already point at the new upload: that asset must survive both the rollback and the cleanup
+	replacesInPlace := func(publicID string) bool {
+		return existingAvatar != nil && existingAvatar.PublicID == publicID
+	}
 
 	// Upload new avatar to Cloudinary
 	uploadResult, err := uc.cloudinaryServ.UploadAvatar(ctx, file, userID)
+	if err == errors.ErrAvatarTooLarge || stderrors.Is(err, errors.ErrUpstreamTimeout) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, &errors.DomainError{
 			Code:    "AVATAR_UPLOAD_FAILED",
@@ -135,35 +336,187 @@ func (uc *userUseCase) UpdateAvatar(ctx context.Context, userID string, file mul
 	// Create new avatar entity
 	newAvatar := entity.NewAvatar(userID, uploadResult.PublicID, uploadResult.PublicURL, uploadResult.SecureURL)
 
-	// Save or update avatar in database
-	if existingAvatar != nil {
-		// Update existing avatar
-		newAvatar.ID = existingAvatar.ID
-		if err := uc.avatarRepo.Update(ctx, newAvatar); err != nil {
-			return nil, err
-		}
+	// Update user's avatar reference
+	user.Avatar = newAvatar
+	user.AvatarChangedAt = time.Now()
+	user.UpdatedAt = time.Now()
 
-		// Delete old avatar from Cloudinary (if it has a public_id)
-		if existingAvatar.PublicID != "" {
-			// Delete in background, don't fail if deletion fails
-			go func() {
-				_ = uc.cloudinaryServ.DeleteAvatar(context.Background(), existingAvatar.PublicID)
-			}()
+	// Save the avatar and the user together, so a failure can't leave one pointing at the other's old state
+	err = uc.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if existingAvatar != nil {
+			newAvatar.ID = existingAvatar.ID
+			if err := uc.avatarRepo.Update(ctx, newAvatar); err != nil {
+				return err
+			}
+		} else if err := uc.avatarRepo.Create(ctx, newAvatar); err != nil {
+			return err
 		}
-	} else {
-		// Create new avatar
-		if err := uc.avatarRepo.Create(ctx, newAvatar); err != nil {
-			return nil, err
+		return uc.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		// Nothing references the upload after the rollback, unless it replaced the old asset in place
+		if !replacesInPlace(uploadResult.PublicID) {
+			uc.deleteCloudinaryAvatar(ctx, uploadResult.PublicID)
 		}
+		return nil, err
 	}
 
-	// Update user's avatar reference
-	user.Avatar = newAvatar
+	// Delete old avatar from Cloudinary (if it has a public_id) once nothing references it
+	if existingAvatar != nil && existingAvatar.PublicID != "" && !replacesInPlace(uploadResult.PublicID) {
+		uc.deleteCloudinaryAvatar(ctx, existingAvatar.PublicID)
+	}
+
+	return user, nil
+}
+
+// DeleteAvatar removes the user's avatar from Cloudinary and the database.
+// Users without an avatar are returned unchanged.
+func (uc *userUseCase) DeleteAvatar(ctx context.Context, userID string) (*entity.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Avatar == nil {
+		return user, nil
+	}
+
+	// Delete the Cloudinary asset first so a failure leaves the avatar in place to retry.
+	// External avatars (e.g. from OAuth) have no public_id.
+	if user.Avatar.PublicID != "" {
+		if err := uc.cloudinaryServ.DeleteAvatar(ctx, user.Avatar.PublicID); err != nil {
+			return nil, &errors.DomainError{
+				Code:    "AVATAR_DELETE_FAILED",
+				Message: "failed to delete avatar",
+				Err:     err,
+			}
+		}
+	}
+
+	user.Avatar = nil
 	user.UpdatedAt = time.Now()
 
+	err = uc.txManager.WithTx(ctx, func(ctx context.Context) error {
+		if err := uc.avatarRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		return uc.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
 
+// RequestEmailChange stores newEmail as the user's pending email and mails a confirmation link to it.
+// The account keeps its current email until the link is followed, see ConfirmEmailChange.
+// Accounts with a password must confirm it with currentPassword, so a stolen session can't take over the account.
+func (uc *userUseCase) RequestEmailChange(ctx context.Context, userID, newEmail, currentPassword string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+			return errors.ErrInvalidCredentials
+		}
+	}
+
+	newEmail = entity.NormalizeEmail(newEmail)
+	if err := uc.checkEmailAvailable(ctx, newEmail); err != nil {
+		return err
+	}
+
+	if uc.emailLimiter != nil && !uc.emailLimiter.Allow(newEmail) {
+		return errors.ErrTooManyRequests
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate email change token: %w", err)
+	}
+
+	// A new request replaces any pending one
+	user.PendingEmail = newEmail
+	user.EmailChangeToken = token
+	user.EmailChangeTokenExpiresAt = time.Now().Add(emailChangeTokenTTL)
+	user.UpdatedAt = time.Now()
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err := uc.emailService.SendEmailChangeConfirmation(ctx, newEmail, user.Name, token); err != nil {
+		return fmt.Errorf("failed to send email change confirmation: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange swaps the user's email for the pending one the token was issued for
+func (uc *userUseCase) ConfirmEmailChange(ctx context.Context, token string) error {
+	user, err := uc.userRepo.GetByEmailChangeToken(ctx, token)
+	if err != nil {
+		return errors.ErrInvalidEmailChangeToken
+	}
+
+	if time.Now().After(user.EmailChangeTokenExpiresAt) {
+		return errors.ErrEmailChangeTokenExpired
+	}
+
+	// Another account may have taken the address since the change was requested
+	if err := uc.checkEmailAvailable(ctx, user.PendingEmail); err != nil {
+		return err
+	}
+
+	// Following the link sent to the new address verifies it,
+	// so a verification still pending for the old address no longer applies
+	user.Email = user.PendingEmail
+	user.EmailVerified = true
+	user.VerificationToken = ""
+	user.VerificationTokenExpiresAt = time.Time{}
+	user.PendingEmail = ""
+	user.EmailChangeToken = ""
+	user.EmailChangeTokenExpiresAt = time.Time{}
+	user.UpdatedAt = time.Now()
+
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+// checkEmailAvailable rejects an email that already belongs to an account, including the caller's own
+func (uc *userUseCase) checkEmailAvailable(ctx context.Context, address string) error {
+	existingUser, err := uc.userRepo.GetByEmail(ctx, address)
+	if err == nil && existingUser != nil {
+		return errors.ErrEmailAlreadyInUse
+	}
+	if err != nil && err != errors.ErrUserNotFound {
+		return err
+	}
+	return nil
+}
+
+// checkCooldown rejects a change made less than cooldown after the previous one. Admins are exempt.
+func (uc *userUseCase) checkCooldown(user *entity.User, field string, lastChangedAt time.Time, cooldown time.Duration) error {
+	if cooldown <= 0 || lastChangedAt.IsZero() || user.IsAdmin() {
+		return nil
+	}
+
+	remaining := time.Until(lastChangedAt.Add(cooldown))
+	if remaining <= 0 {
+		return nil
+	}
+
+	return errors.ErrProfileUpdateCooldown.WithMessage(
+		fmt.Sprintf("%s was changed recently, please try again in %s", field, remaining.Round(time.Second)),
+	)
+}
+
 func (uc *userUseCase) Delete(ctx context.Context, id string) error {
 	// Get user to check if they have an avatar
 	user, err := uc.userRepo.GetByID(ctx, id)
@@ -171,19 +524,224 @@ func (uc *userUseCase) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	// Delete avatar from Cloudinary if exists
-	if user.Avatar != nil && user.Avatar.PublicID != "" {
-		// Delete in background, don't fail if deletion fails
-		go func() {
-			_ = uc.cloudinaryServ.DeleteAvatar(context.Background(), user.Avatar.PublicID)
-		}()
+	return uc.deleteUser(ctx, user)
+}
+
+// RequestDeletion soft-deletes the account; it is purged once the grace period has passed
+func (uc *userUseCase) RequestDeletion(ctx context.Context, id string) error {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if user.IsPendingDeletion() {
+		return nil // Keep the original request time
+	}
+
+	user.RequestDeletion()
+	return uc.userRepo.Update(ctx, user)
+}
+
+// Deactivate disables the account until the user reactivates it with their password.
+// Unlike RequestDeletion, the account and its data are kept indefinitely.
+func (uc *userUseCase) Deactivate(ctx context.Context, id string) error {
+	user, err := uc.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if user.IsDeactivated() {
+		return nil // Keep the original deactivation time
+	}
+
+	user.Deactivate()
+	return uc.userRepo.Update(ctx, user)
+}
+
+// Reactivate re-enables a deactivated account after checking the user's credentials
+// and returns the user, so the caller can sign them in. It is not a second way to log in:
+// active accounts are rejected, and the email verification and deletion grace period
+// checks of a normal login apply.
+func (uc *userUseCase) Reactivate(ctx context.Context, email, password string) (*entity.User, error) {
+	user, err := uc.userRepo.GetByEmail(ctx, entity.NormalizeEmail(email))
+	if err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	if !user.IsDeactivated() {
+		return nil, errors.ErrInvalidCredentials
+	}
+	if !user.EmailVerified {
+		return nil, errors.ErrEmailNotVerified
+	}
+	if user.DeletionDue(uc.deletionGracePeriod) {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	user.Reactivate()
+	// Coming back within the grace period also cancels a pending deletion
+	if user.IsPendingDeletion() {
+		user.CancelDeletion()
+	}
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// PurgeDeletedAccounts hard-deletes accounts whose deletion grace period has passed
+// and returns how many were removed
+func (uc *userUseCase) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	requestedBefore := time.Now().Add(-uc.deletionGracePeriod)
+	users, err := uc.userRepo.ListPendingDeletion(ctx, requestedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, user := range users {
+		// The attachment rows go with the user's messages, so collect them first
+		attachments, err := uc.sentAttachments(ctx, user.ID)
+		if err != nil {
+			return purged, err
+		}
+		// The listed users are a snapshot: one who logged back in since is no longer pending,
+		// so the delete is conditional and skips them
+		deleted, err := uc.userRepo.DeletePendingDeletion(ctx, user.ID, requestedBefore)
+		if err != nil {
+			return purged, err
+		}
+		if !deleted {
+			continue
+		}
+		if user.Avatar != nil && user.Avatar.PublicID != "" {
+			uc.deleteCloudinaryAvatar(ctx, user.Avatar.PublicID)
+		}
+		uc.deleteAttachments(ctx, attachments)
+		purged++
+	}
+
+	return purged, nil
+}
+
+// deleteUser removes the user and their external resources
+func (uc *userUseCase) deleteUser(ctx context.Context, user *entity.User) error {
+	attachments, err := uc.sentAttachments(ctx, user.ID)
+	if err != nil {
+		return err
 	}
 
 	// Delete avatar from database (cascade will handle this via foreign key)
 	// Delete user from database
-	return uc.userRepo.Delete(ctx, id)
+	if err := uc.userRepo.Delete(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Delete avatar from Cloudinary if exists
+	if user.Avatar != nil && user.Avatar.PublicID != "" {
+		uc.deleteCloudinaryAvatar(ctx, user.Avatar.PublicID)
+	}
+	uc.deleteAttachments(ctx, attachments)
+
+	return nil
+}
+
+// sentAttachments returns the attachments of the user's messages, none when attachments are not cleaned up
+func (uc *userUseCase) sentAttachments(ctx context.Context, userID string) ([]*entity.Attachment, error) {
+	if uc.attachments == nil {
+		return nil, nil
+	}
+	return uc.attachments.SentAttachments(ctx, userID)
 }
 
-func (uc *userUseCase) List(ctx context.Context, limit, offset int) ([]*entity.User, error) {
-	return uc.userRepo.List(ctx, limit, offset)
+// deleteAttachments deletes the attachments of a deleted user's messages from Cloudinary
+func (uc *userUseCase) deleteAttachments(ctx context.Context, attachments []*entity.Attachment) {
+	if uc.attachments == nil || len(attachments) == 0 {
+		return
+	}
+	uc.attachments.DeleteAttachmentAssets(ctx, attachments)
+}
+
+// deleteCloudinaryAvatar deletes an avatar that is no longer referenced. A failed deletion doesn't fail
+// the caller; it is recorded so RetryAvatarDeletions can remove the asset later.
+func (uc *userUseCase) deleteCloudinaryAvatar(ctx context.Context, publicID string) {
+	err := uc.tryDeleteCloudinaryAvatar(ctx, publicID)
+	if err == nil {
+		return
+	}
+
+	logger.ErrorContext(ctx, "Failed to delete avatar from Cloudinary", err, zap.String("public_id", publicID))
+	// Record it even if the request was canceled meanwhile
+	if err := uc.avatarDeletionRepo.Add(context.WithoutCancel(ctx), publicID); err != nil {
+		logger.ErrorContext(ctx, "Failed to record avatar for deletion", err, zap.String("public_id", publicID))
+	}
+}
+
+// tryDeleteCloudinaryAvatar makes a single deletion attempt bounded by avatarDeleteTimeout
+func (uc *userUseCase) tryDeleteCloudinaryAvatar(ctx context.Context, publicID string) error {
+	ctx, cancel := context.WithTimeout(ctx, avatarDeleteTimeout)
+	defer cancel()
+	return uc.cloudinaryServ.DeleteAvatar(ctx, publicID)
+}
+
+// RetryAvatarDeletions retries deleting the avatars whose Cloudinary deletion failed earlier
+// and returns how many were deleted. Avatars that still fail stay recorded for the next run.
+func (uc *userUseCase) RetryAvatarDeletions(ctx context.Context) (int, error) {
+	publicIDs, err := uc.avatarDeletionRepo.List(ctx, avatarDeletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, publicID := range publicIDs {
+		if err := uc.tryDeleteCloudinaryAvatar(ctx, publicID); err != nil {
+			logger.Warn("Avatar deletion retry failed", zap.String("public_id", publicID), zap.Error(err))
+			continue
+		}
+		if err := uc.avatarDeletionRepo.Remove(ctx, publicID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// List returns a page of users along with the total number of users
+func (uc *userUseCase) List(ctx context.Context, limit, offset int) ([]*entity.User, int64, error) {
+	users, err := uc.userRepo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := uc.userRepo.Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// Search returns a page of users whose name or email contains the query,
+// falling back to the plain list when the query is empty
+func (uc *userUseCase) Search(ctx context.Context, query string, limit, offset int) ([]*entity.User, int64, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return uc.List(ctx, limit, offset)
+	}
+	return uc.userRepo.Search(ctx, query, limit, offset)
+}
+
+// generateToken generates a random token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
 }
@@ -2,15 +2,23 @@ package user_test
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
 	"testing"
 	"time"
 
 	"backend/internal/domain/entity"
 	"backend/internal/domain/errors"
+	"backend/internal/infrastructure/cloudinary"
+	"backend/internal/infrastructure/logger"
 	"backend/internal/usecase/user"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // MockUserRepository is a mock implementation of UserRepository
@@ -39,6 +47,54 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*ent
 	return args.Get(0).(*entity.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByUsernames(ctx context.Context, usernames []string) ([]*entity.User, error) {
+	args := m.Called(ctx, usernames)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByOAuthID(ctx context.Context, provider, oauthID string) (*entity.User, error) {
+	args := m.Called(ctx, provider, oauthID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByVerificationToken(ctx context.Context, token string) (*entity.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByResetPasswordToken(ctx context.Context, token string) (*entity.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmailChangeToken(ctx context.Context, token string) (*entity.User, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Update(ctx context.Context, user *entity.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
@@ -57,26 +113,337 @@ func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*en
 	return args.Get(0).([]*entity.User), args.Error(1)
 }
 
+func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) Search(ctx context.Context, query string, limit, offset int) ([]*entity.User, int64, error) {
+	args := m.Called(ctx, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*entity.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) SearchContactable(ctx context.Context, query, excludeID string, limit int) ([]*entity.User, error) {
+	args := m.Called(ctx, query, excludeID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListPendingDeletion(ctx context.Context, requestedBefore time.Time) ([]*entity.User, error) {
+	args := m.Called(ctx, requestedBefore)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) DeletePendingDeletion(ctx context.Context, id string, requestedBefore time.Time) (bool, error) {
+	args := m.Called(ctx, id, requestedBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockAvatarRepository is a mock implementation of AvatarRepository
+type MockAvatarRepository struct {
+	mock.Mock
+}
+
+func (m *MockAvatarRepository) Create(ctx context.Context, avatar *entity.Avatar) error {
+	args := m.Called(ctx, avatar)
+	return args.Error(0)
+}
+
+func (m *MockAvatarRepository) GetByUserID(ctx context.Context, userID string) (*entity.Avatar, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Avatar), args.Error(1)
+}
+
+func (m *MockAvatarRepository) GetByUserIDs(ctx context.Context, userIDs []string) (map[string]*entity.Avatar, error) {
+	args := m.Called(ctx, userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*entity.Avatar), args.Error(1)
+}
+
+func (m *MockAvatarRepository) Update(ctx context.Context, avatar *entity.Avatar) error {
+	args := m.Called(ctx, avatar)
+	return args.Error(0)
+}
+
+func (m *MockAvatarRepository) Delete(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockAvatarDeletionRepository is a mock implementation of AvatarDeletionRepository
+type MockAvatarDeletionRepository struct {
+	mock.Mock
+}
+
+func (m *MockAvatarDeletionRepository) Add(ctx context.Context, publicID string) error {
+	args := m.Called(ctx, publicID)
+	return args.Error(0)
+}
+
+func (m *MockAvatarDeletionRepository) List(ctx context.Context, limit int) ([]string, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAvatarDeletionRepository) Remove(ctx context.Context, publicID string) error {
+	args := m.Called(ctx, publicID)
+	return args.Error(0)
+}
+
+// passthroughTxManager runs fn directly; transactional behavior is covered by the repository tests
+type passthroughTxManager struct{}
+
+func (passthroughTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// MockCloudinaryService is a mock implementation of cloudinary.Service
+type MockCloudinaryService struct {
+	mock.Mock
+}
+
+func (m *MockCloudinaryService) UploadAvatar(ctx context.Context, file multipart.File, userID string) (*cloudinary.UploadResult, error) {
+	args := m.Called(ctx, file, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudinary.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinaryService) DeleteAvatar(ctx context.Context, publicID string) error {
+	args := m.Called(ctx, publicID)
+	return args.Error(0)
+}
+
+func (m *MockCloudinaryService) ThumbnailURLs(publicID string) map[int]string {
+	args := m.Called(publicID)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(map[int]string)
+}
+
+func (m *MockCloudinaryService) UploadAttachment(ctx context.Context, file io.Reader, conversationID string) (*cloudinary.UploadResult, error) {
+	args := m.Called(ctx, file, conversationID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudinary.UploadResult), args.Error(1)
+}
+
+func (m *MockCloudinaryService) DeleteAttachment(ctx context.Context, publicID, resourceType string) error {
+	args := m.Called(ctx, publicID, resourceType)
+	return args.Error(0)
+}
+
+// MockEmailService is a mock implementation of email.EmailService
+type MockEmailService struct {
+	mock.Mock
+}
+
+func (m *MockEmailService) SendVerificationEmail(ctx context.Context, to, name, token string) error {
+	args := m.Called(ctx, to, name, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendPasswordResetEmail(ctx context.Context, to, name, token string) error {
+	args := m.Called(ctx, to, name, token)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendWelcomeEmail(ctx context.Context, to, name string) error {
+	args := m.Called(ctx, to, name)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendEmailChangeConfirmation(ctx context.Context, to, name, token string) error {
+	args := m.Called(ctx, to, name, token)
+	return args.Error(0)
+}
+
+const gracePeriod = 30 * 24 * time.Hour
+
+var passwordPolicy = entity.PasswordPolicy{MinLength: 8}
+
+// hashPassword returns a bcrypt hash of "password123"
+func hashPassword(t *testing.T) string {
+	t.Helper()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	return string(hashed)
+}
+
+func TestRegister_WeakPassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, entity.PasswordPolicy{MinLength: 10, RequireSymbol: true}, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+
+	result, err := uc.Register(context.Background(), "test@example.com", "12345678", "Test User", "test_user", "+15551234567")
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, errors.ErrWeakPassword)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestRegister_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := user.NewUserUseCase(mockRepo)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
 
 	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "test_user").Return(nil, errors.ErrUserNotFound)
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
 
-	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "1234567890")
+	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "test_user", "+15551234567")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, "test@example.com", result.Email)
 	assert.Equal(t, "Test User", result.Name)
 	assert.NotEmpty(t, result.ID)
+	cost, err := bcrypt.Cost([]byte(result.Password))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, cost)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_NormalizesPhone(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "test_user").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "test_user", "+1 (555) 123-4567")
+
+	require.NoError(t, err)
+	assert.Equal(t, "+15551234567", result.Phone)
+}
+
+func TestRegister_InvalidPhone(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "test_user").Return(nil, errors.ErrUserNotFound)
+
+	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "test_user", "555-0100")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrInvalidPhoneNumber, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRegister_PhoneOptionalAndUsernameNormalized(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "test_user").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", " Test_User ", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "test_user", result.Username)
+	assert.Empty(t, result.Phone)
+}
+
+func TestRegister_UsernameTaken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "test_user").Return(&entity.User{ID: "456", Username: "test_user"}, nil)
+
+	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "test_user", "")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUsernameTaken, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRegister_InvalidUsername(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
+
+	for _, username := range []string{"", "ab", "no spaces", "dash-ed", strings.Repeat("a", entity.UsernameMaxLength+1)} {
+		_, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", username, "")
+		assert.Equal(t, errors.ErrInvalidUsername, err, username)
+	}
+	mockRepo.AssertNotCalled(t, "GetByUsername", mock.Anything, mock.Anything)
+}
+
+func TestCreateAdmin_Success(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "admin@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("GetByUsername", mock.Anything, "admin").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *entity.User) bool {
+		return u.Role == entity.RoleAdmin && u.EmailVerified
+	})).Return(nil)
+
+	result, err := uc.CreateAdmin(context.Background(), "Admin@Example.com", "password123", "Administrator", "admin")
+
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", result.Email)
+	assert.True(t, result.IsAdmin())
+	assert.True(t, result.EmailVerified)
+	cost, err := bcrypt.Cost([]byte(result.Password))
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost, cost)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateAdmin_RefusesDuplicate(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "admin@example.com").Return(&entity.User{ID: "123", Email: "admin@example.com"}, nil)
+
+	result, err := uc.CreateAdmin(context.Background(), "admin@example.com", "password123", "Administrator", "admin")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrUserExists, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateAdmin_WeakPassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmail", mock.Anything, "admin@example.com").Return(nil, errors.ErrUserNotFound)
+
+	result, err := uc.CreateAdmin(context.Background(), "admin@example.com", "short", "Administrator", "admin")
+
+	assert.Nil(t, result)
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 func TestRegister_UserExists(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := user.NewUserUseCase(mockRepo)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
 
 	existingUser := &entity.User{
 		ID:        "123",
@@ -86,7 +453,7 @@ func TestRegister_UserExists(t *testing.T) {
 	}
 	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
 
-	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "1234567890")
+	result, err := uc.Register(context.Background(), "test@example.com", "password123", "Test User", "test_user", "+15551234567")
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -96,14 +463,12 @@ func TestRegister_UserExists(t *testing.T) {
 
 func TestAuthenticate_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := user.NewUserUseCase(mockRepo)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
 
-	// Pre-hashed password for "password123"
-	hashedPassword := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
 	existingUser := &entity.User{
 		ID:       "123",
 		Email:    "test@example.com",
-		Password: hashedPassword,
+		Password: hashPassword(t),
 		Name:     "Test User",
 	}
 
@@ -119,7 +484,7 @@ func TestAuthenticate_Success(t *testing.T) {
 
 func TestAuthenticate_InvalidCredentials(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := user.NewUserUseCase(mockRepo)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
 
 	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.ErrUserNotFound)
 
@@ -133,7 +498,7 @@ func TestAuthenticate_InvalidCredentials(t *testing.T) {
 
 func TestGetByID_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := user.NewUserUseCase(mockRepo)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
 
 	expectedUser := &entity.User{
 		ID:    "123",
@@ -154,7 +519,7 @@ func TestGetByID_Success(t *testing.T) {
 
 func TestGetByID_NotFound(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	uc := user.NewUserUseCase(mockRepo)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
 
 	mockRepo.On("GetByID", mock.Anything, "999").Return(nil, errors.ErrUserNotFound)
 
@@ -165,3 +530,873 @@ func TestGetByID_NotFound(t *testing.T) {
 	assert.Equal(t, errors.ErrUserNotFound, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestList_ReturnsPageAndTotal(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	page := []*entity.User{
+		{ID: "user-1", Email: "a@example.com"},
+		{ID: "user-2", Email: "b@example.com"},
+	}
+	mockRepo.On("List", mock.Anything, 2, 0).Return(page, nil)
+	mockRepo.On("Count", mock.Anything).Return(int64(5), nil)
+
+	users, total, err := uc.List(context.Background(), 2, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, int64(5), total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearch_UsesRepositorySearch(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	matches := []*entity.User{{ID: "user-1", Name: "Alice", Email: "alice@example.com"}}
+	mockRepo.On("Search", mock.Anything, "ali", 10, 0).Return(matches, int64(1), nil)
+
+	users, total, err := uc.Search(context.Background(), "  ali ", 10, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, matches, users)
+	assert.Equal(t, int64(1), total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearch_EmptyQueryFallsBackToList(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	page := []*entity.User{{ID: "user-1"}, {ID: "user-2"}}
+	mockRepo.On("List", mock.Anything, 10, 0).Return(page, nil)
+	mockRepo.On("Count", mock.Anything).Return(int64(2), nil)
+
+	users, total, err := uc.Search(context.Background(), "   ", 10, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, int64(2), total)
+	mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticate_ReactivatesWithinGracePeriod(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:                  "123",
+		Email:               "test@example.com",
+		Password:            hashPassword(t),
+		DeletionRequestedAt: time.Now().Add(-10 * 24 * time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	result, err := uc.Authenticate(context.Background(), "test@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsPendingDeletion())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticate_PastGracePeriodRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:                  "123",
+		Email:               "test@example.com",
+		Password:            hashPassword(t),
+		DeletionRequestedAt: time.Now().Add(-31 * 24 * time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
+
+	result, err := uc.Authenticate(context.Background(), "test@example.com", "password123")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrInvalidCredentials, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRequestDeletion_MarksPending(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	err := uc.RequestDeletion(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.True(t, existingUser.IsPendingDeletion())
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestDeactivate_MarksDeactivated(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	err := uc.Deactivate(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.True(t, existingUser.IsDeactivated())
+	assert.False(t, existingUser.IsPendingDeletion())
+}
+
+func TestAuthenticate_DeactivatedRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:            "123",
+		Email:         "test@example.com",
+		Password:      hashPassword(t),
+		DeactivatedAt: time.Now().Add(-time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
+
+	result, err := uc.Authenticate(context.Background(), "test@example.com", "password123")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrAccountDeactivated, err)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestReactivate_ClearsDeactivation(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:            "123",
+		Email:         "test@example.com",
+		Password:      hashPassword(t),
+		EmailVerified: true,
+		DeactivatedAt: time.Now().Add(-time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	result, err := uc.Reactivate(context.Background(), "Test@Example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsDeactivated())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReactivate_WrongPasswordRejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:            "123",
+		Email:         "test@example.com",
+		Password:      hashPassword(t),
+		DeactivatedAt: time.Now().Add(-time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
+
+	result, err := uc.Reactivate(context.Background(), "test@example.com", "wrong-password")
+
+	assert.Nil(t, result)
+	assert.Equal(t, errors.ErrInvalidCredentials, err)
+	assert.True(t, existingUser.IsDeactivated())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestReactivate_CancelsPendingDeletionWithinGracePeriod(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:                  "123",
+		Email:               "test@example.com",
+		Password:            hashPassword(t),
+		EmailVerified:       true,
+		DeactivatedAt:       time.Now().Add(-48 * time.Hour),
+		DeletionRequestedAt: time.Now().Add(-24 * time.Hour),
+	}
+	mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	result, err := uc.Reactivate(context.Background(), "test@example.com", "password123")
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsDeactivated())
+	assert.False(t, result.IsPendingDeletion())
+}
+
+func TestReactivate_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *entity.User
+		wantErr error
+	}{
+		{"active account", &entity.User{EmailVerified: true}, errors.ErrInvalidCredentials},
+		{"unverified email", &entity.User{DeactivatedAt: time.Now().Add(-time.Hour)}, errors.ErrEmailNotVerified},
+		{"past deletion grace period", &entity.User{
+			EmailVerified:       true,
+			DeactivatedAt:       time.Now().Add(-40 * 24 * time.Hour),
+			DeletionRequestedAt: time.Now().Add(-31 * 24 * time.Hour),
+		}, errors.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+			tt.user.ID = "123"
+			tt.user.Email = "test@example.com"
+			tt.user.Password = hashPassword(t)
+			mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(tt.user, nil)
+
+			result, err := uc.Reactivate(context.Background(), "test@example.com", "password123")
+
+			assert.Nil(t, result)
+			assert.Equal(t, tt.wantErr, err)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPurgeDeletedAccounts_SkipsUsersReactivatedSinceTheQuery(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	// Both were past the grace period when listed; "reactivated" logged back in before its delete ran
+	requestedAt := time.Now().Add(-31 * 24 * time.Hour)
+	expired := &entity.User{ID: "expired", DeletionRequestedAt: requestedAt, Avatar: &entity.Avatar{PublicID: "expired_avatar"}}
+	reactivated := &entity.User{ID: "reactivated", DeletionRequestedAt: requestedAt, Avatar: &entity.Avatar{PublicID: "reactivated_avatar"}}
+
+	cutoffIsGraceAgo := mock.MatchedBy(func(before time.Time) bool {
+		return before.Sub(time.Now().Add(-gracePeriod)).Abs() < time.Minute
+	})
+	mockRepo.On("ListPendingDeletion", mock.Anything, cutoffIsGraceAgo).Return([]*entity.User{expired, reactivated}, nil)
+	mockRepo.On("DeletePendingDeletion", mock.Anything, "expired", cutoffIsGraceAgo).Return(true, nil)
+	mockRepo.On("DeletePendingDeletion", mock.Anything, "reactivated", cutoffIsGraceAgo).Return(false, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "expired_avatar").Return(nil)
+
+	purged, err := uc.PurgeDeletedAccounts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, "reactivated_avatar")
+}
+
+// fakeAttachmentCleaner returns fixed attachments per sender and records the ones deleted
+type fakeAttachmentCleaner struct {
+	sent    map[string][]*entity.Attachment
+	deleted []*entity.Attachment
+}
+
+func (c *fakeAttachmentCleaner) SentAttachments(ctx context.Context, userID string) ([]*entity.Attachment, error) {
+	return c.sent[userID], nil
+}
+
+func (c *fakeAttachmentCleaner) DeleteAttachmentAssets(ctx context.Context, attachments []*entity.Attachment) {
+	c.deleted = append(c.deleted, attachments...)
+}
+
+func TestPurgeDeletedAccounts_DeletesSentAttachments(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	attachments := &fakeAttachmentCleaner{sent: map[string][]*entity.Attachment{
+		"expired":     {{PublicID: "chat/conv-1/expired", ResourceType: "image"}},
+		"reactivated": {{PublicID: "chat/conv-1/reactivated", ResourceType: "image"}},
+	}}
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, attachments, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	requestedAt := time.Now().Add(-31 * 24 * time.Hour)
+	expired := &entity.User{ID: "expired", DeletionRequestedAt: requestedAt}
+	reactivated := &entity.User{ID: "reactivated", DeletionRequestedAt: requestedAt}
+	mockRepo.On("ListPendingDeletion", mock.Anything, mock.Anything).Return([]*entity.User{expired, reactivated}, nil)
+	mockRepo.On("DeletePendingDeletion", mock.Anything, "expired", mock.Anything).Return(true, nil)
+	mockRepo.On("DeletePendingDeletion", mock.Anything, "reactivated", mock.Anything).Return(false, nil)
+
+	purged, err := uc.PurgeDeletedAccounts(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, attachments.sent["expired"], attachments.deleted)
+}
+
+func TestUpdateAvatar_RejectedDuringCooldown(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, time.Hour, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Role: entity.RoleUser, AvatarChangedAt: time.Now().Add(-10 * time.Minute)}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+
+	result, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, errors.ErrProfileUpdateCooldown)
+	var domainErr *errors.DomainError
+	assert.ErrorAs(t, err, &domainErr)
+	assert.Contains(t, domainErr.Message, "50m")
+	mockCloudinary.AssertNotCalled(t, "UploadAvatar", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateAvatar_AllowedAfterCooldown(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, time.Hour, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Role: entity.RoleUser, AvatarChangedAt: time.Now().Add(-2 * time.Hour)}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(nil, errors.ErrUserNotFound)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+		Return(&cloudinary.UploadResult{PublicID: "p", PublicURL: "http://x", SecureURL: "https://x"}, nil)
+
+	result, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), result.AvatarChangedAt, time.Second)
+	mockRepo.AssertExpectations(t)
+	mockCloudinary.AssertExpectations(t)
+}
+
+func TestUpdateAvatar_UploadTimeout(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Role: entity.RoleUser}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(nil, errors.ErrUserNotFound)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+		Return(nil, fmt.Errorf("failed to upload avatar: %w", errors.WrapTimeout(context.DeadlineExceeded)))
+
+	result, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, errors.ErrUpstreamTimeout)
+	mockAvatarRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateAvatar_AdminExemptFromCooldown(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, time.Hour, passwordPolicy, bcrypt.MinCost)
+
+	admin := &entity.User{ID: "123", Role: entity.RoleAdmin, AvatarChangedAt: time.Now().Add(-time.Minute)}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(admin, nil)
+	mockRepo.On("Update", mock.Anything, admin).Return(nil)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(nil, errors.ErrUserNotFound)
+	mockAvatarRepo.On("Create", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+		Return(&cloudinary.UploadResult{PublicID: "p"}, nil)
+
+	_, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	assert.NoError(t, err)
+	mockCloudinary.AssertExpectations(t)
+}
+
+func TestUpdateAvatar_TooLarge(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByID", mock.Anything, "123").Return(&entity.User{ID: "123"}, nil)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(nil, errors.ErrUserNotFound)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").Return(nil, errors.ErrAvatarTooLarge)
+
+	_, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	// Surfaced as is, so the client gets a 400 rather than an upload failure
+	assert.Equal(t, errors.ErrAvatarTooLarge, err)
+	mockAvatarRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdate_NameChangeCooldown(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, time.Hour, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Name: "Old Name", NameChangedAt: time.Now().Add(-time.Minute)}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	_, err := uc.Update(context.Background(), "123", "New Name", "", "")
+	assert.ErrorIs(t, err, errors.ErrProfileUpdateCooldown)
+
+	// Changing only the phone is not limited
+	_, err = uc.Update(context.Background(), "123", "Old Name", "", "+15550100123")
+	assert.NoError(t, err)
+}
+
+func TestUpdate_NormalizesPhone(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Name: "Test User", Phone: "+15550100123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	result, err := uc.Update(context.Background(), "123", "Test User", "", "+44 20 7946 0958")
+	require.NoError(t, err)
+	assert.Equal(t, "+442079460958", result.Phone)
+
+	// An empty number clears the stored one
+	result, err = uc.Update(context.Background(), "123", "Test User", "", "")
+	require.NoError(t, err)
+	assert.Empty(t, result.Phone)
+
+	_, err = uc.Update(context.Background(), "123", "Test User", "", "12")
+	assert.Equal(t, errors.ErrInvalidPhoneNumber, err)
+}
+
+func TestUpdate_Username(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Name: "Test User", Username: "old_name"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("GetByUsername", mock.Anything, "taken").Return(&entity.User{ID: "456", Username: "taken"}, nil)
+	mockRepo.On("GetByUsername", mock.Anything, "new_name").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	_, err := uc.Update(context.Background(), "123", "Test User", "Taken", "")
+	assert.Equal(t, errors.ErrUsernameTaken, err)
+
+	result, err := uc.Update(context.Background(), "123", "Test User", "New_Name", "")
+	require.NoError(t, err)
+	assert.Equal(t, "new_name", result.Username)
+
+	// An empty username keeps the current one
+	result, err = uc.Update(context.Background(), "123", "Test User", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "new_name", result.Username)
+}
+
+func TestRequestEmailChange_SendsConfirmationToNewAddress(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, mockEmail, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Email: "old@example.com", Password: hashPassword(t), Name: "Test User", EmailVerified: true}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockEmail.On("SendEmailChangeConfirmation", mock.Anything, "new@example.com", "Test User", mock.AnythingOfType("string")).Return(nil)
+
+	err := uc.RequestEmailChange(context.Background(), "123", "  New@Example.com ", "password123")
+
+	require.NoError(t, err)
+	// The email only changes once the new address is confirmed
+	assert.Equal(t, "old@example.com", existingUser.Email)
+	assert.Equal(t, "new@example.com", existingUser.PendingEmail)
+	assert.NotEmpty(t, existingUser.EmailChangeToken)
+	assert.True(t, existingUser.EmailChangeTokenExpiresAt.After(time.Now()))
+	mockEmail.AssertCalled(t, "SendEmailChangeConfirmation", mock.Anything, "new@example.com", "Test User", existingUser.EmailChangeToken)
+}
+
+func TestRequestEmailChange_EmailInUse(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, mockEmail, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByID", mock.Anything, "123").Return(&entity.User{ID: "123", Email: "old@example.com"}, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "taken@example.com").Return(&entity.User{ID: "456", Email: "taken@example.com"}, nil)
+
+	err := uc.RequestEmailChange(context.Background(), "123", "taken@example.com", "")
+
+	assert.ErrorIs(t, err, errors.ErrEmailAlreadyInUse)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockEmail.AssertNotCalled(t, "SendEmailChangeConfirmation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// limitedEmails allows a fixed number of sends per address
+type limitedEmails struct {
+	max   int
+	sends map[string]int
+}
+
+func (l *limitedEmails) Allow(email string) bool {
+	if l.sends == nil {
+		l.sends = make(map[string]int)
+	}
+	l.sends[email]++
+	return l.sends[email] <= l.max
+}
+
+func TestRequestEmailChange_WithoutPasswordSendsForOAuthAccount(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockEmail := new(MockEmailService)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, mockEmail, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Email: "old@example.com", Name: "Test User", OAuthProvider: "google", OAuthID: "g-1"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockEmail.On("SendEmailChangeConfirmation", mock.Anything, "new@example.com", "Test User", mock.AnythingOfType("string")).Return(nil)
+
+	err := uc.RequestEmailChange(context.Background(), "123", "new@example.com", "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", existingUser.PendingEmail)
+}
+
+func TestRequestEmailChange_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		limiter  user.EmailRateLimiter
+		wantErr  error
+	}{
+		{"missing password", "", nil, errors.ErrInvalidCredentials},
+		{"wrong password", "wrong-password", nil, errors.ErrInvalidCredentials},
+		{"address over the email limit", "password123", &limitedEmails{max: 0}, errors.ErrTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockEmail := new(MockEmailService)
+			uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, mockEmail, tt.limiter, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+			mockRepo.On("GetByID", mock.Anything, "123").Return(&entity.User{ID: "123", Email: "old@example.com", Password: hashPassword(t)}, nil)
+			mockRepo.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, errors.ErrUserNotFound)
+
+			err := uc.RequestEmailChange(context.Background(), "123", "new@example.com", tt.password)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+			mockEmail.AssertNotCalled(t, "SendEmailChangeConfirmation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestConfirmEmailChange_SwapsEmail(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:                         "123",
+		Email:                      "old@example.com",
+		VerificationToken:          "verify-token",
+		VerificationTokenExpiresAt: time.Now().Add(time.Hour),
+		PendingEmail:               "new@example.com",
+		EmailChangeToken:           "change-token",
+		EmailChangeTokenExpiresAt:  time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetByEmailChangeToken", mock.Anything, "change-token").Return(existingUser, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, errors.ErrUserNotFound)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+
+	err := uc.ConfirmEmailChange(context.Background(), "change-token")
+
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", existingUser.Email)
+	assert.True(t, existingUser.EmailVerified)
+	assert.Empty(t, existingUser.VerificationToken)
+	assert.Empty(t, existingUser.PendingEmail)
+	assert.Empty(t, existingUser.EmailChangeToken)
+	assert.True(t, existingUser.EmailChangeTokenExpiresAt.IsZero())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestConfirmEmailChange_InvalidToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockRepo.On("GetByEmailChangeToken", mock.Anything, "unknown").Return(nil, errors.ErrUserNotFound)
+
+	err := uc.ConfirmEmailChange(context.Background(), "unknown")
+
+	assert.ErrorIs(t, err, errors.ErrInvalidEmailChangeToken)
+}
+
+func TestConfirmEmailChange_ExpiredToken(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:                        "123",
+		Email:                     "old@example.com",
+		PendingEmail:              "new@example.com",
+		EmailChangeToken:          "change-token",
+		EmailChangeTokenExpiresAt: time.Now().Add(-time.Minute),
+	}
+	mockRepo.On("GetByEmailChangeToken", mock.Anything, "change-token").Return(existingUser, nil)
+
+	err := uc.ConfirmEmailChange(context.Background(), "change-token")
+
+	assert.ErrorIs(t, err, errors.ErrEmailChangeTokenExpired)
+	assert.Equal(t, "old@example.com", existingUser.Email)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestConfirmEmailChange_EmailTakenSinceRequest(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, nil, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{
+		ID:                        "123",
+		Email:                     "old@example.com",
+		PendingEmail:              "new@example.com",
+		EmailChangeToken:          "change-token",
+		EmailChangeTokenExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetByEmailChangeToken", mock.Anything, "change-token").Return(existingUser, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "new@example.com").Return(&entity.User{ID: "456", Email: "new@example.com"}, nil)
+
+	err := uc.ConfirmEmailChange(context.Background(), "change-token")
+
+	assert.ErrorIs(t, err, errors.ErrEmailAlreadyInUse)
+	assert.Equal(t, "old@example.com", existingUser.Email)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestDeleteAvatar_RemovesCloudinaryAssetAndRow(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Avatar: &entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/123"}}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/123").Return(nil)
+	mockAvatarRepo.On("Delete", mock.Anything, "123").Return(nil)
+
+	result, err := uc.DeleteAvatar(context.Background(), "123")
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Avatar)
+	mockCloudinary.AssertExpectations(t)
+	mockAvatarRepo.AssertExpectations(t)
+}
+
+func TestDeleteAvatar_NoAvatar(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+
+	result, err := uc.DeleteAvatar(context.Background(), "123")
+
+	require.NoError(t, err)
+	assert.Equal(t, existingUser, result)
+	mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, mock.Anything)
+	mockAvatarRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestDeleteAvatar_ExternalAvatarSkipsCloudinary(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Avatar: entity.NewExternalAvatar("123", "https://example.com/a.png")}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockAvatarRepo.On("Delete", mock.Anything, "123").Return(nil)
+
+	result, err := uc.DeleteAvatar(context.Background(), "123")
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Avatar)
+	mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, mock.Anything)
+}
+
+func TestDeleteAvatar_CloudinaryFailureKeepsAvatar(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Avatar: &entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/123"}}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/123").Return(assert.AnError)
+
+	_, err := uc.DeleteAvatar(context.Background(), "123")
+
+	var domainErr *errors.DomainError
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, "AVATAR_DELETE_FAILED", domainErr.Code)
+	mockAvatarRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestUpdateAvatar_DeletesReplacedAvatar(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockDeletionRepo := new(MockAvatarDeletionRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, mockDeletionRepo, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(&entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/old"}, nil)
+	mockAvatarRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+		Return(&cloudinary.UploadResult{PublicID: "avatars/new"}, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/old").Return(nil)
+
+	_, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	require.NoError(t, err)
+	// The old asset is gone by the time the request returns
+	mockCloudinary.AssertCalled(t, "DeleteAvatar", mock.Anything, "avatars/old")
+	mockDeletionRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
+
+func TestUpdateAvatar_RecordsFailedDeletion(t *testing.T) {
+	logger.Init("release")
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockDeletionRepo := new(MockAvatarDeletionRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, mockDeletionRepo, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(nil)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(&entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/old"}, nil)
+	mockAvatarRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+		Return(&cloudinary.UploadResult{PublicID: "avatars/new"}, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/old").Return(assert.AnError)
+	mockDeletionRepo.On("Add", mock.Anything, "avatars/old").Return(nil)
+
+	result, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	// The new avatar is kept; the old asset is left for RetryAvatarDeletions
+	require.NoError(t, err)
+	assert.Equal(t, "avatars/new", result.Avatar.PublicID)
+	mockDeletionRepo.AssertExpectations(t)
+}
+
+func TestUpdateAvatar_SaveFailureKeepsOldAvatar(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockAvatarRepo := new(MockAvatarRepository)
+	mockDeletionRepo := new(MockAvatarDeletionRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, mockDeletionRepo, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123"}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Update", mock.Anything, existingUser).Return(assert.AnError)
+	mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(&entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/old"}, nil)
+	mockAvatarRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+	mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+		Return(&cloudinary.UploadResult{PublicID: "avatars/new"}, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/new").Return(nil)
+
+	_, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+	// The rolled-back upload is removed and the old asset, still referenced, is kept
+	assert.ErrorIs(t, err, assert.AnError)
+	mockCloudinary.AssertCalled(t, "DeleteAvatar", mock.Anything, "avatars/new")
+	mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, "avatars/old")
+}
+
+func TestUpdateAvatar_SamePublicIDIsNeverDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		saveErr error
+	}{
+		{"replacement saved", nil},
+		{"replacement rolled back", assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockAvatarRepo := new(MockAvatarRepository)
+			mockDeletionRepo := new(MockAvatarDeletionRepository)
+			mockCloudinary := new(MockCloudinaryService)
+			uc := user.NewUserUseCase(mockRepo, mockAvatarRepo, mockDeletionRepo, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+			existingUser := &entity.User{ID: "123"}
+			mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+			mockRepo.On("Update", mock.Anything, existingUser).Return(tt.saveErr)
+			mockAvatarRepo.On("GetByUserID", mock.Anything, "123").Return(&entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/user_123"}, nil)
+			mockAvatarRepo.On("Update", mock.Anything, mock.AnythingOfType("*entity.Avatar")).Return(nil)
+			// The upload overwrote the old asset under the same public ID
+			mockCloudinary.On("UploadAvatar", mock.Anything, mock.Anything, "123").
+				Return(&cloudinary.UploadResult{PublicID: "avatars/user_123"}, nil)
+
+			_, err := uc.UpdateAvatar(context.Background(), "123", nil)
+
+			assert.ErrorIs(t, err, tt.saveErr)
+			mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, mock.Anything)
+			mockDeletionRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestDelete_RecordsFailedAvatarDeletion(t *testing.T) {
+	logger.Init("release")
+	mockRepo := new(MockUserRepository)
+	mockDeletionRepo := new(MockAvatarDeletionRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, nil, mockDeletionRepo, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Avatar: &entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/user_123"}}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Delete", mock.Anything, "123").Return(nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/user_123").Return(assert.AnError)
+	mockDeletionRepo.On("Add", mock.Anything, "avatars/user_123").Return(nil)
+
+	err := uc.Delete(context.Background(), "123")
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockDeletionRepo.AssertExpectations(t)
+}
+
+func TestDelete_KeepsAvatarWhenUserDeleteFails(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(mockRepo, nil, nil, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	existingUser := &entity.User{ID: "123", Avatar: &entity.Avatar{ID: "a1", UserID: "123", PublicID: "avatars/user_123"}}
+	mockRepo.On("GetByID", mock.Anything, "123").Return(existingUser, nil)
+	mockRepo.On("Delete", mock.Anything, "123").Return(assert.AnError)
+
+	err := uc.Delete(context.Background(), "123")
+
+	assert.Error(t, err)
+	mockCloudinary.AssertNotCalled(t, "DeleteAvatar", mock.Anything, mock.Anything)
+}
+
+func TestRetryAvatarDeletions(t *testing.T) {
+	logger.Init("release")
+	mockDeletionRepo := new(MockAvatarDeletionRepository)
+	mockCloudinary := new(MockCloudinaryService)
+	uc := user.NewUserUseCase(nil, nil, mockDeletionRepo, passthroughTxManager{}, mockCloudinary, nil, nil, nil, nil, gracePeriod, 0, 0, passwordPolicy, bcrypt.MinCost)
+
+	mockDeletionRepo.On("List", mock.Anything, mock.AnythingOfType("int")).Return([]string{"avatars/a", "avatars/b"}, nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/a").Return(nil)
+	mockCloudinary.On("DeleteAvatar", mock.Anything, "avatars/b").Return(assert.AnError)
+	mockDeletionRepo.On("Remove", mock.Anything, "avatars/a").Return(nil)
+
+	deleted, err := uc.RetryAvatarDeletions(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	// The asset that still fails stays recorded for the next run
+	mockDeletionRepo.AssertNotCalled(t, "Remove", mock.Anything, "avatars/b")
+}
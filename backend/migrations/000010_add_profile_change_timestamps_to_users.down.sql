@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_changed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS name_changed_at;
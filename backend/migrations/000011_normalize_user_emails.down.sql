@@ -0,0 +1 @@
+-- Original email casing is not retained; nothing to revert.
@@ -0,0 +1,12 @@
+-- Emails are now trimmed and lowercased before storage and lookup.
+-- Normalize existing rows so mixed-case accounts can still log in. Rows whose
+-- normalized email collides with another account are left untouched and must
+-- be merged manually.
+UPDATE users u
+SET email = LOWER(TRIM(u.email))
+WHERE u.email <> LOWER(TRIM(u.email))
+  AND NOT EXISTS (
+    SELECT 1 FROM users other
+    WHERE other.id <> u.id
+      AND LOWER(TRIM(other.email)) = LOWER(TRIM(u.email))
+  );
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_oauth_identities (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_oauth_identity UNIQUE (provider, provider_user_id),
+    CONSTRAINT unique_user_oauth_provider UNIQUE (user_id, provider)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_oauth_identities_user_id ON user_oauth_identities(user_id);
+
+-- Move the single provider stored on users into the identities table
+INSERT INTO user_oauth_identities (id, user_id, provider, provider_user_id, created_at)
+SELECT gen_random_uuid(), id, oauth_provider, oauth_id, to_timestamp(created_at / 1000.0)
+FROM users
+WHERE oauth_provider IS NOT NULL AND oauth_provider <> ''
+  AND oauth_id IS NOT NULL AND oauth_id <> ''
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS pending_avatar_deletions (
+    public_id VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_avatar_deletions_created_at ON pending_avatar_deletions(created_at);
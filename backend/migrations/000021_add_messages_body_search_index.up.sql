@@ -0,0 +1,4 @@
+-- Trigram index so case-insensitive substring search on message bodies does not scan the whole table
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_messages_body_trgm ON messages USING GIN (LOWER(body) gin_trgm_ops);
@@ -0,0 +1 @@
+ALTER TABLE conversation_participants DROP COLUMN IF EXISTS muted;
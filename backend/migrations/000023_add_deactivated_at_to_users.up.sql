@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Usernames are optional, stored lowercase and unique. NULLs don't conflict,
+-- so existing users and OAuth sign-ups can pick one later.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(30);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
@@ -0,0 +1,23 @@
+-- The direct key is the sorted participant pair of a direct conversation, joined by ':'.
+-- Group conversations keep it NULL, which the unique index doesn't treat as a conflict.
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS direct_key VARCHAR(73);
+
+-- Backfill existing direct conversations. A pair that already ended up with more than one
+-- keeps the key on its oldest conversation only, so the unique index can still be created.
+UPDATE conversations c
+SET direct_key = keyed.direct_key
+FROM (
+    SELECT DISTINCT ON (pairs.direct_key) pairs.conversation_id, pairs.direct_key
+    FROM (
+        SELECT cp.conversation_id,
+               string_agg(cp.user_id::text, ':' ORDER BY cp.user_id::text COLLATE "C") AS direct_key
+        FROM conversation_participants cp
+        JOIN conversations dc ON dc.id = cp.conversation_id AND dc.type = 'direct'
+        GROUP BY cp.conversation_id
+    ) pairs
+    JOIN conversations oc ON oc.id = pairs.conversation_id
+    ORDER BY pairs.direct_key, oc.created_at, oc.id
+) keyed
+WHERE c.id = keyed.conversation_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_direct_key ON conversations(direct_key);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS pending_attachment_deletions (
+    public_id VARCHAR(255) PRIMARY KEY,
+    resource_type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_attachment_deletions_created_at ON pending_attachment_deletions(created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE conversation_reads DROP COLUMN IF EXISTS marked_unread;
+DELETE FROM conversation_reads WHERE last_read_message_id IS NULL OR last_read_at IS NULL;
+ALTER TABLE conversation_reads ALTER COLUMN last_read_at SET NOT NULL;
+ALTER TABLE conversation_reads ALTER COLUMN last_read_message_id SET NOT NULL;
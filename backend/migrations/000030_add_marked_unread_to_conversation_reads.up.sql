@@ -0,0 +1,4 @@
+-- A participant can mark a conversation unread before reading anything in it, so the read position is optional
+ALTER TABLE conversation_reads ALTER COLUMN last_read_message_id DROP NOT NULL;
+ALTER TABLE conversation_reads ALTER COLUMN last_read_at DROP NOT NULL;
+ALTER TABLE conversation_reads ADD COLUMN IF NOT EXISTS marked_unread BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+-- query_key is the lowercase query, so a repeated search is stored once
+CREATE TABLE IF NOT EXISTS recent_searches (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    query_key VARCHAR(100) NOT NULL,
+    query VARCHAR(100) NOT NULL,
+    searched_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, query_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recent_searches_user_searched ON recent_searches(user_id, searched_at DESC);
@@ -0,0 +1,147 @@
+// Package i18n holds the message catalogs used to localize API error messages.
+//
+// Each supported language has a catalog file, locales/<language>.json, embedded in the binary.
+// Adding a locale only takes a new catalog file; keys missing from it fall back to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when the client accepts none of the supported languages
+const DefaultLanguage = "en"
+
+// Catalog holds the messages of one language
+type Catalog struct {
+	// Validation maps a validator tag to its message; {field} and {param} are replaced
+	// with the field name and the tag's parameter
+	Validation map[string]string `json:"validation"`
+	// Errors maps a domain error code to its message. The English messages live with the
+	// domain errors themselves, so the English catalog leaves this empty.
+	Errors map[string]string `json:"errors"`
+}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs parses the embedded catalog files, keyed by language
+func mustLoadCatalogs() map[string]*Catalog {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: read locales: %v", err))
+	}
+
+	loaded := make(map[string]*Catalog, len(files))
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: read %s: %v", file.Name(), err))
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: parse %s: %v", file.Name(), err))
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = &catalog
+	}
+	if loaded[DefaultLanguage] == nil {
+		panic("i18n: missing catalog for the default language")
+	}
+	return loaded
+}
+
+// Languages returns the supported languages, sorted
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// ValidationMessage returns the message for a validator tag in language, falling back to English
+func ValidationMessage(language, tag string) (string, bool) {
+	if message, ok := catalogs[language].lookupValidation(tag); ok {
+		return message, true
+	}
+	return catalogs[DefaultLanguage].lookupValidation(tag)
+}
+
+// ErrorMessage returns the message for a domain error code in language.
+// It reports false when the catalog has no translation, in which case the error's own message applies.
+func ErrorMessage(language, code string) (string, bool) {
+	if message, ok := catalogs[language].lookupError(code); ok {
+		return message, true
+	}
+	return catalogs[DefaultLanguage].lookupError(code)
+}
+
+func (c *Catalog) lookupValidation(tag string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	message, ok := c.Validation[tag]
+	return message, ok
+}
+
+func (c *Catalog) lookupError(code string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	message, ok := c.Errors[code]
+	return message, ok
+}
+
+// Match picks the supported language the client prefers most according to an Accept-Language
+// header, e.g. "es-MX,es;q=0.9,en;q=0.8". Region subtags match their base language.
+func Match(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		preferences = append(preferences, preference{tag: strings.ToLower(tag), quality: quality})
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, p := range preferences {
+		if p.tag == "*" {
+			return DefaultLanguage
+		}
+		if _, ok := catalogs[p.tag]; ok {
+			return p.tag
+		}
+		base, _, _ := strings.Cut(p.tag, "-")
+		if _, ok := catalogs[base]; ok {
+			return base
+		}
+	}
+	return DefaultLanguage
+}
@@ -0,0 +1,64 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"backend/pkg/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "en"},
+		{"es", "es"},
+		{"es-MX,es;q=0.9,en;q=0.8", "es"},
+		{"fr-FR,fr;q=0.9", "en"},
+		{"fr,es;q=0.5", "es"},
+		{"en;q=0.4,es;q=0.6", "es"},
+		{"ES-es", "es"},
+		{"es;q=0,en", "en"},
+		{"*", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			assert.Equal(t, tt.want, i18n.Match(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestValidationMessage_FallsBackToEnglish(t *testing.T) {
+	message, ok := i18n.ValidationMessage("es", "required")
+	assert.True(t, ok)
+	assert.Equal(t, "{field} es obligatorio", message)
+
+	message, ok = i18n.ValidationMessage("xx", "required")
+	assert.True(t, ok)
+	assert.Equal(t, "{field} is required", message)
+}
+
+func TestErrorMessage_EnglishUsesDomainMessage(t *testing.T) {
+	_, ok := i18n.ErrorMessage("en", "USER_NOT_FOUND")
+	assert.False(t, ok)
+
+	message, ok := i18n.ErrorMessage("es", "USER_NOT_FOUND")
+	assert.True(t, ok)
+	assert.Equal(t, "usuario no encontrado", message)
+}
+
+func TestCatalogs_TranslateEnglishValidationTags(t *testing.T) {
+	english, _ := i18n.ValidationMessage("en", "invalid")
+	assert.NotEmpty(t, english)
+
+	for _, language := range i18n.Languages() {
+		for _, tag := range []string{"required", "required_if", "email", "min", "min_items", "max", "max_items", "oneof", "uuid", "phone", "username", "unique", "direct_members", "invalid"} {
+			message, ok := i18n.ValidationMessage(language, tag)
+			assert.True(t, ok, "%s: %s", language, tag)
+			assert.Contains(t, message, "{field}", "%s: %s", language, tag)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Package phone parses user-entered phone numbers and normalizes them to E.164.
+package phone
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrInvalid is returned for input that is not a possible phone number
+var ErrInvalid = errors.New("invalid phone number")
+
+// defaultRegion is set at startup and on config reload, so it is read atomically
+var defaultRegion atomic.Value
+
+// IsRegion reports whether region is a known ISO 3166-1 alpha-2 region code, e.g. "US"
+func IsRegion(region string) bool {
+	return phonenumbers.GetCountryCodeForRegion(strings.ToUpper(region)) != 0
+}
+
+// SetDefaultRegion sets the region used to parse numbers written without a leading "+country code".
+// An empty region requires every number to be in international format.
+func SetDefaultRegion(region string) {
+	defaultRegion.Store(strings.ToUpper(region))
+}
+
+// Normalize parses raw and returns it in E.164 format, e.g. "+15551234567".
+// Numbers are checked for a plausible length for their country rather than against the
+// currently assigned number ranges, so new or test numbers are accepted.
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrInvalid
+	}
+
+	region, _ := defaultRegion.Load().(string)
+	if region == "" && !strings.HasPrefix(raw, "+") {
+		return "", ErrInvalid
+	}
+
+	number, err := phonenumbers.Parse(raw, region)
+	if err != nil || !phonenumbers.IsPossibleNumber(number) {
+		return "", ErrInvalid
+	}
+	return phonenumbers.Format(number, phonenumbers.E164), nil
+}
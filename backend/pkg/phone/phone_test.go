@@ -0,0 +1,52 @@
+package phone_test
+
+import (
+	"testing"
+
+	"backend/pkg/phone"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize_InternationalFormat(t *testing.T) {
+	phone.SetDefaultRegion("")
+
+	valid := map[string]string{
+		"+15551234567":       "+15551234567",
+		"+1 (555) 123-4567":  "+15551234567",
+		" +44 20 7946 0958 ": "+442079460958",
+		"+84 912 345 678":    "+84912345678",
+	}
+	for raw, want := range valid {
+		got, err := phone.Normalize(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+
+	for _, raw := range []string{"", "not a phone", "+1", "+1555123456789012", "5551234567", "+999 1234567"} {
+		_, err := phone.Normalize(raw)
+		assert.ErrorIs(t, err, phone.ErrInvalid, raw)
+	}
+}
+
+func TestNormalize_DefaultRegion(t *testing.T) {
+	phone.SetDefaultRegion("gb")
+	t.Cleanup(func() { phone.SetDefaultRegion("") })
+
+	got, err := phone.Normalize("020 7946 0958")
+	require.NoError(t, err)
+	assert.Equal(t, "+442079460958", got)
+
+	// International numbers keep their own country code
+	got, err = phone.Normalize("+1 555 123 4567")
+	require.NoError(t, err)
+	assert.Equal(t, "+15551234567", got)
+}
+
+func TestIsRegion(t *testing.T) {
+	assert.True(t, phone.IsRegion("US"))
+	assert.True(t, phone.IsRegion("vn"))
+	assert.False(t, phone.IsRegion("XX"))
+	assert.False(t, phone.IsRegion(""))
+}
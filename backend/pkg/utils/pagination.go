@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultPageLimit is the page size used when a list request sets no limit
+	DefaultPageLimit = 10
+	// MaxPageLimit caps the page size of offset-paginated list endpoints
+	MaxPageLimit = 100
+)
+
+// PaginatedResponse is one page of a list endpoint's results with the same pagination metadata on every endpoint
+type PaginatedResponse struct {
+	Items  interface{} `json:"items"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+	// HasNext reports whether another page follows this one
+	HasNext bool `json:"has_next"`
+	// NextCursor fetches the next page of cursor-paginated endpoints
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPaginatedResponse wraps a page of items fetched with limit and offset out of total results
+func NewPaginatedResponse(items interface{}, total int64, limit, offset int) *PaginatedResponse {
+	return &PaginatedResponse{
+		Items:   items,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		HasNext: int64(offset)+int64(limit) < total,
+	}
+}
+
+// WithNextCursor sets the cursor of the following page; an empty cursor marks the last page
+func (p *PaginatedResponse) WithNextCursor(cursor string) *PaginatedResponse {
+	p.NextCursor = cursor
+	p.HasNext = cursor != ""
+	return p
+}
+
+// ClampLimit returns defaultLimit for a missing or non-positive limit and caps it at maxLimit
+func ClampLimit(limit, defaultLimit, maxLimit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+// ParsePagination reads the limit and offset query parameters, clamping the limit to MaxPageLimit.
+// Malformed values fall back to the defaults and a negative offset starts from the beginning.
+func ParsePagination(c *gin.Context) (limit, offset int) {
+	limit, _ = strconv.Atoi(c.Query("limit"))
+	offset, _ = strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	return ClampLimit(limit, DefaultPageLimit, MaxPageLimit), offset
+}
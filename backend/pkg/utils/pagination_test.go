@@ -0,0 +1,59 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query         string
+		limit, offset int
+	}{
+		{"", utils.DefaultPageLimit, 0},
+		{"?limit=25&offset=50", 25, 50},
+		{"?limit=1000", utils.MaxPageLimit, 0},
+		{"?limit=0&offset=-5", utils.DefaultPageLimit, 0},
+		{"?limit=abc&offset=xyz", utils.DefaultPageLimit, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users"+tt.query, nil)
+
+			limit, offset := utils.ParsePagination(c)
+
+			assert.Equal(t, tt.limit, limit)
+			assert.Equal(t, tt.offset, offset)
+		})
+	}
+}
+
+func TestNewPaginatedResponse_HasNext(t *testing.T) {
+	assert.True(t, utils.NewPaginatedResponse([]string{}, 25, 10, 10).HasNext)
+	assert.False(t, utils.NewPaginatedResponse([]string{}, 20, 10, 10).HasNext)
+	assert.False(t, utils.NewPaginatedResponse([]string{}, 0, 10, 0).HasNext)
+}
+
+func TestPaginatedResponse_JSON(t *testing.T) {
+	page := utils.NewPaginatedResponse([]string{"a", "b"}, 5, 2, 0)
+
+	body, err := json.Marshal(page)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":["a","b"],"total":5,"limit":2,"offset":0,"has_next":true}`, string(body))
+
+	body, err = json.Marshal(page.WithNextCursor("cursor-1"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":["a","b"],"total":5,"limit":2,"offset":0,"has_next":true,"next_cursor":"cursor-1"}`, string(body))
+}
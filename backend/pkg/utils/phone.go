@@ -0,0 +1,19 @@
+package utils
+
+import (
+	domainErrors "backend/internal/domain/errors"
+	"backend/pkg/phone"
+)
+
+// NormalizePhone returns the phone number in E.164 format so every stored number has the same form.
+// An empty number stays empty.
+func NormalizePhone(number string) (string, error) {
+	if number == "" {
+		return "", nil
+	}
+	normalized, err := phone.Normalize(number)
+	if err != nil {
+		return "", domainErrors.ErrInvalidPhoneNumber
+	}
+	return normalized, nil
+}
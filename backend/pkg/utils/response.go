@@ -3,8 +3,12 @@ package utils
 import (
 	"errors"
 	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
 
 	domainErrors "backend/internal/domain/errors"
+	"backend/pkg/i18n"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -24,6 +28,64 @@ type ErrorData struct {
 	Details interface{} `json:"details,omitempty"`
 }
 
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the lowercased error code to form a problem type URI, e.g. urn:tkhanchat:problem:user_not_found
+const problemTypePrefix = "urn:tkhanchat:problem:"
+
+// Problem represents an RFC 7807 problem details body.
+// Code and Details are extension members carrying the same values as ErrorData.
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// problemDetailsByDefault is set at startup and on config reload, so it is read atomically
+var problemDetailsByDefault atomic.Bool
+
+// UseProblemDetails sets whether error responses are sent as problem details even to clients
+// that don't ask for them with an Accept header
+func UseProblemDetails(enabled bool) {
+	problemDetailsByDefault.Store(enabled)
+}
+
+// wantsProblem reports whether the error response should be sent as problem details
+func wantsProblem(c *gin.Context) bool {
+	if problemDetailsByDefault.Load() {
+		return true
+	}
+	return c.Request != nil && strings.Contains(c.GetHeader("Accept"), ProblemContentType)
+}
+
+// problemResponse sends an RFC 7807 problem details response; an empty code yields the generic about:blank type
+func problemResponse(c *gin.Context, statusCode int, code, detail string, details interface{}) {
+	problemType := "about:blank"
+	if code != "" {
+		problemType = problemTypePrefix + strings.ToLower(code)
+	}
+	var instance string
+	if c.Request != nil {
+		instance = c.Request.URL.Path
+	}
+	// Set before rendering, which keeps an existing Content-Type
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(statusCode, Problem{
+		Type:     problemType,
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   detail,
+		Instance: instance,
+		Code:     code,
+		Details:  details,
+	})
+}
+
 // SuccessResponse sends a success response
 func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
 	c.JSON(statusCode, Response{
@@ -33,8 +95,17 @@ func SuccessResponse(c *gin.Context, statusCode int, message string, data interf
 	})
 }
 
-// ErrorResponse sends an error response
+// ErrorResponse sends an error response, as problem details when the client or the config asks for them
 func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
+	if wantsProblem(c) {
+		var details interface{}
+		if err != nil {
+			details = err.Error()
+		}
+		problemResponse(c, statusCode, "", message, details)
+		return
+	}
+
 	response := Response{
 		Success: false,
 		Message: message,
@@ -49,15 +120,21 @@ func ErrorResponse(c *gin.Context, statusCode int, message string, err error) {
 	c.JSON(statusCode, response)
 }
 
-// ValidationErrorResponse sends a validation error response
+// ValidationErrorResponse sends a validation error response, with messages in the request's language
 func ValidationErrorResponse(c *gin.Context, err error) {
+	language := requestLanguage(c)
 	var validationErrors []string
 	if ve, ok := err.(validator.ValidationErrors); ok {
 		for _, fe := range ve {
-			validationErrors = append(validationErrors, formatValidationError(fe))
+			validationErrors = append(validationErrors, formatValidationError(language, fe))
 		}
 	}
 
+	if wantsProblem(c) {
+		problemResponse(c, http.StatusBadRequest, "VALIDATION_ERROR", "validation failed", validationErrors)
+		return
+	}
+
 	c.JSON(http.StatusBadRequest, Response{
 		Success: false,
 		Message: "validation failed",
@@ -68,16 +145,31 @@ func ValidationErrorResponse(c *gin.Context, err error) {
 	})
 }
 
-// HandleDomainError handles domain-specific errors
+// HandleDomainError handles domain-specific errors, as problem details when the client or the config asks for them.
+// The message is translated to the request's language when its catalog has the error code.
 func HandleDomainError(c *gin.Context, err error) {
+	HandleDomainErrorWithDetails(c, err, nil)
+}
+
+// HandleDomainErrorWithDetails is HandleDomainError, sending details along with the error code
+func HandleDomainErrorWithDetails(c *gin.Context, err error, details interface{}) {
 	var domainErr *domainErrors.DomainError
 	if errors.As(err, &domainErr) {
 		statusCode := getStatusCodeFromDomainError(domainErr)
+		message := domainErr.Message
+		if translated, ok := i18n.ErrorMessage(requestLanguage(c), domainErr.Code); ok {
+			message = translated
+		}
+		if wantsProblem(c) {
+			problemResponse(c, statusCode, domainErr.Code, message, details)
+			return
+		}
 		c.JSON(statusCode, Response{
 			Success: false,
-			Message: domainErr.Message,
+			Message: message,
 			Error: &ErrorData{
-				Code: domainErr.Code,
+				Code:    domainErr.Code,
+				Details: details,
 			},
 		})
 		return
@@ -90,29 +182,55 @@ func HandleDomainError(c *gin.Context, err error) {
 // getStatusCodeFromDomainError maps domain errors to HTTP status codes
 func getStatusCodeFromDomainError(err *domainErrors.DomainError) int {
 	switch err.Code {
-	case "USER_NOT_FOUND":
+	case "USER_NOT_FOUND", "OAUTH_PROVIDER_NOT_SUPPORTED", "OAUTH_PROVIDER_NOT_LINKED", "CONVERSATION_NOT_FOUND", "MESSAGE_NOT_FOUND":
 		return http.StatusNotFound
-	case "USER_EXISTS":
+	case "USER_EXISTS", "USER_ALREADY_EXISTS", "EMAIL_ALREADY_IN_USE", "USERNAME_TAKEN", "CONVERSATION_EXISTS":
 		return http.StatusConflict
 	case "INVALID_CREDENTIALS":
 		return http.StatusUnauthorized
-	case "UNAUTHORIZED", "INVALID_TOKEN":
+	case "UNAUTHORIZED", "INVALID_TOKEN", "TOKEN_REVOKED", "TOKEN_EXPIRED", "REFRESH_TOKEN_NOT_FOUND", "INVALID_OAUTH_STATE":
 		return http.StatusUnauthorized
+	case "EMAIL_NOT_VERIFIED", "ACCOUNT_DEACTIVATED", "PASSWORD_LOGIN_UNAVAILABLE", "NOT_CONVERSATION_MEMBER", "NOT_MESSAGE_SENDER":
+		return http.StatusForbidden
+	case "WEAK_PASSWORD", "INVALID_VERIFICATION_TOKEN", "INVALID_RESET_TOKEN", "INVALID_EMAIL_CHANGE_TOKEN", "AVATAR_TOO_LARGE", "ATTACHMENT_TOO_LARGE", "INVALID_PARTICIPANTS", "INVALID_MESSAGE_BODY", "INVALID_CURSOR", "INVALID_REACTION", "INVALID_SEARCH_QUERY", "INVALID_MEDIA_TYPE", "INVALID_ATTACHMENT_COUNT", "INVALID_IDEMPOTENCY_KEY", "INVALID_PHONE_NUMBER", "INVALID_USERNAME":
+		return http.StatusBadRequest
+	case "VERIFICATION_TOKEN_EXPIRED", "RESET_TOKEN_EXPIRED", "EMAIL_CHANGE_TOKEN_EXPIRED":
+		return http.StatusGone
+	case "EMAIL_ALREADY_VERIFIED", "CANNOT_UNLINK_LAST_LOGIN":
+		return http.StatusConflict
+	case "IDEMPOTENCY_KEY_REUSED":
+		return http.StatusUnprocessableEntity
+	case "VERIFICATION_RESEND_TOO_SOON", "PROFILE_UPDATE_COOLDOWN", "TOO_MANY_REQUESTS", "RATE_LIMITED":
+		return http.StatusTooManyRequests
+	case "CLIENT_UPGRADE_REQUIRED":
+		return http.StatusUpgradeRequired
+	case "UPSTREAM_TIMEOUT":
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
-// formatValidationError formats a validation error
-func formatValidationError(fe validator.FieldError) string {
-	switch fe.Tag() {
-	case "required":
-		return fe.Field() + " is required"
-	case "email":
-		return fe.Field() + " must be a valid email"
-	case "min":
-		return fe.Field() + " must be at least " + fe.Param() + " characters"
-	default:
-		return fe.Field() + " is invalid"
+// requestLanguage picks the language of error messages from the request's Accept-Language header
+// and announces it in the Content-Language response header
+func requestLanguage(c *gin.Context) string {
+	language := i18n.DefaultLanguage
+	if c.Request != nil {
+		language = i18n.Match(c.GetHeader("Accept-Language"))
+	}
+	c.Header("Content-Language", language)
+	return language
+}
+
+// formatValidationError formats a validation error in the given language
+func formatValidationError(language string, fe validator.FieldError) string {
+	key := fe.Tag()
+	if (key == "min" || key == "max") && fe.Kind() == reflect.Slice {
+		key += "_items"
+	}
+	message, ok := i18n.ValidationMessage(language, key)
+	if !ok {
+		message, _ = i18n.ValidationMessage(language, "invalid")
 	}
+	return strings.NewReplacer("{field}", fe.Field(), "{param}", fe.Param()).Replace(message)
 }
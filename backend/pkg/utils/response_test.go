@@ -0,0 +1,229 @@
+package utils_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/domain/errors"
+	"backend/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDomainError_StatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err    *errors.DomainError
+		status int
+	}{
+		{errors.ErrUserNotFound, http.StatusNotFound},
+		{errors.ErrUserExists, http.StatusConflict},
+		{errors.ErrUserAlreadyExists, http.StatusConflict},
+		{errors.ErrInvalidCredentials, http.StatusUnauthorized},
+		{errors.ErrUnauthorized, http.StatusUnauthorized},
+		{errors.ErrInvalidToken, http.StatusUnauthorized},
+		{errors.ErrTokenRevoked, http.StatusUnauthorized},
+		{errors.ErrTokenExpired, http.StatusUnauthorized},
+		{errors.ErrRefreshTokenNotFound, http.StatusUnauthorized},
+		{errors.ErrEmailNotVerified, http.StatusForbidden},
+		{errors.ErrAccountDeactivated, http.StatusForbidden},
+		{errors.ErrPasswordLoginUnavailable, http.StatusForbidden},
+		{errors.ErrEmailAlreadyVerified, http.StatusConflict},
+		{errors.ErrVerificationResendTooSoon, http.StatusTooManyRequests},
+		{errors.ErrInvalidVerificationToken, http.StatusBadRequest},
+		{errors.ErrVerificationTokenExpired, http.StatusGone},
+		{errors.ErrInvalidResetToken, http.StatusBadRequest},
+		{errors.ErrResetTokenExpired, http.StatusGone},
+		{errors.ErrOAuthProviderNotSupported, http.StatusNotFound},
+		{errors.ErrInvalidOAuthState, http.StatusUnauthorized},
+		{errors.ErrOAuthProviderNotLinked, http.StatusNotFound},
+		{errors.ErrCannotUnlinkLastLogin, http.StatusConflict},
+		{errors.ErrTooManyRequests, http.StatusTooManyRequests},
+		{errors.ErrEmailAlreadyInUse, http.StatusConflict},
+		{errors.ErrUsernameTaken, http.StatusConflict},
+		{errors.ErrInvalidEmailChangeToken, http.StatusBadRequest},
+		{errors.ErrEmailChangeTokenExpired, http.StatusGone},
+		{errors.ErrAvatarTooLarge, http.StatusBadRequest},
+		{errors.ErrAttachmentTooLarge, http.StatusBadRequest},
+		{errors.ErrConversationNotFound, http.StatusNotFound},
+		{errors.ErrNotConversationMember, http.StatusForbidden},
+		{errors.ErrInvalidParticipants, http.StatusBadRequest},
+		{errors.ErrInvalidMessageBody, http.StatusBadRequest},
+		{errors.ErrInvalidReaction, http.StatusBadRequest},
+		{errors.ErrInvalidSearchQuery, http.StatusBadRequest},
+		{errors.ErrInvalidIdempotencyKey, http.StatusBadRequest},
+		{errors.ErrInvalidPhoneNumber, http.StatusBadRequest},
+		{errors.ErrInvalidUsername, http.StatusBadRequest},
+		{errors.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+		{errors.ErrInvalidCursor, http.StatusBadRequest},
+		{errors.ErrMessageNotFound, http.StatusNotFound},
+		{errors.ErrConversationExists, http.StatusConflict},
+		{errors.ErrNotMessageSender, http.StatusForbidden},
+		{errors.ErrUpstreamTimeout, http.StatusGatewayTimeout},
+		{errors.ErrWeakPassword, http.StatusBadRequest},
+		{errors.ErrProfileUpdateCooldown, http.StatusTooManyRequests},
+		{errors.ErrRateLimited, http.StatusTooManyRequests},
+		{errors.ErrClientUpgradeRequired, http.StatusUpgradeRequired},
+		{&errors.DomainError{Code: "SOMETHING_ELSE", Message: "unmapped"}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Code, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			// Wrapped errors must resolve to the same status as the bare error
+			utils.HandleDomainError(c, fmt.Errorf("wrapped: %w", tt.err))
+
+			assert.Equal(t, tt.status, w.Code)
+
+			var body utils.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.False(t, body.Success)
+			assert.Equal(t, tt.err.Message, body.Message)
+			require.NotNil(t, body.Error)
+			assert.Equal(t, tt.err.Code, body.Error.Code)
+		})
+	}
+}
+
+func TestHandleDomainError_UnknownError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	utils.HandleDomainError(c, fmt.Errorf("database is down"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandleDomainError_DeadlineExceeded(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	utils.HandleDomainError(c, fmt.Errorf("failed to send email: %w", errors.WrapTimeout(context.DeadlineExceeded)))
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestHandleDomainError_OtherUpstreamErrorIsNotATimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	utils.HandleDomainError(c, fmt.Errorf("failed to send email: %w", errors.WrapTimeout(fmt.Errorf("connection refused"))))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// problemContext returns a test context for a request to path with the given Accept header
+func problemContext(path, accept string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	return c, w
+}
+
+func TestHandleDomainError_ProblemDetailsWhenAccepted(t *testing.T) {
+	c, w := problemContext("/api/v1/users/123", "application/problem+json, application/json")
+
+	utils.HandleDomainError(c, errors.ErrUserNotFound)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "urn:tkhanchat:problem:user_not_found",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "user not found",
+		"instance": "/api/v1/users/123",
+		"code": "USER_NOT_FOUND"
+	}`, w.Body.String())
+}
+
+func TestErrorResponse_ProblemDetailsWhenEnabled(t *testing.T) {
+	utils.UseProblemDetails(true)
+	t.Cleanup(func() { utils.UseProblemDetails(false) })
+	c, w := problemContext("/api/v1/users/me", "")
+
+	utils.ErrorResponse(c, http.StatusUnauthorized, "missing authorization header", nil)
+
+	assert.Equal(t, utils.ProblemContentType, w.Header().Get("Content-Type"))
+	var problem utils.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, utils.Problem{
+		Type:     "about:blank",
+		Title:    "Unauthorized",
+		Status:   http.StatusUnauthorized,
+		Detail:   "missing authorization header",
+		Instance: "/api/v1/users/me",
+	}, problem)
+}
+
+func TestErrorResponse_DefaultsToResponseShape(t *testing.T) {
+	c, w := problemContext("/api/v1/users/me", "application/json")
+
+	utils.ErrorResponse(c, http.StatusUnauthorized, "missing authorization header", nil)
+
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	var body utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.False(t, body.Success)
+	assert.Equal(t, "missing authorization header", body.Message)
+}
+
+func TestValidationErrorResponse_Localized(t *testing.T) {
+	c, w := problemContext("/api/v1/auth/register", "")
+	c.Request.Header.Set("Accept-Language", "es-ES,es;q=0.9")
+	req := struct {
+		Email    string `validate:"required,email"`
+		Password string `validate:"min=8"`
+	}{Password: "short"}
+
+	utils.ValidationErrorResponse(c, utils.Validator().Struct(req))
+
+	assert.Equal(t, "es", w.Header().Get("Content-Language"))
+	var body utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotNil(t, body.Error)
+	assert.Equal(t, []interface{}{
+		"Email es obligatorio",
+		"Password debe tener al menos 8 caracteres",
+	}, body.Error.Details)
+}
+
+func TestHandleDomainError_Localized(t *testing.T) {
+	c, w := problemContext("/api/v1/users/123", "")
+	c.Request.Header.Set("Accept-Language", "es")
+
+	utils.HandleDomainError(c, errors.ErrUserNotFound)
+
+	var body utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "usuario no encontrado", body.Message)
+	assert.Equal(t, "USER_NOT_FOUND", body.Error.Code)
+}
+
+func TestHandleDomainError_UntranslatedCodeKeepsMessage(t *testing.T) {
+	c, w := problemContext("/api/v1/users/me", "")
+	c.Request.Header.Set("Accept-Language", "es")
+	weak := errors.ErrWeakPassword.WithMessage("password must contain a digit")
+
+	utils.HandleDomainError(c, weak)
+
+	var body utils.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "password must contain a digit", body.Message)
+}
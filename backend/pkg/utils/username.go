@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"context"
+
+	"backend/internal/domain/entity"
+	domainErrors "backend/internal/domain/errors"
+	"backend/internal/domain/repository"
+)
+
+// NormalizeUsername lowercases the username and checks its length and characters
+func NormalizeUsername(username string) (string, error) {
+	username = entity.NormalizeUsername(username)
+	if !entity.IsValidUsername(username) {
+		return "", domainErrors.ErrInvalidUsername
+	}
+	return username, nil
+}
+
+// EnsureUsernameAvailable returns ErrUsernameTaken if a user other than userID has the username.
+// The unique index still decides races between concurrent claims.
+func EnsureUsernameAvailable(ctx context.Context, userRepo repository.UserRepository, username, userID string) error {
+	existing, err := userRepo.GetByUsername(ctx, username)
+	if err == domainErrors.ErrUserNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != userID {
+		return domainErrors.ErrUsernameTaken
+	}
+	return nil
+}
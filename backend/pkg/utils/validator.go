@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"backend/internal/domain/entity"
+	"backend/pkg/phone"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is the shared request validator. Custom rules are registered on it
+// once at startup so every handler validates requests the same way.
+var validate = newValidator()
+
+// Validator returns the shared request validator
+func Validator() *validator.Validate {
+	return validate
+}
+
+// newValidator creates the validator with the custom rules shared by all request DTOs:
+//   - phone: a phone number in E.164 format, or in local format when a default region is configured
+//   - username: 3 to 30 letters, digits or underscores, compared case-insensitively
+func newValidator() *validator.Validate {
+	v := validator.New()
+	if err := v.RegisterValidation("phone", validatePhone); err != nil {
+		panic(err)
+	}
+	if err := v.RegisterValidation("username", validateUsername); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func validatePhone(fl validator.FieldLevel) bool {
+	_, err := phone.Normalize(fl.Field().String())
+	return err == nil
+}
+
+func validateUsername(fl validator.FieldLevel) bool {
+	return entity.IsValidUsername(entity.NormalizeUsername(fl.Field().String()))
+}